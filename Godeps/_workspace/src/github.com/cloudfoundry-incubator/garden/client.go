@@ -2,6 +2,7 @@ package garden
 
 import (
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -59,8 +60,75 @@ type Client interface {
 	// Errors:
 	// * Container not found.
 	Lookup(handle string) (Container, error)
-	
-	CommitAndSave(handle,dest string) error
+
+	// CommitAndSave commits the changes made to a container's root filesystem
+	// as a new image and writes it, in `docker save` format, to dest on the
+	// garden host. dest must have a .tar suffix.
+	//
+	// Errors:
+	// * Container not found.
+	// * The container's rootfs provider does not support committing.
+	CommitAndSave(handle, dest string) error
+
+	// CommitAndStream commits the changes made to a container's root filesystem
+	// as a new image and streams it back, in `docker save` format, as a tar
+	// stream. The caller must close the stream.
+	//
+	// Errors:
+	// * Container not found.
+	// * The container's rootfs provider does not support committing.
+	CommitAndStream(handle string) (io.ReadCloser, error)
+
+	// CommitImage commits the changes made to a container's root filesystem
+	// as a new image in the server's local image store, tagged as
+	// repository:tag. The image can then be used as the rootfs of new
+	// containers via "docker:///repository#tag" without contacting a registry.
+	//
+	// The ID of the new image is returned.
+	//
+	// Errors:
+	// * Container not found.
+	// * The container's rootfs provider does not support committing.
+	CommitImage(handle, repository, tag string) (string, error)
+
+	// PullImage fetches the image for a rootfs URL, such as
+	// "docker:///busybox#1.0", into the server's local image store ahead of
+	// time, so that containers created from it later need not wait for it to
+	// be downloaded. Registry credentials may be given in the URL's user info.
+	//
+	// The ID of the image is returned.
+	//
+	// Errors:
+	// * The rootfs URL is not supported for pulling.
+	// * The image could not be fetched.
+	PullImage(rootfs string) (string, error)
+
+	// CreationProgress returns the progress so far of fetching the rootfs of a
+	// container that is still being created, such as the layers downloaded,
+	// so that a slow create can be followed while it is in flight. Once the
+	// container is created, the progress is found in its events instead.
+	//
+	// Errors:
+	// * No container with the handle is being created.
+	CreationProgress(handle string) ([]string, error)
+
+	// ListImages lists the images in the server's local image store.
+	//
+	// Errors:
+	// * None.
+	ListImages() ([]ImageInfo, error)
+
+	// Checkpoint freezes a container's process tree, memory included, into the
+	// server's depot and stops it. The container is resumed from the
+	// checkpoint the next time the server restores its containers, which may
+	// be after the host has been rebooted or on another host that the depot
+	// and snapshot were copied to.
+	//
+	// Errors:
+	// * Container not found.
+	// * The container is not running.
+	// * The process tree could not be checkpointed.
+	Checkpoint(handle string) error
 }
 
 type ContainerNotFoundError struct {
@@ -167,6 +235,14 @@ type ContainerSpec struct {
 	// is the same as the root user in the host. Otherwise, the container has a user namespace and the root
 	// user in the container is mapped to a non-root user in the host. Defaults to false.
 	Privileged bool `json:"privileged,omitempty"`
+
+	// Limits are enforced on the container before it is returned.
+	Limits Limits `json:"limits,omitempty"`
+}
+
+// Limits that can be set when a container is created.
+type Limits struct {
+	Pid PidLimits `json:"pid,omitempty"`
 }
 
 // BindMount specifies parameters for a single mount point.
@@ -201,6 +277,24 @@ type Capacity struct {
 	MaxContainers uint64 `json:"max_containers,omitempty"`
 }
 
+// ImageInfo describes an image layer in the server's local image store.
+type ImageInfo struct {
+	ID     string `json:"id"`
+	Parent string `json:"parent,omitempty"`
+
+	// Size of the layer alone, excluding its parents, in bytes.
+	Size int64 `json:"size"`
+
+	Created time.Time `json:"created"`
+
+	// LastUsed is when a container last used the image, or it was last
+	// pulled. It is zero if neither has happened.
+	LastUsed time.Time `json:"last_used"`
+
+	// InUse is true while a container's rootfs is layered on the image.
+	InUse bool `json:"in_use"`
+}
+
 type Properties map[string]string
 
 type BindMountMode uint8
@@ -1,7 +1,8 @@
 package client
 
 import (
-	"fmt"
+	"io"
+
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden/client/connection"
 )
@@ -85,5 +86,53 @@ func (client *client) Lookup(handle string) (garden.Container, error) {
 }
 
 func (client *client) CommitAndSave(handle, dest string) error {
-	return fmt.Errorf("client doest not support CommitAndSave,handle : %s",handle)
+	err := client.connection.CommitAndSave(handle, dest)
+
+	if err, ok := err.(connection.Error); ok && err.StatusCode == 404 {
+		return garden.ContainerNotFoundError{handle}
+	}
+
+	return err
+}
+
+func (client *client) CommitAndStream(handle string) (io.ReadCloser, error) {
+	stream, err := client.connection.CommitAndStream(handle)
+
+	if err, ok := err.(connection.Error); ok && err.StatusCode == 404 {
+		return nil, garden.ContainerNotFoundError{handle}
+	}
+
+	return stream, err
+}
+
+func (client *client) CommitImage(handle, repository, tag string) (string, error) {
+	imageID, err := client.connection.CommitImage(handle, repository, tag)
+
+	if err, ok := err.(connection.Error); ok && err.StatusCode == 404 {
+		return "", garden.ContainerNotFoundError{handle}
+	}
+
+	return imageID, err
+}
+
+func (client *client) PullImage(rootfs string) (string, error) {
+	return client.connection.PullImage(rootfs)
+}
+
+func (client *client) ListImages() ([]garden.ImageInfo, error) {
+	return client.connection.ListImages()
+}
+
+func (client *client) CreationProgress(handle string) ([]string, error) {
+	return client.connection.CreationProgress(handle)
+}
+
+func (client *client) Checkpoint(handle string) error {
+	err := client.connection.Checkpoint(handle)
+
+	if err, ok := err.(connection.Error); ok && err.StatusCode == 404 {
+		return garden.ContainerNotFoundError{handle}
+	}
+
+	return err
 }
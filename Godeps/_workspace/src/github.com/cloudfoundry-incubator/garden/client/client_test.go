@@ -2,6 +2,8 @@ package client_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -272,4 +274,174 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("CommitAndStream", func() {
+		It("streams the committed image", func() {
+			stream := ioutil.NopCloser(strings.NewReader("some-tar"))
+			fakeConnection.CommitAndStreamReturns(stream, nil)
+
+			reader, err := client.CommitAndStream("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(reader).Should(Equal(stream))
+
+			Ω(fakeConnection.CommitAndStreamArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the error is a 404", func() {
+			BeforeEach(func() {
+				fakeConnection.CommitAndStreamReturns(nil, connection.Error{404, ""})
+			})
+
+			It("returns a ContainerNotFoundError with the requested handle", func() {
+				_, err := client.CommitAndStream("some-handle")
+				Ω(err).Should(MatchError(garden.ContainerNotFoundError{"some-handle"}))
+			})
+		})
+	})
+
+	Describe("CommitImage", func() {
+		It("sends a commit request and returns the image ID", func() {
+			fakeConnection.CommitImageReturns("some-image-id", nil)
+
+			imageID, err := client.CommitImage("some-handle", "some-repo", "some-tag")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(imageID).Should(Equal("some-image-id"))
+
+			handle, repository, tag := fakeConnection.CommitImageArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(repository).Should(Equal("some-repo"))
+			Ω(tag).Should(Equal("some-tag"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CommitImageReturns("", disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.CommitImage("some-handle", "some-repo", "some-tag")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+
+		Context("when the error is a 404", func() {
+			BeforeEach(func() {
+				fakeConnection.CommitImageReturns("", connection.Error{404, ""})
+			})
+
+			It("returns a ContainerNotFoundError with the requested handle", func() {
+				_, err := client.CommitImage("some-handle", "some-repo", "some-tag")
+				Ω(err).Should(MatchError(garden.ContainerNotFoundError{"some-handle"}))
+			})
+		})
+	})
+
+	Describe("PullImage", func() {
+		It("sends a pull request and returns the image ID", func() {
+			fakeConnection.PullImageReturns("some-image-id", nil)
+
+			imageID, err := client.PullImage("docker:///some-repo#some-tag")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(imageID).Should(Equal("some-image-id"))
+
+			Ω(fakeConnection.PullImageArgsForCall(0)).Should(Equal("docker:///some-repo#some-tag"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.PullImageReturns("", disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.PullImage("docker:///some-repo")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("ListImages", func() {
+		It("sends a list images request and returns the images", func() {
+			images := []garden.ImageInfo{{ID: "some-image-id", Size: 42}}
+			fakeConnection.ListImagesReturns(images, nil)
+
+			listed, err := client.ListImages()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(listed).Should(Equal(images))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ListImagesReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.ListImages()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("CreationProgress", func() {
+		It("sends a creation progress request and returns the events", func() {
+			fakeConnection.CreationProgressReturns([]string{"fetching layer some-layer"}, nil)
+
+			events, err := client.CreationProgress("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(events).Should(Equal([]string{"fetching layer some-layer"}))
+
+			Ω(fakeConnection.CreationProgressArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CreationProgressReturns(nil, disaster)
+			})
+
+			It("returns it", func() {
+				_, err := client.CreationProgress("some-handle")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Checkpoint", func() {
+		It("sends a checkpoint request", func() {
+			err := client.Checkpoint("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.CheckpointArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when there is a connection error", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CheckpointReturns(disaster)
+			})
+
+			It("returns it", func() {
+				err := client.Checkpoint("some-handle")
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+
+		Context("when the error is a 404", func() {
+			BeforeEach(func() {
+				fakeConnection.CheckpointReturns(connection.Error{404, ""})
+			})
+
+			It("returns a ContainerNotFoundError with the requested handle", func() {
+				err := client.Checkpoint("some-handle")
+				Ω(err).Should(MatchError(garden.ContainerNotFoundError{"some-handle"}))
+			})
+		})
+	})
 })
@@ -41,6 +41,8 @@ type Connection interface {
 	Destroy(handle string) error
 
 	Stop(handle string, kill bool) error
+	Pause(handle string) error
+	Resume(handle string) error
 
 	Info(handle string) (garden.ContainerInfo, error)
 	BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error)
@@ -51,26 +53,46 @@ type Connection interface {
 
 	LimitBandwidth(handle string, limits garden.BandwidthLimits) (garden.BandwidthLimits, error)
 	LimitCPU(handle string, limits garden.CPULimits) (garden.CPULimits, error)
+	LimitBlockIO(handle string, limits garden.BlockIOLimits) (garden.BlockIOLimits, error)
+	LimitPids(handle string, limits garden.PidLimits) (garden.PidLimits, error)
 	LimitDisk(handle string, limits garden.DiskLimits) (garden.DiskLimits, error)
 	LimitMemory(handle string, limit garden.MemoryLimits) (garden.MemoryLimits, error)
 
 	CurrentBandwidthLimits(handle string) (garden.BandwidthLimits, error)
 	CurrentCPULimits(handle string) (garden.CPULimits, error)
+	CurrentBlockIOLimits(handle string) (garden.BlockIOLimits, error)
+	CurrentPidLimits(handle string) (garden.PidLimits, error)
 	CurrentDiskLimits(handle string) (garden.DiskLimits, error)
 	CurrentMemoryLimits(handle string) (garden.MemoryLimits, error)
 
+	StreamOomEvents(handle string) (garden.OomEventStream, error)
+
 	Run(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	Attach(handle string, processID uint32, io garden.ProcessIO) (garden.Process, error)
 
 	NetIn(handle string, hostPort, containerPort uint32) (uint32, uint32, error)
+	NetInMapping(handle string, mapping garden.PortMapping) (garden.PortMapping, error)
 	NetOut(handle string, rule garden.NetOutRule) error
+	NetInRemove(handle string, mapping garden.PortMapping) error
+	NetOutRemove(handle string, rule garden.NetOutRule) error
 
 	Properties(handle string) (garden.Properties, error)
 	Property(handle string, name string) (string, error)
 	SetProperty(handle string, name string, value string) error
 
 	Metrics(handle string) (garden.Metrics, error)
+	NetworkState(handle string) (garden.NetworkState, error)
 	RemoveProperty(handle string, name string) error
+
+	CommitAndSave(handle string, dstPath string) error
+	CommitAndStream(handle string) (io.ReadCloser, error)
+	CommitImage(handle string, repository, tag string) (string, error)
+
+	PullImage(rootfs string) (string, error)
+	ListImages() ([]garden.ImageInfo, error)
+	CreationProgress(handle string) ([]string, error)
+
+	Checkpoint(handle string) error
 }
 
 type connection struct {
@@ -157,6 +179,30 @@ func (c *connection) Stop(handle string, kill bool) error {
 	)
 }
 
+func (c *connection) Pause(handle string) error {
+	return c.do(
+		routes.Pause,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) Resume(handle string) error {
+	return c.do(
+		routes.Resume,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) Destroy(handle string) error {
 	return c.do(
 		routes.Destroy,
@@ -252,6 +298,37 @@ func (c *connection) NetIn(handle string, hostPort, containerPort uint32) (uint3
 	return res.HostPort, res.ContainerPort, nil
 }
 
+func (c *connection) NetInMapping(handle string, mapping garden.PortMapping) (garden.PortMapping, error) {
+	res := &transport.NetInResponse{}
+
+	err := c.do(
+		routes.NetIn,
+		&transport.NetInRequest{
+			Handle:        handle,
+			HostPort:      mapping.HostPort,
+			ContainerPort: mapping.ContainerPort,
+			Protocol:      mapping.Protocol,
+			PortCount:     mapping.PortCount,
+		},
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	if err != nil {
+		return garden.PortMapping{}, err
+	}
+
+	return garden.PortMapping{
+		HostPort:      res.HostPort,
+		ContainerPort: res.ContainerPort,
+		Protocol:      res.Protocol,
+		PortCount:     res.PortCount,
+	}, nil
+}
+
 func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
 	return c.do(
 		routes.NetOut,
@@ -264,6 +341,34 @@ func (c *connection) NetOut(handle string, rule garden.NetOutRule) error {
 	)
 }
 
+func (c *connection) NetInRemove(handle string, mapping garden.PortMapping) error {
+	return c.do(
+		routes.NetInRemove,
+		&transport.NetInRequest{
+			Handle:   handle,
+			HostPort: mapping.HostPort,
+			Protocol: mapping.Protocol,
+		},
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
+func (c *connection) NetOutRemove(handle string, rule garden.NetOutRule) error {
+	return c.do(
+		routes.NetOutRemove,
+		rule,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) Property(handle string, name string) (string, error) {
 	var res struct {
 		Value string `json:"value"`
@@ -386,6 +491,70 @@ func (c *connection) CurrentCPULimits(handle string) (garden.CPULimits, error) {
 	return res, err
 }
 
+func (c *connection) LimitBlockIO(handle string, limits garden.BlockIOLimits) (garden.BlockIOLimits, error) {
+	res := garden.BlockIOLimits{}
+
+	err := c.do(
+		routes.LimitBlockIO,
+		limits,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
+func (c *connection) CurrentBlockIOLimits(handle string) (garden.BlockIOLimits, error) {
+	res := garden.BlockIOLimits{}
+
+	err := c.do(
+		routes.CurrentBlockIOLimits,
+		nil,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
+func (c *connection) LimitPids(handle string, limits garden.PidLimits) (garden.PidLimits, error) {
+	res := garden.PidLimits{}
+
+	err := c.do(
+		routes.LimitPids,
+		limits,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
+func (c *connection) CurrentPidLimits(handle string) (garden.PidLimits, error) {
+	res := garden.PidLimits{}
+
+	err := c.do(
+		routes.CurrentPidLimits,
+		nil,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+
+	return res, err
+}
+
 func (c *connection) LimitDisk(handle string, limits garden.DiskLimits) (garden.DiskLimits, error) {
 	res := garden.DiskLimits{}
 
@@ -450,6 +619,23 @@ func (c *connection) CurrentMemoryLimits(handle string) (garden.MemoryLimits, er
 	return res, err
 }
 
+func (c *connection) StreamOomEvents(handle string) (garden.OomEventStream, error) {
+	body, err := c.doStream(
+		routes.StreamOomEvents,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOomEventStream(body), nil
+}
+
 func (c *connection) StreamIn(handle string, dstPath string, reader io.Reader) error {
 	body, err := c.doStream(
 		routes.StreamIn,
@@ -483,6 +669,108 @@ func (c *connection) StreamOut(handle string, srcPath string) (io.ReadCloser, er
 	)
 }
 
+func (c *connection) CommitAndSave(handle string, dstPath string) error {
+	res := map[string]string{}
+
+	return c.do(
+		routes.CommitAndSave,
+		nil,
+		&res,
+		rata.Params{
+			"handle": handle,
+		},
+		url.Values{
+			"destination": []string{dstPath},
+		},
+	)
+}
+
+func (c *connection) CommitAndStream(handle string) (io.ReadCloser, error) {
+	return c.doStream(
+		routes.CommitAndStream,
+		nil,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+		"",
+	)
+}
+
+func (c *connection) CommitImage(handle string, repository, tag string) (string, error) {
+	res := &transport.CommitImageResponse{}
+
+	err := c.do(
+		routes.CommitImage,
+		&transport.CommitImageRequest{
+			Repository: repository,
+			Tag:        tag,
+		},
+		res,
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ImageID, nil
+}
+
+func (c *connection) PullImage(rootfs string) (string, error) {
+	res := &transport.PullImageResponse{}
+
+	err := c.do(
+		routes.PullImage,
+		&transport.PullImageRequest{
+			RootFSPath: rootfs,
+		},
+		res,
+		nil,
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return res.ImageID, nil
+}
+
+func (c *connection) ListImages() ([]garden.ImageInfo, error) {
+	res := &transport.ListImagesResponse{}
+
+	if err := c.do(routes.ListImages, nil, res, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return res.Images, nil
+}
+
+func (c *connection) CreationProgress(handle string) ([]string, error) {
+	res := &transport.CreationProgressResponse{}
+
+	err := c.do(routes.CreationProgress, nil, res, rata.Params{"handle": handle}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Events, nil
+}
+
+func (c *connection) Checkpoint(handle string) error {
+	return c.do(
+		routes.Checkpoint,
+		nil,
+		&struct{}{},
+		rata.Params{
+			"handle": handle,
+		},
+		nil,
+	)
+}
+
 func (c *connection) List(filterProperties garden.Properties) ([]string, error) {
 	values := url.Values{}
 	for name, val := range filterProperties {
@@ -518,6 +806,12 @@ func (c *connection) Metrics(handle string) (garden.Metrics, error) {
 	return res, err
 }
 
+func (c *connection) NetworkState(handle string) (garden.NetworkState, error) {
+	res := garden.NetworkState{}
+	err := c.do(routes.NetworkState, nil, &res, rata.Params{"handle": handle}, nil)
+	return res, err
+}
+
 func (c *connection) Info(handle string) (garden.ContainerInfo, error) {
 	res := garden.ContainerInfo{}
 
@@ -276,6 +276,48 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Pausing", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/pause"),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should pause the container", func() {
+			err := connection.Pause("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Resuming", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/resume"),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should resume the container", func() {
+			err := connection.Resume("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Checkpointing", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/containers/foo/checkpoint"),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should checkpoint the container", func() {
+			err := connection.Checkpoint("foo")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("Limiting Memory", func() {
 		Describe("setting the memory limit", func() {
 			BeforeEach(func() {
@@ -348,6 +390,32 @@ var _ = Describe("Connection", func() {
 			})
 		})
 
+		Describe("setting hard caps and pinning", func() {
+			limits := garden.CPULimits{
+				QuotaInMicroseconds:  50000,
+				PeriodInMicroseconds: 100000,
+				Cpus:                 "0-1",
+				Mems:                 "0",
+			}
+
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/cpu"),
+						verifyRequestBody(&limits, &garden.CPULimits{}),
+						ghttp.RespondWith(200, marshalProto(&limits)),
+					),
+				)
+			})
+
+			It("sends and returns them", func() {
+				newLimits, err := connection.LimitCPU("foo", limits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(newLimits).Should(Equal(limits))
+			})
+		})
+
 		Describe("getting", func() {
 			BeforeEach(func() {
 				server.AppendHandlers(
@@ -369,6 +437,93 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Limiting block IO", func() {
+		limits := garden.BlockIOLimits{
+			Weight: 500,
+			Devices: []garden.BlockIODeviceLimits{
+				{Major: 8, Minor: 0, WriteBytesPerSecond: 1024},
+			},
+		}
+
+		Describe("setting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/block_io"),
+						verifyRequestBody(&limits, &garden.BlockIOLimits{}),
+						ghttp.RespondWith(200, marshalProto(&limits)),
+					),
+				)
+			})
+
+			It("sends and returns them", func() {
+				newLimits, err := connection.LimitBlockIO("foo", limits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(newLimits).Should(Equal(limits))
+			})
+		})
+
+		Describe("getting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/block_io"),
+						ghttp.RespondWith(200, marshalProto(&limits)),
+					),
+				)
+			})
+
+			It("returns them", func() {
+				currentLimits, err := connection.CurrentBlockIOLimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(currentLimits).Should(Equal(limits))
+			})
+		})
+	})
+
+	Describe("Limiting pids", func() {
+		limits := garden.PidLimits{Max: 1024}
+
+		Describe("setting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PUT", "/containers/foo/limits/pid"),
+						verifyRequestBody(&limits, &garden.PidLimits{}),
+						ghttp.RespondWith(200, marshalProto(&limits)),
+					),
+				)
+			})
+
+			It("sends and returns it", func() {
+				newLimits, err := connection.LimitPids("foo", limits)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(newLimits).Should(Equal(limits))
+			})
+		})
+
+		Describe("getting", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo/limits/pid"),
+						ghttp.RespondWith(200, marshalProto(&limits)),
+					),
+				)
+			})
+
+			It("returns it", func() {
+				currentLimits, err := connection.CurrentPidLimits("foo")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				Ω(currentLimits).Should(Equal(limits))
+			})
+		})
+	})
+
 	Describe("Limiting Bandwidth", func() {
 		Describe("setting", func() {
 			BeforeEach(func() {
@@ -529,6 +684,43 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("NetInMapping", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/containers/foo-handle/net/in"),
+					verifyRequestBody(map[string]interface{}{
+						"handle":         "foo-handle",
+						"host_port":      float64(8080),
+						"container_port": float64(8081),
+						"protocol":       float64(garden.ProtocolUDP),
+						"port_count":     float64(3),
+					}, make(map[string]interface{})),
+					ghttp.RespondWith(200, marshalProto(map[string]interface{}{
+						"host_port":      1234,
+						"container_port": 1235,
+						"protocol":       garden.ProtocolUDP,
+						"port_count":     3,
+					}))))
+		})
+
+		It("should return the mapping", func() {
+			mapping, err := connection.NetInMapping("foo-handle", garden.PortMapping{
+				HostPort:      8080,
+				ContainerPort: 8081,
+				Protocol:      garden.ProtocolUDP,
+				PortCount:     3,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mapping).Should(Equal(garden.PortMapping{
+				HostPort:      1234,
+				ContainerPort: 1235,
+				Protocol:      garden.ProtocolUDP,
+				PortCount:     3,
+			}))
+		})
+	})
+
 	Describe("NetOut", func() {
 		var (
 			rule   garden.NetOutRule
@@ -564,6 +756,47 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("NetInRemove", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo-handle/net/in"),
+					verifyRequestBody(map[string]interface{}{
+						"handle":    "foo-handle",
+						"host_port": float64(8080),
+						"protocol":  float64(garden.ProtocolUDP),
+					}, make(map[string]interface{})),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should remove the mapping from the host port for the protocol", func() {
+			Ω(connection.NetInRemove("foo-handle", garden.PortMapping{
+				HostPort: 8080,
+				Protocol: garden.ProtocolUDP,
+			})).Should(Succeed())
+		})
+	})
+
+	Describe("NetOutRemove", func() {
+		rule := garden.NetOutRule{
+			Protocol: garden.ProtocolTCP,
+			Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))},
+			Ports:    []garden.PortRange{garden.PortRangeFromPort(2)},
+		}
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("DELETE", "/containers/foo-handle/net/out"),
+					verifyRequestBody(&rule, &garden.NetOutRule{}),
+					ghttp.RespondWith(200, "{}")))
+		})
+
+		It("should send the rule over the wire", func() {
+			Ω(connection.NetOutRemove("foo-handle", rule)).Should(Succeed())
+		})
+	})
+
 	Describe("Listing containers", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(
@@ -736,6 +969,49 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Getting container network state", func() {
+		handle := "container-handle"
+		state := garden.NetworkState{
+			NATChain: []garden.IPTablesRule{
+				{Table: "nat", Chain: "w--instance-abc", Protocol: "tcp", DestinationPorts: "8080", Target: "DNAT", ToDestination: "10.0.0.2:80", Spec: "-A w--instance-abc -p tcp --dport 8080 -j DNAT --to-destination 10.0.0.2:80"},
+			},
+			Qdiscs: []garden.Qdisc{{Device: "w-abc-0", Kind: "tbf", Handle: "8001:", Parent: "root"}},
+			Drift: []garden.NetworkDrift{
+				{NetOut: &garden.NetOutRule{Protocol: garden.ProtocolTCP}, Message: "no iptables rule"},
+			},
+		}
+		var status int
+
+		BeforeEach(func() {
+			status = 200
+		})
+
+		JustBeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", fmt.Sprintf("/containers/%s/net/state", handle)),
+					ghttp.RespondWith(status, marshalProto(state))))
+		})
+
+		It("returns the rules, qdiscs and drift", func() {
+			returnedState, err := connection.NetworkState(handle)
+
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(returnedState).Should(Equal(state))
+		})
+
+		Context("when getting the network state fails", func() {
+			BeforeEach(func() {
+				status = 400
+			})
+
+			It("returns an error", func() {
+				_, err := connection.NetworkState(handle)
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
 	Describe("Getting container info", func() {
 		var infoResponse garden.ContainerInfo
 
@@ -1044,6 +1320,215 @@ var _ = Describe("Connection", func() {
 		})
 	})
 
+	Describe("Committing and streaming an image", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/foo-handle/images/stream"),
+					ghttp.RespondWith(200, "some-tar"),
+				),
+			)
+		})
+
+		It("streams the committed image", func() {
+			reader, err := connection.CommitAndStream("foo-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			readBytes, err := ioutil.ReadAll(reader)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(readBytes).Should(Equal([]byte("some-tar")))
+
+			reader.Close()
+		})
+	})
+
+	Describe("Committing an image", func() {
+		Context("when committing succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/images"),
+						verifyRequestBody(&transport.CommitImageRequest{
+							Repository: "some-repo",
+							Tag:        "some-tag",
+						}, &transport.CommitImageRequest{}),
+						ghttp.RespondWith(200, marshalProto(&transport.CommitImageResponse{
+							ImageID: "some-image-id",
+						}))))
+			})
+
+			It("returns the committed image's ID", func() {
+				imageID, err := connection.CommitImage("foo-handle", "some-repo", "some-tag")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(imageID).Should(Equal("some-image-id"))
+			})
+		})
+
+		Context("when committing fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("POST", "/containers/foo-handle/images"),
+						ghttp.RespondWith(500, "some error")))
+			})
+
+			It("returns an error with the code and message", func() {
+				_, err := connection.CommitImage("foo-handle", "some-repo", "some-tag")
+				Ω(err).Should(MatchError(Error{500, "some error"}))
+			})
+		})
+	})
+
+	Describe("Pulling an image", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/images"),
+					verifyRequestBody(&transport.PullImageRequest{
+						RootFSPath: "docker:///some-repo#some-tag",
+					}, &transport.PullImageRequest{}),
+					ghttp.RespondWith(200, marshalProto(&transport.PullImageResponse{
+						ImageID: "some-image-id",
+					}))))
+		})
+
+		It("returns the pulled image's ID", func() {
+			imageID, err := connection.PullImage("docker:///some-repo#some-tag")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(imageID).Should(Equal("some-image-id"))
+		})
+	})
+
+	Describe("Listing images", func() {
+		images := []garden.ImageInfo{
+			{ID: "some-image-id", Parent: "some-parent-id", Size: 42},
+			{ID: "some-parent-id", Size: 1},
+		}
+
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/images"),
+					ghttp.RespondWith(200, marshalProto(&transport.ListImagesResponse{Images: images}))))
+		})
+
+		It("returns the images", func() {
+			listed, err := connection.ListImages()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(listed).Should(HaveLen(2))
+			Ω(listed[0].ID).Should(Equal("some-image-id"))
+			Ω(listed[0].Parent).Should(Equal("some-parent-id"))
+			Ω(listed[0].Size).Should(Equal(int64(42)))
+			Ω(listed[1].ID).Should(Equal("some-parent-id"))
+		})
+	})
+
+	Describe("Listing images, as sent over the wire", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/images"),
+					ghttp.RespondWith(200, `{"images":[{"id":"some-image-id","parent":"some-parent-id","size":42}]}`)))
+		})
+
+		It("decodes the images", func() {
+			listed, err := connection.ListImages()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(listed).Should(HaveLen(1))
+			Ω(listed[0].ID).Should(Equal("some-image-id"))
+			Ω(listed[0].Parent).Should(Equal("some-parent-id"))
+			Ω(listed[0].Size).Should(Equal(int64(42)))
+		})
+	})
+
+	Describe("Getting the progress of a container's creation", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/containers/some-handle/progress"),
+					ghttp.RespondWith(200, marshalProto(&transport.CreationProgressResponse{
+						Events: []string{"fetching layer some-layer"},
+					}))))
+		})
+
+		It("returns the events so far", func() {
+			events, err := connection.CreationProgress("some-handle")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(events).Should(Equal([]string{"fetching layer some-layer"}))
+		})
+	})
+
+	Describe("Streaming OOM events", func() {
+		Context("when streaming succeeds", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/events/oom"),
+						ghttp.RespondWith(200, marshalProto(
+							&garden.OomEvent{
+								Time:   time.Unix(123, 0),
+								Policy: garden.OomPolicyKillProcess,
+							},
+							&garden.OomEvent{
+								Time:   time.Unix(456, 0),
+								Policy: garden.OomPolicyNotify,
+							},
+						)),
+					),
+				)
+			})
+
+			It("decodes each event until the stream ends", func() {
+				stream, err := connection.StreamOomEvents("foo-handle")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				defer stream.Close()
+
+				event, err := stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(event.Time).Should(BeTemporally("==", time.Unix(123, 0)))
+				Ω(event.Policy).Should(Equal(garden.OomPolicyKillProcess))
+
+				event, err = stream.Next()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(event.Time).Should(BeTemporally("==", time.Unix(456, 0)))
+				Ω(event.Policy).Should(Equal(garden.OomPolicyNotify))
+
+				_, err = stream.Next()
+				Ω(err).Should(Equal(io.EOF))
+			})
+
+			Context("when the stream is closed", func() {
+				It("ends the stream", func() {
+					stream, err := connection.StreamOomEvents("foo-handle")
+					Ω(err).ShouldNot(HaveOccurred())
+
+					err = stream.Close()
+					Ω(err).ShouldNot(HaveOccurred())
+
+					_, err = stream.Next()
+					Ω(err).Should(Equal(io.EOF))
+				})
+			})
+		})
+
+		Context("when the request fails", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("GET", "/containers/foo-handle/events/oom"),
+						ghttp.RespondWith(500, ""),
+					),
+				)
+			})
+
+			It("returns an error", func() {
+				_, err := connection.StreamOomEvents("foo-handle")
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
 	Describe("Running", func() {
 		var (
 			spec         garden.ProcessSpec
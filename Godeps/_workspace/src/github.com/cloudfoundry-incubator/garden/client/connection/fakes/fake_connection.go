@@ -125,6 +125,26 @@ type FakeConnection struct {
 		result1 garden.CPULimits
 		result2 error
 	}
+	LimitBlockIOStub        func(handle string, limits garden.BlockIOLimits) (garden.BlockIOLimits, error)
+	limitBlockIOMutex       sync.RWMutex
+	limitBlockIOArgsForCall []struct {
+		handle string
+		limits garden.BlockIOLimits
+	}
+	limitBlockIOReturns struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}
+	LimitPidsStub        func(handle string, limits garden.PidLimits) (garden.PidLimits, error)
+	limitPidsMutex       sync.RWMutex
+	limitPidsArgsForCall []struct {
+		handle string
+		limits garden.PidLimits
+	}
+	limitPidsReturns struct {
+		result1 garden.PidLimits
+		result2 error
+	}
 	LimitDiskStub        func(handle string, limits garden.DiskLimits) (garden.DiskLimits, error)
 	limitDiskMutex       sync.RWMutex
 	limitDiskArgsForCall []struct {
@@ -163,6 +183,24 @@ type FakeConnection struct {
 		result1 garden.CPULimits
 		result2 error
 	}
+	CurrentBlockIOLimitsStub        func(handle string) (garden.BlockIOLimits, error)
+	currentBlockIOLimitsMutex       sync.RWMutex
+	currentBlockIOLimitsArgsForCall []struct {
+		handle string
+	}
+	currentBlockIOLimitsReturns struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}
+	CurrentPidLimitsStub        func(handle string) (garden.PidLimits, error)
+	currentPidLimitsMutex       sync.RWMutex
+	currentPidLimitsArgsForCall []struct {
+		handle string
+	}
+	currentPidLimitsReturns struct {
+		result1 garden.PidLimits
+		result2 error
+	}
 	CurrentDiskLimitsStub        func(handle string) (garden.DiskLimits, error)
 	currentDiskLimitsMutex       sync.RWMutex
 	currentDiskLimitsArgsForCall []struct {
@@ -181,6 +219,15 @@ type FakeConnection struct {
 		result1 garden.MemoryLimits
 		result2 error
 	}
+	StreamOomEventsStub        func(handle string) (garden.OomEventStream, error)
+	streamOomEventsMutex       sync.RWMutex
+	streamOomEventsArgsForCall []struct {
+		handle string
+	}
+	streamOomEventsReturns struct {
+		result1 garden.OomEventStream
+		result2 error
+	}
 	RunStub        func(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -215,6 +262,16 @@ type FakeConnection struct {
 		result2 uint32
 		result3 error
 	}
+	NetInMappingStub        func(handle string, mapping garden.PortMapping) (garden.PortMapping, error)
+	netInMappingMutex       sync.RWMutex
+	netInMappingArgsForCall []struct {
+		handle  string
+		mapping garden.PortMapping
+	}
+	netInMappingReturns struct {
+		result1 garden.PortMapping
+		result2 error
+	}
 	NetOutStub        func(handle string, rule garden.NetOutRule) error
 	netOutMutex       sync.RWMutex
 	netOutArgsForCall []struct {
@@ -224,6 +281,24 @@ type FakeConnection struct {
 	netOutReturns struct {
 		result1 error
 	}
+	NetInRemoveStub        func(handle string, mapping garden.PortMapping) error
+	netInRemoveMutex       sync.RWMutex
+	netInRemoveArgsForCall []struct {
+		handle  string
+		mapping garden.PortMapping
+	}
+	netInRemoveReturns struct {
+		result1 error
+	}
+	NetOutRemoveStub        func(handle string, rule garden.NetOutRule) error
+	netOutRemoveMutex       sync.RWMutex
+	netOutRemoveArgsForCall []struct {
+		handle string
+		rule   garden.NetOutRule
+	}
+	netOutRemoveReturns struct {
+		result1 error
+	}
 	PropertiesStub        func(handle string) (garden.Properties, error)
 	propertiesMutex       sync.RWMutex
 	propertiesArgsForCall []struct {
@@ -262,6 +337,15 @@ type FakeConnection struct {
 		result1 garden.Metrics
 		result2 error
 	}
+	NetworkStateStub        func(handle string) (garden.NetworkState, error)
+	networkStateMutex       sync.RWMutex
+	networkStateArgsForCall []struct {
+		handle string
+	}
+	networkStateReturns struct {
+		result1 garden.NetworkState
+		result2 error
+	}
 	RemovePropertyStub        func(handle string, name string) error
 	removePropertyMutex       sync.RWMutex
 	removePropertyArgsForCall []struct {
@@ -271,6 +355,84 @@ type FakeConnection struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	CommitAndSaveStub        func(handle string, dstPath string) error
+	commitAndSaveMutex       sync.RWMutex
+	commitAndSaveArgsForCall []struct {
+		handle  string
+		dstPath string
+	}
+	commitAndSaveReturns struct {
+		result1 error
+	}
+	CommitAndStreamStub        func(handle string) (io.ReadCloser, error)
+	commitAndStreamMutex       sync.RWMutex
+	commitAndStreamArgsForCall []struct {
+		handle string
+	}
+	commitAndStreamReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	CommitImageStub        func(handle string, repository string, tag string) (string, error)
+	commitImageMutex       sync.RWMutex
+	commitImageArgsForCall []struct {
+		handle     string
+		repository string
+		tag        string
+	}
+	commitImageReturns struct {
+		result1 string
+		result2 error
+	}
+	PullImageStub        func(rootfs string) (string, error)
+	pullImageMutex       sync.RWMutex
+	pullImageArgsForCall []struct {
+		rootfs string
+	}
+	pullImageReturns struct {
+		result1 string
+		result2 error
+	}
+	ListImagesStub        func() ([]garden.ImageInfo, error)
+	listImagesMutex       sync.RWMutex
+	listImagesArgsForCall []struct{}
+	listImagesReturns     struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}
+	CreationProgressStub        func(handle string) ([]string, error)
+	creationProgressMutex       sync.RWMutex
+	creationProgressArgsForCall []struct {
+		handle string
+	}
+	creationProgressReturns struct {
+		result1 []string
+		result2 error
+	}
+	CheckpointStub        func(handle string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		handle string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	PauseStub        func(handle string) error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct {
+		handle string
+	}
+	pauseReturns struct {
+		result1 error
+	}
+	ResumeStub        func(handle string) error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct {
+		handle string
+	}
+	resumeReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeConnection) Ping() error {
@@ -688,6 +850,74 @@ func (fake *FakeConnection) LimitCPUReturns(result1 garden.CPULimits, result2 er
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) LimitBlockIO(handle string, limits garden.BlockIOLimits) (garden.BlockIOLimits, error) {
+	fake.limitBlockIOMutex.Lock()
+	fake.limitBlockIOArgsForCall = append(fake.limitBlockIOArgsForCall, struct {
+		handle string
+		limits garden.BlockIOLimits
+	}{handle, limits})
+	fake.limitBlockIOMutex.Unlock()
+	if fake.LimitBlockIOStub != nil {
+		return fake.LimitBlockIOStub(handle, limits)
+	} else {
+		return fake.limitBlockIOReturns.result1, fake.limitBlockIOReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LimitBlockIOCallCount() int {
+	fake.limitBlockIOMutex.RLock()
+	defer fake.limitBlockIOMutex.RUnlock()
+	return len(fake.limitBlockIOArgsForCall)
+}
+
+func (fake *FakeConnection) LimitBlockIOArgsForCall(i int) (string, garden.BlockIOLimits) {
+	fake.limitBlockIOMutex.RLock()
+	defer fake.limitBlockIOMutex.RUnlock()
+	return fake.limitBlockIOArgsForCall[i].handle, fake.limitBlockIOArgsForCall[i].limits
+}
+
+func (fake *FakeConnection) LimitBlockIOReturns(result1 garden.BlockIOLimits, result2 error) {
+	fake.LimitBlockIOStub = nil
+	fake.limitBlockIOReturns = struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) LimitPids(handle string, limits garden.PidLimits) (garden.PidLimits, error) {
+	fake.limitPidsMutex.Lock()
+	fake.limitPidsArgsForCall = append(fake.limitPidsArgsForCall, struct {
+		handle string
+		limits garden.PidLimits
+	}{handle, limits})
+	fake.limitPidsMutex.Unlock()
+	if fake.LimitPidsStub != nil {
+		return fake.LimitPidsStub(handle, limits)
+	} else {
+		return fake.limitPidsReturns.result1, fake.limitPidsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) LimitPidsCallCount() int {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return len(fake.limitPidsArgsForCall)
+}
+
+func (fake *FakeConnection) LimitPidsArgsForCall(i int) (string, garden.PidLimits) {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return fake.limitPidsArgsForCall[i].handle, fake.limitPidsArgsForCall[i].limits
+}
+
+func (fake *FakeConnection) LimitPidsReturns(result1 garden.PidLimits, result2 error) {
+	fake.LimitPidsStub = nil
+	fake.limitPidsReturns = struct {
+		result1 garden.PidLimits
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) LimitDisk(handle string, limits garden.DiskLimits) (garden.DiskLimits, error) {
 	fake.limitDiskMutex.Lock()
 	fake.limitDiskArgsForCall = append(fake.limitDiskArgsForCall, struct {
@@ -822,6 +1052,72 @@ func (fake *FakeConnection) CurrentCPULimitsReturns(result1 garden.CPULimits, re
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) CurrentBlockIOLimits(handle string) (garden.BlockIOLimits, error) {
+	fake.currentBlockIOLimitsMutex.Lock()
+	fake.currentBlockIOLimitsArgsForCall = append(fake.currentBlockIOLimitsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.currentBlockIOLimitsMutex.Unlock()
+	if fake.CurrentBlockIOLimitsStub != nil {
+		return fake.CurrentBlockIOLimitsStub(handle)
+	} else {
+		return fake.currentBlockIOLimitsReturns.result1, fake.currentBlockIOLimitsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CurrentBlockIOLimitsCallCount() int {
+	fake.currentBlockIOLimitsMutex.RLock()
+	defer fake.currentBlockIOLimitsMutex.RUnlock()
+	return len(fake.currentBlockIOLimitsArgsForCall)
+}
+
+func (fake *FakeConnection) CurrentBlockIOLimitsArgsForCall(i int) string {
+	fake.currentBlockIOLimitsMutex.RLock()
+	defer fake.currentBlockIOLimitsMutex.RUnlock()
+	return fake.currentBlockIOLimitsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CurrentBlockIOLimitsReturns(result1 garden.BlockIOLimits, result2 error) {
+	fake.CurrentBlockIOLimitsStub = nil
+	fake.currentBlockIOLimitsReturns = struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) CurrentPidLimits(handle string) (garden.PidLimits, error) {
+	fake.currentPidLimitsMutex.Lock()
+	fake.currentPidLimitsArgsForCall = append(fake.currentPidLimitsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.currentPidLimitsMutex.Unlock()
+	if fake.CurrentPidLimitsStub != nil {
+		return fake.CurrentPidLimitsStub(handle)
+	} else {
+		return fake.currentPidLimitsReturns.result1, fake.currentPidLimitsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CurrentPidLimitsCallCount() int {
+	fake.currentPidLimitsMutex.RLock()
+	defer fake.currentPidLimitsMutex.RUnlock()
+	return len(fake.currentPidLimitsArgsForCall)
+}
+
+func (fake *FakeConnection) CurrentPidLimitsArgsForCall(i int) string {
+	fake.currentPidLimitsMutex.RLock()
+	defer fake.currentPidLimitsMutex.RUnlock()
+	return fake.currentPidLimitsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CurrentPidLimitsReturns(result1 garden.PidLimits, result2 error) {
+	fake.CurrentPidLimitsStub = nil
+	fake.currentPidLimitsReturns = struct {
+		result1 garden.PidLimits
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) CurrentDiskLimits(handle string) (garden.DiskLimits, error) {
 	fake.currentDiskLimitsMutex.Lock()
 	fake.currentDiskLimitsArgsForCall = append(fake.currentDiskLimitsArgsForCall, struct {
@@ -888,6 +1184,39 @@ func (fake *FakeConnection) CurrentMemoryLimitsReturns(result1 garden.MemoryLimi
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) StreamOomEvents(handle string) (garden.OomEventStream, error) {
+	fake.streamOomEventsMutex.Lock()
+	fake.streamOomEventsArgsForCall = append(fake.streamOomEventsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.streamOomEventsMutex.Unlock()
+	if fake.StreamOomEventsStub != nil {
+		return fake.StreamOomEventsStub(handle)
+	} else {
+		return fake.streamOomEventsReturns.result1, fake.streamOomEventsReturns.result2
+	}
+}
+
+func (fake *FakeConnection) StreamOomEventsCallCount() int {
+	fake.streamOomEventsMutex.RLock()
+	defer fake.streamOomEventsMutex.RUnlock()
+	return len(fake.streamOomEventsArgsForCall)
+}
+
+func (fake *FakeConnection) StreamOomEventsArgsForCall(i int) string {
+	fake.streamOomEventsMutex.RLock()
+	defer fake.streamOomEventsMutex.RUnlock()
+	return fake.streamOomEventsArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) StreamOomEventsReturns(result1 garden.OomEventStream, result2 error) {
+	fake.StreamOomEventsStub = nil
+	fake.streamOomEventsReturns = struct {
+		result1 garden.OomEventStream
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) Run(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
 	fake.runMutex.Lock()
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
@@ -994,6 +1323,40 @@ func (fake *FakeConnection) NetInReturns(result1 uint32, result2 uint32, result3
 	}{result1, result2, result3}
 }
 
+func (fake *FakeConnection) NetInMapping(handle string, mapping garden.PortMapping) (garden.PortMapping, error) {
+	fake.netInMappingMutex.Lock()
+	fake.netInMappingArgsForCall = append(fake.netInMappingArgsForCall, struct {
+		handle  string
+		mapping garden.PortMapping
+	}{handle, mapping})
+	fake.netInMappingMutex.Unlock()
+	if fake.NetInMappingStub != nil {
+		return fake.NetInMappingStub(handle, mapping)
+	} else {
+		return fake.netInMappingReturns.result1, fake.netInMappingReturns.result2
+	}
+}
+
+func (fake *FakeConnection) NetInMappingCallCount() int {
+	fake.netInMappingMutex.RLock()
+	defer fake.netInMappingMutex.RUnlock()
+	return len(fake.netInMappingArgsForCall)
+}
+
+func (fake *FakeConnection) NetInMappingArgsForCall(i int) (string, garden.PortMapping) {
+	fake.netInMappingMutex.RLock()
+	defer fake.netInMappingMutex.RUnlock()
+	return fake.netInMappingArgsForCall[i].handle, fake.netInMappingArgsForCall[i].mapping
+}
+
+func (fake *FakeConnection) NetInMappingReturns(result1 garden.PortMapping, result2 error) {
+	fake.NetInMappingStub = nil
+	fake.netInMappingReturns = struct {
+		result1 garden.PortMapping
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) NetOut(handle string, rule garden.NetOutRule) error {
 	fake.netOutMutex.Lock()
 	fake.netOutArgsForCall = append(fake.netOutArgsForCall, struct {
@@ -1027,6 +1390,72 @@ func (fake *FakeConnection) NetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) NetInRemove(handle string, mapping garden.PortMapping) error {
+	fake.netInRemoveMutex.Lock()
+	fake.netInRemoveArgsForCall = append(fake.netInRemoveArgsForCall, struct {
+		handle  string
+		mapping garden.PortMapping
+	}{handle, mapping})
+	fake.netInRemoveMutex.Unlock()
+	if fake.NetInRemoveStub != nil {
+		return fake.NetInRemoveStub(handle, mapping)
+	} else {
+		return fake.netInRemoveReturns.result1
+	}
+}
+
+func (fake *FakeConnection) NetInRemoveCallCount() int {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return len(fake.netInRemoveArgsForCall)
+}
+
+func (fake *FakeConnection) NetInRemoveArgsForCall(i int) (string, garden.PortMapping) {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return fake.netInRemoveArgsForCall[i].handle, fake.netInRemoveArgsForCall[i].mapping
+}
+
+func (fake *FakeConnection) NetInRemoveReturns(result1 error) {
+	fake.NetInRemoveStub = nil
+	fake.netInRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) NetOutRemove(handle string, rule garden.NetOutRule) error {
+	fake.netOutRemoveMutex.Lock()
+	fake.netOutRemoveArgsForCall = append(fake.netOutRemoveArgsForCall, struct {
+		handle string
+		rule   garden.NetOutRule
+	}{handle, rule})
+	fake.netOutRemoveMutex.Unlock()
+	if fake.NetOutRemoveStub != nil {
+		return fake.NetOutRemoveStub(handle, rule)
+	} else {
+		return fake.netOutRemoveReturns.result1
+	}
+}
+
+func (fake *FakeConnection) NetOutRemoveCallCount() int {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return len(fake.netOutRemoveArgsForCall)
+}
+
+func (fake *FakeConnection) NetOutRemoveArgsForCall(i int) (string, garden.NetOutRule) {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return fake.netOutRemoveArgsForCall[i].handle, fake.netOutRemoveArgsForCall[i].rule
+}
+
+func (fake *FakeConnection) NetOutRemoveReturns(result1 error) {
+	fake.NetOutRemoveStub = nil
+	fake.netOutRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConnection) Properties(handle string) (garden.Properties, error) {
 	fake.propertiesMutex.Lock()
 	fake.propertiesArgsForCall = append(fake.propertiesArgsForCall, struct {
@@ -1161,6 +1590,39 @@ func (fake *FakeConnection) MetricsReturns(result1 garden.Metrics, result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeConnection) NetworkState(handle string) (garden.NetworkState, error) {
+	fake.networkStateMutex.Lock()
+	fake.networkStateArgsForCall = append(fake.networkStateArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.networkStateMutex.Unlock()
+	if fake.NetworkStateStub != nil {
+		return fake.NetworkStateStub(handle)
+	} else {
+		return fake.networkStateReturns.result1, fake.networkStateReturns.result2
+	}
+}
+
+func (fake *FakeConnection) NetworkStateCallCount() int {
+	fake.networkStateMutex.RLock()
+	defer fake.networkStateMutex.RUnlock()
+	return len(fake.networkStateArgsForCall)
+}
+
+func (fake *FakeConnection) NetworkStateArgsForCall(i int) string {
+	fake.networkStateMutex.RLock()
+	defer fake.networkStateMutex.RUnlock()
+	return fake.networkStateArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) NetworkStateReturns(result1 garden.NetworkState, result2 error) {
+	fake.NetworkStateStub = nil
+	fake.networkStateReturns = struct {
+		result1 garden.NetworkState
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConnection) RemoveProperty(handle string, name string) error {
 	fake.removePropertyMutex.Lock()
 	fake.removePropertyArgsForCall = append(fake.removePropertyArgsForCall, struct {
@@ -1194,4 +1656,292 @@ func (fake *FakeConnection) RemovePropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConnection) CommitAndSave(handle string, dstPath string) error {
+	fake.commitAndSaveMutex.Lock()
+	fake.commitAndSaveArgsForCall = append(fake.commitAndSaveArgsForCall, struct {
+		handle  string
+		dstPath string
+	}{handle, dstPath})
+	fake.commitAndSaveMutex.Unlock()
+	if fake.CommitAndSaveStub != nil {
+		return fake.CommitAndSaveStub(handle, dstPath)
+	} else {
+		return fake.commitAndSaveReturns.result1
+	}
+}
+
+func (fake *FakeConnection) CommitAndSaveCallCount() int {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return len(fake.commitAndSaveArgsForCall)
+}
+
+func (fake *FakeConnection) CommitAndSaveArgsForCall(i int) (string, string) {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return fake.commitAndSaveArgsForCall[i].handle, fake.commitAndSaveArgsForCall[i].dstPath
+}
+
+func (fake *FakeConnection) CommitAndSaveReturns(result1 error) {
+	fake.CommitAndSaveStub = nil
+	fake.commitAndSaveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) CommitAndStream(handle string) (io.ReadCloser, error) {
+	fake.commitAndStreamMutex.Lock()
+	fake.commitAndStreamArgsForCall = append(fake.commitAndStreamArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.commitAndStreamMutex.Unlock()
+	if fake.CommitAndStreamStub != nil {
+		return fake.CommitAndStreamStub(handle)
+	} else {
+		return fake.commitAndStreamReturns.result1, fake.commitAndStreamReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CommitAndStreamCallCount() int {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return len(fake.commitAndStreamArgsForCall)
+}
+
+func (fake *FakeConnection) CommitAndStreamArgsForCall(i int) string {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return fake.commitAndStreamArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CommitAndStreamReturns(result1 io.ReadCloser, result2 error) {
+	fake.CommitAndStreamStub = nil
+	fake.commitAndStreamReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) CommitImage(handle string, repository string, tag string) (string, error) {
+	fake.commitImageMutex.Lock()
+	fake.commitImageArgsForCall = append(fake.commitImageArgsForCall, struct {
+		handle     string
+		repository string
+		tag        string
+	}{handle, repository, tag})
+	fake.commitImageMutex.Unlock()
+	if fake.CommitImageStub != nil {
+		return fake.CommitImageStub(handle, repository, tag)
+	} else {
+		return fake.commitImageReturns.result1, fake.commitImageReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CommitImageCallCount() int {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return len(fake.commitImageArgsForCall)
+}
+
+func (fake *FakeConnection) CommitImageArgsForCall(i int) (string, string, string) {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return fake.commitImageArgsForCall[i].handle, fake.commitImageArgsForCall[i].repository, fake.commitImageArgsForCall[i].tag
+}
+
+func (fake *FakeConnection) CommitImageReturns(result1 string, result2 error) {
+	fake.CommitImageStub = nil
+	fake.commitImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) PullImage(rootfs string) (string, error) {
+	fake.pullImageMutex.Lock()
+	fake.pullImageArgsForCall = append(fake.pullImageArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.pullImageMutex.Unlock()
+	if fake.PullImageStub != nil {
+		return fake.PullImageStub(rootfs)
+	} else {
+		return fake.pullImageReturns.result1, fake.pullImageReturns.result2
+	}
+}
+
+func (fake *FakeConnection) PullImageCallCount() int {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return len(fake.pullImageArgsForCall)
+}
+
+func (fake *FakeConnection) PullImageArgsForCall(i int) string {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return fake.pullImageArgsForCall[i].rootfs
+}
+
+func (fake *FakeConnection) PullImageReturns(result1 string, result2 error) {
+	fake.PullImageStub = nil
+	fake.pullImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) ListImages() ([]garden.ImageInfo, error) {
+	fake.listImagesMutex.Lock()
+	fake.listImagesArgsForCall = append(fake.listImagesArgsForCall, struct{}{})
+	fake.listImagesMutex.Unlock()
+	if fake.ListImagesStub != nil {
+		return fake.ListImagesStub()
+	} else {
+		return fake.listImagesReturns.result1, fake.listImagesReturns.result2
+	}
+}
+
+func (fake *FakeConnection) ListImagesCallCount() int {
+	fake.listImagesMutex.RLock()
+	defer fake.listImagesMutex.RUnlock()
+	return len(fake.listImagesArgsForCall)
+}
+
+func (fake *FakeConnection) ListImagesReturns(result1 []garden.ImageInfo, result2 error) {
+	fake.ListImagesStub = nil
+	fake.listImagesReturns = struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) CreationProgress(handle string) ([]string, error) {
+	fake.creationProgressMutex.Lock()
+	fake.creationProgressArgsForCall = append(fake.creationProgressArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.creationProgressMutex.Unlock()
+	if fake.CreationProgressStub != nil {
+		return fake.CreationProgressStub(handle)
+	} else {
+		return fake.creationProgressReturns.result1, fake.creationProgressReturns.result2
+	}
+}
+
+func (fake *FakeConnection) CreationProgressCallCount() int {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return len(fake.creationProgressArgsForCall)
+}
+
+func (fake *FakeConnection) CreationProgressArgsForCall(i int) string {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return fake.creationProgressArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CreationProgressReturns(result1 []string, result2 error) {
+	fake.CreationProgressStub = nil
+	fake.creationProgressReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConnection) Checkpoint(handle string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(handle)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeConnection) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeConnection) CheckpointArgsForCall(i int) string {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Pause(handle string) error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub(handle)
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeConnection) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeConnection) PauseArgsForCall(i int) string {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return fake.pauseArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeConnection) Resume(handle string) error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub(handle)
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeConnection) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeConnection) ResumeArgsForCall(i int) string {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return fake.resumeArgsForCall[i].handle
+}
+
+func (fake *FakeConnection) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ connection.Connection = new(FakeConnection)
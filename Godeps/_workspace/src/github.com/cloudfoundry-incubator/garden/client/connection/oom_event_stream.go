@@ -0,0 +1,54 @@
+package connection
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// oomEventStream decodes the events the server writes, one JSON message
+// each, until the response ends.
+type oomEventStream struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+
+	closed bool
+	mutex  sync.Mutex
+}
+
+func newOomEventStream(body io.ReadCloser) *oomEventStream {
+	return &oomEventStream{
+		body:    body,
+		decoder: json.NewDecoder(body),
+	}
+}
+
+func (s *oomEventStream) Next() (garden.OomEvent, error) {
+	var event garden.OomEvent
+
+	err := s.decoder.Decode(&event)
+	if err != nil {
+		s.mutex.Lock()
+		closed := s.closed
+		s.mutex.Unlock()
+
+		// reading a closed body fails with an error of its own
+		if closed {
+			return garden.OomEvent{}, io.EOF
+		}
+
+		return garden.OomEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (s *oomEventStream) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	s.mutex.Unlock()
+
+	return s.body.Close()
+}
@@ -29,6 +29,14 @@ func (container *container) Stop(kill bool) error {
 	return container.connection.Stop(container.handle, kill)
 }
 
+func (container *container) Pause() error {
+	return container.connection.Pause(container.handle)
+}
+
+func (container *container) Resume() error {
+	return container.connection.Resume(container.handle)
+}
+
 func (container *container) Info() (garden.ContainerInfo, error) {
 	return container.connection.Info(container.handle)
 }
@@ -67,6 +75,32 @@ func (container *container) CurrentCPULimits() (garden.CPULimits, error) {
 	return container.connection.CurrentCPULimits(container.handle)
 }
 
+func (container *container) LimitBlockIO(limits garden.BlockIOLimits) error {
+	_, err := container.connection.LimitBlockIO(container.handle, limits)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (container *container) CurrentBlockIOLimits() (garden.BlockIOLimits, error) {
+	return container.connection.CurrentBlockIOLimits(container.handle)
+}
+
+func (container *container) LimitPids(limits garden.PidLimits) error {
+	_, err := container.connection.LimitPids(container.handle, limits)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (container *container) CurrentPidLimits() (garden.PidLimits, error) {
+	return container.connection.CurrentPidLimits(container.handle)
+}
+
 func (container *container) LimitDisk(limits garden.DiskLimits) error {
 	_, err := container.connection.LimitDisk(container.handle, limits)
 	if err != nil {
@@ -101,18 +135,38 @@ func (container *container) Attach(processID uint32, io garden.ProcessIO) (garde
 	return container.connection.Attach(container.handle, processID, io)
 }
 
+func (container *container) StreamOomEvents() (garden.OomEventStream, error) {
+	return container.connection.StreamOomEvents(container.handle)
+}
+
 func (container *container) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
 	return container.connection.NetIn(container.handle, hostPort, containerPort)
 }
 
+func (container *container) NetInMapping(mapping garden.PortMapping) (garden.PortMapping, error) {
+	return container.connection.NetInMapping(container.handle, mapping)
+}
+
 func (container *container) NetOut(netOutRule garden.NetOutRule) error {
 	return container.connection.NetOut(container.handle, netOutRule)
 }
 
+func (container *container) NetInRemove(mapping garden.PortMapping) error {
+	return container.connection.NetInRemove(container.handle, mapping)
+}
+
+func (container *container) NetOutRemove(netOutRule garden.NetOutRule) error {
+	return container.connection.NetOutRemove(container.handle, netOutRule)
+}
+
 func (container *container) Metrics() (garden.Metrics, error) {
 	return container.connection.Metrics(container.handle)
 }
 
+func (container *container) NetworkState() (garden.NetworkState, error) {
+	return container.connection.NetworkState(container.handle)
+}
+
 func (container *container) Properties() (garden.Properties, error) {
 	return container.connection.Properties(container.handle)
 }
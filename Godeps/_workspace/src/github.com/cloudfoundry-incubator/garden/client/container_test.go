@@ -69,6 +69,50 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("Pause", func() {
+		It("sends a pause request", func() {
+			err := container.Pause()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.PauseArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when pausing fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.PauseReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Pause()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Resume", func() {
+		It("sends a resume request", func() {
+			err := container.Resume()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.ResumeArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when resuming fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.ResumeReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.Resume()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Info", func() {
 		It("sends an info request", func() {
 			infoToReturn := garden.ContainerInfo{
@@ -99,6 +143,35 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("NetworkState", func() {
+		It("sends a network state request", func() {
+			stateToReturn := garden.NetworkState{
+				Qdiscs: []garden.Qdisc{{Kind: "tbf"}},
+			}
+
+			fakeConnection.NetworkStateReturns(stateToReturn, nil)
+
+			state, err := container.NetworkState()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(fakeConnection.NetworkStateArgsForCall(0)).Should(Equal("some-handle"))
+			Ω(state).Should(Equal(stateToReturn))
+		})
+
+		Context("when getting the network state fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.NetworkStateReturns(garden.NetworkState{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.NetworkState()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Properties", func() {
 		Context("when getting properties succeeds", func() {
 			BeforeEach(func() {
@@ -268,6 +341,58 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("LimitBlockIO", func() {
+		It("sends a limit block io request", func() {
+			err := container.LimitBlockIO(garden.BlockIOLimits{
+				Weight: 500,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, limits := fakeConnection.LimitBlockIOArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(limits).Should(Equal(garden.BlockIOLimits{Weight: 500}))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.LimitBlockIOReturns(garden.BlockIOLimits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.LimitBlockIO(garden.BlockIOLimits{})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("LimitPids", func() {
+		It("sends a limit pids request", func() {
+			err := container.LimitPids(garden.PidLimits{
+				Max: 1024,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			handle, limits := fakeConnection.LimitPidsArgsForCall(0)
+			Ω(handle).Should(Equal("some-handle"))
+			Ω(limits).Should(Equal(garden.PidLimits{Max: 1024}))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.LimitPidsReturns(garden.PidLimits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.LimitPids(garden.PidLimits{})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("LimitDisk", func() {
 		It("sends a limit bandwidth request", func() {
 			err := container.LimitDisk(garden.DiskLimits{
@@ -377,6 +502,62 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("CurrentBlockIOLimits", func() {
+		It("sends an empty limit request and returns its response", func() {
+			limitsToReturn := garden.BlockIOLimits{
+				Weight: 500,
+			}
+
+			fakeConnection.CurrentBlockIOLimitsReturns(limitsToReturn, nil)
+
+			limits, err := container.CurrentBlockIOLimits()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(limits).Should(Equal(limitsToReturn))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CurrentBlockIOLimitsReturns(garden.BlockIOLimits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.CurrentBlockIOLimits()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("CurrentPidLimits", func() {
+		It("sends an empty limit request and returns its response", func() {
+			limitsToReturn := garden.PidLimits{
+				Max: 1024,
+			}
+
+			fakeConnection.CurrentPidLimitsReturns(limitsToReturn, nil)
+
+			limits, err := container.CurrentPidLimits()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(limits).Should(Equal(limitsToReturn))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.CurrentPidLimitsReturns(garden.PidLimits{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.CurrentPidLimits()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("CurrentDiskLimits", func() {
 		It("sends an empty limit request and returns its response", func() {
 			limitsToReturn := garden.DiskLimits{
@@ -438,6 +619,33 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("StreamOomEvents", func() {
+		It("streams the container's OOM events", func() {
+			streamToReturn := new(wfakes.FakeOomEventStream)
+
+			fakeConnection.StreamOomEventsReturns(streamToReturn, nil)
+
+			stream, err := container.StreamOomEvents()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(stream).Should(Equal(streamToReturn))
+			Ω(fakeConnection.StreamOomEventsArgsForCall(0)).Should(Equal("some-handle"))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.StreamOomEventsReturns(nil, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.StreamOomEvents()
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Run", func() {
 		It("sends a run request and returns the process id and a stream", func() {
 			fakeConnection.RunStub = func(handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
@@ -567,6 +775,40 @@ var _ = Describe("Container", func() {
 		})
 	})
 
+	Describe("NetInMapping", func() {
+		mapping := garden.PortMapping{
+			HostPort:      123,
+			ContainerPort: 456,
+			Protocol:      garden.ProtocolUDP,
+			PortCount:     3,
+		}
+
+		It("sends a net in request with the mapping", func() {
+			fakeConnection.NetInMappingReturns(mapping, nil)
+
+			mapped, err := container.NetInMapping(mapping)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(mapped).Should(Equal(mapping))
+
+			h, m := fakeConnection.NetInMappingArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(m).Should(Equal(mapping))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.NetInMappingReturns(garden.PortMapping{}, disaster)
+			})
+
+			It("returns the error", func() {
+				_, err := container.NetInMapping(mapping)
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("NetOut", func() {
 		It("sends NetOut requests over the connection", func() {
 			Ω(container.NetOut(garden.NetOutRule{
@@ -602,4 +844,59 @@ var _ = Describe("Container", func() {
 			Ω(err).Should(Equal(disaster))
 		})
 	})
+
+	Describe("NetInRemove", func() {
+		It("sends NetInRemove requests over the connection", func() {
+			mapping := garden.PortMapping{
+				HostPort: 8080,
+				Protocol: garden.ProtocolUDP,
+			}
+
+			Ω(container.NetInRemove(mapping)).Should(Succeed())
+
+			h, removed := fakeConnection.NetInRemoveArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(removed).Should(Equal(mapping))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.NetInRemoveReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.NetInRemove(garden.PortMapping{HostPort: 8080})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("NetOutRemove", func() {
+		It("sends NetOutRemove requests over the connection", func() {
+			rule := garden.NetOutRule{
+				Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))},
+			}
+
+			Ω(container.NetOutRemove(rule)).Should(Succeed())
+
+			h, removedRule := fakeConnection.NetOutRemoveArgsForCall(0)
+			Ω(h).Should(Equal("some-handle"))
+			Ω(removedRule).Should(Equal(rule))
+		})
+
+		Context("when the request fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeConnection.NetOutRemoveReturns(disaster)
+			})
+
+			It("returns the error", func() {
+				err := container.NetOutRemove(garden.NetOutRule{})
+				Ω(err).Should(Equal(disaster))
+			})
+		})
+	})
 })
@@ -1,7 +1,9 @@
 package garden
 
 import (
+	"fmt"
 	"io"
+	"time"
 )
 
 //go:generate counterfeiter . Container
@@ -26,6 +28,20 @@ type Container interface {
 	// * None.
 	Stop(kill bool) error
 
+	// Pause freezes every process running in a container, so that it uses no
+	// CPU until it is resumed. Its processes keep their memory and are not
+	// signalled.
+	//
+	// Errors:
+	// * The container is not running.
+	Pause() error
+
+	// Resume thaws a container that was paused.
+	//
+	// Errors:
+	// * The container is not paused.
+	Resume() error
+
 	// Returns information about a container.
 	Info() (ContainerInfo, error)
 
@@ -51,6 +67,22 @@ type Container interface {
 
 	CurrentCPULimits() (CPULimits, error)
 
+	// Limits the block IO of a container: its weight against other containers'
+	// and throttles on individual devices.
+	//
+	// Devices that are not given keep their throttles.
+	LimitBlockIO(limits BlockIOLimits) error
+
+	CurrentBlockIOLimits() (BlockIOLimits, error)
+
+	// Limits the number of processes and threads in a container, however many
+	// users they run as.
+	//
+	// Errors if the host's kernel cannot limit them (before Linux 4.3).
+	LimitPids(limits PidLimits) error
+
+	CurrentPidLimits() (PidLimits, error)
+
 	// Limits the disk usage for a container.
 	//
 	// The disk limits that are set by this command only have effect for the container's unprivileged user.
@@ -62,8 +94,9 @@ type Container interface {
 
 	// Limits the memory usage for a container.
 	//
-	// The limit applies to all process in the container. When the limit is
-	// exceeded, the container will be automatically stopped.
+	// The limit applies to all process in the container. What happens when the
+	// limit is exceeded depends on the limits' OOM policy; by default the
+	// container is stopped.
 	//
 	// Errors:
 	// * The kernel does not support setting memory.memsw.limit_in_bytes.
@@ -71,6 +104,10 @@ type Container interface {
 
 	CurrentMemoryLimits() (MemoryLimits, error)
 
+	// StreamOomEvents subscribes to the container's out of memory events from
+	// now on. Events that happened before are in its info.
+	StreamOomEvents() (OomEventStream, error)
+
 	// Map a port on the host to a port in the container so that traffic to the
 	// host port is forwarded to the container port.
 	//
@@ -86,6 +123,17 @@ type Container interface {
 	// * When no port can be acquired from the server's port pool.
 	NetIn(hostPort, containerPort uint32) (uint32, uint32, error)
 
+	// Map ports on the host to ports in the container as NetIn does, for the
+	// protocol and number of consecutive ports given in the mapping.
+	//
+	// The resulting mapping is returned in the response.
+	//
+	// Errors:
+	// * When the protocol is neither TCP nor UDP.
+	// * When a range of ports is to be mapped but no host port is given.
+	// * When no port can be acquired from the server's port pool.
+	NetInMapping(mapping PortMapping) (PortMapping, error)
+
 	// Whitelist outbound network traffic.
 	//
 	// If the configuration directive deny_networks is not used,
@@ -98,6 +146,21 @@ type Container interface {
 	// * An error is returned if the NetOut call fails.
 	NetOut(netOutRule NetOutRule) error
 
+	// Remove the port mapping from the mapping's host port for its protocol
+	// (TCP by default); its other fields are ignored. A port acquired from
+	// the server's port pool is returned to it once nothing is mapped from it.
+	//
+	// Errors:
+	// * When the protocol is neither TCP nor UDP.
+	// * When no port is mapped from the host port for the protocol.
+	NetInRemove(mapping PortMapping) error
+
+	// Remove a whitelisting rule previously added with NetOut.
+	//
+	// Errors:
+	// * When no such rule was added.
+	NetOutRemove(netOutRule NetOutRule) error
+
 	// Run a script inside a container.
 	//
 	// The 'privileged' flag remains for backwards compatibility, but the 'user' flag is preferred.
@@ -116,6 +179,11 @@ type Container interface {
 	// Metrics returns the current set of metrics for a container
 	Metrics() (Metrics, error)
 
+	// NetworkState returns the iptables rules and queueing disciplines in effect
+	// for the container, flagging any which differ from those NetIn and NetOut
+	// asked for.
+	NetworkState() (NetworkState, error)
+
 	// Properties returns the current set of properties
 	Properties() (Properties, error)
 
@@ -199,14 +267,24 @@ const (
 type PortMapping struct {
 	HostPort      uint32
 	ContainerPort uint32
+
+	// TCP or UDP; default TCP
+	Protocol Protocol
+
+	// the number of consecutive ports mapped from HostPort and ContainerPort
+	// onwards; default 1
+	PortCount uint32
 }
 
 // ContainerInfo holds information about a container.
 type ContainerInfo struct {
 	State         string        // Either "active" or "stopped".
 	Events        []string      // List of events that occurred for the container. It currently includes only "oom" (Out Of Memory) event if it occurred.
+	OomEvents     []OomEvent    // The container's latest (up to 10) out of memory events, in the order they occurred.
 	HostIP        string        // The IP address of the gateway which controls the host side of the container's virtual ethernet pair.
 	ContainerIP   string        // The IP address of the container side of the container's virtual ethernet pair.
+	HostIPv6      string        // The IPv6 address of the gateway, if the container has an IPv6 network.
+	ContainerIPv6 string        // The IPv6 address of the container, if it has an IPv6 network.
 	ExternalIP    string        //
 	ContainerPath string        // The path to the directory holding the container's files (both its control scripts and filesystem).
 	ProcessIDs    []uint32      // List of running processes.
@@ -232,9 +310,11 @@ type ContainerInfoEntry struct {
 }
 
 type Metrics struct {
-	MemoryStat ContainerMemoryStat
-	CPUStat    ContainerCPUStat
-	DiskStat   ContainerDiskStat
+	MemoryStat  ContainerMemoryStat
+	CPUStat     ContainerCPUStat
+	DiskStat    ContainerDiskStat
+	BlockIOStat ContainerBlockIOStat
+	PidStat     ContainerPidStat
 }
 
 type ContainerMetricsEntry struct {
@@ -284,6 +364,26 @@ type ContainerDiskStat struct {
 	InodesUsed uint64
 }
 
+type ContainerBlockIOStat struct {
+	Devices []ContainerBlockIODeviceStat
+}
+
+type ContainerBlockIODeviceStat struct {
+	Major uint64
+	Minor uint64
+
+	ReadBytes  uint64
+	WriteBytes uint64
+
+	Reads  uint64
+	Writes uint64
+}
+
+type ContainerPidStat struct {
+	// Processes and threads in the container.
+	Current uint64
+}
+
 type ContainerBandwidthStat struct {
 	InRate   uint64
 	InBurst  uint64
@@ -313,10 +413,116 @@ type DiskLimits struct {
 type MemoryLimits struct {
 	//	Memory usage limit in bytes.
 	LimitInBytes uint64 `json:"limit_in_bytes,omitempty"`
+
+	// Usage the container is pushed back down to when the host is short of
+	// memory. Zero leaves it unset.
+	SoftLimitInBytes uint64 `json:"soft_limit_in_bytes,omitempty"`
+
+	// Swap the container may use on top of LimitInBytes. Zero allows none.
+	SwapInBytes uint64 `json:"swap_in_bytes,omitempty"`
+
+	// How readily the container's memory is swapped out, from 1 to 100. Zero
+	// leaves it as it is; use a zero SwapInBytes to prevent swapping.
+	Swappiness uint64 `json:"swappiness,omitempty"`
+
+	// Limit on the kernel memory used on the container's behalf. Zero leaves
+	// it unset. Setting it requires Linux 4.6 or later: older kernels refuse
+	// it once the container has processes, which it does as soon as it is
+	// created.
+	KmemLimitInBytes uint64 `json:"kmem_limit_in_bytes,omitempty"`
+
+	// What happens when the limit is exceeded. Defaults to OomPolicyStop.
+	OomPolicy OomPolicy `json:"oom_policy,omitempty"`
+}
+
+type OomPolicy string
+
+const (
+	// Stop the container, killing all of its processes.
+	OomPolicyStop OomPolicy = "stop"
+
+	// Kill only the process the kernel chooses, and keep the container running.
+	OomPolicyKillProcess OomPolicy = "kill_process"
+
+	// Kill nothing; the container's processes wait for memory until it is
+	// freed or the limit is raised.
+	OomPolicyNotify OomPolicy = "notify"
+)
+
+// Validate returns an error unless the policy is one of the above, or empty
+// for the default.
+func (policy OomPolicy) Validate() error {
+	switch policy {
+	case "", OomPolicyStop, OomPolicyKillProcess, OomPolicyNotify:
+		return nil
+	}
+
+	return fmt.Errorf("unknown oom policy: %q", string(policy))
+}
+
+// OomEvent records a container running out of memory.
+type OomEvent struct {
+	Time time.Time `json:"time"`
+
+	// The policy that was applied.
+	Policy OomPolicy `json:"policy"`
+
+	// The container's memory usage at the time.
+	MemoryStat ContainerMemoryStat `json:"memory_stat"`
+}
+
+// OomEventStream delivers a container's out of memory events as they occur.
+type OomEventStream interface {
+	// Next blocks until the next event, returning io.EOF once the stream is
+	// closed or the container is destroyed.
+	Next() (OomEvent, error)
+
+	Close() error
 }
 
 type CPULimits struct {
+	// Relative weight of the container's CPU time against other containers'.
 	LimitInShares uint64 `json:"limit_in_shares,omitempty"`
+
+	// Hard cap on the CPU time, in microseconds, the container may use in
+	// each period. Zero leaves the container uncapped.
+	QuotaInMicroseconds uint64 `json:"quota_in_microseconds,omitempty"`
+
+	// Length of the period the quota applies to, in microseconds. Zero leaves
+	// the period as it is (100ms by default).
+	PeriodInMicroseconds uint64 `json:"period_in_microseconds,omitempty"`
+
+	// CPUs and memory nodes the container's processes are pinned to, in the
+	// kernel's list format (e.g. "0-3,6"). Empty leaves them as they are.
+	Cpus string `json:"cpus,omitempty"`
+	Mems string `json:"mems,omitempty"`
+}
+
+type BlockIOLimits struct {
+	// Relative weight of the container's IO time against other containers',
+	// from 10 to 1000. Zero leaves the weight as it is.
+	Weight uint64 `json:"weight,omitempty"`
+
+	Devices []BlockIODeviceLimits `json:"devices,omitempty"`
+}
+
+// Throttles on a single block device, identified by its major and minor
+// numbers. A zero rate lifts the throttle.
+type BlockIODeviceLimits struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+
+	ReadBytesPerSecond  uint64 `json:"read_bytes_per_second,omitempty"`
+	WriteBytesPerSecond uint64 `json:"write_bytes_per_second,omitempty"`
+
+	ReadIOPerSecond  uint64 `json:"read_io_per_second,omitempty"`
+	WriteIOPerSecond uint64 `json:"write_io_per_second,omitempty"`
+}
+
+type PidLimits struct {
+	// Maximum number of processes and threads in the container. Zero lifts the
+	// limit.
+	Max uint64 `json:"max,omitempty"`
 }
 
 // Resource limits.
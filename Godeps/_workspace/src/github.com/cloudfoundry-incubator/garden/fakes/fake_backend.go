@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"io"
 	"sync"
 	"time"
 
@@ -92,6 +93,68 @@ type FakeBackend struct {
 	graceTimeReturns struct {
 		result1 time.Duration
 	}
+	CommitAndSaveStub        func(handle string, dest string) error
+	commitAndSaveMutex       sync.RWMutex
+	commitAndSaveArgsForCall []struct {
+		handle string
+		dest   string
+	}
+	commitAndSaveReturns struct {
+		result1 error
+	}
+	CommitAndStreamStub        func(handle string) (io.ReadCloser, error)
+	commitAndStreamMutex       sync.RWMutex
+	commitAndStreamArgsForCall []struct {
+		handle string
+	}
+	commitAndStreamReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	CommitImageStub        func(handle string, repository string, tag string) (string, error)
+	commitImageMutex       sync.RWMutex
+	commitImageArgsForCall []struct {
+		handle     string
+		repository string
+		tag        string
+	}
+	commitImageReturns struct {
+		result1 string
+		result2 error
+	}
+	PullImageStub        func(rootfs string) (string, error)
+	pullImageMutex       sync.RWMutex
+	pullImageArgsForCall []struct {
+		rootfs string
+	}
+	pullImageReturns struct {
+		result1 string
+		result2 error
+	}
+	ListImagesStub        func() ([]garden.ImageInfo, error)
+	listImagesMutex       sync.RWMutex
+	listImagesArgsForCall []struct{}
+	listImagesReturns     struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}
+	CreationProgressStub        func(handle string) ([]string, error)
+	creationProgressMutex       sync.RWMutex
+	creationProgressArgsForCall []struct {
+		handle string
+	}
+	creationProgressReturns struct {
+		result1 []string
+		result2 error
+	}
+	CheckpointStub        func(handle string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		handle string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeBackend) Ping() error {
@@ -411,4 +474,228 @@ func (fake *FakeBackend) GraceTimeReturns(result1 time.Duration) {
 	}{result1}
 }
 
+func (fake *FakeBackend) CommitAndSave(handle string, dest string) error {
+	fake.commitAndSaveMutex.Lock()
+	fake.commitAndSaveArgsForCall = append(fake.commitAndSaveArgsForCall, struct {
+		handle string
+		dest   string
+	}{handle, dest})
+	fake.commitAndSaveMutex.Unlock()
+	if fake.CommitAndSaveStub != nil {
+		return fake.CommitAndSaveStub(handle, dest)
+	} else {
+		return fake.commitAndSaveReturns.result1
+	}
+}
+
+func (fake *FakeBackend) CommitAndSaveCallCount() int {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return len(fake.commitAndSaveArgsForCall)
+}
+
+func (fake *FakeBackend) CommitAndSaveArgsForCall(i int) (string, string) {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return fake.commitAndSaveArgsForCall[i].handle, fake.commitAndSaveArgsForCall[i].dest
+}
+
+func (fake *FakeBackend) CommitAndSaveReturns(result1 error) {
+	fake.CommitAndSaveStub = nil
+	fake.commitAndSaveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackend) CommitAndStream(handle string) (io.ReadCloser, error) {
+	fake.commitAndStreamMutex.Lock()
+	fake.commitAndStreamArgsForCall = append(fake.commitAndStreamArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.commitAndStreamMutex.Unlock()
+	if fake.CommitAndStreamStub != nil {
+		return fake.CommitAndStreamStub(handle)
+	} else {
+		return fake.commitAndStreamReturns.result1, fake.commitAndStreamReturns.result2
+	}
+}
+
+func (fake *FakeBackend) CommitAndStreamCallCount() int {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return len(fake.commitAndStreamArgsForCall)
+}
+
+func (fake *FakeBackend) CommitAndStreamArgsForCall(i int) string {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return fake.commitAndStreamArgsForCall[i].handle
+}
+
+func (fake *FakeBackend) CommitAndStreamReturns(result1 io.ReadCloser, result2 error) {
+	fake.CommitAndStreamStub = nil
+	fake.commitAndStreamReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) CommitImage(handle string, repository string, tag string) (string, error) {
+	fake.commitImageMutex.Lock()
+	fake.commitImageArgsForCall = append(fake.commitImageArgsForCall, struct {
+		handle     string
+		repository string
+		tag        string
+	}{handle, repository, tag})
+	fake.commitImageMutex.Unlock()
+	if fake.CommitImageStub != nil {
+		return fake.CommitImageStub(handle, repository, tag)
+	} else {
+		return fake.commitImageReturns.result1, fake.commitImageReturns.result2
+	}
+}
+
+func (fake *FakeBackend) CommitImageCallCount() int {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return len(fake.commitImageArgsForCall)
+}
+
+func (fake *FakeBackend) CommitImageArgsForCall(i int) (string, string, string) {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return fake.commitImageArgsForCall[i].handle, fake.commitImageArgsForCall[i].repository, fake.commitImageArgsForCall[i].tag
+}
+
+func (fake *FakeBackend) CommitImageReturns(result1 string, result2 error) {
+	fake.CommitImageStub = nil
+	fake.commitImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) PullImage(rootfs string) (string, error) {
+	fake.pullImageMutex.Lock()
+	fake.pullImageArgsForCall = append(fake.pullImageArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.pullImageMutex.Unlock()
+	if fake.PullImageStub != nil {
+		return fake.PullImageStub(rootfs)
+	} else {
+		return fake.pullImageReturns.result1, fake.pullImageReturns.result2
+	}
+}
+
+func (fake *FakeBackend) PullImageCallCount() int {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return len(fake.pullImageArgsForCall)
+}
+
+func (fake *FakeBackend) PullImageArgsForCall(i int) string {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return fake.pullImageArgsForCall[i].rootfs
+}
+
+func (fake *FakeBackend) PullImageReturns(result1 string, result2 error) {
+	fake.PullImageStub = nil
+	fake.pullImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) ListImages() ([]garden.ImageInfo, error) {
+	fake.listImagesMutex.Lock()
+	fake.listImagesArgsForCall = append(fake.listImagesArgsForCall, struct{}{})
+	fake.listImagesMutex.Unlock()
+	if fake.ListImagesStub != nil {
+		return fake.ListImagesStub()
+	} else {
+		return fake.listImagesReturns.result1, fake.listImagesReturns.result2
+	}
+}
+
+func (fake *FakeBackend) ListImagesCallCount() int {
+	fake.listImagesMutex.RLock()
+	defer fake.listImagesMutex.RUnlock()
+	return len(fake.listImagesArgsForCall)
+}
+
+func (fake *FakeBackend) ListImagesReturns(result1 []garden.ImageInfo, result2 error) {
+	fake.ListImagesStub = nil
+	fake.listImagesReturns = struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) CreationProgress(handle string) ([]string, error) {
+	fake.creationProgressMutex.Lock()
+	fake.creationProgressArgsForCall = append(fake.creationProgressArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.creationProgressMutex.Unlock()
+	if fake.CreationProgressStub != nil {
+		return fake.CreationProgressStub(handle)
+	} else {
+		return fake.creationProgressReturns.result1, fake.creationProgressReturns.result2
+	}
+}
+
+func (fake *FakeBackend) CreationProgressCallCount() int {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return len(fake.creationProgressArgsForCall)
+}
+
+func (fake *FakeBackend) CreationProgressArgsForCall(i int) string {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return fake.creationProgressArgsForCall[i].handle
+}
+
+func (fake *FakeBackend) CreationProgressReturns(result1 []string, result2 error) {
+	fake.CreationProgressStub = nil
+	fake.creationProgressReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackend) Checkpoint(handle string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(handle)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeBackend) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeBackend) CheckpointArgsForCall(i int) string {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].handle
+}
+
+func (fake *FakeBackend) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ garden.Backend = new(FakeBackend)
@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -74,6 +75,68 @@ type FakeClient struct {
 		result1 garden.Container
 		result2 error
 	}
+	CommitAndSaveStub        func(handle string, dest string) error
+	commitAndSaveMutex       sync.RWMutex
+	commitAndSaveArgsForCall []struct {
+		handle string
+		dest   string
+	}
+	commitAndSaveReturns struct {
+		result1 error
+	}
+	CommitAndStreamStub        func(handle string) (io.ReadCloser, error)
+	commitAndStreamMutex       sync.RWMutex
+	commitAndStreamArgsForCall []struct {
+		handle string
+	}
+	commitAndStreamReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	CommitImageStub        func(handle string, repository string, tag string) (string, error)
+	commitImageMutex       sync.RWMutex
+	commitImageArgsForCall []struct {
+		handle     string
+		repository string
+		tag        string
+	}
+	commitImageReturns struct {
+		result1 string
+		result2 error
+	}
+	PullImageStub        func(rootfs string) (string, error)
+	pullImageMutex       sync.RWMutex
+	pullImageArgsForCall []struct {
+		rootfs string
+	}
+	pullImageReturns struct {
+		result1 string
+		result2 error
+	}
+	ListImagesStub        func() ([]garden.ImageInfo, error)
+	listImagesMutex       sync.RWMutex
+	listImagesArgsForCall []struct{}
+	listImagesReturns     struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}
+	CreationProgressStub        func(handle string) ([]string, error)
+	creationProgressMutex       sync.RWMutex
+	creationProgressArgsForCall []struct {
+		handle string
+	}
+	creationProgressReturns struct {
+		result1 []string
+		result2 error
+	}
+	CheckpointStub        func(handle string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		handle string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeClient) Ping() error {
@@ -322,4 +385,228 @@ func (fake *FakeClient) LookupReturns(result1 garden.Container, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeClient) CommitAndSave(handle string, dest string) error {
+	fake.commitAndSaveMutex.Lock()
+	fake.commitAndSaveArgsForCall = append(fake.commitAndSaveArgsForCall, struct {
+		handle string
+		dest   string
+	}{handle, dest})
+	fake.commitAndSaveMutex.Unlock()
+	if fake.CommitAndSaveStub != nil {
+		return fake.CommitAndSaveStub(handle, dest)
+	} else {
+		return fake.commitAndSaveReturns.result1
+	}
+}
+
+func (fake *FakeClient) CommitAndSaveCallCount() int {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return len(fake.commitAndSaveArgsForCall)
+}
+
+func (fake *FakeClient) CommitAndSaveArgsForCall(i int) (string, string) {
+	fake.commitAndSaveMutex.RLock()
+	defer fake.commitAndSaveMutex.RUnlock()
+	return fake.commitAndSaveArgsForCall[i].handle, fake.commitAndSaveArgsForCall[i].dest
+}
+
+func (fake *FakeClient) CommitAndSaveReturns(result1 error) {
+	fake.CommitAndSaveStub = nil
+	fake.commitAndSaveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) CommitAndStream(handle string) (io.ReadCloser, error) {
+	fake.commitAndStreamMutex.Lock()
+	fake.commitAndStreamArgsForCall = append(fake.commitAndStreamArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.commitAndStreamMutex.Unlock()
+	if fake.CommitAndStreamStub != nil {
+		return fake.CommitAndStreamStub(handle)
+	} else {
+		return fake.commitAndStreamReturns.result1, fake.commitAndStreamReturns.result2
+	}
+}
+
+func (fake *FakeClient) CommitAndStreamCallCount() int {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return len(fake.commitAndStreamArgsForCall)
+}
+
+func (fake *FakeClient) CommitAndStreamArgsForCall(i int) string {
+	fake.commitAndStreamMutex.RLock()
+	defer fake.commitAndStreamMutex.RUnlock()
+	return fake.commitAndStreamArgsForCall[i].handle
+}
+
+func (fake *FakeClient) CommitAndStreamReturns(result1 io.ReadCloser, result2 error) {
+	fake.CommitAndStreamStub = nil
+	fake.commitAndStreamReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) CommitImage(handle string, repository string, tag string) (string, error) {
+	fake.commitImageMutex.Lock()
+	fake.commitImageArgsForCall = append(fake.commitImageArgsForCall, struct {
+		handle     string
+		repository string
+		tag        string
+	}{handle, repository, tag})
+	fake.commitImageMutex.Unlock()
+	if fake.CommitImageStub != nil {
+		return fake.CommitImageStub(handle, repository, tag)
+	} else {
+		return fake.commitImageReturns.result1, fake.commitImageReturns.result2
+	}
+}
+
+func (fake *FakeClient) CommitImageCallCount() int {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return len(fake.commitImageArgsForCall)
+}
+
+func (fake *FakeClient) CommitImageArgsForCall(i int) (string, string, string) {
+	fake.commitImageMutex.RLock()
+	defer fake.commitImageMutex.RUnlock()
+	return fake.commitImageArgsForCall[i].handle, fake.commitImageArgsForCall[i].repository, fake.commitImageArgsForCall[i].tag
+}
+
+func (fake *FakeClient) CommitImageReturns(result1 string, result2 error) {
+	fake.CommitImageStub = nil
+	fake.commitImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) PullImage(rootfs string) (string, error) {
+	fake.pullImageMutex.Lock()
+	fake.pullImageArgsForCall = append(fake.pullImageArgsForCall, struct {
+		rootfs string
+	}{rootfs})
+	fake.pullImageMutex.Unlock()
+	if fake.PullImageStub != nil {
+		return fake.PullImageStub(rootfs)
+	} else {
+		return fake.pullImageReturns.result1, fake.pullImageReturns.result2
+	}
+}
+
+func (fake *FakeClient) PullImageCallCount() int {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return len(fake.pullImageArgsForCall)
+}
+
+func (fake *FakeClient) PullImageArgsForCall(i int) string {
+	fake.pullImageMutex.RLock()
+	defer fake.pullImageMutex.RUnlock()
+	return fake.pullImageArgsForCall[i].rootfs
+}
+
+func (fake *FakeClient) PullImageReturns(result1 string, result2 error) {
+	fake.PullImageStub = nil
+	fake.pullImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ListImages() ([]garden.ImageInfo, error) {
+	fake.listImagesMutex.Lock()
+	fake.listImagesArgsForCall = append(fake.listImagesArgsForCall, struct{}{})
+	fake.listImagesMutex.Unlock()
+	if fake.ListImagesStub != nil {
+		return fake.ListImagesStub()
+	} else {
+		return fake.listImagesReturns.result1, fake.listImagesReturns.result2
+	}
+}
+
+func (fake *FakeClient) ListImagesCallCount() int {
+	fake.listImagesMutex.RLock()
+	defer fake.listImagesMutex.RUnlock()
+	return len(fake.listImagesArgsForCall)
+}
+
+func (fake *FakeClient) ListImagesReturns(result1 []garden.ImageInfo, result2 error) {
+	fake.ListImagesStub = nil
+	fake.listImagesReturns = struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) CreationProgress(handle string) ([]string, error) {
+	fake.creationProgressMutex.Lock()
+	fake.creationProgressArgsForCall = append(fake.creationProgressArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.creationProgressMutex.Unlock()
+	if fake.CreationProgressStub != nil {
+		return fake.CreationProgressStub(handle)
+	} else {
+		return fake.creationProgressReturns.result1, fake.creationProgressReturns.result2
+	}
+}
+
+func (fake *FakeClient) CreationProgressCallCount() int {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return len(fake.creationProgressArgsForCall)
+}
+
+func (fake *FakeClient) CreationProgressArgsForCall(i int) string {
+	fake.creationProgressMutex.RLock()
+	defer fake.creationProgressMutex.RUnlock()
+	return fake.creationProgressArgsForCall[i].handle
+}
+
+func (fake *FakeClient) CreationProgressReturns(result1 []string, result2 error) {
+	fake.CreationProgressStub = nil
+	fake.creationProgressReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) Checkpoint(handle string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(handle)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeClient) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeClient) CheckpointArgsForCall(i int) string {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].handle
+}
+
+func (fake *FakeClient) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ garden.Client = new(FakeClient)
@@ -0,0 +1,75 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+type FakeOomEventStream struct {
+	NextStub        func() (garden.OomEvent, error)
+	nextMutex       sync.RWMutex
+	nextArgsForCall []struct{}
+	nextReturns     struct {
+		result1 garden.OomEvent
+		result2 error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct{}
+	closeReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeOomEventStream) Next() (garden.OomEvent, error) {
+	fake.nextMutex.Lock()
+	fake.nextArgsForCall = append(fake.nextArgsForCall, struct{}{})
+	fake.nextMutex.Unlock()
+	if fake.NextStub != nil {
+		return fake.NextStub()
+	} else {
+		return fake.nextReturns.result1, fake.nextReturns.result2
+	}
+}
+
+func (fake *FakeOomEventStream) NextCallCount() int {
+	fake.nextMutex.RLock()
+	defer fake.nextMutex.RUnlock()
+	return len(fake.nextArgsForCall)
+}
+
+func (fake *FakeOomEventStream) NextReturns(result1 garden.OomEvent, result2 error) {
+	fake.NextStub = nil
+	fake.nextReturns = struct {
+		result1 garden.OomEvent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeOomEventStream) Close() error {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		return fake.CloseStub()
+	} else {
+		return fake.closeReturns.result1
+	}
+}
+
+func (fake *FakeOomEventStream) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeOomEventStream) CloseReturns(result1 error) {
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ garden.OomEventStream = new(FakeOomEventStream)
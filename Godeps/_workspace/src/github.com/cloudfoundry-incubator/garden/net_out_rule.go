@@ -6,7 +6,7 @@ type NetOutRule struct {
 	// the protocol to be whitelisted; default TCP
 	Protocol Protocol `json:"protocol,omitempty"`
 
-	// a list of ranges of IP addresses to whitelist; Start to End inclusive; default all
+	// a list of ranges of IPv4 or IPv6 addresses to whitelist; Start to End inclusive; default all
 	Networks []IPRange `json:"networks,omitempty"`
 
 	// a list of ranges of ports to whitelist; Start to End inclusive; ignored if Protocol is ICMP; default all
@@ -0,0 +1,55 @@
+package garden
+
+// NetworkState is the network configuration the host has in effect for a
+// container, as read back from the kernel.
+type NetworkState struct {
+	InstanceChain []IPTablesRule // The rules of the container's filter chain, which NetOut adds to.
+	LogChain      []IPTablesRule // The rules of the chain logging connections of NetOut rules with Log set.
+	NATChain      []IPTablesRule // The rules of the container's NAT chain, which NetIn adds to.
+	Qdiscs        []Qdisc        // The traffic control queueing disciplines on the host side of the container's veth pair.
+
+	// The rules of the container's ip6tables filter and NAT chains; empty
+	// unless the container has an IPv6 network.
+	IPv6InstanceChain []IPTablesRule
+	IPv6NATChain      []IPTablesRule
+
+	// Differences between the rules in effect and those the container's
+	// NetIn and NetOut calls asked for.
+	Drift []NetworkDrift
+}
+
+// IPTablesRule is a rule in one of a container's iptables chains.
+type IPTablesRule struct {
+	Table string // "filter" or "nat"
+	Chain string
+
+	Protocol         string // Empty if the rule matches all protocols.
+	Source           string // An address, a CIDR block or a "start-end" range; empty if any.
+	Destination      string // An address, a CIDR block or a "start-end" range; empty if any.
+	DestinationPorts string // A port or a "start:end" range; empty if any.
+	Target           string // The chain or target the rule jumps or goes to.
+	Goto             bool   // True if the rule goes to Target rather than jumping to it.
+	ToDestination    string // The address and port a DNAT rule rewrites destinations to.
+
+	Spec string // The whole rule, as iptables -S prints it.
+}
+
+// Qdisc is a traffic control queueing discipline.
+type Qdisc struct {
+	Device string
+	Kind   string // e.g. "tbf" or "ingress"
+	Handle string // As tc(8) prints it, e.g. "8001:"
+	Parent string // "root", "ingress" or the parent's handle
+}
+
+// NetworkDrift is a difference between the rules in effect for a container
+// and those its NetIn and NetOut calls asked for.
+type NetworkDrift struct {
+	// Exactly one of these is set: the mapping or rule whose iptables rule is
+	// missing, or the rule in effect which no mapping or rule asked for.
+	NetIn      *PortMapping
+	NetOut     *NetOutRule
+	Unexpected *IPTablesRule
+
+	Message string
+}
@@ -13,7 +13,9 @@ const (
 	BulkMetrics = "BulkMetrics"
 	Destroy     = "Destroy"
 
-	Stop = "Stop"
+	Stop   = "Stop"
+	Pause  = "Pause"
+	Resume = "Resume"
 
 	StreamIn  = "StreamIn"
 	StreamOut = "StreamOut"
@@ -27,14 +29,24 @@ const (
 	LimitCPU         = "LimitCPU"
 	CurrentCPULimits = "CurrentCPULimits"
 
+	LimitBlockIO         = "LimitBlockIO"
+	CurrentBlockIOLimits = "CurrentBlockIOLimits"
+
+	LimitPids        = "LimitPids"
+	CurrentPidLimits = "CurrentPidLimits"
+
 	LimitDisk         = "LimitDisk"
 	CurrentDiskLimits = "CurrentDiskLimits"
 
 	LimitMemory         = "LimitMemory"
 	CurrentMemoryLimits = "CurrentMemoryLimits"
 
-	NetIn  = "NetIn"
-	NetOut = "NetOut"
+	StreamOomEvents = "StreamOomEvents"
+
+	NetIn        = "NetIn"
+	NetInRemove  = "NetInRemove"
+	NetOut       = "NetOut"
+	NetOutRemove = "NetOutRemove"
 
 	Run    = "Run"
 	Attach = "Attach"
@@ -45,10 +57,21 @@ const (
 
 	Metrics = "Metrics"
 
+	NetworkState = "NetworkState"
+
 	RemoveProperty = "RemoveProperty"
-	
+
 	// add commit container diff and save image to tar interface, lvguanglin, 2015/7/8
-	CommitAndSave = "CommitAndSave"
+	CommitAndSave   = "CommitAndSave"
+	CommitAndStream = "CommitAndStream"
+	CommitImage     = "CommitImage"
+
+	PullImage  = "PullImage"
+	ListImages = "ListImages"
+
+	CreationProgress = "CreationProgress"
+
+	Checkpoint = "Checkpoint"
 )
 
 var Routes = rata.Routes{
@@ -64,6 +87,8 @@ var Routes = rata.Routes{
 
 	{Path: "/containers/:handle", Method: "DELETE", Name: Destroy},
 	{Path: "/containers/:handle/stop", Method: "PUT", Name: Stop},
+	{Path: "/containers/:handle/pause", Method: "PUT", Name: Pause},
+	{Path: "/containers/:handle/resume", Method: "PUT", Name: Resume},
 
 	{Path: "/containers/:handle/files", Method: "PUT", Name: StreamIn},
 	{Path: "/containers/:handle/files", Method: "GET", Name: StreamOut},
@@ -74,14 +99,24 @@ var Routes = rata.Routes{
 	{Path: "/containers/:handle/limits/cpu", Method: "PUT", Name: LimitCPU},
 	{Path: "/containers/:handle/limits/cpu", Method: "GET", Name: CurrentCPULimits},
 
+	{Path: "/containers/:handle/limits/block_io", Method: "PUT", Name: LimitBlockIO},
+	{Path: "/containers/:handle/limits/block_io", Method: "GET", Name: CurrentBlockIOLimits},
+
+	{Path: "/containers/:handle/limits/pid", Method: "PUT", Name: LimitPids},
+	{Path: "/containers/:handle/limits/pid", Method: "GET", Name: CurrentPidLimits},
+
 	{Path: "/containers/:handle/limits/disk", Method: "PUT", Name: LimitDisk},
 	{Path: "/containers/:handle/limits/disk", Method: "GET", Name: CurrentDiskLimits},
 
 	{Path: "/containers/:handle/limits/memory", Method: "PUT", Name: LimitMemory},
 	{Path: "/containers/:handle/limits/memory", Method: "GET", Name: CurrentMemoryLimits},
 
+	{Path: "/containers/:handle/events/oom", Method: "GET", Name: StreamOomEvents},
+
 	{Path: "/containers/:handle/net/in", Method: "POST", Name: NetIn},
+	{Path: "/containers/:handle/net/in", Method: "DELETE", Name: NetInRemove},
 	{Path: "/containers/:handle/net/out", Method: "POST", Name: NetOut},
+	{Path: "/containers/:handle/net/out", Method: "DELETE", Name: NetOutRemove},
 
 	{Path: "/containers/:handle/processes/:pid/attaches/:streamid/stdout", Method: "GET", Name: Stdout},
 	{Path: "/containers/:handle/processes/:pid/attaches/:streamid/stderr", Method: "GET", Name: Stderr},
@@ -94,7 +129,18 @@ var Routes = rata.Routes{
 	{Path: "/containers/:handle/properties/:key", Method: "DELETE", Name: RemoveProperty},
 
 	{Path: "/containers/:handle/metrics", Method: "GET", Name: Metrics},
-	
+
+	{Path: "/containers/:handle/net/state", Method: "GET", Name: NetworkState},
+
 	// add commit container diff and save image to tar interface, lvguanglin, 2015/7/8
 	{Path: "/containers/:handle/images", Method: "GET", Name: CommitAndSave},
+	{Path: "/containers/:handle/images/stream", Method: "GET", Name: CommitAndStream},
+	{Path: "/containers/:handle/images", Method: "POST", Name: CommitImage},
+
+	{Path: "/images", Method: "POST", Name: PullImage},
+	{Path: "/images", Method: "GET", Name: ListImages},
+
+	{Path: "/containers/:handle/progress", Method: "GET", Name: CreationProgress},
+
+	{Path: "/containers/:handle/checkpoint", Method: "PUT", Name: Checkpoint},
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -49,7 +50,7 @@ func (s *GardenServer) handleCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	hLog := s.logger.Session("create", lager.Data{
-		"request": spec,
+		"request": redactedSpec(spec),
 	})
 
 	if spec.GraceTime == 0 {
@@ -179,6 +180,64 @@ func (s *GardenServer) handleStop(w http.ResponseWriter, r *http.Request) {
 	s.writeSuccess(w)
 }
 
+func (s *GardenServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("pause", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("pausing")
+
+	err = container.Pause()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("paused")
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("resume", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("resuming")
+
+	err = container.Resume()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("resumed")
+
+	s.writeSuccess(w)
+}
+
 func (s *GardenServer) handleStreamIn(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
@@ -345,6 +404,11 @@ func (s *GardenServer) handleLimitMemory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := request.OomPolicy.Validate(); err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
 		s.writeError(w, err, hLog)
@@ -411,6 +475,80 @@ func (s *GardenServer) handleCurrentMemoryLimits(w http.ResponseWriter, r *http.
 	s.writeResponse(w, limits)
 }
 
+func (s *GardenServer) handleStreamOomEvents(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("stream-oom-events", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("streaming")
+
+	stream, err := container.StreamOomEvents()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer stream.Close()
+
+	// the client going away is what usually ends the stream
+	if closeNotifier, ok := w.(http.CloseNotifier); ok {
+		gone := closeNotifier.CloseNotify()
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			select {
+			case <-gone:
+				stream.Close()
+			case <-done:
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	for {
+		event, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			hLog.Error("failed-to-get-event", err)
+			return
+		}
+
+		err = transport.WriteMessage(w, event)
+		if err != nil {
+			hLog.Error("failed-to-write-event", err)
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	hLog.Info("streamed")
+}
+
 func (s *GardenServer) handleLimitDisk(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
@@ -516,7 +654,7 @@ func (s *GardenServer) handleLimitCPU(w http.ResponseWriter, r *http.Request) {
 	s.bomberman.Pause(container.Handle())
 	defer s.bomberman.Unpause(container.Handle())
 
-	if request.LimitInShares > 0 {
+	if request != (garden.CPULimits{}) {
 		hLog.Debug("limiting", lager.Data{
 			"requested-limits": request,
 		})
@@ -572,6 +710,159 @@ func (s *GardenServer) handleCurrentCPULimits(w http.ResponseWriter, r *http.Req
 	s.writeResponse(w, limits)
 }
 
+func (s *GardenServer) handleLimitBlockIO(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("limit-block-io", lager.Data{
+		"handle": handle,
+	})
+
+	var request garden.BlockIOLimits
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	if request.Weight > 0 || len(request.Devices) > 0 {
+		hLog.Debug("limiting", lager.Data{
+			"requested-limits": request,
+		})
+
+		err = container.LimitBlockIO(request)
+		if err != nil {
+			s.writeError(w, err, hLog)
+			return
+		}
+	}
+
+	limits, err := container.CurrentBlockIOLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentBlockIOLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-block-io-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentBlockIOLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleLimitPids(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("limit-pids", lager.Data{
+		"handle": handle,
+	})
+
+	var request garden.PidLimits
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("limiting", lager.Data{
+		"requested-limits": request,
+	})
+
+	// a zero maximum lifts the limit, so every request is applied
+	err = container.LimitPids(request)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	limits, err := container.CurrentPidLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("limited", lager.Data{
+		"resulting-limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
+func (s *GardenServer) handleCurrentPidLimits(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("current-pid-limits", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("getting")
+
+	limits, err := container.CurrentPidLimits()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("got", lager.Data{
+		"limits": limits,
+	})
+
+	s.writeResponse(w, limits)
+}
+
 func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
@@ -584,8 +875,12 @@ func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hostPort := request.HostPort
-	containerPort := request.ContainerPort
+	mapping := garden.PortMapping{
+		HostPort:      request.HostPort,
+		ContainerPort: request.ContainerPort,
+		Protocol:      request.Protocol,
+		PortCount:     request.PortCount,
+	}
 
 	container, err := s.backend.Lookup(handle)
 	if err != nil {
@@ -597,24 +892,37 @@ func (s *GardenServer) handleNetIn(w http.ResponseWriter, r *http.Request) {
 	defer s.bomberman.Unpause(container.Handle())
 
 	hLog.Debug("port-mapping", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
+		"host-port":      mapping.HostPort,
+		"container-port": mapping.ContainerPort,
+		"protocol":       mapping.Protocol,
+		"port-count":     mapping.PortCount,
 	})
 
-	hostPort, containerPort, err = container.NetIn(hostPort, containerPort)
+	// plain port mappings keep going through NetIn, as they did before
+	// protocols and ranges could be requested
+	if mapping.Protocol == garden.ProtocolAll && mapping.PortCount == 0 {
+		mapping.HostPort, mapping.ContainerPort, err = container.NetIn(mapping.HostPort, mapping.ContainerPort)
+	} else {
+		mapping, err = container.NetInMapping(mapping)
+	}
+
 	if err != nil {
 		s.writeError(w, err, hLog)
 		return
 	}
 
 	hLog.Info("port-mapped", lager.Data{
-		"host-port":      hostPort,
-		"container-port": containerPort,
+		"host-port":      mapping.HostPort,
+		"container-port": mapping.ContainerPort,
+		"protocol":       mapping.Protocol,
+		"port-count":     mapping.PortCount,
 	})
 
 	s.writeResponse(w, &transport.NetInResponse{
-		HostPort:      hostPort,
-		ContainerPort: containerPort,
+		HostPort:      mapping.HostPort,
+		ContainerPort: mapping.ContainerPort,
+		Protocol:      mapping.Protocol,
+		PortCount:     mapping.PortCount,
 	})
 }
 
@@ -657,6 +965,89 @@ func (s *GardenServer) handleNetOut(w http.ResponseWriter, r *http.Request) {
 	s.writeSuccess(w)
 }
 
+func (s *GardenServer) handleNetInRemove(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("net-in-remove", lager.Data{
+		"handle": handle,
+	})
+
+	var request transport.NetInRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	mapping := garden.PortMapping{
+		HostPort: request.HostPort,
+		Protocol: request.Protocol,
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("removing-port-mapping", lager.Data{
+		"host-port": mapping.HostPort,
+		"protocol":  mapping.Protocol,
+	})
+
+	err = container.NetInRemove(mapping)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("removed-port-mapping", lager.Data{
+		"host-port": mapping.HostPort,
+		"protocol":  mapping.Protocol,
+	})
+
+	s.writeSuccess(w)
+}
+
+func (s *GardenServer) handleNetOutRemove(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("net-out-remove", lager.Data{
+		"handle": handle,
+	})
+
+	var rule garden.NetOutRule
+	if !s.readRequest(&rule, w, r) {
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("removing-rule", lager.Data{
+		"rule": rule,
+	})
+
+	err = container.NetOutRemove(rule)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("removed-rule", lager.Data{
+		"rule": rule,
+	})
+
+	s.writeSuccess(w)
+}
+
 func (s *GardenServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
@@ -682,6 +1073,31 @@ func (s *GardenServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	s.writeResponse(w, metrics)
 }
 
+func (s *GardenServer) handleNetworkState(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("get-network-state", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	state, err := container.NetworkState()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, state)
+}
+
 func (s *GardenServer) handleProperties(w http.ResponseWriter, r *http.Request) {
 	handle := r.FormValue(":handle")
 
@@ -1100,6 +1516,219 @@ func (s *GardenServer) handleCommitAndSave(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+func (s *GardenServer) handleCommitAndStream(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("commit-stream", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("committing")
+
+	reader, err := s.backend.CommitAndStream(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+
+	n, err := io.Copy(w, reader)
+	if err != nil {
+		if n == 0 {
+			s.writeError(w, err, hLog)
+		} else {
+			hLog.Error("failed-to-stream", err)
+		}
+
+		return
+	}
+
+	hLog.Info("streamed", lager.Data{
+		"bytes": n,
+	})
+}
+
+func (s *GardenServer) handleCommitImage(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("commit-image", lager.Data{
+		"handle": handle,
+	})
+
+	var request transport.CommitImageRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	if request.Repository == "" {
+		s.writeError(w, errors.New("repository is required to commit an image"), hLog)
+		return
+	}
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("committing", lager.Data{
+		"repository": request.Repository,
+		"tag":        request.Tag,
+	})
+
+	imageID, err := s.backend.CommitImage(handle, request.Repository, request.Tag)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("committed", lager.Data{
+		"repository": request.Repository,
+		"tag":        request.Tag,
+		"image":      imageID,
+	})
+
+	s.writeResponse(w, &transport.CommitImageResponse{
+		ImageID: imageID,
+	})
+}
+
+func (s *GardenServer) handlePullImage(w http.ResponseWriter, r *http.Request) {
+	var request transport.PullImageRequest
+	if !s.readRequest(&request, w, r) {
+		return
+	}
+
+	rootfsURL, err := url.Parse(request.RootFSPath)
+	if err != nil || request.RootFSPath == "" {
+		s.writeError(w, errors.New("a valid rootfs is required to pull an image"), s.logger.Session("pull-image"))
+		return
+	}
+
+	// registry credentials may be given in the URL; keep them out of the logs
+	rootfsURL.User = nil
+
+	hLog := s.logger.Session("pull-image", lager.Data{
+		"rootfs": rootfsURL.String(),
+	})
+
+	hLog.Debug("pulling")
+
+	imageID, err := s.backend.PullImage(request.RootFSPath)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("pulled", lager.Data{
+		"image": imageID,
+	})
+
+	s.writeResponse(w, &transport.PullImageResponse{
+		ImageID: imageID,
+	})
+}
+
+func (s *GardenServer) handleListImages(w http.ResponseWriter, r *http.Request) {
+	hLog := s.logger.Session("list-images")
+
+	images, err := s.backend.ListImages()
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, &transport.ListImagesResponse{
+		Images: images,
+	})
+}
+
+func (s *GardenServer) handleCreationProgress(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("creation-progress", lager.Data{
+		"handle": handle,
+	})
+
+	events, err := s.backend.CreationProgress(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.writeResponse(w, &transport.CreationProgressResponse{
+		Events: events,
+	})
+}
+
+func (s *GardenServer) handleCheckpoint(w http.ResponseWriter, r *http.Request) {
+	handle := r.FormValue(":handle")
+
+	hLog := s.logger.Session("checkpoint", lager.Data{
+		"handle": handle,
+	})
+
+	container, err := s.backend.Lookup(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	s.bomberman.Pause(container.Handle())
+	defer s.bomberman.Unpause(container.Handle())
+
+	hLog.Debug("checkpointing")
+
+	err = s.backend.Checkpoint(handle)
+	if err != nil {
+		s.writeError(w, err, hLog)
+		return
+	}
+
+	hLog.Info("checkpointed")
+
+	s.writeSuccess(w)
+}
+
+// redactedSpec returns a copy of spec which is safe to log, without the
+// docker registry credentials given in its properties or rootfs URL.
+func redactedSpec(spec garden.ContainerSpec) garden.ContainerSpec {
+	if spec.Properties != nil {
+		properties := garden.Properties{}
+		for name, value := range spec.Properties {
+			if strings.HasPrefix(name, "docker:registry_") {
+				value = "[REDACTED]"
+			}
+
+			properties[name] = value
+		}
+
+		spec.Properties = properties
+	}
+
+	if rootfsURL, err := url.Parse(spec.RootFSPath); err == nil && rootfsURL.User != nil {
+		rootfsURL.User = nil
+		spec.RootFSPath = rootfsURL.String()
+	}
+
+	return spec
+}
+
 func (s *GardenServer) writeError(w http.ResponseWriter, err error, logger lager.Logger) {
 	logger.Error("failed", err)
 
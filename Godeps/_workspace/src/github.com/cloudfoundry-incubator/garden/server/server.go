@@ -75,18 +75,27 @@ func New(
 		routes.Destroy:                http.HandlerFunc(s.handleDestroy),
 		routes.List:                   http.HandlerFunc(s.handleList),
 		routes.Stop:                   http.HandlerFunc(s.handleStop),
+		routes.Pause:                  http.HandlerFunc(s.handlePause),
+		routes.Resume:                 http.HandlerFunc(s.handleResume),
 		routes.StreamIn:               http.HandlerFunc(s.handleStreamIn),
 		routes.StreamOut:              http.HandlerFunc(s.handleStreamOut),
 		routes.LimitBandwidth:         http.HandlerFunc(s.handleLimitBandwidth),
 		routes.CurrentBandwidthLimits: http.HandlerFunc(s.handleCurrentBandwidthLimits),
 		routes.LimitCPU:               http.HandlerFunc(s.handleLimitCPU),
 		routes.CurrentCPULimits:       http.HandlerFunc(s.handleCurrentCPULimits),
+		routes.LimitBlockIO:           http.HandlerFunc(s.handleLimitBlockIO),
+		routes.CurrentBlockIOLimits:   http.HandlerFunc(s.handleCurrentBlockIOLimits),
+		routes.LimitPids:              http.HandlerFunc(s.handleLimitPids),
+		routes.CurrentPidLimits:       http.HandlerFunc(s.handleCurrentPidLimits),
 		routes.LimitDisk:              http.HandlerFunc(s.handleLimitDisk),
 		routes.CurrentDiskLimits:      http.HandlerFunc(s.handleCurrentDiskLimits),
 		routes.LimitMemory:            http.HandlerFunc(s.handleLimitMemory),
 		routes.CurrentMemoryLimits:    http.HandlerFunc(s.handleCurrentMemoryLimits),
+		routes.StreamOomEvents:        http.HandlerFunc(s.handleStreamOomEvents),
 		routes.NetIn:                  http.HandlerFunc(s.handleNetIn),
+		routes.NetInRemove:            http.HandlerFunc(s.handleNetInRemove),
 		routes.NetOut:                 http.HandlerFunc(s.handleNetOut),
+		routes.NetOutRemove:           http.HandlerFunc(s.handleNetOutRemove),
 		routes.Info:                   http.HandlerFunc(s.handleInfo),
 		routes.BulkInfo:               http.HandlerFunc(s.handleBulkInfo),
 		routes.BulkMetrics:            http.HandlerFunc(s.handleBulkMetrics),
@@ -95,13 +104,23 @@ func New(
 		routes.Stderr:                 http.HandlerFunc(s.streamer.handleStderr),
 		routes.Attach:                 http.HandlerFunc(s.handleAttach),
 		routes.Metrics:                http.HandlerFunc(s.handleMetrics),
+		routes.NetworkState:           http.HandlerFunc(s.handleNetworkState),
 		routes.Properties:             http.HandlerFunc(s.handleProperties),
 		routes.Property:               http.HandlerFunc(s.handleProperty),
 		routes.SetProperty:            http.HandlerFunc(s.handleSetProperty),
 		routes.RemoveProperty:         http.HandlerFunc(s.handleRemoveProperty),
-		
+
 		// add commit container diff and save image to tar interface, lvguanglin, 2015/7/8
-		routes.CommitAndSave:		   http.HandlerFunc(s.handleCommitAndSave),
+		routes.CommitAndSave:   http.HandlerFunc(s.handleCommitAndSave),
+		routes.CommitAndStream: http.HandlerFunc(s.handleCommitAndStream),
+		routes.CommitImage:     http.HandlerFunc(s.handleCommitImage),
+
+		routes.PullImage:  http.HandlerFunc(s.handlePullImage),
+		routes.ListImages: http.HandlerFunc(s.handleListImages),
+
+		routes.CreationProgress: http.HandlerFunc(s.handleCreationProgress),
+
+		routes.Checkpoint: http.HandlerFunc(s.handleCheckpoint),
 	}
 
 	mux, err := rata.NewRouter(routes.Routes, handlers)
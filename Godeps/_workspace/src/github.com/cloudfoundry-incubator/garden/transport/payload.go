@@ -22,12 +22,41 @@ type ProcessPayload struct {
 }
 
 type NetInRequest struct {
-	Handle        string `json:"handle,omitempty"`
-	HostPort      uint32 `json:"host_port,omitempty"`
-	ContainerPort uint32 `json:"container_port,omitempty"`
+	Handle        string          `json:"handle,omitempty"`
+	HostPort      uint32          `json:"host_port,omitempty"`
+	ContainerPort uint32          `json:"container_port,omitempty"`
+	Protocol      garden.Protocol `json:"protocol,omitempty"`
+	PortCount     uint32          `json:"port_count,omitempty"`
 }
 
 type NetInResponse struct {
-	HostPort      uint32 `json:"host_port,omitempty"`
-	ContainerPort uint32 `json:"container_port,omitempty"`
+	HostPort      uint32          `json:"host_port,omitempty"`
+	ContainerPort uint32          `json:"container_port,omitempty"`
+	Protocol      garden.Protocol `json:"protocol,omitempty"`
+	PortCount     uint32          `json:"port_count,omitempty"`
+}
+
+type CommitImageRequest struct {
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+type CommitImageResponse struct {
+	ImageID string `json:"image_id,omitempty"`
+}
+
+type PullImageRequest struct {
+	RootFSPath string `json:"rootfs,omitempty"`
+}
+
+type PullImageResponse struct {
+	ImageID string `json:"image_id,omitempty"`
+}
+
+type ListImagesResponse struct {
+	Images []garden.ImageInfo `json:"images,omitempty"`
+}
+
+type CreationProgressResponse struct {
+	Events []string `json:"events,omitempty"`
 }
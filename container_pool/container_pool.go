@@ -13,6 +13,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"github.com/bitly/go-simplejson"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
 	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
 	"github.com/cloudfoundry-incubator/garden-linux/old/rootfs_provider"
 	"github.com/cloudfoundry-incubator/garden-linux/old/sysconfig"
 	"github.com/cloudfoundry-incubator/garden-linux/old/uid_pool"
@@ -39,11 +41,25 @@ import (
 
 var ErrUnknownRootFSProvider = errors.New("unknown rootfs provider")
 
+// Container properties holding credentials for the registry the container's
+// rootfs is fetched from. They are only used to fetch the rootfs, and are not
+// kept as properties of the container.
+const (
+	RegistryUsernameProperty = "docker:registry_username"
+	RegistryPasswordProperty = "docker:registry_password"
+)
+
 //go:generate counterfeiter -o fake_container_pool/FakeFilterProvider.go . FilterProvider
 type FilterProvider interface {
 	ProvideFilter(containerId string) network.Filter
 }
 
+// ImageLister describes the images cached for docker rootfses.
+//go:generate counterfeiter -o fake_container_pool/FakeImageLister.go . ImageLister
+type ImageLister interface {
+	List() ([]garden.ImageInfo, error)
+}
+
 //go:generate counterfeiter -o fake_subnet_pool/FakeSubnetPool.go . SubnetPool
 type SubnetPool interface {
 	Acquire(subnet subnets.SubnetSelector, ip subnets.IPSelector) (*linux_backend.Network, error)
@@ -64,10 +80,15 @@ type LinuxContainerPool struct {
 	allowNetworks []string
 
 	rootfsProviders map[string]rootfs_provider.RootFSProvider
+	fetchProgress   *repository_fetcher.Progress
+	imageLister     ImageLister
 
 	uidPool    uid_pool.UIDPool
 	subnetPool SubnetPool
 
+	// nil unless containers get IPv6 networks as well as IPv4 ones
+	ipv6SubnetPool SubnetPool
+
 	externalIP net.IP
 	mtu        int
 
@@ -78,11 +99,18 @@ type LinuxContainerPool struct {
 	filterProvider FilterProvider
 	defaultChain   iptables.Chain
 
+	// nil unless containers get IPv6 networks
+	ipv6DefaultChain iptables.Chain
+
 	runner command_runner.CommandRunner
 
 	quotaManager quota_manager.QuotaManager
 
 	containerIDs chan string
+
+	// the IDs of the containers being created, by handle
+	creating      map[string]string
+	creatingMutex *sync.Mutex
 	
 	hostIFName string
 	hostBrName string
@@ -93,13 +121,16 @@ func New(
 	binPath, depotPath string,
 	sysconfig sysconfig.Config,
 	rootfsProviders map[string]rootfs_provider.RootFSProvider,
+	fetchProgress *repository_fetcher.Progress,
+	imageLister ImageLister,
 	uidPool uid_pool.UIDPool,
 	externalIP net.IP,
 	mtu int,
 	subnetPool SubnetPool,
+	ipv6SubnetPool SubnetPool,
 	bridges bridgemgr.BridgeManager,
 	filterProvider FilterProvider,
-	defaultChain iptables.Chain,
+	defaultChain, ipv6DefaultChain iptables.Chain,
 	portPool linux_container.PortPool,
 	denyNetworks, allowNetworks []string,
 	runner command_runner.CommandRunner,
@@ -115,6 +146,8 @@ func New(
 		sysconfig: sysconfig,
 
 		rootfsProviders: rootfsProviders,
+		fetchProgress:   fetchProgress,
+		imageLister:     imageLister,
 
 		allowNetworks: allowNetworks,
 		denyNetworks:  denyNetworks,
@@ -124,13 +157,16 @@ func New(
 		externalIP: externalIP,
 		mtu:        mtu,
 
-		subnetPool: subnetPool,
+		subnetPool:     subnetPool,
+		ipv6SubnetPool: ipv6SubnetPool,
 
 		bridges: bridges,
 
 		filterProvider: filterProvider,
 		defaultChain:   defaultChain,
 
+		ipv6DefaultChain: ipv6DefaultChain,
+
 		portPool: portPool,
 
 		runner: runner,
@@ -139,6 +175,9 @@ func New(
 
 		containerIDs: make(chan string),
 
+		creating:      map[string]string{},
+		creatingMutex: new(sync.Mutex),
+
 		hostIFName: hostIFName,
 		hostBrName: hostBrName,
 	}
@@ -150,6 +189,10 @@ func New(
 
 func (p *LinuxContainerPool) MaxContainers() int {
 	maxNet := p.subnetPool.Capacity()
+	if p.ipv6SubnetPool != nil && p.ipv6SubnetPool.Capacity() < maxNet {
+		maxNet = p.ipv6SubnetPool.Capacity()
+	}
+
 	maxUid := p.uidPool.InitialSize()
 	if maxNet < maxUid {
 		return maxNet
@@ -165,6 +208,8 @@ func (p *LinuxContainerPool) Setup() error {
 		"CONTAINER_DEPOT_PATH=" + p.depotPath,
 		"CONTAINER_DEPOT_MOUNT_POINT_PATH=" + p.quotaManager.MountPoint(),
 		fmt.Sprintf("DISK_QUOTA_ENABLED=%v", p.quotaManager.IsEnabled()),
+		fmt.Sprintf("GARDEN_IPV6_ENABLED=%v", p.ipv6SubnetPool != nil),
+		"DISK_QUOTA_TYPE=" + p.quotaManager.Type(),
 		"PATH=" + os.Getenv("PATH"),
 	}
 
@@ -178,28 +223,48 @@ func (p *LinuxContainerPool) Setup() error {
 
 func (p *LinuxContainerPool) setupIPTables() error {
 	for _, n := range p.allowNetworks {
-		if n == "" {
+		chain := p.defaultChainFor(n)
+		if n == "" || chain == nil {
 			continue
 		}
 
-		if err := p.defaultChain.AppendRule("", n, iptables.Return); err != nil {
+		if err := chain.AppendRule("", n, iptables.Return); err != nil {
 			return fmt.Errorf("container_pool: setting up allow rules in iptables: %v", err)
 		}
 	}
 
 	for _, n := range p.denyNetworks {
-		if n == "" {
+		chain := p.defaultChainFor(n)
+		if n == "" || chain == nil {
 			continue
 		}
 
-		if err := p.defaultChain.AppendRule("", n, iptables.Reject); err != nil {
+		if err := chain.AppendRule("", n, iptables.Reject); err != nil {
 			return fmt.Errorf("container_pool: setting up deny rules in iptables: %v", err)
 		}
 	}
 
+	// IPv4 deny rules such as 0.0.0.0/0 cannot cover IPv6, so IPv6 traffic
+	// out of containers is rejected unless it is allowed
+	if p.ipv6DefaultChain != nil {
+		if err := p.ipv6DefaultChain.AppendRule("", "", iptables.Reject); err != nil {
+			return fmt.Errorf("container_pool: setting up the default IPv6 rule in ip6tables: %v", err)
+		}
+	}
+
 	return nil
 }
 
+// defaultChainFor returns the default chain for the address family of
+// network, which is nil for IPv6 networks unless IPv6 is enabled.
+func (p *LinuxContainerPool) defaultChainFor(network string) iptables.Chain {
+	if strings.Contains(network, ":") {
+		return p.ipv6DefaultChain
+	}
+
+	return p.defaultChain
+}
+
 func (p *LinuxContainerPool) Prune(keep map[string]bool) error {
 	entries, err := ioutil.ReadDir(p.depotPath)
 	if err != nil {
@@ -242,6 +307,10 @@ func (p *LinuxContainerPool) pruneEntry(id string) {
 	pLog.Info("end of prune")
 }
 
+// Create creates a container for spec, fetching its rootfs first if need be.
+// While Create is in flight, the progress of the fetch can be followed with
+// CreationProgress; once it returns, the progress becomes the container's
+// events, or if Create fails, it is logged along with the error instead.
 func (p *LinuxContainerPool) Create(spec garden.ContainerSpec) (c linux_backend.Container, err error) {
 	id := <-p.containerIDs
 	containerPath := path.Join(p.depotPath, id)
@@ -261,7 +330,21 @@ func (p *LinuxContainerPool) Create(spec garden.ContainerSpec) (c linux_backend.
 
 	handle := getHandle(spec.Handle, id)
 
-	rootFSEnv, err := p.acquireSystemResources(id, handle, containerPath, spec.RootFSPath, resources, spec.BindMounts, pLog, spec.Properties)
+	p.startCreating(handle, id)
+	defer p.stopCreating(handle)
+
+	properties, registryCredentials := extractRegistryCredentials(spec.Properties)
+
+	rootFSEnv, err := p.acquireSystemResources(id, handle, containerPath, spec.RootFSPath, registryCredentials, resources, spec.BindMounts, pLog, properties)
+	fetchEvents := p.fetchProgress.Events(id)
+	defer func() {
+		if err != nil && len(fetchEvents) > 0 {
+			pLog.Error("create-failed-after-fetching", err, lager.Data{
+				"fetch-events": fetchEvents,
+			})
+		}
+	}()
+
 	if err != nil {
 		return nil, err
 	}
@@ -277,23 +360,58 @@ func (p *LinuxContainerPool) Create(spec garden.ContainerSpec) (c linux_backend.
 		"rootfs-env": rootFSEnv,
 		"create-env": specEnv,
 	})
-	return linux_container.NewLinuxContainer(
+	container := linux_container.NewLinuxContainer(
 		pLog,
 		id,
 		handle,
 		containerPath,
-		spec.Properties,
+		properties,
 		spec.GraceTime,
 		resources,
 		p.portPool,
 		p.runner,
 		cgroups_manager.New(p.sysconfig.CgroupPath, id),
 		p.quotaManager,
-		bandwidth_manager.New(containerPath, id, p.runner),
+		bandwidth_manager.New(containerPath, id, p.runner, bandwidth_manager.NetlinkTrafficControl{}),
 		process_tracker.New(containerPath, p.runner),
 		rootFSEnv.Merge(specEnv),
 		p.filterProvider.ProvideFilter(id),
-	), nil
+	)
+
+	// explain what took the time in creating the container
+	for _, event := range fetchEvents {
+		container.RegisterEvent(event)
+	}
+
+	return container, nil
+}
+
+// CreationProgress returns the progress so far of fetching the rootfs of the
+// container being created with handle.
+func (p *LinuxContainerPool) CreationProgress(handle string) ([]string, error) {
+	p.creatingMutex.Lock()
+	id, found := p.creating[handle]
+	p.creatingMutex.Unlock()
+
+	if !found {
+		return nil, garden.ContainerNotFoundError{handle}
+	}
+
+	return p.fetchProgress.Pending(id), nil
+}
+
+func (p *LinuxContainerPool) startCreating(handle, id string) {
+	p.creatingMutex.Lock()
+	defer p.creatingMutex.Unlock()
+
+	p.creating[handle] = id
+}
+
+func (p *LinuxContainerPool) stopCreating(handle string) {
+	p.creatingMutex.Lock()
+	defer p.creatingMutex.Unlock()
+
+	delete(p.creating, handle)
 }
 
 func (p *LinuxContainerPool) releaseUIDs(userUID, rootUID uint32) {
@@ -341,9 +459,19 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 		return nil, err
 	}
 
+	// a container snapshotted while IPv6 was enabled keeps its IPv6 network
+	if resources.IPv6Network != nil && p.ipv6SubnetPool != nil {
+		if err = p.ipv6SubnetPool.Remove(resources.IPv6Network); err != nil {
+			p.releaseUIDs(resources.UserUID, resources.RootUID)
+			p.subnetPool.Release(resources.Network)
+			return nil, err
+		}
+	}
+
 	if err = p.bridges.Rereserve(resources.Bridge, resources.Network.Subnet, id); err != nil {
 		p.releaseUIDs(resources.UserUID, resources.RootUID)
 		p.subnetPool.Release(resources.Network)
+		p.releaseIPv6Network(resources.IPv6Network)
 		return nil, err
 	}
 
@@ -352,6 +480,7 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 		if err != nil {
 			p.releaseUIDs(resources.UserUID, resources.RootUID)
 			p.subnetPool.Release(resources.Network)
+			p.releaseIPv6Network(resources.IPv6Network)
 
 			for _, port := range resources.Ports {
 				p.portPool.Release(port)
@@ -365,7 +494,7 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 
 	cgroupsManager := cgroups_manager.New(p.sysconfig.CgroupPath, id)
 
-	bandwidthManager := bandwidth_manager.New(containerPath, id, p.runner)
+	bandwidthManager := bandwidth_manager.New(containerPath, id, p.runner, bandwidth_manager.NetlinkTrafficControl{})
 
 	containerLogger := p.logger.Session(id)
 
@@ -374,6 +503,17 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 		return nil, err
 	}
 
+	containerResources := linux_backend.NewResources(
+		resources.UserUID,
+		resources.RootUID,
+		resources.Network,
+		resources.Bridge,
+		resources.Ports,
+		p.externalIP,
+	)
+
+	containerResources.IPv6Network = resources.IPv6Network
+
 	container := linux_container.NewLinuxContainer(
 		containerLogger,
 		id,
@@ -381,14 +521,7 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 		containerPath,
 		containerSnapshot.Properties,
 		containerSnapshot.GraceTime,
-		linux_backend.NewResources(
-			resources.UserUID,
-			resources.RootUID,
-			resources.Network,
-			resources.Bridge,
-			resources.Ports,
-			p.externalIP,
-		),
+		containerResources,
 		p.portPool,
 		p.runner,
 		cgroupsManager,
@@ -399,6 +532,14 @@ func (p *LinuxContainerPool) Restore(snapshot io.Reader) (linux_backend.Containe
 		p.filterProvider.ProvideFilter(id),
 	)
 
+	// resuming a checkpoint needs the rootfs it was taken in
+	if containerSnapshot.State == string(linux_container.StateCheckpointed) {
+		if err := p.remountRootFS(rLog, id); err != nil {
+			rLog.Error("remount-rootfs-failed", err)
+			return nil, err
+		}
+	}
+
 	err = container.Restore(containerSnapshot)
 	if err != nil {
 		return nil, err
@@ -503,6 +644,37 @@ func (p *LinuxContainerPool) saveRootFSProvider(id string, provider string) erro
 	return ioutil.WriteFile(providerFile, []byte(provider), 0644)
 }
 
+// saveRootFSURL records the rootfs the container was created from, without
+// any credentials, so that it can be mounted again on restore.
+func (p *LinuxContainerPool) saveRootFSURL(id string, rootfsURL *url.URL) error {
+	withoutCredentials := *rootfsURL
+	withoutCredentials.User = nil
+
+	urlFile := path.Join(p.depotPath, id, "rootfs-url")
+	return ioutil.WriteFile(urlFile, []byte(withoutCredentials.String()), 0644)
+}
+
+// remountRootFS mounts a container's rootfs again, as it is not mounted
+// after a reboot or a move to another host.
+func (p *LinuxContainerPool) remountRootFS(logger lager.Logger, id string) error {
+	provider, err := p.rootfsProviderFor(id)
+	if err != nil {
+		return err
+	}
+
+	// containers created before the rootfs URL was recorded get the
+	// provider's default rootfs
+	rootfsURL := &url.URL{}
+	if contents, err := ioutil.ReadFile(path.Join(p.depotPath, id, "rootfs-url")); err == nil {
+		if rootfsURL, err = url.Parse(string(contents)); err != nil {
+			return err
+		}
+	}
+
+	_, err = provider.RemountRootFS(logger.Session("remount-rootfs"), id, rootfsURL)
+	return err
+}
+
 func (p *LinuxContainerPool) acquirePoolResources(spec garden.ContainerSpec, id string) (*linux_backend.Resources, error) {
 	resources := linux_backend.NewResources(0, 1, nil, "", nil, p.externalIP)
 
@@ -520,6 +692,14 @@ func (p *LinuxContainerPool) acquirePoolResources(spec garden.ContainerSpec, id
 		return nil, err
 	}
 
+	// the network spec only selects the IPv4 network
+	if p.ipv6SubnetPool != nil {
+		if resources.IPv6Network, err = p.ipv6SubnetPool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector); err != nil {
+			p.releasePoolResources(resources)
+			return nil, err
+		}
+	}
+
 	return resources, nil
 }
 
@@ -553,6 +733,14 @@ func (p *LinuxContainerPool) releasePoolResources(resources *linux_backend.Resou
 	if resources.Network != nil {
 		p.subnetPool.Release(resources.Network)
 	}
+
+	p.releaseIPv6Network(resources.IPv6Network)
+}
+
+func (p *LinuxContainerPool) releaseIPv6Network(network *linux_backend.Network) {
+	if network != nil && p.ipv6SubnetPool != nil {
+		p.ipv6SubnetPool.Release(network)
+	}
 }
 
 /***********************container directy network support*******************************/
@@ -641,7 +829,7 @@ func traverseJsonMap(jsonMap map[string]interface{},key string) (string, bool) {
 
 /***********************container directy network support*******************************/
 
-func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, rootFSPath string, resources *linux_backend.Resources, bindMounts []garden.BindMount, pLog lager.Logger, properties garden.Properties) (process.Env, error) {
+func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, rootFSPath string, registryCredentials *url.Userinfo, resources *linux_backend.Resources, bindMounts []garden.BindMount, pLog lager.Logger, properties garden.Properties) (process.Env, error) {
 	if err := os.MkdirAll(containerPath, 0755); err != nil {
 		return nil, fmt.Errorf("containerpool: creating container directory: %v", err)
 	}
@@ -662,9 +850,26 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 		return nil, ErrUnknownRootFSProvider
 	}
 
-	rootfsPath, rootFSEnvVars, err := provider.ProvideRootFS(pLog.Session("create-rootfs"), id, rootfsURL)
+	// credentials are only given to the provider, so that they never end up
+	// in the logs below
+	providerURL := *rootfsURL
+	if registryCredentials != nil {
+		providerURL.User = registryCredentials
+	}
+
+	quotaSubject := quota_manager.Subject{ID: id, UID: resources.UserUID}
+
+	// the container's directories must be accounted for before anything is
+	// written to them
+	if err := p.quotaManager.Setup(pLog.Session("setup-quota"), quotaSubject); err != nil {
+		pLog.Error("setup-quota-failed", err)
+		return nil, err
+	}
+
+	rootfsPath, rootFSEnvVars, err := provider.ProvideRootFS(pLog.Session("create-rootfs"), id, &providerURL)
 	if err != nil {
 		pLog.Error("provide-rootfs-failed", err)
+		p.quotaManager.TearDown(pLog, quotaSubject)
 		return nil, err
 	}
 
@@ -675,7 +880,8 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 			"Bridge": resources.Bridge,
 		})
 
-		provider.CleanupRootFS(pLog, rootfsPath)
+		p.quotaManager.TearDown(pLog, quotaSubject)
+		provider.CleanupRootFS(pLog, id)
 		return nil, err
 	}
 
@@ -685,7 +891,8 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 			"Bridge": resources.Bridge,
 		})
 
-		provider.CleanupRootFS(pLog, rootfsPath)
+		p.quotaManager.TearDown(pLog, quotaSubject)
+		provider.CleanupRootFS(pLog, id)
 		return nil, err
 	}
 
@@ -714,6 +921,7 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 	createCmd := path.Join(p.binPath, "create.sh")
 	create := exec.Command(createCmd, containerPath)
 	suff, _ := resources.Network.Subnet.Mask.Size()
+
 	env := process.Env{
 		"id":                   id,
 		"rootfs_path":          rootfsPath,
@@ -734,6 +942,13 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 		"container_hostname":            containerHostname,
 		"PATH":                 os.Getenv("PATH"),
 	}
+
+	if resources.IPv6Network != nil {
+		env["network_host_ipv6"] = subnets.GatewayIP(resources.IPv6Network.Subnet).String()
+		env["network_container_ipv6"] = resources.IPv6Network.IP.String()
+		env["network_cidr_ipv6"] = resources.IPv6Network.Subnet.String()
+	}
+
 	create.Env = env.Array()
 
 	pRunner := logging.Runner{
@@ -763,6 +978,14 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 		return nil, err
 	}
 
+	err = p.saveRootFSURL(id, rootfsURL)
+	if err != nil {
+		p.logger.Error("save-rootfs-url-failed", err, lager.Data{
+			"Id": id,
+		})
+		return nil, err
+	}
+
 	err = p.writeBindMounts(containerPath, rootfsPath, bindMounts)
 	if err != nil {
 		p.logger.Error("bind-mounts-failed", err)
@@ -781,6 +1004,30 @@ func (p *LinuxContainerPool) acquireSystemResources(id, handle, containerPath, r
 	return rootFSEnvVars, nil
 }
 
+// extractRegistryCredentials splits the registry credentials out of the
+// properties a container was created with.
+func extractRegistryCredentials(properties garden.Properties) (garden.Properties, *url.Userinfo) {
+	username, found := properties[RegistryUsernameProperty]
+	if !found {
+		if _, found := properties[RegistryPasswordProperty]; !found {
+			return properties, nil
+		}
+	}
+
+	remaining := garden.Properties{}
+	for key, value := range properties {
+		if key != RegistryUsernameProperty && key != RegistryPasswordProperty {
+			remaining[key] = value
+		}
+	}
+
+	if username == "" {
+		return remaining, nil
+	}
+
+	return remaining, url.UserPassword(username, properties[RegistryPasswordProperty])
+}
+
 func (p *LinuxContainerPool) tryReleaseSystemResources(logger lager.Logger, id string) {
 	err := p.releaseSystemResources(logger, id)
 	if err != nil {
@@ -817,6 +1064,11 @@ func (p *LinuxContainerPool) releaseSystemResources(logger lager.Logger, id stri
 		return err
 	}
 
+	// only the ID is known when pruning, and it is all that tearing down needs
+	if err = p.quotaManager.TearDown(logger, quota_manager.Subject{ID: id}); err != nil {
+		return err
+	}
+
 	if err = provider.CleanupRootFS(logger, id); err != nil {
 		return err
 	}
@@ -848,17 +1100,12 @@ func (p *LinuxContainerPool) CommitContainerAndSaveImage(id, dest string) error
 	pLog := p.logger.Session("CommitContainerAndSaveImage", lager.Data{"id": id})
 
 	pLog.Info("CommitContainerAndSaveImage-START")
-	
-	rootfsProvider, err := ioutil.ReadFile(path.Join(p.depotPath, id, "rootfs-provider"))
+
+	provider, err := p.rootfsProviderFor(id)
 	if err != nil {
-		rootfsProvider = []byte("")
+		return err
 	}
 
-	provider, found := p.rootfsProviders[string(rootfsProvider)]
-	if !found {
-		return ErrUnknownRootFSProvider
-	}
-	
 	if err := provider.CommitAndSaveRootFS(pLog,id,dest); err != nil {
 		return err
 	}
@@ -867,6 +1114,105 @@ func (p *LinuxContainerPool) CommitContainerAndSaveImage(id, dest string) error
 	return nil
 }
 
+func (p *LinuxContainerPool) CommitContainerAndStreamImage(id string) (io.ReadCloser, error) {
+	pLog := p.logger.Session("commit-and-stream", lager.Data{"id": id})
+
+	provider, err := p.rootfsProviderFor(id)
+	if err != nil {
+		return nil, err
+	}
+
+	pLog.Info("committing")
+
+	stream, err := provider.CommitAndStreamRootFS(pLog, id)
+	if err != nil {
+		pLog.Error("commit-failed", err)
+		return nil, err
+	}
+
+	pLog.Info("committed")
+
+	return stream, nil
+}
+
+func (p *LinuxContainerPool) CommitContainerAsImage(id, repoName, tag string) (string, error) {
+	pLog := p.logger.Session("commit-as-image", lager.Data{
+		"id":   id,
+		"repo": repoName,
+		"tag":  tag,
+	})
+
+	provider, err := p.rootfsProviderFor(id)
+	if err != nil {
+		return "", err
+	}
+
+	pLog.Info("committing")
+
+	imageID, err := provider.CommitRootFS(pLog, id, repoName, tag)
+	if err != nil {
+		pLog.Error("commit-failed", err)
+		return "", err
+	}
+
+	pLog.Info("committed", lager.Data{"image": imageID})
+
+	return imageID, nil
+}
+
+// PullImage fetches the image for a rootfs URL, so that containers created
+// from it later need not wait for it.
+func (p *LinuxContainerPool) PullImage(rootfs string) (string, error) {
+	rootfsURL, err := url.Parse(rootfs)
+	if err != nil {
+		return "", err
+	}
+
+	provider, found := p.rootfsProviders[rootfsURL.Scheme]
+	if !found {
+		return "", ErrUnknownRootFSProvider
+	}
+
+	// credentials are only given to the provider, so that they never end up
+	// in the logs
+	loggedURL := *rootfsURL
+	loggedURL.User = nil
+
+	pLog := p.logger.Session("pull-image", lager.Data{
+		"rootfs": loggedURL.String(),
+	})
+
+	pLog.Info("pulling")
+
+	imageID, err := provider.PullRootFS(pLog, rootfsURL)
+	if err != nil {
+		pLog.Error("pull-failed", err)
+		return "", err
+	}
+
+	pLog.Info("pulled", lager.Data{"image": imageID})
+
+	return imageID, nil
+}
+
+func (p *LinuxContainerPool) ListImages() ([]garden.ImageInfo, error) {
+	return p.imageLister.List()
+}
+
+func (p *LinuxContainerPool) rootfsProviderFor(id string) (rootfs_provider.RootFSProvider, error) {
+	rootfsProvider, err := ioutil.ReadFile(path.Join(p.depotPath, id, "rootfs-provider"))
+	if err != nil {
+		rootfsProvider = []byte("")
+	}
+
+	provider, found := p.rootfsProviders[string(rootfsProvider)]
+	if !found {
+		return nil, ErrUnknownRootFSProvider
+	}
+
+	return provider, nil
+}
+
 func getHandle(handle, id string) string {
 	if handle != "" {
 		return handle
@@ -0,0 +1,46 @@
+// This file was generated by counterfeiter
+package fake_container_pool
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/container_pool"
+)
+
+type FakeImageLister struct {
+	ListStub        func() ([]garden.ImageInfo, error)
+	listMutex       sync.RWMutex
+	listArgsForCall []struct{}
+	listReturns     struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}
+}
+
+func (fake *FakeImageLister) List() ([]garden.ImageInfo, error) {
+	fake.listMutex.Lock()
+	fake.listArgsForCall = append(fake.listArgsForCall, struct{}{})
+	fake.listMutex.Unlock()
+	if fake.ListStub != nil {
+		return fake.ListStub()
+	} else {
+		return fake.listReturns.result1, fake.listReturns.result2
+	}
+}
+
+func (fake *FakeImageLister) ListCallCount() int {
+	fake.listMutex.RLock()
+	defer fake.listMutex.RUnlock()
+	return len(fake.listArgsForCall)
+}
+
+func (fake *FakeImageLister) ListReturns(result1 []garden.ImageInfo, result2 error) {
+	fake.ListStub = nil
+	fake.listReturns = struct {
+		result1 []garden.ImageInfo
+		result2 error
+	}{result1, result2}
+}
+
+var _ container_pool.ImageLister = new(FakeImageLister)
@@ -22,6 +22,11 @@ type FakeContainer struct {
 	Started    bool
 
 	CleanedUp bool
+
+	OnChangeCallback func()
+
+	CheckpointError error
+	Checkpointed    bool
 }
 
 func NewFakeContainer(spec garden.ContainerSpec) *FakeContainer {
@@ -84,3 +89,17 @@ func (c *FakeContainer) Snapshot(snapshot io.Writer) error {
 
 	return nil
 }
+
+func (c *FakeContainer) Checkpoint() error {
+	if c.CheckpointError != nil {
+		return c.CheckpointError
+	}
+
+	c.Checkpointed = true
+
+	return nil
+}
+
+func (c *FakeContainer) OnChange(callback func()) {
+	c.OnChangeCallback = callback
+}
@@ -21,12 +21,30 @@ type FakeContainerPool struct {
 	CreateError  error
 	RestoreError error
 	DestroyError error
+	CommitError  error
+
+	CommitStream io.ReadCloser
 
 	ContainerSetup func(*FakeContainer)
 
 	CreatedContainers   []linux_backend.Container
 	DestroyedContainers []linux_backend.Container
 	RestoredSnapshots   []io.Reader
+	CommittedIDs        []string
+	SavedImages         []string
+	CommittedImages     []string
+
+	CommitImageID string
+
+	PullError    error
+	PulledImages []string
+	PullImageID  string
+
+	ListImagesError error
+	Images          []garden.ImageInfo
+
+	CreationProgressError  error
+	CreationProgressEvents map[string][]string
 }
 
 func New() *FakeContainerPool {
@@ -113,3 +131,61 @@ func (p *FakeContainerPool) Destroy(container linux_backend.Container) error {
 
 	return nil
 }
+
+func (p *FakeContainerPool) CommitContainerAndSaveImage(id, dest string) error {
+	if p.CommitError != nil {
+		return p.CommitError
+	}
+
+	p.CommittedIDs = append(p.CommittedIDs, id)
+	p.SavedImages = append(p.SavedImages, dest)
+
+	return nil
+}
+
+func (p *FakeContainerPool) CommitContainerAndStreamImage(id string) (io.ReadCloser, error) {
+	if p.CommitError != nil {
+		return nil, p.CommitError
+	}
+
+	p.CommittedIDs = append(p.CommittedIDs, id)
+
+	return p.CommitStream, nil
+}
+
+func (p *FakeContainerPool) CommitContainerAsImage(id, repoName, tag string) (string, error) {
+	if p.CommitError != nil {
+		return "", p.CommitError
+	}
+
+	p.CommittedIDs = append(p.CommittedIDs, id)
+	p.CommittedImages = append(p.CommittedImages, repoName+":"+tag)
+
+	return p.CommitImageID, nil
+}
+
+func (p *FakeContainerPool) PullImage(rootfs string) (string, error) {
+	if p.PullError != nil {
+		return "", p.PullError
+	}
+
+	p.PulledImages = append(p.PulledImages, rootfs)
+
+	return p.PullImageID, nil
+}
+
+func (p *FakeContainerPool) ListImages() ([]garden.ImageInfo, error) {
+	if p.ListImagesError != nil {
+		return nil, p.ListImagesError
+	}
+
+	return p.Images, nil
+}
+
+func (p *FakeContainerPool) CreationProgress(handle string) ([]string, error) {
+	if p.CreationProgressError != nil {
+		return nil, p.CreationProgressError
+	}
+
+	return p.CreationProgressEvents[handle], nil
+}
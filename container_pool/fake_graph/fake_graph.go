@@ -6,12 +6,16 @@ import (
 
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/runconfig"
 )
 
 type FakeGraph struct {
 	exists map[string]*image.Image
 
 	WhenRegistering func(image *image.Image, layer archive.ArchiveReader) error
+	WhenDeleting    func(name string) error
+
+	Deleted []string
 
 	mutex *sync.RWMutex
 }
@@ -54,6 +58,18 @@ func (graph *FakeGraph) Get(imageID string) (*image.Image, error) {
 	}
 }
 
+func (graph *FakeGraph) Map() (map[string]*image.Image, error) {
+	graph.mutex.RLock()
+	defer graph.mutex.RUnlock()
+
+	images := make(map[string]*image.Image, len(graph.exists))
+	for id, img := range graph.exists {
+		images[id] = img
+	}
+
+	return images, nil
+}
+
 func (graph *FakeGraph) Register(image *image.Image, layer archive.ArchiveReader) error {
 	if graph.WhenRegistering != nil {
 		return graph.WhenRegistering(image, layer)
@@ -61,3 +77,23 @@ func (graph *FakeGraph) Register(image *image.Image, layer archive.ArchiveReader
 
 	return nil
 }
+
+func (graph *FakeGraph) Create(layerData archive.ArchiveReader, containerID, containerImage, comment, author string, containerConfig, config *runconfig.Config) (*image.Image, error) {
+	return nil, errors.New("fake graph does not support Create")
+}
+
+func (graph *FakeGraph) Delete(name string) error {
+	if graph.WhenDeleting != nil {
+		if err := graph.WhenDeleting(name); err != nil {
+			return err
+		}
+	}
+
+	graph.mutex.Lock()
+	defer graph.mutex.Unlock()
+
+	delete(graph.exists, name)
+	graph.Deleted = append(graph.Deleted, name)
+
+	return nil
+}
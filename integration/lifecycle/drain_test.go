@@ -263,7 +263,7 @@ var _ = Describe("Through a restart", func() {
 
 	Describe("a memory limit", func() {
 		It("is still enforced", func() {
-			err := container.LimitMemory(garden.MemoryLimits{4 * 1024 * 1024})
+			err := container.LimitMemory(garden.MemoryLimits{LimitInBytes: 4 * 1024 * 1024})
 			Expect(err).ToNot(HaveOccurred())
 
 			restartGarden(gardenArgs...)
@@ -301,7 +301,7 @@ var _ = Describe("Through a restart", func() {
 
 	Describe("a container's list of events", func() {
 		It("is still reported", func() {
-			err := container.LimitMemory(garden.MemoryLimits{4 * 1024 * 1024})
+			err := container.LimitMemory(garden.MemoryLimits{LimitInBytes: 4 * 1024 * 1024})
 			Expect(err).ToNot(HaveOccurred())
 
 			// trigger 'out of memory' event
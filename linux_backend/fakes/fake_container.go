@@ -110,6 +110,22 @@ type FakeContainer struct {
 	limitCPUReturns struct {
 		result1 error
 	}
+	LimitBlockIOStub        func(limits garden.BlockIOLimits) error
+	limitBlockIOMutex       sync.RWMutex
+	limitBlockIOArgsForCall []struct {
+		limits garden.BlockIOLimits
+	}
+	limitBlockIOReturns struct {
+		result1 error
+	}
+	LimitPidsStub        func(limits garden.PidLimits) error
+	limitPidsMutex       sync.RWMutex
+	limitPidsArgsForCall []struct {
+		limits garden.PidLimits
+	}
+	limitPidsReturns struct {
+		result1 error
+	}
 	CurrentCPULimitsStub        func() (garden.CPULimits, error)
 	currentCPULimitsMutex       sync.RWMutex
 	currentCPULimitsArgsForCall []struct{}
@@ -117,6 +133,20 @@ type FakeContainer struct {
 		result1 garden.CPULimits
 		result2 error
 	}
+	CurrentBlockIOLimitsStub        func() (garden.BlockIOLimits, error)
+	currentBlockIOLimitsMutex       sync.RWMutex
+	currentBlockIOLimitsArgsForCall []struct{}
+	currentBlockIOLimitsReturns     struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}
+	CurrentPidLimitsStub        func() (garden.PidLimits, error)
+	currentPidLimitsMutex       sync.RWMutex
+	currentPidLimitsArgsForCall []struct{}
+	currentPidLimitsReturns     struct {
+		result1 garden.PidLimits
+		result2 error
+	}
 	LimitDiskStub        func(limits garden.DiskLimits) error
 	limitDiskMutex       sync.RWMutex
 	limitDiskArgsForCall []struct {
@@ -147,6 +177,13 @@ type FakeContainer struct {
 		result1 garden.MemoryLimits
 		result2 error
 	}
+	StreamOomEventsStub        func() (garden.OomEventStream, error)
+	streamOomEventsMutex       sync.RWMutex
+	streamOomEventsArgsForCall []struct{}
+	streamOomEventsReturns     struct {
+		result1 garden.OomEventStream
+		result2 error
+	}
 	NetInStub        func(hostPort, containerPort uint32) (uint32, uint32, error)
 	netInMutex       sync.RWMutex
 	netInArgsForCall []struct {
@@ -158,6 +195,15 @@ type FakeContainer struct {
 		result2 uint32
 		result3 error
 	}
+	NetInMappingStub        func(mapping garden.PortMapping) (garden.PortMapping, error)
+	netInMappingMutex       sync.RWMutex
+	netInMappingArgsForCall []struct {
+		mapping garden.PortMapping
+	}
+	netInMappingReturns struct {
+		result1 garden.PortMapping
+		result2 error
+	}
 	NetOutStub        func(netOutRule garden.NetOutRule) error
 	netOutMutex       sync.RWMutex
 	netOutArgsForCall []struct {
@@ -166,6 +212,22 @@ type FakeContainer struct {
 	netOutReturns struct {
 		result1 error
 	}
+	NetInRemoveStub        func(mapping garden.PortMapping) error
+	netInRemoveMutex       sync.RWMutex
+	netInRemoveArgsForCall []struct {
+		mapping garden.PortMapping
+	}
+	netInRemoveReturns struct {
+		result1 error
+	}
+	NetOutRemoveStub        func(netOutRule garden.NetOutRule) error
+	netOutRemoveMutex       sync.RWMutex
+	netOutRemoveArgsForCall []struct {
+		netOutRule garden.NetOutRule
+	}
+	netOutRemoveReturns struct {
+		result1 error
+	}
 	RunStub        func(garden.ProcessSpec, garden.ProcessIO) (garden.Process, error)
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
@@ -193,6 +255,13 @@ type FakeContainer struct {
 		result1 garden.Metrics
 		result2 error
 	}
+	NetworkStateStub        func() (garden.NetworkState, error)
+	networkStateMutex       sync.RWMutex
+	networkStateArgsForCall []struct{}
+	networkStateReturns     struct {
+		result1 garden.NetworkState
+		result2 error
+	}
 	PropertiesStub        func() (garden.Properties, error)
 	propertiesMutex       sync.RWMutex
 	propertiesArgsForCall []struct{}
@@ -226,6 +295,29 @@ type FakeContainer struct {
 	removePropertyReturns struct {
 		result1 error
 	}
+	OnChangeStub        func(arg1 func())
+	onChangeMutex       sync.RWMutex
+	onChangeArgsForCall []struct {
+		arg1 func()
+	}
+	CheckpointStub        func() error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct{}
+	checkpointReturns     struct {
+		result1 error
+	}
+	PauseStub        func() error
+	pauseMutex       sync.RWMutex
+	pauseArgsForCall []struct{}
+	pauseReturns     struct {
+		result1 error
+	}
+	ResumeStub        func() error
+	resumeMutex       sync.RWMutex
+	resumeArgsForCall []struct{}
+	resumeReturns     struct {
+		result1 error
+	}
 }
 
 func (fake *FakeContainer) ID() string {
@@ -615,6 +707,70 @@ func (fake *FakeContainer) LimitCPUReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) LimitBlockIO(limits garden.BlockIOLimits) error {
+	fake.limitBlockIOMutex.Lock()
+	fake.limitBlockIOArgsForCall = append(fake.limitBlockIOArgsForCall, struct {
+		limits garden.BlockIOLimits
+	}{limits})
+	fake.limitBlockIOMutex.Unlock()
+	if fake.LimitBlockIOStub != nil {
+		return fake.LimitBlockIOStub(limits)
+	} else {
+		return fake.limitBlockIOReturns.result1
+	}
+}
+
+func (fake *FakeContainer) LimitBlockIOCallCount() int {
+	fake.limitBlockIOMutex.RLock()
+	defer fake.limitBlockIOMutex.RUnlock()
+	return len(fake.limitBlockIOArgsForCall)
+}
+
+func (fake *FakeContainer) LimitBlockIOArgsForCall(i int) garden.BlockIOLimits {
+	fake.limitBlockIOMutex.RLock()
+	defer fake.limitBlockIOMutex.RUnlock()
+	return fake.limitBlockIOArgsForCall[i].limits
+}
+
+func (fake *FakeContainer) LimitBlockIOReturns(result1 error) {
+	fake.LimitBlockIOStub = nil
+	fake.limitBlockIOReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) LimitPids(limits garden.PidLimits) error {
+	fake.limitPidsMutex.Lock()
+	fake.limitPidsArgsForCall = append(fake.limitPidsArgsForCall, struct {
+		limits garden.PidLimits
+	}{limits})
+	fake.limitPidsMutex.Unlock()
+	if fake.LimitPidsStub != nil {
+		return fake.LimitPidsStub(limits)
+	} else {
+		return fake.limitPidsReturns.result1
+	}
+}
+
+func (fake *FakeContainer) LimitPidsCallCount() int {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return len(fake.limitPidsArgsForCall)
+}
+
+func (fake *FakeContainer) LimitPidsArgsForCall(i int) garden.PidLimits {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return fake.limitPidsArgsForCall[i].limits
+}
+
+func (fake *FakeContainer) LimitPidsReturns(result1 error) {
+	fake.LimitPidsStub = nil
+	fake.limitPidsReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainer) CurrentCPULimits() (garden.CPULimits, error) {
 	fake.currentCPULimitsMutex.Lock()
 	fake.currentCPULimitsArgsForCall = append(fake.currentCPULimitsArgsForCall, struct{}{})
@@ -640,6 +796,56 @@ func (fake *FakeContainer) CurrentCPULimitsReturns(result1 garden.CPULimits, res
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) CurrentBlockIOLimits() (garden.BlockIOLimits, error) {
+	fake.currentBlockIOLimitsMutex.Lock()
+	fake.currentBlockIOLimitsArgsForCall = append(fake.currentBlockIOLimitsArgsForCall, struct{}{})
+	fake.currentBlockIOLimitsMutex.Unlock()
+	if fake.CurrentBlockIOLimitsStub != nil {
+		return fake.CurrentBlockIOLimitsStub()
+	} else {
+		return fake.currentBlockIOLimitsReturns.result1, fake.currentBlockIOLimitsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) CurrentBlockIOLimitsCallCount() int {
+	fake.currentBlockIOLimitsMutex.RLock()
+	defer fake.currentBlockIOLimitsMutex.RUnlock()
+	return len(fake.currentBlockIOLimitsArgsForCall)
+}
+
+func (fake *FakeContainer) CurrentBlockIOLimitsReturns(result1 garden.BlockIOLimits, result2 error) {
+	fake.CurrentBlockIOLimitsStub = nil
+	fake.currentBlockIOLimitsReturns = struct {
+		result1 garden.BlockIOLimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainer) CurrentPidLimits() (garden.PidLimits, error) {
+	fake.currentPidLimitsMutex.Lock()
+	fake.currentPidLimitsArgsForCall = append(fake.currentPidLimitsArgsForCall, struct{}{})
+	fake.currentPidLimitsMutex.Unlock()
+	if fake.CurrentPidLimitsStub != nil {
+		return fake.CurrentPidLimitsStub()
+	} else {
+		return fake.currentPidLimitsReturns.result1, fake.currentPidLimitsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) CurrentPidLimitsCallCount() int {
+	fake.currentPidLimitsMutex.RLock()
+	defer fake.currentPidLimitsMutex.RUnlock()
+	return len(fake.currentPidLimitsArgsForCall)
+}
+
+func (fake *FakeContainer) CurrentPidLimitsReturns(result1 garden.PidLimits, result2 error) {
+	fake.CurrentPidLimitsStub = nil
+	fake.currentPidLimitsReturns = struct {
+		result1 garden.PidLimits
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) LimitDisk(limits garden.DiskLimits) error {
 	fake.limitDiskMutex.Lock()
 	fake.limitDiskArgsForCall = append(fake.limitDiskArgsForCall, struct {
@@ -754,6 +960,31 @@ func (fake *FakeContainer) CurrentMemoryLimitsReturns(result1 garden.MemoryLimit
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) StreamOomEvents() (garden.OomEventStream, error) {
+	fake.streamOomEventsMutex.Lock()
+	fake.streamOomEventsArgsForCall = append(fake.streamOomEventsArgsForCall, struct{}{})
+	fake.streamOomEventsMutex.Unlock()
+	if fake.StreamOomEventsStub != nil {
+		return fake.StreamOomEventsStub()
+	} else {
+		return fake.streamOomEventsReturns.result1, fake.streamOomEventsReturns.result2
+	}
+}
+
+func (fake *FakeContainer) StreamOomEventsCallCount() int {
+	fake.streamOomEventsMutex.RLock()
+	defer fake.streamOomEventsMutex.RUnlock()
+	return len(fake.streamOomEventsArgsForCall)
+}
+
+func (fake *FakeContainer) StreamOomEventsReturns(result1 garden.OomEventStream, result2 error) {
+	fake.StreamOomEventsStub = nil
+	fake.streamOomEventsReturns = struct {
+		result1 garden.OomEventStream
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) NetIn(hostPort uint32, containerPort uint32) (uint32, uint32, error) {
 	fake.netInMutex.Lock()
 	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
@@ -789,6 +1020,39 @@ func (fake *FakeContainer) NetInReturns(result1 uint32, result2 uint32, result3
 	}{result1, result2, result3}
 }
 
+func (fake *FakeContainer) NetInMapping(mapping garden.PortMapping) (garden.PortMapping, error) {
+	fake.netInMappingMutex.Lock()
+	fake.netInMappingArgsForCall = append(fake.netInMappingArgsForCall, struct {
+		mapping garden.PortMapping
+	}{mapping})
+	fake.netInMappingMutex.Unlock()
+	if fake.NetInMappingStub != nil {
+		return fake.NetInMappingStub(mapping)
+	} else {
+		return fake.netInMappingReturns.result1, fake.netInMappingReturns.result2
+	}
+}
+
+func (fake *FakeContainer) NetInMappingCallCount() int {
+	fake.netInMappingMutex.RLock()
+	defer fake.netInMappingMutex.RUnlock()
+	return len(fake.netInMappingArgsForCall)
+}
+
+func (fake *FakeContainer) NetInMappingArgsForCall(i int) garden.PortMapping {
+	fake.netInMappingMutex.RLock()
+	defer fake.netInMappingMutex.RUnlock()
+	return fake.netInMappingArgsForCall[i].mapping
+}
+
+func (fake *FakeContainer) NetInMappingReturns(result1 garden.PortMapping, result2 error) {
+	fake.NetInMappingStub = nil
+	fake.netInMappingReturns = struct {
+		result1 garden.PortMapping
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) NetOut(netOutRule garden.NetOutRule) error {
 	fake.netOutMutex.Lock()
 	fake.netOutArgsForCall = append(fake.netOutArgsForCall, struct {
@@ -821,6 +1085,70 @@ func (fake *FakeContainer) NetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) NetInRemove(mapping garden.PortMapping) error {
+	fake.netInRemoveMutex.Lock()
+	fake.netInRemoveArgsForCall = append(fake.netInRemoveArgsForCall, struct {
+		mapping garden.PortMapping
+	}{mapping})
+	fake.netInRemoveMutex.Unlock()
+	if fake.NetInRemoveStub != nil {
+		return fake.NetInRemoveStub(mapping)
+	} else {
+		return fake.netInRemoveReturns.result1
+	}
+}
+
+func (fake *FakeContainer) NetInRemoveCallCount() int {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return len(fake.netInRemoveArgsForCall)
+}
+
+func (fake *FakeContainer) NetInRemoveArgsForCall(i int) garden.PortMapping {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return fake.netInRemoveArgsForCall[i].mapping
+}
+
+func (fake *FakeContainer) NetInRemoveReturns(result1 error) {
+	fake.NetInRemoveStub = nil
+	fake.netInRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) NetOutRemove(netOutRule garden.NetOutRule) error {
+	fake.netOutRemoveMutex.Lock()
+	fake.netOutRemoveArgsForCall = append(fake.netOutRemoveArgsForCall, struct {
+		netOutRule garden.NetOutRule
+	}{netOutRule})
+	fake.netOutRemoveMutex.Unlock()
+	if fake.NetOutRemoveStub != nil {
+		return fake.NetOutRemoveStub(netOutRule)
+	} else {
+		return fake.netOutRemoveReturns.result1
+	}
+}
+
+func (fake *FakeContainer) NetOutRemoveCallCount() int {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return len(fake.netOutRemoveArgsForCall)
+}
+
+func (fake *FakeContainer) NetOutRemoveArgsForCall(i int) garden.NetOutRule {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return fake.netOutRemoveArgsForCall[i].netOutRule
+}
+
+func (fake *FakeContainer) NetOutRemoveReturns(result1 error) {
+	fake.NetOutRemoveStub = nil
+	fake.netOutRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeContainer) Run(arg1 garden.ProcessSpec, arg2 garden.ProcessIO) (garden.Process, error) {
 	fake.runMutex.Lock()
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
@@ -914,6 +1242,31 @@ func (fake *FakeContainer) MetricsReturns(result1 garden.Metrics, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeContainer) NetworkState() (garden.NetworkState, error) {
+	fake.networkStateMutex.Lock()
+	fake.networkStateArgsForCall = append(fake.networkStateArgsForCall, struct{}{})
+	fake.networkStateMutex.Unlock()
+	if fake.NetworkStateStub != nil {
+		return fake.NetworkStateStub()
+	} else {
+		return fake.networkStateReturns.result1, fake.networkStateReturns.result2
+	}
+}
+
+func (fake *FakeContainer) NetworkStateCallCount() int {
+	fake.networkStateMutex.RLock()
+	defer fake.networkStateMutex.RUnlock()
+	return len(fake.networkStateArgsForCall)
+}
+
+func (fake *FakeContainer) NetworkStateReturns(result1 garden.NetworkState, result2 error) {
+	fake.NetworkStateStub = nil
+	fake.networkStateReturns = struct {
+		result1 garden.NetworkState
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainer) Properties() (garden.Properties, error) {
 	fake.propertiesMutex.Lock()
 	fake.propertiesArgsForCall = append(fake.propertiesArgsForCall, struct{}{})
@@ -1037,4 +1390,99 @@ func (fake *FakeContainer) RemovePropertyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeContainer) OnChange(arg1 func()) {
+	fake.onChangeMutex.Lock()
+	fake.onChangeArgsForCall = append(fake.onChangeArgsForCall, struct {
+		arg1 func()
+	}{arg1})
+	fake.onChangeMutex.Unlock()
+	if fake.OnChangeStub != nil {
+		fake.OnChangeStub(arg1)
+	}
+}
+
+func (fake *FakeContainer) OnChangeCallCount() int {
+	fake.onChangeMutex.RLock()
+	defer fake.onChangeMutex.RUnlock()
+	return len(fake.onChangeArgsForCall)
+}
+
+func (fake *FakeContainer) OnChangeArgsForCall(i int) func() {
+	fake.onChangeMutex.RLock()
+	defer fake.onChangeMutex.RUnlock()
+	return fake.onChangeArgsForCall[i].arg1
+}
+
+func (fake *FakeContainer) Checkpoint() error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct{}{})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub()
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeContainer) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeContainer) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Pause() error {
+	fake.pauseMutex.Lock()
+	fake.pauseArgsForCall = append(fake.pauseArgsForCall, struct{}{})
+	fake.pauseMutex.Unlock()
+	if fake.PauseStub != nil {
+		return fake.PauseStub()
+	} else {
+		return fake.pauseReturns.result1
+	}
+}
+
+func (fake *FakeContainer) PauseCallCount() int {
+	fake.pauseMutex.RLock()
+	defer fake.pauseMutex.RUnlock()
+	return len(fake.pauseArgsForCall)
+}
+
+func (fake *FakeContainer) PauseReturns(result1 error) {
+	fake.PauseStub = nil
+	fake.pauseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainer) Resume() error {
+	fake.resumeMutex.Lock()
+	fake.resumeArgsForCall = append(fake.resumeArgsForCall, struct{}{})
+	fake.resumeMutex.Unlock()
+	if fake.ResumeStub != nil {
+		return fake.ResumeStub()
+	} else {
+		return fake.resumeReturns.result1
+	}
+}
+
+func (fake *FakeContainer) ResumeCallCount() int {
+	fake.resumeMutex.RLock()
+	defer fake.resumeMutex.RUnlock()
+	return len(fake.resumeArgsForCall)
+}
+
+func (fake *FakeContainer) ResumeReturns(result1 error) {
+	fake.ResumeStub = nil
+	fake.resumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ linux_backend.Container = new(FakeContainer)
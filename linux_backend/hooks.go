@@ -91,6 +91,11 @@ func configureHostNetwork(config process.Env, configurer network.Configurer) err
 		return err
 	}
 
+	ipv6Net, err := ipv6Network(config)
+	if err != nil {
+		return err
+	}
+
 	// Temporary until PID is passed in from Go rewrite of wshd.
 	containerPid, _ := pidFromFile("../run/wshd.pid")
 	if err != nil {
@@ -105,6 +110,8 @@ func configureHostNetwork(config process.Env, configurer network.Configurer) err
 		ContainerPid:  containerPid,
 		Subnet:        ipNet,
 		Mtu:           int(mtu),
+		BridgeIPv6:    net.ParseIP(config["network_host_ipv6"]),
+		SubnetIPv6:    ipv6Net,
 	})
 	if err != nil {
 		return err
@@ -125,6 +132,11 @@ func configureContainerNetwork(config process.Env, configurer network.Configurer
 		return err
 	}
 
+	ipv6Net, err := ipv6Network(config)
+	if err != nil {
+		return err
+	}
+
 	err = configurer.ConfigureContainer(&network.ContainerConfig{
 		Hostname:      config["container_hostname"],
 		ContainerIntf: config["network_container_iface"],
@@ -132,6 +144,9 @@ func configureContainerNetwork(config process.Env, configurer network.Configurer
 		GatewayIP:     net.ParseIP(config["network_host_ip"]),
 		Subnet:        ipNet,
 		Mtu:           int(mtu),
+		ContainerIPv6: net.ParseIP(config["network_container_ipv6"]),
+		GatewayIPv6:   net.ParseIP(config["network_host_ipv6"]),
+		SubnetIPv6:    ipv6Net,
 	})
 	if err != nil {
 		return err
@@ -140,6 +155,17 @@ func configureContainerNetwork(config process.Env, configurer network.Configurer
 	return nil
 }
 
+// ipv6Network parses the container's IPv6 subnet, which is only configured
+// when the server has an IPv6 network pool.
+func ipv6Network(config process.Env) (*net.IPNet, error) {
+	if config["network_cidr_ipv6"] == "" {
+		return nil, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(config["network_cidr_ipv6"])
+	return ipNet, err
+}
+
 func must(err error) {
 	if err != nil {
 		panic(err)
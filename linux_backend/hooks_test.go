@@ -126,6 +126,41 @@ var _ = Describe("Hooks", func() {
 					Expect(hostConfig.Mtu).To(Equal(5000))
 				})
 
+				It("leaves IPv6 unconfigured when the container has no IPv6 network", func() {
+					Expect(func() { hooks.Main(hook.PARENT_AFTER_CLONE) }).ToNot(Panic())
+
+					hostConfig := fakeNetworkConfigurer.ConfigureHostArgsForCall(0)
+					Expect(hostConfig.BridgeIPv6).To(BeNil())
+					Expect(hostConfig.SubnetIPv6).To(BeNil())
+				})
+
+				Context("when the container has an IPv6 network", func() {
+					BeforeEach(func() {
+						config["network_cidr_ipv6"] = "fd00::/126"
+						config["network_host_ipv6"] = "fd00::2"
+						config["network_container_ipv6"] = "fd00::1"
+					})
+
+					It("configures the bridge's IPv6 address", func() {
+						Expect(func() { hooks.Main(hook.PARENT_AFTER_CLONE) }).ToNot(Panic())
+
+						hostConfig := fakeNetworkConfigurer.ConfigureHostArgsForCall(0)
+						Expect(hostConfig.BridgeIPv6).To(Equal(net.ParseIP("fd00::2")))
+						_, expectedSubnet, _ := net.ParseCIDR("fd00::/126")
+						Expect(hostConfig.SubnetIPv6).To(Equal(expectedSubnet))
+					})
+
+					Context("and its CIDR is badly formatted", func() {
+						BeforeEach(func() {
+							config["network_cidr_ipv6"] = "fd00::/126/9"
+						})
+
+						It("panics", func() {
+							Expect(func() { hooks.Main(hook.PARENT_AFTER_CLONE) }).To(Panic())
+						})
+					})
+				})
+
 				Context("when the network configurer fails", func() {
 					BeforeEach(func() {
 						fakeNetworkConfigurer.ConfigureHostReturns(errors.New("oh no!"))
@@ -230,6 +265,24 @@ var _ = Describe("Hooks", func() {
 					Expect(networkConfig.Mtu).To(Equal(5000))
 				})
 
+				Context("when the container has an IPv6 network", func() {
+					BeforeEach(func() {
+						config["network_cidr_ipv6"] = "fd00::/126"
+						config["network_host_ipv6"] = "fd00::2"
+						config["network_container_ipv6"] = "fd00::1"
+					})
+
+					It("configures the container's IPv6 address and gateway", func() {
+						Expect(func() { hooks.Main(hook.CHILD_AFTER_PIVOT) }).ToNot(Panic())
+
+						networkConfig := fakeNetworkConfigurer.ConfigureContainerArgsForCall(0)
+						Expect(networkConfig.ContainerIPv6).To(Equal(net.ParseIP("fd00::1")))
+						Expect(networkConfig.GatewayIPv6).To(Equal(net.ParseIP("fd00::2")))
+						_, expectedSubnet, _ := net.ParseCIDR("fd00::/126")
+						Expect(networkConfig.SubnetIPv6).To(Equal(expectedSubnet))
+					})
+				})
+
 				Context("when the network configurer returns an error", func() {
 					BeforeEach(func() {
 						fakeNetworkConfigurer.ConfigureContainerReturns(errors.New("oh no!"))
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -24,6 +26,14 @@ type Container interface {
 	Snapshot(io.Writer) error
 	Cleanup()
 
+	// Checkpoint dumps the container's process tree into its depot and stops
+	// it, so that restoring its snapshot resumes it.
+	Checkpoint() error
+
+	// OnChange registers a callback to run after each change to the
+	// container's state.
+	OnChange(func())
+
 	garden.Container
 }
 
@@ -35,6 +45,11 @@ type ContainerPool interface {
 	Prune(keep map[string]bool) error
 	MaxContainers() int
 	CommitContainerAndSaveImage(id, dest string) error
+	CommitContainerAndStreamImage(id string) (io.ReadCloser, error)
+	CommitContainerAsImage(id, repoName, tag string) (string, error)
+	PullImage(rootfs string) (string, error)
+	ListImages() ([]garden.ImageInfo, error)
+	CreationProgress(handle string) ([]string, error)
 }
 
 type ContainerRepository interface {
@@ -51,6 +66,7 @@ type LinuxBackend struct {
 	containerPool ContainerPool
 	systemInfo    system_info.Provider
 	snapshotsPath string
+	snapshotMutex *sync.Mutex
 
 	containerRepo ContainerRepository
 }
@@ -84,6 +100,7 @@ func New(
 		containerPool: containerPool,
 		systemInfo:    systemInfo,
 		snapshotsPath: snapshotsPath,
+		snapshotMutex: new(sync.Mutex),
 
 		containerRepo: containerRepo,
 	}
@@ -95,9 +112,11 @@ func (b *LinuxBackend) Setup() error {
 
 func (b *LinuxBackend) Start() error {
 	if b.snapshotsPath != "" {
+		var restored []Container
+
 		_, err := os.Stat(b.snapshotsPath)
 		if err == nil {
-			b.restoreSnapshots()
+			restored = b.restoreSnapshots()
 			os.RemoveAll(b.snapshotsPath)
 		}
 
@@ -105,6 +124,12 @@ func (b *LinuxBackend) Start() error {
 		if err != nil {
 			return err
 		}
+
+		// the restored containers are snapshotted afresh, so that they
+		// survive another unclean exit
+		for _, container := range restored {
+			b.watchForChanges(container)
+		}
 	}
 
 	keep := map[string]bool{}
@@ -156,8 +181,19 @@ func (b *LinuxBackend) Create(spec garden.ContainerSpec) (garden.Container, erro
 		return nil, err
 	}
 
+	// the container's cgroups exist only once it has started
+	if spec.Limits.Pid.Max != 0 {
+		err = container.LimitPids(spec.Limits.Pid)
+		if err != nil {
+			b.containerPool.Destroy(container)
+			return nil, err
+		}
+	}
+
 	b.containerRepo.Add(container)
 
+	b.watchForChanges(container)
+
 	return container, nil
 }
 
@@ -174,6 +210,8 @@ func (b *LinuxBackend) Destroy(handle string) error {
 
 	b.containerRepo.Delete(container)
 
+	b.removeSnapshot(container)
+
 	return nil
 }
 
@@ -210,6 +248,49 @@ func (b *LinuxBackend) CommitAndSave(handle, dest string) error {
 	return nil
 }
 
+func (b *LinuxBackend) CommitAndStream(handle string) (io.ReadCloser, error) {
+	container, err := b.containerRepo.FindByHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.containerPool.CommitContainerAndStreamImage(container.ID())
+}
+
+func (b *LinuxBackend) CommitImage(handle, repoName, tag string) (string, error) {
+	container, err := b.containerRepo.FindByHandle(handle)
+	if err != nil {
+		return "", err
+	}
+
+	if tag == "" {
+		tag = "latest"
+	}
+
+	return b.containerPool.CommitContainerAsImage(container.ID(), repoName, tag)
+}
+
+func (b *LinuxBackend) PullImage(rootfs string) (string, error) {
+	return b.containerPool.PullImage(rootfs)
+}
+
+func (b *LinuxBackend) ListImages() ([]garden.ImageInfo, error) {
+	return b.containerPool.ListImages()
+}
+
+func (b *LinuxBackend) CreationProgress(handle string) ([]string, error) {
+	return b.containerPool.CreationProgress(handle)
+}
+
+func (b *LinuxBackend) Checkpoint(handle string) error {
+	container, err := b.containerRepo.FindByHandle(handle)
+	if err != nil {
+		return err
+	}
+
+	return container.Checkpoint()
+}
+
 func (b *LinuxBackend) Containers(props garden.Properties) ([]garden.Container, error) {
 	return toGardenContainers(b.containerRepo.Query(withProperties(props))), nil
 }
@@ -274,7 +355,7 @@ func (b *LinuxBackend) Stop() {
 	}
 }
 
-func (b *LinuxBackend) restoreSnapshots() {
+func (b *LinuxBackend) restoreSnapshots() []Container {
 	sLog := b.logger.Session("restore")
 
 	entries, err := ioutil.ReadDir(b.snapshotsPath)
@@ -284,7 +365,13 @@ func (b *LinuxBackend) restoreSnapshots() {
 		})
 	}
 
+	var restored []Container
 	for _, entry := range entries {
+		// skip snapshots that were still being written
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
 		snapshot := path.Join(b.snapshotsPath, entry.Name())
 
 		lLog := sLog.Session("load", lager.Data{
@@ -296,13 +383,20 @@ func (b *LinuxBackend) restoreSnapshots() {
 		file, err := os.Open(snapshot)
 		if err != nil {
 			lLog.Error("failed-to-open", err)
+			continue
 		}
 
-		_, err = b.restore(file)
+		container, err := b.restore(file)
+		file.Close()
 		if err != nil {
 			lLog.Error("failed-to-restore", err)
+			continue
 		}
+
+		restored = append(restored, container)
 	}
+
+	return restored
 }
 
 func (b *LinuxBackend) saveSnapshot(container Container) error {
@@ -310,26 +404,86 @@ func (b *LinuxBackend) saveSnapshot(container Container) error {
 		return nil
 	}
 
-	b.logger.Info("save-snapshot", lager.Data{
+	b.snapshotMutex.Lock()
+	defer b.snapshotMutex.Unlock()
+
+	// a container destroyed while its snapshot was waiting must not be
+	// brought back
+	if current, err := b.containerRepo.FindByHandle(container.Handle()); err != nil || current.ID() != container.ID() {
+		return nil
+	}
+
+	b.logger.Debug("save-snapshot", lager.Data{
 		"container": container.ID(),
 	})
 
-	snapshotPath := path.Join(b.snapshotsPath, container.ID())
-
-	snapshot, err := os.Create(snapshotPath)
+	// write the snapshot beside its final path and rename it into place, so
+	// that a crash never leaves a truncated snapshot behind
+	snapshot, err := ioutil.TempFile(b.snapshotsPath, "."+container.ID())
 	if err != nil {
 		return &FailedToSnapshotError{err}
 	}
 
 	err = container.Snapshot(snapshot)
+	if err == nil {
+		err = snapshot.Sync()
+	}
+
+	if closeErr := snapshot.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(snapshot.Name(), path.Join(b.snapshotsPath, container.ID()))
+	}
+
 	if err != nil {
+		os.Remove(snapshot.Name())
 		return &FailedToSnapshotError{err}
 	}
 
-	return snapshot.Close()
+	return nil
+}
+
+// watchForChanges snapshots the container now and whenever its state
+// changes, so that it can be restored after an unclean exit.
+func (b *LinuxBackend) watchForChanges(container Container) {
+	container.OnChange(func() {
+		b.trySaveSnapshot(container)
+	})
+
+	b.trySaveSnapshot(container)
+}
+
+// trySaveSnapshot saves a snapshot of the container after its state has
+// changed. Failing to do so does not fail the change; the container is
+// snapshotted again on its next change, or when the backend stops.
+func (b *LinuxBackend) trySaveSnapshot(container Container) {
+	err := b.saveSnapshot(container)
+	if err != nil {
+		b.logger.Error("failed-to-save-snapshot", err, lager.Data{
+			"container": container.ID(),
+		})
+	}
+}
+
+func (b *LinuxBackend) removeSnapshot(container Container) {
+	if b.snapshotsPath == "" {
+		return
+	}
+
+	b.snapshotMutex.Lock()
+	defer b.snapshotMutex.Unlock()
+
+	err := os.Remove(path.Join(b.snapshotsPath, container.ID()))
+	if err != nil && !os.IsNotExist(err) {
+		b.logger.Error("failed-to-remove-snapshot", err, lager.Data{
+			"container": container.ID(),
+		})
+	}
 }
 
-func (b *LinuxBackend) restore(snapshot io.Reader) (garden.Container, error) {
+func (b *LinuxBackend) restore(snapshot io.Reader) (Container, error) {
 	container, err := b.containerPool.Restore(snapshot)
 	if err != nil {
 		return nil, err
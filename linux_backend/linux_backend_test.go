@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -144,6 +145,31 @@ var _ = Describe("LinuxBackend", func() {
 				Expect(err).To(HaveOccurred())
 			})
 
+			It("snapshots the restored containers afresh", func() {
+				err := linuxBackend.Start()
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = os.Stat(path.Join(snapshotsPath, "handle-a"))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = os.Stat(path.Join(snapshotsPath, "handle-b"))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			Context("when a snapshot was still being written", func() {
+				BeforeEach(func() {
+					err := ioutil.WriteFile(path.Join(snapshotsPath, ".some-id123"), []byte("handle-c"), 0644)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("does not restore it", func() {
+					err := linuxBackend.Start()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeContainerPool.RestoredSnapshots).To(HaveLen(2))
+				})
+			})
+
 			It("registers the containers", func() {
 				err := linuxBackend.Start()
 				Expect(err).ToNot(HaveOccurred())
@@ -303,6 +329,90 @@ var _ = Describe("LinuxBackend", func() {
 		})
 	})
 
+	Describe("snapshotting containers as they change", func() {
+		var tmpdir string
+
+		BeforeEach(func() {
+			var err error
+			tmpdir, err = ioutil.TempDir(os.TempDir(), "garden-server-test")
+			Expect(err).ToNot(HaveOccurred())
+
+			snapshotsPath = path.Join(tmpdir, "snapshots")
+		})
+
+		JustBeforeEach(func() {
+			err := linuxBackend.Start()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpdir)
+		})
+
+		snapshots := func() []string {
+			entries, err := ioutil.ReadDir(snapshotsPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+
+			return names
+		}
+
+		It("snapshots a container once it is created", func() {
+			container, err := linuxBackend.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(snapshots()).To(Equal([]string{container.Handle()}))
+			Expect(fakeContainerPool.CreatedContainers[0].(*fake_container_pool.FakeContainer).SavedSnapshots).To(HaveLen(1))
+		})
+
+		It("snapshots a container whenever its state changes", func() {
+			_, err := linuxBackend.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).ToNot(HaveOccurred())
+
+			created := fakeContainerPool.CreatedContainers[0].(*fake_container_pool.FakeContainer)
+			Expect(created.OnChangeCallback).ToNot(BeNil())
+
+			created.OnChangeCallback()
+			created.OnChangeCallback()
+
+			Expect(created.SavedSnapshots).To(HaveLen(3))
+			Expect(snapshots()).To(Equal([]string{"some-handle"}))
+		})
+
+		It("removes the snapshot when the container is destroyed", func() {
+			_, err := linuxBackend.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).ToNot(HaveOccurred())
+
+			container, err := linuxBackend.Lookup("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(linuxBackend.Destroy("some-handle")).To(Succeed())
+			Expect(snapshots()).To(BeEmpty())
+
+			container.(*fake_container_pool.FakeContainer).OnChangeCallback()
+			Expect(snapshots()).To(BeEmpty())
+		})
+
+		Context("when snapshotting fails", func() {
+			BeforeEach(func() {
+				fakeContainerPool.ContainerSetup = func(container *fake_container_pool.FakeContainer) {
+					container.SnapshotError = errors.New("oh no!")
+				}
+			})
+
+			It("still creates the container, leaving no partial snapshot behind", func() {
+				_, err := linuxBackend.Create(garden.ContainerSpec{Handle: "some-handle"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(snapshots()).To(BeEmpty())
+			})
+		})
+	})
+
 	Describe("Create", func() {
 		It("creates a container from the pool", func() {
 			Expect(fakeContainerPool.CreatedContainers).To(BeEmpty())
@@ -333,6 +443,48 @@ var _ = Describe("LinuxBackend", func() {
 			})
 		})
 
+		It("limits the container's processes as the spec asks", func() {
+			container, err := linuxBackend.Create(garden.ContainerSpec{
+				Limits: garden.Limits{
+					Pid: garden.PidLimits{Max: 1024},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeContainer := container.(*fake_container_pool.FakeContainer)
+			Expect(fakeContainer.LimitPidsCallCount()).To(Equal(1))
+			Expect(fakeContainer.LimitPidsArgsForCall(0)).To(Equal(garden.PidLimits{Max: 1024}))
+		})
+
+		Context("when the spec has no pid limit", func() {
+			It("leaves the container's processes unlimited", func() {
+				container, err := linuxBackend.Create(garden.ContainerSpec{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.(*fake_container_pool.FakeContainer).LimitPidsCallCount()).To(BeZero())
+			})
+		})
+
+		Context("when limiting the container's processes fails", func() {
+			It("destroys the container and returns the error", func() {
+				disaster := errors.New("no pids cgroup")
+
+				var setupContainer *fake_container_pool.FakeContainer
+				fakeContainerPool.ContainerSetup = func(c *fake_container_pool.FakeContainer) {
+					c.LimitPidsReturns(disaster)
+					setupContainer = c
+				}
+
+				_, err := linuxBackend.Create(garden.ContainerSpec{
+					Limits: garden.Limits{
+						Pid: garden.PidLimits{Max: 1024},
+					},
+				})
+				Expect(err).To(Equal(disaster))
+				Expect(fakeContainerPool.DestroyedContainers).To(ContainElement(setupContainer))
+			})
+		})
+
 		It("registers the container", func() {
 			container, err := linuxBackend.Create(garden.ContainerSpec{})
 			Expect(err).ToNot(HaveOccurred())
@@ -652,6 +804,140 @@ var _ = Describe("LinuxBackend", func() {
 		})
 	})
 
+	Describe("CommitAndStream", func() {
+		var container *fake_container_pool.FakeContainer
+
+		JustBeforeEach(func() {
+			container = fake_container_pool.NewFakeContainer(garden.ContainerSpec{Handle: "some-handle"})
+			containerRepo.Add(container)
+		})
+
+		It("streams the committed image of the container from the pool", func() {
+			fakeContainerPool.CommitStream = ioutil.NopCloser(strings.NewReader("some-image-tar"))
+
+			stream, err := linuxBackend.CommitAndStream("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeContainerPool.CommittedIDs).To(Equal([]string{container.ID()}))
+
+			contents, err := ioutil.ReadAll(stream)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("some-image-tar"))
+		})
+
+		Context("when the container does not exist", func() {
+			It("returns ContainerNotFoundError", func() {
+				_, err := linuxBackend.CommitAndStream("bogus-handle")
+				Expect(err).To(Equal(garden.ContainerNotFoundError{"bogus-handle"}))
+			})
+		})
+
+		Context("when committing the container fails", func() {
+			disaster := errors.New("failed to commit")
+
+			BeforeEach(func() {
+				fakeContainerPool.CommitError = disaster
+			})
+
+			It("returns the error", func() {
+				_, err := linuxBackend.CommitAndStream("some-handle")
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("PullImage", func() {
+		It("pulls the image with the pool", func() {
+			fakeContainerPool.PullImageID = "some-image-id"
+
+			imageID, err := linuxBackend.PullImage("docker:///some-repo#some-tag")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageID).To(Equal("some-image-id"))
+
+			Expect(fakeContainerPool.PulledImages).To(Equal([]string{"docker:///some-repo#some-tag"}))
+		})
+
+		Context("when pulling fails", func() {
+			disaster := errors.New("failed to pull")
+
+			BeforeEach(func() {
+				fakeContainerPool.PullError = disaster
+			})
+
+			It("returns the error", func() {
+				_, err := linuxBackend.PullImage("docker:///some-repo")
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("ListImages", func() {
+		It("returns the images cached by the pool", func() {
+			fakeContainerPool.Images = []garden.ImageInfo{{ID: "some-image", Size: 10}}
+
+			images, err := linuxBackend.ListImages()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(images).To(Equal([]garden.ImageInfo{{ID: "some-image", Size: 10}}))
+		})
+	})
+
+	Describe("CreationProgress", func() {
+		It("returns the progress of the create from the pool", func() {
+			fakeContainerPool.CreationProgressEvents = map[string][]string{
+				"some-handle": {"fetching layer some-layer"},
+			}
+
+			events, err := linuxBackend.CreationProgress("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(events).To(Equal([]string{"fetching layer some-layer"}))
+		})
+
+		Context("when no container is being created with the handle", func() {
+			It("returns the pool's error", func() {
+				fakeContainerPool.CreationProgressError = garden.ContainerNotFoundError{"bogus-handle"}
+
+				_, err := linuxBackend.CreationProgress("bogus-handle")
+				Expect(err).To(Equal(garden.ContainerNotFoundError{"bogus-handle"}))
+			})
+		})
+	})
+
+	Describe("Checkpoint", func() {
+		var container *fake_container_pool.FakeContainer
+
+		BeforeEach(func() {
+			container = fake_container_pool.NewFakeContainer(garden.ContainerSpec{Handle: "some-handle"})
+			containerRepo.Add(container)
+		})
+
+		It("checkpoints the container", func() {
+			err := linuxBackend.Checkpoint("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(container.Checkpointed).To(BeTrue())
+		})
+
+		Context("when the container does not exist", func() {
+			It("returns ContainerNotFoundError", func() {
+				err := linuxBackend.Checkpoint("bogus-handle")
+				Expect(err).To(Equal(garden.ContainerNotFoundError{"bogus-handle"}))
+			})
+		})
+
+		Context("when checkpointing the container fails", func() {
+			disaster := errors.New("failed to checkpoint")
+
+			BeforeEach(func() {
+				container.CheckpointError = disaster
+			})
+
+			It("returns the error", func() {
+				err := linuxBackend.Checkpoint("some-handle")
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Containers", func() {
 		It("returns a list of all existing containers", func() {
 			container1, err := linuxBackend.Create(garden.ContainerSpec{})
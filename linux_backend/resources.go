@@ -42,6 +42,9 @@ type Resources struct {
 	Ports      []uint32
 	ExternalIP net.IP
 
+	// IPv6Network is nil unless the server has an IPv6 network pool.
+	IPv6Network *Network
+
 	portsLock *sync.Mutex
 }
 
@@ -71,3 +74,18 @@ func (r *Resources) AddPort(port uint32) {
 
 	r.Ports = append(r.Ports, port)
 }
+
+// RemovePort forgets a port added with AddPort, reporting whether it was.
+func (r *Resources) RemovePort(port uint32) bool {
+	r.portsLock.Lock()
+	defer r.portsLock.Unlock()
+
+	for i, p := range r.Ports {
+		if p == port {
+			r.Ports = append(r.Ports[:i], r.Ports[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package linux_container
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// freezer.state reads FREEZING until every task in the cgroup has stopped,
+// and a task that cannot be stopped yet needs the write to be repeated.
+const (
+	freezeAttempts = 100
+	freezeInterval = 10 * time.Millisecond
+)
+
+var ErrFreezeTimedOut = errors.New("container: timed out freezing processes")
+
+type InvalidStateError struct {
+	Operation string
+	State     State
+}
+
+func (err InvalidStateError) Error() string {
+	return fmt.Sprintf("cannot %s a container that is %s", err.Operation, err.State)
+}
+
+func (c *LinuxContainer) Pause() error {
+	cLog := c.logger.Session("pause")
+
+	if state := c.State(); state != StateActive {
+		return InvalidStateError{"pause", state}
+	}
+
+	cLog.Debug("freezing")
+
+	err := c.freeze()
+	if err != nil {
+		cLog.Error("failed-to-freeze", err)
+		return err
+	}
+
+	c.setState(StatePaused)
+
+	cLog.Info("paused")
+
+	c.changed()
+
+	return nil
+}
+
+func (c *LinuxContainer) Resume() error {
+	cLog := c.logger.Session("resume")
+
+	if state := c.State(); state != StatePaused {
+		return InvalidStateError{"resume", state}
+	}
+
+	cLog.Debug("thawing")
+
+	err := c.thaw()
+	if err != nil {
+		cLog.Error("failed-to-thaw", err)
+		return err
+	}
+
+	c.setState(StateActive)
+
+	cLog.Info("resumed")
+
+	c.changed()
+
+	return nil
+}
+
+func (c *LinuxContainer) freeze() error {
+	for i := 0; i < freezeAttempts; i++ {
+		err := c.cgroupsManager.Set("freezer", "freezer.state", "FROZEN")
+		if err != nil {
+			return err
+		}
+
+		state, err := c.cgroupsManager.Get("freezer", "freezer.state")
+		if err != nil {
+			return err
+		}
+
+		if state == "FROZEN" {
+			return nil
+		}
+
+		time.Sleep(freezeInterval)
+	}
+
+	// don't leave the container half frozen
+	c.thaw()
+
+	return ErrFreezeTimedOut
+}
+
+func (c *LinuxContainer) thaw() error {
+	return c.cgroupsManager.Set("freezer", "freezer.state", "THAWED")
+}
@@ -0,0 +1,243 @@
+package linux_container_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/linux_backend"
+	"github.com/cloudfoundry-incubator/garden-linux/linux_container"
+	networkFakes "github.com/cloudfoundry-incubator/garden-linux/network/fakes"
+	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager/fake_cgroups_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool/fake_port_pool"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/process"
+	"github.com/cloudfoundry-incubator/garden-linux/process_tracker/fake_process_tracker"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+)
+
+var _ = Describe("Freezing containers", func() {
+	var fakeCgroups *fake_cgroups_manager.FakeCgroupsManager
+	var fakeQuotaManager *fake_quota_manager.FakeQuotaManager
+	var fakeBandwidthManager *fake_bandwidth_manager.FakeBandwidthManager
+	var fakeRunner *fake_command_runner.FakeCommandRunner
+	var containerResources *linux_backend.Resources
+	var container *linux_container.LinuxContainer
+	var containerDir string
+
+	BeforeEach(func() {
+		fakeRunner = fake_command_runner.New()
+
+		fakeCgroups = fake_cgroups_manager.New("/cgroups", "some-id")
+
+		fakeQuotaManager = fake_quota_manager.New()
+		fakeBandwidthManager = fake_bandwidth_manager.New()
+
+		var err error
+		containerDir, err = ioutil.TempDir("", "depot")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, subnet, _ := net.ParseCIDR("2.3.4.0/30")
+		containerResources = linux_backend.NewResources(
+			1234,
+			1235,
+			&linux_backend.Network{
+				IP:     net.ParseIP("1.2.3.4"),
+				Subnet: subnet,
+			},
+			"some-bridge",
+			[]uint32{},
+			nil,
+		)
+	})
+
+	JustBeforeEach(func() {
+		container = linux_container.NewLinuxContainer(
+			lagertest.NewTestLogger("test"),
+			"some-id",
+			"some-handle",
+			containerDir,
+			nil,
+			1*time.Second,
+			containerResources,
+			fake_port_pool.New(1000),
+			fakeRunner,
+			fakeCgroups,
+			fakeQuotaManager,
+			fakeBandwidthManager,
+			new(fake_process_tracker.FakeProcessTracker),
+			process.Env{"env1": "env1Value", "env2": "env2Value"},
+			new(networkFakes.FakeFilter),
+		)
+	})
+
+	Describe("Pausing", func() {
+		JustBeforeEach(func() {
+			Expect(container.Start()).To(Succeed())
+		})
+
+		It("freezes the container's cgroup", func() {
+			err := container.Pause()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(
+				fake_cgroups_manager.SetValue{
+					Subsystem: "freezer",
+					Name:      "freezer.state",
+					Value:     "FROZEN",
+				},
+			))
+		})
+
+		It("sets the container's state to paused", func() {
+			err := container.Pause()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(container.State()).To(Equal(linux_container.StatePaused))
+		})
+
+		It("reports the change", func() {
+			changed := false
+			container.OnChange(func() {
+				changed = true
+			})
+
+			Expect(container.Pause()).To(Succeed())
+			Expect(changed).To(BeTrue())
+		})
+
+		It("does not allow processes to be run", func() {
+			Expect(container.Pause()).To(Succeed())
+
+			_, err := container.Run(garden.ProcessSpec{Path: "/some/script"}, garden.ProcessIO{})
+			Expect(err).To(Equal(linux_container.InvalidStateError{"run processes in", linux_container.StatePaused}))
+		})
+
+		Context("when the container is already paused", func() {
+			It("returns an error", func() {
+				Expect(container.Pause()).To(Succeed())
+
+				err := container.Pause()
+				Expect(err).To(Equal(linux_container.InvalidStateError{"pause", linux_container.StatePaused}))
+			})
+		})
+
+		Context("when the container is stopped", func() {
+			It("returns an error", func() {
+				Expect(container.Stop(false)).To(Succeed())
+
+				err := container.Pause()
+				Expect(err).To(Equal(linux_container.InvalidStateError{"pause", linux_container.StateStopped}))
+			})
+		})
+
+		Context("when freezing the cgroup fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeCgroups.WhenSetting("freezer", "freezer.state", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.Pause()
+				Expect(err).To(Equal(disaster))
+			})
+
+			It("does not change the container's state", func() {
+				container.Pause()
+				Expect(container.State()).To(Equal(linux_container.StateActive))
+			})
+		})
+
+		Context("when the cgroup never finishes freezing", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("freezer", "freezer.state", func() (string, error) {
+					return "FREEZING", nil
+				})
+			})
+
+			It("thaws the cgroup and returns an error", func() {
+				err := container.Pause()
+				Expect(err).To(Equal(linux_container.ErrFreezeTimedOut))
+
+				values := fakeCgroups.SetValues()
+				Expect(values[len(values)-1]).To(Equal(fake_cgroups_manager.SetValue{
+					Subsystem: "freezer",
+					Name:      "freezer.state",
+					Value:     "THAWED",
+				}))
+
+				Expect(container.State()).To(Equal(linux_container.StateActive))
+			})
+		})
+	})
+
+	Describe("Resuming", func() {
+		JustBeforeEach(func() {
+			Expect(container.Start()).To(Succeed())
+		})
+
+		Context("when the container is paused", func() {
+			JustBeforeEach(func() {
+				Expect(container.Pause()).To(Succeed())
+			})
+
+			It("thaws the container's cgroup", func() {
+				err := container.Resume()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(ContainElement(
+					fake_cgroups_manager.SetValue{
+						Subsystem: "freezer",
+						Name:      "freezer.state",
+						Value:     "THAWED",
+					},
+				))
+			})
+
+			It("sets the container's state to active", func() {
+				err := container.Resume()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.State()).To(Equal(linux_container.StateActive))
+			})
+		})
+
+		Context("when the container is not paused", func() {
+			It("returns an error", func() {
+				err := container.Resume()
+				Expect(err).To(Equal(linux_container.InvalidStateError{"resume", linux_container.StateActive}))
+			})
+		})
+	})
+
+	Describe("Stopping a paused container", func() {
+		JustBeforeEach(func() {
+			Expect(container.Start()).To(Succeed())
+			Expect(container.Pause()).To(Succeed())
+		})
+
+		It("thaws it so that its processes can be signalled", func() {
+			err := container.Stop(false)
+			Expect(err).ToNot(HaveOccurred())
+
+			values := fakeCgroups.SetValues()
+			Expect(values[len(values)-1]).To(Equal(fake_cgroups_manager.SetValue{
+				Subsystem: "freezer",
+				Name:      "freezer.state",
+				Value:     "THAWED",
+			}))
+
+			Expect(container.State()).To(Equal(linux_container.StateStopped))
+		})
+	})
+})
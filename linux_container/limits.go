@@ -2,11 +2,15 @@ package linux_container
 
 import (
 	"fmt"
-	"os/exec"
-	"path"
+	"math"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+	"github.com/pivotal-golang/lager"
 )
 
 func (c *LinuxContainer) LimitBandwidth(limits garden.BandwidthLimits) error {
@@ -18,9 +22,10 @@ func (c *LinuxContainer) LimitBandwidth(limits garden.BandwidthLimits) error {
 	}
 
 	c.bandwidthMutex.Lock()
-	defer c.bandwidthMutex.Unlock()
-
 	c.currentBandwidthLimits = &limits
+	c.bandwidthMutex.Unlock()
+
+	c.changed()
 
 	return nil
 }
@@ -39,50 +44,110 @@ func (c *LinuxContainer) CurrentBandwidthLimits() (garden.BandwidthLimits, error
 func (c *LinuxContainer) LimitDisk(limits garden.DiskLimits) error {
 	cLog := c.logger.Session("limit-disk")
 
-	err := c.quotaManager.SetLimits(cLog, c.resources.UserUID, limits)
+	err := c.quotaManager.SetLimits(cLog, c.quotaSubject(), limits)
 	if err != nil {
 		return err
 	}
 
 	c.diskMutex.Lock()
-	defer c.diskMutex.Unlock()
-
 	c.currentDiskLimits = &limits
+	c.diskMutex.Unlock()
+
+	c.changed()
 
 	return nil
 }
 
 func (c *LinuxContainer) CurrentDiskLimits() (garden.DiskLimits, error) {
 	cLog := c.logger.Session("current-disk-limits")
-	return c.quotaManager.GetLimits(cLog, c.resources.UserUID)
+	return c.quotaManager.GetLimits(cLog, c.quotaSubject())
+}
+
+func (c *LinuxContainer) quotaSubject() quota_manager.Subject {
+	return quota_manager.Subject{
+		ID:  c.id,
+		UID: c.resources.UserUID,
+	}
 }
 
 func (c *LinuxContainer) LimitMemory(limits garden.MemoryLimits) error {
+	if err := limits.OomPolicy.Validate(); err != nil {
+		return fmt.Errorf("linux_container: %v", err)
+	}
+
 	err := c.startOomNotifier()
 	if err != nil {
 		return err
 	}
 
+	c.memoryMutex.RLock()
+	var previous garden.MemoryLimits
+	if c.currentMemoryLimits != nil {
+		previous = *c.currentMemoryLimits
+	}
+	c.memoryMutex.RUnlock()
+
 	limit := fmt.Sprintf("%d", limits.LimitInBytes)
+	memswLimit := fmt.Sprintf("%d", limits.LimitInBytes+limits.SwapInBytes)
 
-	// memory.memsw.limit_in_bytes must be >= memory.limit_in_bytes
+	// memory.memsw.limit_in_bytes (memory plus swap) must be >=
+	// memory.limit_in_bytes
 	//
 	// however, it must be set after memory.limit_in_bytes, and if we're
 	// increasing the limit, writing memory.limit_in_bytes first will fail.
 	//
 	// so, write memory.limit_in_bytes before and after
 	c.cgroupsManager.Set("memory", "memory.limit_in_bytes", limit)
-	c.cgroupsManager.Set("memory", "memory.memsw.limit_in_bytes", limit)
+	c.cgroupsManager.Set("memory", "memory.memsw.limit_in_bytes", memswLimit)
 
 	err = c.cgroupsManager.Set("memory", "memory.limit_in_bytes", limit)
 	if err != nil {
 		return err
 	}
 
-	c.memoryMutex.Lock()
-	defer c.memoryMutex.Unlock()
+	// the optional limits are left alone unless being set or lifted
+	if limits.SoftLimitInBytes != 0 || previous.SoftLimitInBytes != 0 {
+		err = c.cgroupsManager.Set("memory", "memory.soft_limit_in_bytes", memoryLimitOrUnlimited(limits.SoftLimitInBytes))
+		if err != nil {
+			return err
+		}
+	}
+
+	// before Linux 4.6, the kernel refuses a kernel memory limit with EBUSY
+	// once the cgroup has tasks, which the container's does from creation
+	if limits.KmemLimitInBytes != 0 || previous.KmemLimitInBytes != 0 {
+		err = c.cgroupsManager.Set("memory", "memory.kmem.limit_in_bytes", memoryLimitOrUnlimited(limits.KmemLimitInBytes))
+		if err != nil {
+			return fmt.Errorf("linux_container: set kernel memory limit (requires Linux 4.6 or later): %v", err)
+		}
+	}
 
+	if limits.Swappiness != 0 {
+		err = c.cgroupsManager.Set("memory", "memory.swappiness", fmt.Sprintf("%d", limits.Swappiness))
+		if err != nil {
+			return err
+		}
+	}
+
+	// only the notify policy keeps the kernel from killing a process; leave
+	// the cgroup alone unless it is turning that on or off
+	if limits.OomPolicy == garden.OomPolicyNotify || previous.OomPolicy == garden.OomPolicyNotify {
+		oomKillDisable := "0"
+		if limits.OomPolicy == garden.OomPolicyNotify {
+			oomKillDisable = "1"
+		}
+
+		err = c.cgroupsManager.Set("memory", "memory.oom_control", oomKillDisable)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.memoryMutex.Lock()
 	c.currentMemoryLimits = &limits
+	c.memoryMutex.Unlock()
+
+	c.changed()
 
 	return nil
 }
@@ -98,37 +163,349 @@ func (c *LinuxContainer) CurrentMemoryLimits() (garden.MemoryLimits, error) {
 		return garden.MemoryLimits{}, err
 	}
 
-	return garden.MemoryLimits{uint64(numericLimit)}, nil
+	limits := garden.MemoryLimits{
+		LimitInBytes: uint64(numericLimit),
+	}
+
+	limits.SoftLimitInBytes, err = c.optionalMemoryLimit("memory.soft_limit_in_bytes")
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	limits.KmemLimitInBytes, err = c.optionalMemoryLimit("memory.kmem.limit_in_bytes")
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	limits.Swappiness, err = c.optionalMemoryValue("memory.swappiness")
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	memswLimit, err := c.optionalMemoryLimit("memory.memsw.limit_in_bytes")
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	if memswLimit > limits.LimitInBytes {
+		limits.SwapInBytes = memswLimit - limits.LimitInBytes
+	}
+
+	// the kernel cannot tell stopping apart from killing a process
+	c.memoryMutex.RLock()
+	if c.currentMemoryLimits != nil {
+		limits.OomPolicy = c.currentMemoryLimits.OomPolicy
+	}
+	c.memoryMutex.RUnlock()
+
+	return limits, nil
+}
+
+// optionalMemoryValue reads a memory cgroup value the kernel may not
+// account, e.g. without swap accounting, as zero when it is missing.
+func (c *LinuxContainer) optionalMemoryValue(name string) (uint64, error) {
+	value, err := c.cgroupsManager.Get("memory", name)
+	if err != nil {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// unlimitedMemory is what the kernel reports for a memory limit that is not
+// set: PAGE_COUNTER_MAX pages, or more on kernels older than 3.19.
+var unlimitedMemory = uint64(math.MaxInt64) / uint64(os.Getpagesize()) * uint64(os.Getpagesize())
+
+// optionalMemoryLimit reads an optional memory limit as zero when it is not
+// set, or not accounted.
+func (c *LinuxContainer) optionalMemoryLimit(name string) (uint64, error) {
+	limit, err := c.optionalMemoryValue(name)
+	if err != nil {
+		return 0, err
+	}
+
+	if limit >= unlimitedMemory {
+		return 0, nil
+	}
+
+	return limit, nil
+}
+
+// memoryLimitOrUnlimited formats a limit for the memory cgroup, where -1
+// lifts it.
+func memoryLimitOrUnlimited(limit uint64) string {
+	if limit == 0 {
+		return "-1"
+	}
+
+	return fmt.Sprintf("%d", limit)
 }
 
 func (c *LinuxContainer) LimitCPU(limits garden.CPULimits) error {
-	limit := fmt.Sprintf("%d", limits.LimitInShares)
+	if limits.LimitInShares != 0 {
+		err := c.cgroupsManager.Set("cpu", "cpu.shares", fmt.Sprintf("%d", limits.LimitInShares))
+		if err != nil {
+			return err
+		}
+	}
+
+	if limits.PeriodInMicroseconds != 0 {
+		err := c.cgroupsManager.Set("cpu", "cpu.cfs_period_us", fmt.Sprintf("%d", limits.PeriodInMicroseconds))
+		if err != nil {
+			return err
+		}
+	}
 
-	err := c.cgroupsManager.Set("cpu", "cpu.shares", limit)
+	// -1 lifts any cap set before
+	quota := "-1"
+	if limits.QuotaInMicroseconds != 0 {
+		quota = fmt.Sprintf("%d", limits.QuotaInMicroseconds)
+	}
+
+	err := c.cgroupsManager.Set("cpu", "cpu.cfs_quota_us", quota)
 	if err != nil {
 		return err
 	}
 
-	c.cpuMutex.Lock()
-	defer c.cpuMutex.Unlock()
+	if limits.Cpus != "" {
+		err := c.cgroupsManager.Set("cpuset", "cpuset.cpus", limits.Cpus)
+		if err != nil {
+			return err
+		}
+	}
 
+	if limits.Mems != "" {
+		err := c.cgroupsManager.Set("cpuset", "cpuset.mems", limits.Mems)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.cpuMutex.Lock()
 	c.currentCPULimits = &limits
+	c.cpuMutex.Unlock()
+
+	c.changed()
 
 	return nil
 }
 
 func (c *LinuxContainer) CurrentCPULimits() (garden.CPULimits, error) {
-	actualLimitInShares, err := c.cgroupsManager.Get("cpu", "cpu.shares")
+	shares, err := c.getUintCgroup("cpu", "cpu.shares")
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	period, err := c.getUintCgroup("cpu", "cpu.cfs_period_us")
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	quotaValue, err := c.cgroupsManager.Get("cpu", "cpu.cfs_quota_us")
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	quota, err := strconv.ParseInt(quotaValue, 10, 64)
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+
+	// an uncapped container's quota is -1
+	if quota < 0 {
+		quota = 0
+	}
+
+	cpus, err := c.cgroupsManager.Get("cpuset", "cpuset.cpus")
 	if err != nil {
 		return garden.CPULimits{}, err
 	}
 
-	numericLimit, err := strconv.ParseUint(actualLimitInShares, 10, 0)
+	mems, err := c.cgroupsManager.Get("cpuset", "cpuset.mems")
 	if err != nil {
 		return garden.CPULimits{}, err
 	}
 
-	return garden.CPULimits{uint64(numericLimit)}, nil
+	return garden.CPULimits{
+		LimitInShares:        shares,
+		QuotaInMicroseconds:  uint64(quota),
+		PeriodInMicroseconds: period,
+		Cpus:                 cpus,
+		Mems:                 mems,
+	}, nil
+}
+
+// blockIOThrottles are the blkio cgroup's throttle files, and the rate of a
+// device that each holds.
+var blockIOThrottles = []struct {
+	file string
+	rate func(*garden.BlockIODeviceLimits) *uint64
+}{
+	{"blkio.throttle.read_bps_device", func(d *garden.BlockIODeviceLimits) *uint64 { return &d.ReadBytesPerSecond }},
+	{"blkio.throttle.write_bps_device", func(d *garden.BlockIODeviceLimits) *uint64 { return &d.WriteBytesPerSecond }},
+	{"blkio.throttle.read_iops_device", func(d *garden.BlockIODeviceLimits) *uint64 { return &d.ReadIOPerSecond }},
+	{"blkio.throttle.write_iops_device", func(d *garden.BlockIODeviceLimits) *uint64 { return &d.WriteIOPerSecond }},
+}
+
+func (c *LinuxContainer) LimitBlockIO(limits garden.BlockIOLimits) error {
+	if limits.Weight != 0 {
+		err := c.cgroupsManager.Set("blkio", "blkio.weight", fmt.Sprintf("%d", limits.Weight))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, device := range limits.Devices {
+		for _, throttle := range blockIOThrottles {
+			// a zero rate removes the device's throttle
+			rule := fmt.Sprintf("%d:%d %d", device.Major, device.Minor, *throttle.rate(&device))
+
+			err := c.cgroupsManager.Set("blkio", throttle.file, rule)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	c.blockIOMutex.Lock()
+	c.currentBlockIOLimits = mergeBlockIOLimits(c.currentBlockIOLimits, limits)
+	c.blockIOMutex.Unlock()
+
+	c.changed()
+
+	return nil
+}
+
+func (c *LinuxContainer) CurrentBlockIOLimits() (garden.BlockIOLimits, error) {
+	weight, err := c.getUintCgroup("blkio", "blkio.weight")
+	if err != nil {
+		return garden.BlockIOLimits{}, err
+	}
+
+	limits := garden.BlockIOLimits{Weight: weight}
+
+	for _, throttle := range blockIOThrottles {
+		rules, err := c.cgroupsManager.Get("blkio", throttle.file)
+		if err != nil {
+			return garden.BlockIOLimits{}, err
+		}
+
+		for _, rule := range strings.Split(rules, "\n") {
+			if rule == "" {
+				continue
+			}
+
+			var major, minor, rate uint64
+
+			_, err := fmt.Sscanf(rule, "%d:%d %d", &major, &minor, &rate)
+			if err != nil {
+				return garden.BlockIOLimits{}, fmt.Errorf("linux_container: malformed %s: %q", throttle.file, rule)
+			}
+
+			*throttle.rate(blockIODevice(&limits, major, minor)) = rate
+		}
+	}
+
+	return limits, nil
+}
+
+// blockIODevice finds a device's limits, adding them if they are missing.
+func blockIODevice(limits *garden.BlockIOLimits, major, minor uint64) *garden.BlockIODeviceLimits {
+	for i, device := range limits.Devices {
+		if device.Major == major && device.Minor == minor {
+			return &limits.Devices[i]
+		}
+	}
+
+	limits.Devices = append(limits.Devices, garden.BlockIODeviceLimits{Major: major, Minor: minor})
+
+	return &limits.Devices[len(limits.Devices)-1]
+}
+
+// mergeBlockIOLimits applies newly set limits over those set before, as the
+// blkio cgroup does; devices that are no longer throttled are dropped.
+func mergeBlockIOLimits(current *garden.BlockIOLimits, limits garden.BlockIOLimits) *garden.BlockIOLimits {
+	merged := garden.BlockIOLimits{}
+
+	if current != nil {
+		merged.Weight = current.Weight
+
+		for _, device := range current.Devices {
+			*blockIODevice(&merged, device.Major, device.Minor) = device
+		}
+	}
+
+	if limits.Weight != 0 {
+		merged.Weight = limits.Weight
+	}
+
+	for _, device := range limits.Devices {
+		*blockIODevice(&merged, device.Major, device.Minor) = device
+	}
+
+	throttled := merged.Devices[:0]
+	for _, device := range merged.Devices {
+		if device != (garden.BlockIODeviceLimits{Major: device.Major, Minor: device.Minor}) {
+			throttled = append(throttled, device)
+		}
+	}
+
+	merged.Devices = throttled
+
+	return &merged
+}
+
+func (c *LinuxContainer) LimitPids(limits garden.PidLimits) error {
+	max := "max"
+	if limits.Max != 0 {
+		max = fmt.Sprintf("%d", limits.Max)
+	}
+
+	err := c.cgroupsManager.Set("pids", "pids.max", max)
+	if os.IsNotExist(err) {
+		// the container joins only the subsystems the kernel has
+		return ErrPidsUnsupported
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c.pidMutex.Lock()
+	c.currentPidLimits = &limits
+	c.pidMutex.Unlock()
+
+	c.changed()
+
+	return nil
+}
+
+func (c *LinuxContainer) CurrentPidLimits() (garden.PidLimits, error) {
+	max, err := c.cgroupsManager.Get("pids", "pids.max")
+	if err != nil {
+		return garden.PidLimits{}, err
+	}
+
+	// an unlimited container's maximum is "max"
+	if max == "max" {
+		return garden.PidLimits{}, nil
+	}
+
+	numericMax, err := strconv.ParseUint(max, 10, 64)
+	if err != nil {
+		return garden.PidLimits{}, err
+	}
+
+	return garden.PidLimits{Max: numericMax}, nil
+}
+
+func (c *LinuxContainer) getUintCgroup(subsystem, name string) (uint64, error) {
+	value, err := c.cgroupsManager.Get(subsystem, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(value, 10, 64)
 }
 
 func (c *LinuxContainer) startOomNotifier() error {
@@ -139,16 +516,14 @@ func (c *LinuxContainer) startOomNotifier() error {
 		return nil
 	}
 
-	oomPath := path.Join(c.path, "bin", "oom")
-
-	c.oomNotifier = exec.Command(oomPath, c.cgroupsManager.SubsystemPath("memory"))
-
-	err := c.runner.Start(c.oomNotifier)
+	oomNotifier, err := c.cgroupsManager.NotifyOom()
 	if err != nil {
 		return err
 	}
 
-	go c.watchForOom(c.oomNotifier)
+	c.oomNotifier = oomNotifier
+
+	go c.watchForOom(oomNotifier)
 
 	return nil
 }
@@ -158,16 +533,295 @@ func (c *LinuxContainer) stopOomNotifier() {
 	defer c.oomMutex.RUnlock()
 
 	if c.oomNotifier != nil {
-		c.runner.Kill(c.oomNotifier)
+		c.oomNotifier.Stop()
 	}
 }
 
-func (c *LinuxContainer) watchForOom(oom *exec.Cmd) {
-	err := c.runner.Wait(oom)
-	if err == nil {
-		c.registerEvent("out of memory")
-		c.Stop(false)
+func (c *LinuxContainer) watchForOom(oom cgroups_manager.OomNotification) {
+	for {
+		err := oom.Wait()
+		if err != nil {
+			// TODO: handle case where oom notifier itself failed? kill container?
+			return
+		}
+
+		policy := c.oomPolicy()
+
+		c.RegisterEvent("out of memory")
+		c.recordOomEvent(policy)
+
+		if policy == garden.OomPolicyStop {
+			c.Stop(false)
+			return
+		}
+	}
+}
+
+func (c *LinuxContainer) oomPolicy() garden.OomPolicy {
+	c.memoryMutex.RLock()
+	defer c.memoryMutex.RUnlock()
+
+	if c.currentMemoryLimits == nil || c.currentMemoryLimits.OomPolicy == "" {
+		return garden.OomPolicyStop
+	}
+
+	return c.currentMemoryLimits.OomPolicy
+}
+
+// restoreLimits reconciles the limits saved in a snapshot with those in
+// force, recording any that drifted as events and enforcing them again.
+func (c *LinuxContainer) restoreLimits(cLog lager.Logger, limits LimitsSnapshot) error {
+	if limits.Memory != nil {
+		current, err := c.CurrentMemoryLimits()
+		if err == nil && memoryLimitsDrifted(*limits.Memory, current) {
+			c.registerDrift("memory", *limits.Memory, current)
+		}
+
+		// always enforced again, to watch for ooms
+		err = c.LimitMemory(*limits.Memory)
+		if err != nil {
+			cLog.Error("failed-to-limit-memory", err)
+			return err
+		}
+	}
+
+	if limits.CPU != nil {
+		current, err := c.CurrentCPULimits()
+		if err != nil || cpuLimitsDrifted(*limits.CPU, current) {
+			if err == nil {
+				c.registerDrift("cpu", *limits.CPU, current)
+			}
+
+			err := c.LimitCPU(*limits.CPU)
+			if err != nil {
+				cLog.Error("failed-to-limit-cpu", err)
+				return err
+			}
+		} else {
+			c.cpuMutex.Lock()
+			c.currentCPULimits = limits.CPU
+			c.cpuMutex.Unlock()
+		}
+	}
+
+	if limits.BlockIO != nil {
+		current, err := c.CurrentBlockIOLimits()
+		if err != nil || blockIOLimitsDrifted(*limits.BlockIO, current) {
+			if err == nil {
+				c.registerDrift("block io", *limits.BlockIO, current)
+			}
+
+			err := c.LimitBlockIO(*limits.BlockIO)
+			if err != nil {
+				cLog.Error("failed-to-limit-block-io", err)
+				return err
+			}
+		} else {
+			c.blockIOMutex.Lock()
+			c.currentBlockIOLimits = limits.BlockIO
+			c.blockIOMutex.Unlock()
+		}
+	}
+
+	if limits.Pid != nil {
+		current, err := c.CurrentPidLimits()
+		if err != nil || current != *limits.Pid {
+			if err == nil {
+				c.registerDrift("pid", *limits.Pid, current)
+			}
+
+			err := c.LimitPids(*limits.Pid)
+			if err != nil {
+				cLog.Error("failed-to-limit-pids", err)
+				return err
+			}
+		} else {
+			c.pidMutex.Lock()
+			c.currentPidLimits = limits.Pid
+			c.pidMutex.Unlock()
+		}
+	}
+
+	if limits.Bandwidth != nil {
+		current, err := c.bandwidthManager.GetLimits(cLog)
+		if err != nil || bandwidthLimitsDrifted(*limits.Bandwidth, current) {
+			if err == nil {
+				c.registerDrift("bandwidth", *limits.Bandwidth, current)
+			}
+
+			err := c.LimitBandwidth(*limits.Bandwidth)
+			if err != nil {
+				cLog.Error("failed-to-limit-bandwidth", err)
+				return err
+			}
+		} else {
+			c.bandwidthMutex.Lock()
+			c.currentBandwidthLimits = limits.Bandwidth
+			c.bandwidthMutex.Unlock()
+		}
+	}
+
+	if limits.Disk != nil {
+		// with quotas disabled there is nothing in force to compare against
+		current, err := c.CurrentDiskLimits()
+		if c.quotaManager.IsEnabled() && (err != nil || diskLimitsDrifted(c.quotaManager.Type(), *limits.Disk, current)) {
+			if err == nil {
+				c.registerDrift("disk", *limits.Disk, current)
+			}
+
+			err := c.LimitDisk(*limits.Disk)
+			if err != nil {
+				cLog.Error("failed-to-limit-disk", err)
+				return err
+			}
+		} else {
+			c.diskMutex.Lock()
+			c.currentDiskLimits = limits.Disk
+			c.diskMutex.Unlock()
+		}
+	}
+
+	return nil
+}
+
+func (c *LinuxContainer) registerDrift(kind string, expected, found interface{}) {
+	c.RegisterEvent(fmt.Sprintf("%s limits drifted: expected %+v, found %+v", kind, expected, found))
+}
+
+// memoryLimitsDrifted compares only what LimitMemory would have changed; the
+// kernel cannot report the OOM policy, and stores the limits in whole pages.
+func memoryLimitsDrifted(expected, found garden.MemoryLimits) bool {
+	return !samePages(expected.LimitInBytes, found.LimitInBytes) ||
+		!samePages(expected.SwapInBytes, found.SwapInBytes) ||
+		(expected.SoftLimitInBytes != 0 && !samePages(expected.SoftLimitInBytes, found.SoftLimitInBytes)) ||
+		(expected.KmemLimitInBytes != 0 && !samePages(expected.KmemLimitInBytes, found.KmemLimitInBytes)) ||
+		(expected.Swappiness != 0 && expected.Swappiness != found.Swappiness)
+}
+
+// cpuLimitsDrifted compares only what LimitCPU would have changed. The kernel
+// reports cpu and memory node lists in its own form, e.g. "0-2" for "0,1,2".
+func cpuLimitsDrifted(expected, found garden.CPULimits) bool {
+	return (expected.LimitInShares != 0 && expected.LimitInShares != found.LimitInShares) ||
+		(expected.PeriodInMicroseconds != 0 && expected.PeriodInMicroseconds != found.PeriodInMicroseconds) ||
+		expected.QuotaInMicroseconds != found.QuotaInMicroseconds ||
+		(expected.Cpus != "" && !sameList(expected.Cpus, found.Cpus)) ||
+		(expected.Mems != "" && !sameList(expected.Mems, found.Mems))
+}
+
+func blockIOLimitsDrifted(expected, found garden.BlockIOLimits) bool {
+	if expected.Weight != 0 && expected.Weight != found.Weight {
+		return true
+	}
+
+	for _, device := range expected.Devices {
+		if *blockIODevice(&found, device.Major, device.Minor) != device {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bandwidthLimitsDrifted allows for the burst being rounded to scheduler
+// ticks when it is stored.
+func bandwidthLimitsDrifted(expected garden.BandwidthLimits, found garden.ContainerBandwidthStat) bool {
+	return expected.RateInBytesPerSecond != found.InRate ||
+		expected.RateInBytesPerSecond != found.OutRate ||
+		!roughly(expected.BurstRateInBytesPerSecond, found.InBurst) ||
+		!roughly(expected.BurstRateInBytesPerSecond, found.OutBurst)
+}
+
+func diskLimitsDrifted(quotaType string, expected, found garden.DiskLimits) bool {
+	expected = quota_manager.InBlocks(expected)
+
+	// a loop filesystem only enforces its size, and only grows to it
+	if quotaType == quota_manager.LoopQuota {
+		return expected.BlockHard != 0 && expected.BlockHard != found.BlockHard
+	}
+
+	return expected.BlockSoft != found.BlockSoft ||
+		expected.BlockHard != found.BlockHard ||
+		expected.InodeSoft != found.InodeSoft ||
+		expected.InodeHard != found.InodeHard
+}
+
+func roughly(expected, found uint64) bool {
+	if expected > found {
+		return expected-found <= expected/100
+	}
+
+	return found-expected <= expected/100
+}
+
+// samePages allows for the kernel rounding a limit to a page, up or down
+// depending on its version.
+func samePages(expected, found uint64) bool {
+	pageSize := uint64(os.Getpagesize())
+
+	if expected > found {
+		return expected-found < pageSize
+	}
+
+	return found-expected < pageSize
+}
+
+// sameList compares cpu or memory node lists, such as "0-2,4", by the nodes
+// they contain.
+func sameList(expected, found string) bool {
+	expectedNodes, err := parseList(expected)
+	if err != nil {
+		return expected == found
+	}
+
+	foundNodes, err := parseList(found)
+	if err != nil {
+		return expected == found
+	}
+
+	if len(expectedNodes) != len(foundNodes) {
+		return false
+	}
+
+	for node := range expectedNodes {
+		if !foundNodes[node] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parseList(list string) (map[uint64]bool, error) {
+	nodes := map[uint64]bool{}
+
+	for _, part := range strings.Split(strings.TrimSpace(list), ",") {
+		if part == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(part, "-", 2)
+
+		lo, err := strconv.ParseUint(bounds[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		hi := lo
+		if len(bounds) == 2 {
+			hi, err = strconv.ParseUint(bounds[1], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if hi < lo {
+			return nil, fmt.Errorf("invalid range: %s", part)
+		}
+
+		for node := lo; node <= hi; node++ {
+			nodes[node] = true
+		}
 	}
 
-	// TODO: handle case where oom notifier itself failed? kill container?
+	return nodes, nil
 }
@@ -1,11 +1,15 @@
 package linux_container_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
 	"math"
 	"net"
-	"os/exec"
+	"os"
+	"syscall"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -17,8 +21,10 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/linux_container"
 	networkFakes "github.com/cloudfoundry-incubator/garden-linux/network/fakes"
 	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager/fake_cgroups_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool/fake_port_pool"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/cloudfoundry-incubator/garden-linux/process_tracker/fake_process_tracker"
@@ -158,13 +164,7 @@ var _ = Describe("Linux containers", func() {
 			err := container.LimitMemory(limits)
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(fakeRunner).To(HaveStartedExecuting(
-				fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-					Args: []string{"/cgroups/memory/instance-some-id"},
-				},
-			))
-
+			Expect(fakeCgroups.OomNotifications()).To(HaveLen(1))
 		})
 
 		It("sets memory.limit_in_bytes and then memory.memsw.limit_in_bytes", func() {
@@ -197,17 +197,140 @@ var _ = Describe("Linux containers", func() {
 
 		})
 
-		Context("when the oom notifier is already running", func() {
-			It("does not start another", func() {
-				started := 0
+		Context("with an unknown OOM policy", func() {
+			It("returns an error and sets nothing", func() {
+				err := container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+					OomPolicy:    "bogus",
+				})
+				Expect(err).To(MatchError(`linux_container: unknown oom policy: "bogus"`))
 
-				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-				}, func(*exec.Cmd) error {
-					started++
-					return nil
+				Expect(fakeCgroups.SetValues()).To(BeEmpty())
+				Expect(fakeCgroups.OomNotifications()).To(BeEmpty())
+			})
+		})
+
+		Context("when swap is allowed", func() {
+			It("allows it on top of the limit in memory.memsw.limit_in_bytes", func() {
+				err := container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+					SwapInBytes:  1024,
 				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(Equal(
+					[]fake_cgroups_manager.SetValue{
+						{
+							Subsystem: "memory",
+							Name:      "memory.limit_in_bytes",
+							Value:     "102400",
+						},
+						{
+							Subsystem: "memory",
+							Name:      "memory.memsw.limit_in_bytes",
+							Value:     "103424",
+						},
+						{
+							Subsystem: "memory",
+							Name:      "memory.limit_in_bytes",
+							Value:     "102400",
+						},
+					},
+				))
+			})
+		})
+
+		Context("with a soft limit, kernel memory limit and swappiness", func() {
+			limits := garden.MemoryLimits{
+				LimitInBytes:     102400,
+				SoftLimitInBytes: 51200,
+				KmemLimitInBytes: 25600,
+				Swappiness:       10,
+			}
+
+			It("sets them after the limit", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()[3:]).To(Equal(
+					[]fake_cgroups_manager.SetValue{
+						{
+							Subsystem: "memory",
+							Name:      "memory.soft_limit_in_bytes",
+							Value:     "51200",
+						},
+						{
+							Subsystem: "memory",
+							Name:      "memory.kmem.limit_in_bytes",
+							Value:     "25600",
+						},
+						{
+							Subsystem: "memory",
+							Name:      "memory.swappiness",
+							Value:     "10",
+						},
+					},
+				))
+			})
 
+			Context("and they are later left out", func() {
+				It("lifts the soft and kernel memory limits", func() {
+					err := container.LimitMemory(limits)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = container.LimitMemory(garden.MemoryLimits{
+						LimitInBytes: 102400,
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeCgroups.SetValues()[9:]).To(Equal(
+						[]fake_cgroups_manager.SetValue{
+							{
+								Subsystem: "memory",
+								Name:      "memory.soft_limit_in_bytes",
+								Value:     "-1",
+							},
+							{
+								Subsystem: "memory",
+								Name:      "memory.kmem.limit_in_bytes",
+								Value:     "-1",
+							},
+						},
+					))
+				})
+			})
+
+			Context("when setting memory.kmem.limit_in_bytes fails", func() {
+				disaster := errors.New("oh no!")
+
+				BeforeEach(func() {
+					fakeCgroups.WhenSetting("memory", "memory.kmem.limit_in_bytes", func() error {
+						return disaster
+					})
+				})
+
+				It("returns the error, with the kernel it requires", func() {
+					err := container.LimitMemory(limits)
+					Expect(err).To(MatchError("linux_container: set kernel memory limit (requires Linux 4.6 or later): oh no!"))
+				})
+			})
+
+			It("saves them", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				out := new(bytes.Buffer)
+				Expect(container.Snapshot(out)).To(Succeed())
+
+				var snapshot linux_container.ContainerSnapshot
+				Expect(json.NewDecoder(out).Decode(&snapshot)).To(Succeed())
+
+				Expect(snapshot.Limits.Memory).To(Equal(&limits))
+			})
+		})
+
+		Context("when the oom notifier is already running", func() {
+			It("does not start another", func() {
 				limits := garden.MemoryLimits{
 					LimitInBytes: 102400,
 				}
@@ -218,15 +341,13 @@ var _ = Describe("Linux containers", func() {
 				err = container.LimitMemory(limits)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(started).To(Equal(1))
+				Expect(fakeCgroups.OomNotifications()).To(HaveLen(1))
 			})
 		})
 
-		Context("when the oom notifier exits 0", func() {
+		Context("when the container runs out of memory", func() {
 			JustBeforeEach(func() {
-				fakeRunner.WhenWaitingFor(fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-				}, func(cmd *exec.Cmd) error {
+				fakeCgroups.WhenWaitingForOom(func() error {
 					return nil
 				})
 			})
@@ -258,6 +379,245 @@ var _ = Describe("Linux containers", func() {
 					return container.Events()
 				}).Should(ContainElement("out of memory"))
 			})
+
+			It("records an OOM event with the memory stats at the time", func() {
+				fakeCgroups.WhenGetting("memory", "memory.stat", func() (string, error) {
+					return "cache 1\nrss 2\n", nil
+				})
+
+				err := container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(container.OomEvents).Should(HaveLen(1))
+
+				event := container.OomEvents()[0]
+				Expect(event.Time).To(BeTemporally("~", time.Now(), time.Minute))
+				Expect(event.Policy).To(Equal(garden.OomPolicyStop))
+				Expect(event.MemoryStat.Cache).To(Equal(uint64(1)))
+				Expect(event.MemoryStat.Rss).To(Equal(uint64(2)))
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.OomEvents).To(Equal([]garden.OomEvent{event}))
+			})
+
+			It("drops the oldest OOM event once it has 10", func() {
+				oldEvents := []garden.OomEvent{}
+				for i := 0; i < 10; i++ {
+					oldEvents = append(oldEvents, garden.OomEvent{
+						Time:   time.Unix(int64(i), 0),
+						Policy: garden.OomPolicyKillProcess,
+					})
+				}
+
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:     "active",
+					Events:    []string{},
+					OomEvents: oldEvents,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(func() garden.OomPolicy {
+					events := container.OomEvents()
+					return events[len(events)-1].Policy
+				}).Should(Equal(garden.OomPolicyStop))
+
+				events := container.OomEvents()
+				Expect(events).To(HaveLen(10))
+				Expect(events[:9]).To(Equal(oldEvents[1:]))
+			})
+
+			It("sends the OOM event to streams", func() {
+				stream, err := container.StreamOomEvents()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				event, err := stream.Next()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(event.Policy).To(Equal(garden.OomPolicyStop))
+			})
+		})
+
+		Context("when the OOM policy is kill_process", func() {
+			limits := garden.MemoryLimits{
+				LimitInBytes: 102400,
+				OomPolicy:    garden.OomPolicyKillProcess,
+			}
+
+			It("records the OOM without stopping the container", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(container.OomEvents).Should(HaveLen(1))
+				Expect(container.OomEvents()[0].Policy).To(Equal(garden.OomPolicyKillProcess))
+				Expect(container.Events()).To(ContainElement("out of memory"))
+
+				Consistently(fakeRunner).ShouldNot(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/stop.sh",
+					},
+				))
+			})
+
+			It("keeps watching for OOMs", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(container.OomEvents).Should(HaveLen(1))
+				Expect(fakeCgroups.OomNotifications()[0].IsStopped()).To(BeFalse())
+			})
+
+			It("leaves the kernel's OOM killer enabled", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).ToNot(ContainElement(fake_cgroups_manager.SetValue{
+					Subsystem: "memory",
+					Name:      "memory.oom_control",
+					Value:     "1",
+				}))
+			})
+
+			It("reports the policy in the current limits", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, name := range []string{
+					"memory.limit_in_bytes",
+					"memory.memsw.limit_in_bytes",
+					"memory.soft_limit_in_bytes",
+					"memory.kmem.limit_in_bytes",
+					"memory.swappiness",
+				} {
+					fakeCgroups.WhenGetting("memory", name, func() (string, error) {
+						return "102400", nil
+					})
+				}
+
+				current, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(current.OomPolicy).To(Equal(garden.OomPolicyKillProcess))
+			})
+		})
+
+		Context("when the OOM policy is notify", func() {
+			limits := garden.MemoryLimits{
+				LimitInBytes: 102400,
+				OomPolicy:    garden.OomPolicyNotify,
+			}
+
+			It("disables the kernel's OOM killer", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(ContainElement(fake_cgroups_manager.SetValue{
+					Subsystem: "memory",
+					Name:      "memory.oom_control",
+					Value:     "1",
+				}))
+			})
+
+			It("records the OOM without stopping the container", func() {
+				err := container.LimitMemory(limits)
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(container.OomEvents).Should(HaveLen(1))
+				Expect(container.OomEvents()[0].Policy).To(Equal(garden.OomPolicyNotify))
+
+				Consistently(fakeRunner).ShouldNot(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/stop.sh",
+					},
+				))
+			})
+
+			Context("and the policy is changed", func() {
+				It("enables the kernel's OOM killer again", func() {
+					err := container.LimitMemory(limits)
+					Expect(err).ToNot(HaveOccurred())
+
+					err = container.LimitMemory(garden.MemoryLimits{
+						LimitInBytes: 102400,
+						OomPolicy:    garden.OomPolicyKillProcess,
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeCgroups.SetValues()).To(ContainElement(fake_cgroups_manager.SetValue{
+						Subsystem: "memory",
+						Name:      "memory.oom_control",
+						Value:     "0",
+					}))
+				})
+			})
+
+			Context("when setting memory.oom_control fails", func() {
+				disaster := errors.New("oh no!")
+
+				BeforeEach(func() {
+					fakeCgroups.WhenSetting("memory", "memory.oom_control", func() error {
+						return disaster
+					})
+				})
+
+				It("returns the error", func() {
+					err := container.LimitMemory(limits)
+					Expect(err).To(Equal(disaster))
+				})
+			})
+		})
+
+		Context("when the container is cleaned up", func() {
+			It("ends OOM event streams", func() {
+				stream, err := container.StreamOomEvents()
+				Expect(err).ToNot(HaveOccurred())
+
+				container.Cleanup()
+
+				_, err = stream.Next()
+				Expect(err).To(Equal(io.EOF))
+			})
+		})
+
+		Context("when an OOM event stream is closed", func() {
+			It("ends the stream", func() {
+				stream, err := container.StreamOomEvents()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stream.Close()).To(Succeed())
+
+				_, err = stream.Next()
+				Expect(err).To(Equal(io.EOF))
+			})
+		})
+
+		Context("when the oom notifier is stopped", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenWaitingForOom(func() error {
+					return cgroups_manager.ErrOomNotificationStopped
+				})
+			})
+
+			It("does not register an 'out of memory' event", func() {
+				err := container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 102400,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Consistently(func() []string {
+					return container.Events()
+				}).ShouldNot(ContainElement("out of memory"))
+			})
 		})
 
 		Context("when setting memory.memsw.limit_in_bytes fails", func() {
@@ -338,11 +698,7 @@ var _ = Describe("Linux containers", func() {
 			disaster := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-				}, func(cmd *exec.Cmd) error {
-					return disaster
-				})
+				fakeCgroups.NotifyOomError = disaster
 			})
 
 			It("returns the error", func() {
@@ -356,6 +712,132 @@ var _ = Describe("Linux containers", func() {
 	})
 
 	Describe("Getting the current memory limit", func() {
+		var softLimit string
+		var kmemLimit string
+		var memswLimit string
+		var memswErr error
+
+		BeforeEach(func() {
+			softLimit = "1024"
+			kmemLimit = "2048"
+			memswLimit = "18446744073709551615"
+			memswErr = nil
+
+			fakeCgroups.WhenGetting("memory", "memory.soft_limit_in_bytes", func() (string, error) {
+				return softLimit, nil
+			})
+
+			fakeCgroups.WhenGetting("memory", "memory.kmem.limit_in_bytes", func() (string, error) {
+				return kmemLimit, nil
+			})
+
+			fakeCgroups.WhenGetting("memory", "memory.swappiness", func() (string, error) {
+				return "30", nil
+			})
+
+			fakeCgroups.WhenGetting("memory", "memory.memsw.limit_in_bytes", func() (string, error) {
+				return memswLimit, memswErr
+			})
+		})
+
+		It("returns the soft limit, kernel memory limit and swappiness", func() {
+			fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+				return "1024", nil
+			})
+
+			limits, err := container.CurrentMemoryLimits()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limits.SoftLimitInBytes).To(Equal(uint64(1024)))
+			Expect(limits.KmemLimitInBytes).To(Equal(uint64(2048)))
+			Expect(limits.Swappiness).To(Equal(uint64(30)))
+		})
+
+		Context("when the soft, kernel memory and swap limits are not set", func() {
+			BeforeEach(func() {
+				// PAGE_COUNTER_MAX pages of 4 kB, as the kernel reports them
+				softLimit = "9223372036854771712"
+				kmemLimit = "9223372036854771712"
+				memswLimit = "9223372036854771712"
+			})
+
+			It("returns them as zero", func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				limits, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.LimitInBytes).To(Equal(uint64(1024)))
+				Expect(limits.SoftLimitInBytes).To(BeZero())
+				Expect(limits.KmemLimitInBytes).To(BeZero())
+				Expect(limits.SwapInBytes).To(BeZero())
+			})
+		})
+
+		Context("when swap is allowed", func() {
+			BeforeEach(func() {
+				memswLimit = "1536"
+			})
+
+			It("returns the swap allowed on top of the limit", func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				limits, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.LimitInBytes).To(Equal(uint64(1024)))
+				Expect(limits.SwapInBytes).To(Equal(uint64(512)))
+			})
+		})
+
+		Context("when no swap is allowed", func() {
+			BeforeEach(func() {
+				memswLimit = "1024"
+			})
+
+			It("returns no swap", func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				limits, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.SwapInBytes).To(BeZero())
+			})
+		})
+
+		Context("when swap is not accounted", func() {
+			BeforeEach(func() {
+				memswErr = errors.New("no such file or directory")
+			})
+
+			It("returns no swap", func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				limits, err := container.CurrentMemoryLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.SwapInBytes).To(BeZero())
+			})
+		})
+
+		Context("when the soft limit is malformed", func() {
+			BeforeEach(func() {
+				softLimit = "1M"
+			})
+
+			It("returns the error", func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				_, err := container.CurrentMemoryLimits()
+				Expect(err.Error()).To(HaveSuffix("invalid syntax"))
+			})
+		})
+
 		It("returns the limited memory", func() {
 			fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
 				return "18446744073709551615", nil
@@ -392,7 +874,7 @@ var _ = Describe("Linux containers", func() {
 	})
 
 	Describe("Limiting CPU", func() {
-		It("sets cpu.shares", func() {
+		It("sets cpu.shares and lifts any cap", func() {
 			limits := garden.CPULimits{
 				LimitInShares: 512,
 			}
@@ -407,11 +889,105 @@ var _ = Describe("Linux containers", func() {
 						Name:      "cpu.shares",
 						Value:     "512",
 					},
+					{
+						Subsystem: "cpu",
+						Name:      "cpu.cfs_quota_us",
+						Value:     "-1",
+					},
 				},
 			))
 
 		})
 
+		It("sets cpu.cfs_period_us and then cpu.cfs_quota_us", func() {
+			err := container.LimitCPU(garden.CPULimits{
+				QuotaInMicroseconds:  50000,
+				PeriodInMicroseconds: 100000,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(Equal(
+				[]fake_cgroups_manager.SetValue{
+					{
+						Subsystem: "cpu",
+						Name:      "cpu.cfs_period_us",
+						Value:     "100000",
+					},
+					{
+						Subsystem: "cpu",
+						Name:      "cpu.cfs_quota_us",
+						Value:     "50000",
+					},
+				},
+			))
+		})
+
+		It("pins the container with cpuset.cpus and cpuset.mems", func() {
+			err := container.LimitCPU(garden.CPULimits{
+				Cpus: "0-1,3",
+				Mems: "0",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(fake_cgroups_manager.SetValue{
+				Subsystem: "cpuset",
+				Name:      "cpuset.cpus",
+				Value:     "0-1,3",
+			}))
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(fake_cgroups_manager.SetValue{
+				Subsystem: "cpuset",
+				Name:      "cpuset.mems",
+				Value:     "0",
+			}))
+		})
+
+		It("reports the change", func() {
+			changed := false
+			container.OnChange(func() {
+				changed = true
+			})
+
+			Expect(container.LimitCPU(garden.CPULimits{LimitInShares: 512})).To(Succeed())
+			Expect(changed).To(BeTrue())
+		})
+
+		Context("when setting cpu.cfs_quota_us fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeCgroups.WhenSetting("cpu", "cpu.cfs_quota_us", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.LimitCPU(garden.CPULimits{
+					QuotaInMicroseconds: 50000,
+				})
+
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when setting cpuset.cpus fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeCgroups.WhenSetting("cpuset", "cpuset.cpus", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.LimitCPU(garden.CPULimits{
+					Cpus: "7",
+				})
+
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
 		Context("when setting cpu.shares fails", func() {
 			disaster := errors.New("oh no!")
 
@@ -432,6 +1008,28 @@ var _ = Describe("Linux containers", func() {
 	})
 
 	Describe("Getting the current CPU limits", func() {
+		var cfsQuota string
+
+		BeforeEach(func() {
+			cfsQuota = "50000"
+
+			fakeCgroups.WhenGetting("cpu", "cpu.cfs_period_us", func() (string, error) {
+				return "100000", nil
+			})
+
+			fakeCgroups.WhenGetting("cpu", "cpu.cfs_quota_us", func() (string, error) {
+				return cfsQuota, nil
+			})
+
+			fakeCgroups.WhenGetting("cpuset", "cpuset.cpus", func() (string, error) {
+				return "0-3", nil
+			})
+
+			fakeCgroups.WhenGetting("cpuset", "cpuset.mems", func() (string, error) {
+				return "0", nil
+			})
+		})
+
 		It("returns the CPU limits", func() {
 			fakeCgroups.WhenGetting("cpu", "cpu.shares", func() (string, error) {
 				return "512", nil
@@ -439,7 +1037,27 @@ var _ = Describe("Linux containers", func() {
 
 			limits, err := container.CurrentCPULimits()
 			Expect(err).ToNot(HaveOccurred())
-			Expect(limits.LimitInShares).To(Equal(uint64(512)))
+			Expect(limits).To(Equal(garden.CPULimits{
+				LimitInShares:        512,
+				QuotaInMicroseconds:  50000,
+				PeriodInMicroseconds: 100000,
+				Cpus:                 "0-3",
+				Mems:                 "0",
+			}))
+		})
+
+		Context("when the container is uncapped", func() {
+			It("returns a zero quota", func() {
+				fakeCgroups.WhenGetting("cpu", "cpu.shares", func() (string, error) {
+					return "512", nil
+				})
+
+				cfsQuota = "-1"
+
+				limits, err := container.CurrentCPULimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.QuotaInMicroseconds).To(BeZero())
+			})
 		})
 
 		Context("when getting the limit fails", func() {
@@ -466,6 +1084,258 @@ var _ = Describe("Linux containers", func() {
 		})
 	})
 
+	Describe("Limiting block IO", func() {
+		It("sets blkio.weight", func() {
+			err := container.LimitBlockIO(garden.BlockIOLimits{
+				Weight: 500,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(Equal(
+				[]fake_cgroups_manager.SetValue{
+					{
+						Subsystem: "blkio",
+						Name:      "blkio.weight",
+						Value:     "500",
+					},
+				},
+			))
+		})
+
+		It("sets every throttle of the given devices", func() {
+			err := container.LimitBlockIO(garden.BlockIOLimits{
+				Devices: []garden.BlockIODeviceLimits{
+					{
+						Major:               8,
+						Minor:               16,
+						ReadBytesPerSecond:  1024,
+						WriteBytesPerSecond: 2048,
+						WriteIOPerSecond:    100,
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(Equal(
+				[]fake_cgroups_manager.SetValue{
+					{
+						Subsystem: "blkio",
+						Name:      "blkio.throttle.read_bps_device",
+						Value:     "8:16 1024",
+					},
+					{
+						Subsystem: "blkio",
+						Name:      "blkio.throttle.write_bps_device",
+						Value:     "8:16 2048",
+					},
+					{
+						Subsystem: "blkio",
+						Name:      "blkio.throttle.read_iops_device",
+						Value:     "8:16 0",
+					},
+					{
+						Subsystem: "blkio",
+						Name:      "blkio.throttle.write_iops_device",
+						Value:     "8:16 100",
+					},
+				},
+			))
+		})
+
+		It("reports the change", func() {
+			changed := false
+			container.OnChange(func() {
+				changed = true
+			})
+
+			Expect(container.LimitBlockIO(garden.BlockIOLimits{Weight: 500})).To(Succeed())
+			Expect(changed).To(BeTrue())
+		})
+
+		Context("when setting a throttle fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeCgroups.WhenSetting("blkio", "blkio.throttle.write_bps_device", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.LimitBlockIO(garden.BlockIOLimits{
+					Devices: []garden.BlockIODeviceLimits{
+						{Major: 8, Minor: 0, WriteBytesPerSecond: 1024},
+					},
+				})
+
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Getting the current block IO limits", func() {
+		BeforeEach(func() {
+			fakeCgroups.WhenGetting("blkio", "blkio.throttle.read_bps_device", func() (string, error) {
+				return "8:0 1024\n8:16 4096\n", nil
+			})
+
+			fakeCgroups.WhenGetting("blkio", "blkio.throttle.write_iops_device", func() (string, error) {
+				return "8:16 100\n", nil
+			})
+		})
+
+		It("returns the weight and every throttled device", func() {
+			fakeCgroups.WhenGetting("blkio", "blkio.weight", func() (string, error) {
+				return "500", nil
+			})
+
+			limits, err := container.CurrentBlockIOLimits()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limits).To(Equal(garden.BlockIOLimits{
+				Weight: 500,
+				Devices: []garden.BlockIODeviceLimits{
+					{Major: 8, Minor: 0, ReadBytesPerSecond: 1024},
+					{Major: 8, Minor: 16, ReadBytesPerSecond: 4096, WriteIOPerSecond: 100},
+				},
+			}))
+		})
+
+		Context("when a throttle is malformed", func() {
+			It("returns an error", func() {
+				fakeCgroups.WhenGetting("blkio", "blkio.weight", func() (string, error) {
+					return "500", nil
+				})
+
+				fakeCgroups.WhenGetting("blkio", "blkio.throttle.write_bps_device", func() (string, error) {
+					return "sda 1024\n", nil
+				})
+
+				_, err := container.CurrentBlockIOLimits()
+				Expect(err).To(MatchError(`linux_container: malformed blkio.throttle.write_bps_device: "sda 1024"`))
+			})
+		})
+
+		Context("when getting the weight fails", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeCgroups.WhenGetting("blkio", "blkio.weight", func() (string, error) {
+					return "", disaster
+				})
+
+				_, err := container.CurrentBlockIOLimits()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
+	Describe("Limiting pids", func() {
+		It("sets pids.max", func() {
+			err := container.LimitPids(garden.PidLimits{Max: 1024})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(Equal(
+				[]fake_cgroups_manager.SetValue{
+					{
+						Subsystem: "pids",
+						Name:      "pids.max",
+						Value:     "1024",
+					},
+				},
+			))
+		})
+
+		Context("when no maximum is given", func() {
+			It("lifts the limit", func() {
+				err := container.LimitPids(garden.PidLimits{})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(Equal(
+					[]fake_cgroups_manager.SetValue{
+						{
+							Subsystem: "pids",
+							Name:      "pids.max",
+							Value:     "max",
+						},
+					},
+				))
+			})
+		})
+
+		It("reports the change", func() {
+			changed := false
+			container.OnChange(func() {
+				changed = true
+			})
+
+			Expect(container.LimitPids(garden.PidLimits{Max: 1024})).To(Succeed())
+			Expect(changed).To(BeTrue())
+		})
+
+		Context("when setting pids.max fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeCgroups.WhenSetting("pids", "pids.max", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.LimitPids(garden.PidLimits{Max: 1024})
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when the kernel has no pids cgroup", func() {
+			BeforeEach(func() {
+				fakeCgroups.WhenSetting("pids", "pids.max", func() error {
+					return &os.PathError{Op: "open", Path: "pids.max", Err: syscall.ENOENT}
+				})
+			})
+
+			It("refuses to limit the pids", func() {
+				err := container.LimitPids(garden.PidLimits{Max: 1024})
+				Expect(err).To(Equal(linux_container.ErrPidsUnsupported))
+			})
+		})
+	})
+
+	Describe("Getting the current pid limit", func() {
+		It("returns the maximum", func() {
+			fakeCgroups.WhenGetting("pids", "pids.max", func() (string, error) {
+				return "1024", nil
+			})
+
+			limits, err := container.CurrentPidLimits()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limits).To(Equal(garden.PidLimits{Max: 1024}))
+		})
+
+		Context("when the container is unlimited", func() {
+			It("returns a zero maximum", func() {
+				fakeCgroups.WhenGetting("pids", "pids.max", func() (string, error) {
+					return "max", nil
+				})
+
+				limits, err := container.CurrentPidLimits()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(limits.Max).To(BeZero())
+			})
+		})
+
+		Context("when getting the limit fails", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeCgroups.WhenGetting("pids", "pids.max", func() (string, error) {
+					return "", disaster
+				})
+
+				_, err := container.CurrentPidLimits()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+
 	Describe("Limiting disk", func() {
 		limits := garden.DiskLimits{
 			BlockSoft: 3,
@@ -478,7 +1348,7 @@ var _ = Describe("Linux containers", func() {
 			ByteHard: 24,
 		}
 
-		It("sets the quota via the quota manager with the uid and limits", func() {
+		It("sets the quota via the quota manager with the container and limits", func() {
 			resultingLimits := garden.DiskLimits{
 				BlockHard: 1234567,
 			}
@@ -488,10 +1358,13 @@ var _ = Describe("Linux containers", func() {
 			err := container.LimitDisk(limits)
 			Expect(err).ToNot(HaveOccurred())
 
-			uid := containerResources.UserUID
+			subject := quota_manager.Subject{
+				ID:  "some-id",
+				UID: containerResources.UserUID,
+			}
 
-			Expect(fakeQuotaManager.Limited).To(HaveKey(uid))
-			Expect(fakeQuotaManager.Limited[uid]).To(Equal(limits))
+			Expect(fakeQuotaManager.Limited).To(HaveKey(subject))
+			Expect(fakeQuotaManager.Limited[subject]).To(Equal(limits))
 		})
 
 		Context("when setting the quota fails", func() {
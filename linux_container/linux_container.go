@@ -1,9 +1,12 @@
 package linux_container
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path"
@@ -16,6 +19,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/linux_backend"
 	"github.com/cloudfoundry-incubator/garden-linux/network"
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 	"github.com/cloudfoundry-incubator/garden-linux/network/subnets"
 	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager"
@@ -35,6 +39,46 @@ func (err UndefinedPropertyError) Error() string {
 	return fmt.Sprintf("property does not exist: %s", err.Key)
 }
 
+type UnmappedPortError struct {
+	HostPort uint32
+	Protocol garden.Protocol
+}
+
+func (err UnmappedPortError) Error() string {
+	return fmt.Sprintf("no %s port is mapped from host port %d", portMappingProtocols[err.Protocol], err.HostPort)
+}
+
+type UnsupportedPortMappingProtocolError struct {
+	Protocol garden.Protocol
+}
+
+func (err UnsupportedPortMappingProtocolError) Error() string {
+	return fmt.Sprintf("ports can only be mapped for TCP or UDP, not protocol %d", err.Protocol)
+}
+
+type PortRangeOverflowError struct {
+	Mapping garden.PortMapping
+}
+
+func (err PortRangeOverflowError) Error() string {
+	return fmt.Sprintf(
+		"cannot map %d ports from host port %d to container port %d: ports stop at %d",
+		err.Mapping.PortCount, err.Mapping.HostPort, err.Mapping.ContainerPort, maxPort,
+	)
+}
+
+var ErrPortRangeWithoutHostPort = errors.New("container: a host port must be given to map a range of ports")
+
+var ErrPidsUnsupported = errors.New("container: the kernel has no pids cgroup to limit processes with")
+
+type UnknownNetOutRuleError struct {
+	Rule garden.NetOutRule
+}
+
+func (err UnknownNetOutRuleError) Error() string {
+	return fmt.Sprintf("no such net out rule: %+v", err.Rule)
+}
+
 type LinuxContainer struct {
 	logger lager.Logger
 
@@ -68,7 +112,12 @@ type LinuxContainer struct {
 	filter network.Filter
 
 	oomMutex    sync.RWMutex
-	oomNotifier *exec.Cmd
+	oomNotifier cgroups_manager.OomNotification
+
+	oomEvents       []garden.OomEvent
+	oomSubscribers  []*oomEventStream
+	oomEventsClosed bool
+	oomEventsMutex  sync.RWMutex
 
 	currentBandwidthLimits *garden.BandwidthLimits
 	bandwidthMutex         sync.RWMutex
@@ -82,6 +131,12 @@ type LinuxContainer struct {
 	currentCPULimits *garden.CPULimits
 	cpuMutex         sync.RWMutex
 
+	currentBlockIOLimits *garden.BlockIOLimits
+	blockIOMutex         sync.RWMutex
+
+	currentPidLimits *garden.PidLimits
+	pidMutex         sync.RWMutex
+
 	netIns      []NetInSpec
 	netInsMutex sync.RWMutex
 
@@ -93,6 +148,9 @@ type LinuxContainer struct {
 	env process.Env
 
 	processIDPool *ProcessIDPool
+
+	onChange      func()
+	onChangeMutex sync.RWMutex
 }
 
 type ProcessIDPool struct {
@@ -120,6 +178,15 @@ func (p *ProcessIDPool) Restore(id uint32) {
 type NetInSpec struct {
 	HostPort      uint32
 	ContainerPort uint32
+	Protocol      garden.Protocol
+	PortCount     uint32
+}
+
+const maxPort = 65535
+
+var portMappingProtocols = map[garden.Protocol]string{
+	garden.ProtocolTCP: "tcp",
+	garden.ProtocolUDP: "udp",
 }
 
 type PortPool interface {
@@ -134,6 +201,11 @@ const (
 	StateBorn    = State("born")
 	StateActive  = State("active")
 	StateStopped = State("stopped")
+	StatePaused  = State("paused")
+
+	// StateCheckpointed is the state of a container whose process tree has
+	// been dumped to its depot, to be resumed when it is next restored.
+	StateCheckpointed = State("checkpointed")
 )
 
 func NewLinuxContainer(
@@ -260,22 +332,26 @@ func (c *LinuxContainer) Snapshot(out io.Writer) error {
 
 		GraceTime: c.graceTime,
 
-		State:  string(c.State()),
-		Events: c.Events(),
+		State:     string(c.State()),
+		Events:    c.Events(),
+		OomEvents: c.OomEvents(),
 
 		Limits: LimitsSnapshot{
 			Bandwidth: c.currentBandwidthLimits,
 			CPU:       c.currentCPULimits,
+			BlockIO:   c.currentBlockIOLimits,
+			Pid:       c.currentPidLimits,
 			Disk:      c.currentDiskLimits,
 			Memory:    c.currentMemoryLimits,
 		},
 
 		Resources: ResourcesSnapshot{
-			UserUID: c.resources.UserUID,
-			RootUID: c.resources.RootUID,
-			Network: c.resources.Network,
-			Bridge:  c.resources.Bridge,
-			Ports:   c.resources.Ports,
+			UserUID:     c.resources.UserUID,
+			RootUID:     c.resources.RootUID,
+			Network:     c.resources.Network,
+			IPv6Network: c.resources.IPv6Network,
+			Bridge:      c.resources.Bridge,
+			Ports:       c.resources.Ports,
 		},
 
 		NetIns:  c.netIns,
@@ -317,6 +393,18 @@ func (c *LinuxContainer) Restore(snapshot ContainerSnapshot) error {
 
 	c.setState(State(snapshot.State))
 
+	if c.State() == StateCheckpointed {
+		resume := exec.Command(path.Join(c.path, "restore.sh"))
+
+		err := cRunner.Run(resume)
+		if err != nil {
+			cLog.Error("failed-to-resume-checkpoint", err)
+			return fmt.Errorf("container: resume checkpoint: %v", err)
+		}
+
+		c.setState(StateActive)
+	}
+
 	snapshotEnv, err := process.NewEnv(snapshot.EnvVars)
 	if err != nil {
 		cLog.Error("restoring-env", err, lager.Data{
@@ -327,15 +415,14 @@ func (c *LinuxContainer) Restore(snapshot ContainerSnapshot) error {
 	c.env = snapshotEnv
 
 	for _, ev := range snapshot.Events {
-		c.registerEvent(ev)
+		c.RegisterEvent(ev)
 	}
 
-	if snapshot.Limits.Memory != nil {
-		err := c.LimitMemory(*snapshot.Limits.Memory)
-		if err != nil {
-			cLog.Error("failed-to-limit-memory", err)
-			return err
-		}
+	c.restoreOomEvents(snapshot.OomEvents)
+
+	err = c.restoreLimits(cLog, snapshot.Limits)
+	if err != nil {
+		return err
 	}
 
 	for _, process := range snapshot.Processes {
@@ -365,7 +452,12 @@ func (c *LinuxContainer) Restore(snapshot ContainerSnapshot) error {
 	}
 
 	for _, in := range snapshot.NetIns {
-		_, _, err = c.NetIn(in.HostPort, in.ContainerPort)
+		_, err = c.NetInMapping(garden.PortMapping{
+			HostPort:      in.HostPort,
+			ContainerPort: in.ContainerPort,
+			Protocol:      in.Protocol,
+			PortCount:     in.PortCount,
+		})
 		if err != nil {
 			cLog.Error("failed-to-reenforce-port-mapping", err)
 			return err
@@ -379,6 +471,14 @@ func (c *LinuxContainer) Restore(snapshot ContainerSnapshot) error {
 		}
 	}
 
+	if c.State() == StatePaused {
+		err := c.freeze()
+		if err != nil {
+			cLog.Error("failed-to-refreeze", err)
+			return err
+		}
+	}
+
 	cLog.Info("restored")
 
 	return nil
@@ -413,16 +513,60 @@ func (c *LinuxContainer) Start() error {
 	return nil
 }
 
+func (c *LinuxContainer) Checkpoint() error {
+	cLog := c.logger.Session("checkpoint")
+
+	if state := c.State(); state != StateActive {
+		return InvalidStateError{"checkpoint", state}
+	}
+
+	cLog.Debug("checkpointing")
+
+	cRunner := logging.Runner{
+		CommandRunner: c.runner,
+		Logger:        cLog,
+	}
+
+	checkpoint := exec.Command(path.Join(c.path, "checkpoint.sh"))
+
+	err := cRunner.Run(checkpoint)
+	if err != nil {
+		cLog.Error("failed-to-checkpoint", err)
+		return fmt.Errorf("container: checkpoint: %v", err)
+	}
+
+	c.stopOomNotifier()
+
+	c.setState(StateCheckpointed)
+
+	cLog.Info("checkpointed")
+
+	c.changed()
+
+	return nil
+}
+
 func (c *LinuxContainer) Cleanup() {
 	cLog := c.logger.Session("cleanup")
 
 	cLog.Debug("stopping-oom-notifier")
 	c.stopOomNotifier()
 
+	cLog.Debug("closing-oom-event-streams")
+	c.closeOomEventStreams()
+
 	cLog.Info("done")
 }
 
 func (c *LinuxContainer) Stop(kill bool) error {
+	// frozen processes cannot handle the signals that stop them
+	if c.State() == StatePaused {
+		err := c.thaw()
+		if err != nil {
+			return err
+		}
+	}
+
 	stop := exec.Command(path.Join(c.path, "stop.sh"))
 
 	if kill {
@@ -438,6 +582,8 @@ func (c *LinuxContainer) Stop(kill bool) error {
 
 	c.setState(StateStopped)
 
+	c.changed()
+
 	return nil
 }
 
@@ -462,7 +608,6 @@ func (c *LinuxContainer) Property(key string) (string, error) {
 
 func (c *LinuxContainer) SetProperty(key string, value string) error {
 	c.propertiesMutex.Lock()
-	defer c.propertiesMutex.Unlock()
 
 	props := garden.Properties{}
 	for k, v := range c.properties {
@@ -473,19 +618,27 @@ func (c *LinuxContainer) SetProperty(key string, value string) error {
 
 	c.properties = props
 
+	c.propertiesMutex.Unlock()
+
+	c.changed()
+
 	return nil
 }
 
 func (c *LinuxContainer) RemoveProperty(key string) error {
 	c.propertiesMutex.Lock()
-	defer c.propertiesMutex.Unlock()
 
 	if _, found := c.properties[key]; !found {
+		c.propertiesMutex.Unlock()
 		return UndefinedPropertyError{key}
 	}
 
 	delete(c.properties, key)
 
+	c.propertiesMutex.Unlock()
+
+	c.changed()
+
 	return nil
 }
 
@@ -511,6 +664,8 @@ func (c *LinuxContainer) Info() (garden.ContainerInfo, error) {
 		mappedPorts = append(mappedPorts, garden.PortMapping{
 			HostPort:      spec.HostPort,
 			ContainerPort: spec.ContainerPort,
+			Protocol:      spec.Protocol,
+			PortCount:     spec.PortCount,
 		})
 	}
 
@@ -526,6 +681,7 @@ func (c *LinuxContainer) Info() (garden.ContainerInfo, error) {
 	info := garden.ContainerInfo{
 		State:         string(c.State()),
 		Events:        c.Events(),
+		OomEvents:     c.OomEvents(),
 		Properties:    properties,
 		ContainerPath: c.path,
 		ProcessIDs:    processIDs,
@@ -536,6 +692,11 @@ func (c *LinuxContainer) Info() (garden.ContainerInfo, error) {
 	info.HostIP = subnets.GatewayIP(c.resources.Network.Subnet).String()
 	info.ExternalIP = c.Resources().ExternalIP.String()
 
+	if c.resources.IPv6Network != nil {
+		info.ContainerIPv6 = c.resources.IPv6Network.IP.String()
+		info.HostIPv6 = subnets.GatewayIP(c.resources.IPv6Network.Subnet).String()
+	}
+
 	return info, nil
 }
 
@@ -624,39 +785,154 @@ func (c *LinuxContainer) StreamOut(srcPath string) (io.ReadCloser, error) {
 }
 
 func (c *LinuxContainer) NetIn(hostPort uint32, containerPort uint32) (uint32, uint32, error) {
-	if hostPort == 0 {
+	mapping, err := c.NetInMapping(garden.PortMapping{
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return mapping.HostPort, mapping.ContainerPort, nil
+}
+
+func (c *LinuxContainer) NetInMapping(mapping garden.PortMapping) (garden.PortMapping, error) {
+	if mapping.Protocol == garden.ProtocolAll {
+		mapping.Protocol = garden.ProtocolTCP
+	}
+
+	if _, ok := portMappingProtocols[mapping.Protocol]; !ok {
+		return garden.PortMapping{}, UnsupportedPortMappingProtocolError{mapping.Protocol}
+	}
+
+	if mapping.PortCount == 0 {
+		mapping.PortCount = 1
+	}
+
+	if mapping.PortCount > 1 && mapping.HostPort == 0 {
+		return garden.PortMapping{}, ErrPortRangeWithoutHostPort
+	}
+
+	if mapping.PortCount > maxPort ||
+		mapping.HostPort+mapping.PortCount-1 > maxPort ||
+		mapping.ContainerPort+mapping.PortCount-1 > maxPort {
+		return garden.PortMapping{}, PortRangeOverflowError{mapping}
+	}
+
+	if mapping.HostPort == 0 {
 		randomPort, err := c.portPool.Acquire()
 		if err != nil {
-			return 0, 0, err
+			return garden.PortMapping{}, err
 		}
 
 		c.resources.AddPort(randomPort)
 
-		hostPort = randomPort
+		mapping.HostPort = randomPort
 	}
 
-	if containerPort == 0 {
-		containerPort = hostPort
+	if mapping.ContainerPort == 0 {
+		mapping.ContainerPort = mapping.HostPort
 	}
 
-	net := exec.Command(path.Join(c.path, "net.sh"), "in")
-	net.Env = []string{
-		fmt.Sprintf("HOST_PORT=%d", hostPort),
-		fmt.Sprintf("CONTAINER_PORT=%d", containerPort),
-		"PATH=" + os.Getenv("PATH"),
+	spec := NetInSpec{
+		HostPort:      mapping.HostPort,
+		ContainerPort: mapping.ContainerPort,
+		Protocol:      mapping.Protocol,
+		PortCount:     mapping.PortCount,
 	}
 
-	err := c.runner.Run(net)
+	err := c.filter.NetIn(c.portForward(spec), c.ipv6IP())
 	if err != nil {
-		return 0, 0, err
+		return garden.PortMapping{}, err
+	}
+
+	c.netInsMutex.Lock()
+	c.netIns = append(c.netIns, spec)
+	c.netInsMutex.Unlock()
+
+	c.changed()
+
+	return mapping, nil
+}
+
+func (c *LinuxContainer) NetInRemove(mapping garden.PortMapping) error {
+	if mapping.Protocol == garden.ProtocolAll {
+		mapping.Protocol = garden.ProtocolTCP
 	}
 
+	if _, ok := portMappingProtocols[mapping.Protocol]; !ok {
+		return UnsupportedPortMappingProtocolError{mapping.Protocol}
+	}
+
+	hostPort := mapping.HostPort
+
 	c.netInsMutex.Lock()
-	defer c.netInsMutex.Unlock()
 
-	c.netIns = append(c.netIns, NetInSpec{hostPort, containerPort})
+	// the host port may still be mapped for the other protocol
+	stillMapped := false
+
+	var removing, kept []NetInSpec
+	for _, spec := range c.netIns {
+		if spec.HostPort == hostPort && spec.Protocol == mapping.Protocol {
+			removing = append(removing, spec)
+		} else {
+			kept = append(kept, spec)
+			stillMapped = stillMapped || spec.HostPort == hostPort
+		}
+	}
+
+	if len(removing) == 0 {
+		c.netInsMutex.Unlock()
+		return UnmappedPortError{hostPort, mapping.Protocol}
+	}
+
+	for i, spec := range removing {
+		err := c.filter.NetInRemove(c.portForward(spec), c.ipv6IP())
+		if err != nil {
+			// keep track of the mappings still in place
+			c.netIns = append(kept, removing[i:]...)
+			c.netInsMutex.Unlock()
+
+			if i > 0 {
+				c.changed()
+			}
+
+			return err
+		}
+	}
+
+	c.netIns = kept
+	c.netInsMutex.Unlock()
+
+	if !stillMapped && c.resources.RemovePort(hostPort) {
+		c.portPool.Release(hostPort)
+	}
+
+	c.changed()
+
+	return nil
+}
 
-	return hostPort, containerPort, nil
+// portForward is the forwarding of a port mapping from the container's
+// external IP to its IPv4 address.
+func (c *LinuxContainer) portForward(spec NetInSpec) iptables.PortForward {
+	return iptables.PortForward{
+		Protocol:      spec.Protocol,
+		Destination:   c.resources.ExternalIP,
+		HostPort:      spec.HostPort,
+		ContainerIP:   c.resources.Network.IP,
+		ContainerPort: spec.ContainerPort,
+		PortCount:     spec.PortCount,
+	}
+}
+
+// ipv6IP is the container's IPv6 address, or nil if it has none.
+func (c *LinuxContainer) ipv6IP() net.IP {
+	if c.resources.IPv6Network == nil {
+		return nil
+	}
+
+	return c.resources.IPv6Network.IP
 }
 
 func (c *LinuxContainer) NetOut(r garden.NetOutRule) error {
@@ -666,13 +942,62 @@ func (c *LinuxContainer) NetOut(r garden.NetOutRule) error {
 	}
 
 	c.netOutsMutex.Lock()
-	defer c.netOutsMutex.Unlock()
-
 	c.netOuts = append(c.netOuts, r)
+	c.netOutsMutex.Unlock()
+
+	c.changed()
+
+	return nil
+}
+
+// NetOutRemove removes a rule added with NetOut. If it was added more than
+// once, only one is removed.
+func (c *LinuxContainer) NetOutRemove(r garden.NetOutRule) error {
+	c.netOutsMutex.Lock()
+
+	index := -1
+	for i, rule := range c.netOuts {
+		if sameNetOutRule(rule, r) {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		c.netOutsMutex.Unlock()
+		return UnknownNetOutRuleError{r}
+	}
+
+	err := c.filter.NetOutRemove(c.netOuts[index])
+	if err != nil {
+		c.netOutsMutex.Unlock()
+		return err
+	}
+
+	c.netOuts = append(c.netOuts[:index], c.netOuts[index+1:]...)
+	c.netOutsMutex.Unlock()
+
+	c.changed()
 
 	return nil
 }
 
+// sameNetOutRule compares rules as they are sent over the wire, so that the
+// same IP in different forms, or a nil and an empty list, match.
+func sameNetOutRule(a, b garden.NetOutRule) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(aJSON, bJSON)
+}
+
 func (c *LinuxContainer) CurrentEnvVars() process.Env {
 	return c.env
 }
@@ -684,9 +1009,33 @@ func (c *LinuxContainer) setState(state State) {
 	c.state = state
 }
 
-func (c *LinuxContainer) registerEvent(event string) {
+// RegisterEvent records an event in the container's history, as reported in
+// its info.
+func (c *LinuxContainer) RegisterEvent(event string) {
 	c.eventsMutex.Lock()
-	defer c.eventsMutex.Unlock()
-
 	c.events = append(c.events, event)
+	c.eventsMutex.Unlock()
+
+	c.changed()
+}
+
+// OnChange registers a callback to run after each change to the container's
+// state, such as a new limit, property, port mapping or process.
+func (c *LinuxContainer) OnChange(callback func()) {
+	c.onChangeMutex.Lock()
+	defer c.onChangeMutex.Unlock()
+
+	c.onChange = callback
+}
+
+// changed must be called without holding any of the container's locks, as
+// the callback may snapshot the container.
+func (c *LinuxContainer) changed() {
+	c.onChangeMutex.RLock()
+	onChange := c.onChange
+	c.onChangeMutex.RUnlock()
+
+	if onChange != nil {
+		onChange()
+	}
 }
@@ -19,6 +19,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/linux_backend"
 	"github.com/cloudfoundry-incubator/garden-linux/linux_container"
 	networkFakes "github.com/cloudfoundry-incubator/garden-linux/network/fakes"
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager/fake_cgroups_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool/fake_port_pool"
@@ -253,14 +254,113 @@ var _ = Describe("Linux containers", func() {
 				err := container.Stop(false)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeRunner).To(HaveKilled(fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-				}))
+				Expect(fakeCgroups.OomNotifications()[0].IsStopped()).To(BeTrue())
 
 			})
 		})
 	})
 
+	Describe("Checkpointing", func() {
+		JustBeforeEach(func() {
+			Expect(container.Start()).To(Succeed())
+		})
+
+		It("executes the container's checkpoint.sh", func() {
+			err := container.Checkpoint()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: containerDir + "/checkpoint.sh",
+				},
+			))
+		})
+
+		It("sets the container's state to checkpointed", func() {
+			err := container.Checkpoint()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(container.State()).To(Equal(linux_container.StateCheckpointed))
+		})
+
+		It("reports the change", func() {
+			changed := false
+			container.OnChange(func() {
+				changed = true
+			})
+
+			Expect(container.Checkpoint()).To(Succeed())
+			Expect(changed).To(BeTrue())
+		})
+
+		Context("when the container has an oom notifier running", func() {
+			JustBeforeEach(func() {
+				err := container.LimitMemory(garden.MemoryLimits{
+					LimitInBytes: 42,
+				})
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("stops it", func() {
+				err := container.Checkpoint()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.OomNotifications()[0].IsStopped()).To(BeTrue())
+			})
+		})
+
+		Context("when checkpoint.sh fails", func() {
+			nastyError := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/checkpoint.sh",
+					}, func(*exec.Cmd) error {
+						return nastyError
+					},
+				)
+			})
+
+			It("returns a wrapped error", func() {
+				err := container.Checkpoint()
+				Expect(err).To(MatchError("container: checkpoint: oh no!"))
+			})
+
+			It("does not change the container's state", func() {
+				err := container.Checkpoint()
+				Expect(err).To(HaveOccurred())
+
+				Expect(container.State()).To(Equal(linux_container.StateActive))
+			})
+		})
+
+		Context("when the container is not active", func() {
+			It("returns an error without running checkpoint.sh", func() {
+				Expect(container.Pause()).To(Succeed())
+
+				err := container.Checkpoint()
+				Expect(err).To(Equal(linux_container.InvalidStateError{"checkpoint", linux_container.StatePaused}))
+
+				Expect(fakeRunner).ToNot(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/checkpoint.sh",
+					},
+				))
+			})
+		})
+
+		Context("when the container is already checkpointed", func() {
+			It("returns an error", func() {
+				Expect(container.Checkpoint()).To(Succeed())
+
+				err := container.Checkpoint()
+				Expect(err).To(Equal(linux_container.InvalidStateError{"checkpoint", linux_container.StateCheckpointed}))
+			})
+		})
+	})
+
 	Describe("Cleaning up", func() {
 		Context("when the container has an oom notifier running", func() {
 			JustBeforeEach(func() {
@@ -274,9 +374,7 @@ var _ = Describe("Linux containers", func() {
 			It("stops it", func() {
 				container.Cleanup()
 
-				Expect(fakeRunner).To(HaveKilled(fake_command_runner.CommandSpec{
-					Path: containerDir + "/bin/oom",
-				}))
+				Expect(fakeCgroups.OomNotifications()[0].IsStopped()).To(BeTrue())
 
 			})
 		})
@@ -424,26 +522,47 @@ var _ = Describe("Linux containers", func() {
 	})
 
 	Describe("Net in", func() {
-		It("executes net.sh in with HOST_PORT and CONTAINER_PORT", func() {
+		It("forwards the host port to the container port", func() {
 			hostPort, containerPort, err := container.NetIn(123, 456)
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(fakeRunner).To(HaveExecutedSerially(
-				fake_command_runner.CommandSpec{
-					Path: containerDir + "/net.sh",
-					Args: []string{"in"},
-					Env: []string{
-						"HOST_PORT=123",
-						"CONTAINER_PORT=456",
-						"PATH=" + os.Getenv("PATH"),
-					},
-				},
-			))
+			Expect(fakeFilter.NetInCallCount()).To(Equal(1))
+			forward, ipv6ContainerIP := fakeFilter.NetInArgsForCall(0)
+			Expect(forward).To(Equal(iptables.PortForward{
+				Protocol:      garden.ProtocolTCP,
+				HostPort:      123,
+				ContainerIP:   net.ParseIP("1.2.3.4"),
+				ContainerPort: 456,
+				PortCount:     1,
+			}))
+			Expect(ipv6ContainerIP).To(BeNil())
 
 			Expect(hostPort).To(Equal(uint32(123)))
 			Expect(containerPort).To(Equal(uint32(456)))
 		})
 
+		Context("when the container has an external IP and an IPv6 network", func() {
+			BeforeEach(func() {
+				_, subnet, err := net.ParseCIDR("fd00::/126")
+				Expect(err).ToNot(HaveOccurred())
+
+				containerResources.ExternalIP = net.ParseIP("5.6.7.8")
+				containerResources.IPv6Network = &linux_backend.Network{
+					IP:     net.ParseIP("fd00::1"),
+					Subnet: subnet,
+				}
+			})
+
+			It("forwards from the external IP, and to the IPv6 address too", func() {
+				_, _, err := container.NetIn(123, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				forward, ipv6ContainerIP := fakeFilter.NetInArgsForCall(0)
+				Expect(forward.Destination).To(Equal(net.ParseIP("5.6.7.8")))
+				Expect(ipv6ContainerIP).To(Equal(net.ParseIP("fd00::1")))
+			})
+		})
+
 		Context("when a host port is not provided", func() {
 			It("acquires one from the port pool", func() {
 				hostPort, containerPort, err := container.NetIn(0, 456)
@@ -479,17 +598,9 @@ var _ = Describe("Linux containers", func() {
 				hostPort, containerPort, err := container.NetIn(123, 0)
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeRunner).To(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: containerDir + "/net.sh",
-						Args: []string{"in"},
-						Env: []string{
-							"HOST_PORT=123",
-							"CONTAINER_PORT=123",
-							"PATH=" + os.Getenv("PATH"),
-						},
-					},
-				))
+				forward, _ := fakeFilter.NetInArgsForCall(0)
+				Expect(forward.HostPort).To(Equal(uint32(123)))
+				Expect(forward.ContainerPort).To(Equal(uint32(123)))
 
 				Expect(hostPort).To(Equal(uint32(123)))
 				Expect(containerPort).To(Equal(uint32(123)))
@@ -500,17 +611,9 @@ var _ = Describe("Linux containers", func() {
 					hostPort, containerPort, err := container.NetIn(0, 0)
 					Expect(err).ToNot(HaveOccurred())
 
-					Expect(fakeRunner).To(HaveExecutedSerially(
-						fake_command_runner.CommandSpec{
-							Path: containerDir + "/net.sh",
-							Args: []string{"in"},
-							Env: []string{
-								"HOST_PORT=1000",
-								"CONTAINER_PORT=1000",
-								"PATH=" + os.Getenv("PATH"),
-							},
-						},
-					))
+					forward, _ := fakeFilter.NetInArgsForCall(0)
+					Expect(forward.HostPort).To(Equal(uint32(1000)))
+					Expect(forward.ContainerPort).To(Equal(uint32(1000)))
 
 					Expect(hostPort).To(Equal(uint32(1000)))
 					Expect(containerPort).To(Equal(uint32(1000)))
@@ -518,23 +621,311 @@ var _ = Describe("Linux containers", func() {
 			})
 		})
 
-		Context("when net.sh fails", func() {
+		Context("when forwarding the port fails", func() {
 			disaster := errors.New("oh no!")
 
 			JustBeforeEach(func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: containerDir + "/net.sh",
-					}, func(*exec.Cmd) error {
-						return disaster
-					},
-				)
+				fakeFilter.NetInReturns(disaster)
 			})
 
 			It("returns the error", func() {
 				_, _, err := container.NetIn(123, 456)
 				Expect(err).To(Equal(disaster))
 			})
+
+			It("does not record the mapping", func() {
+				container.NetIn(123, 456)
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.MappedPorts).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Net in mapping", func() {
+		It("forwards the ports with the protocol", func() {
+			mapping, err := container.NetInMapping(garden.PortMapping{
+				HostPort:      123,
+				ContainerPort: 456,
+				Protocol:      garden.ProtocolUDP,
+				PortCount:     3,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			forward, _ := fakeFilter.NetInArgsForCall(0)
+			Expect(forward).To(Equal(iptables.PortForward{
+				Protocol:      garden.ProtocolUDP,
+				HostPort:      123,
+				ContainerIP:   net.ParseIP("1.2.3.4"),
+				ContainerPort: 456,
+				PortCount:     3,
+			}))
+
+			Expect(mapping).To(Equal(garden.PortMapping{
+				HostPort:      123,
+				ContainerPort: 456,
+				Protocol:      garden.ProtocolUDP,
+				PortCount:     3,
+			}))
+		})
+
+		It("defaults to a single TCP port", func() {
+			mapping, err := container.NetInMapping(garden.PortMapping{
+				HostPort:      123,
+				ContainerPort: 456,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(mapping).To(Equal(garden.PortMapping{
+				HostPort:      123,
+				ContainerPort: 456,
+				Protocol:      garden.ProtocolTCP,
+				PortCount:     1,
+			}))
+		})
+
+		Context("when a host port is not provided for a single port", func() {
+			It("acquires one from the port pool", func() {
+				mapping, err := container.NetInMapping(garden.PortMapping{
+					Protocol: garden.ProtocolUDP,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(mapping.HostPort).To(Equal(uint32(1000)))
+				Expect(mapping.ContainerPort).To(Equal(uint32(1000)))
+				Expect(container.Resources().Ports).To(ContainElement(uint32(1000)))
+			})
+		})
+
+		Context("when a host port is not provided for a range of ports", func() {
+			It("returns ErrPortRangeWithoutHostPort without acquiring a port", func() {
+				_, err := container.NetInMapping(garden.PortMapping{
+					ContainerPort: 456,
+					PortCount:     3,
+				})
+				Expect(err).To(Equal(linux_container.ErrPortRangeWithoutHostPort))
+
+				Expect(fakePortPool.Acquired).To(BeEmpty())
+				Expect(fakeFilter.NetInCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the protocol is neither TCP nor UDP", func() {
+			It("returns an UnsupportedPortMappingProtocolError", func() {
+				_, err := container.NetInMapping(garden.PortMapping{
+					HostPort: 123,
+					Protocol: garden.ProtocolICMP,
+				})
+				Expect(err).To(Equal(linux_container.UnsupportedPortMappingProtocolError{
+					Protocol: garden.ProtocolICMP,
+				}))
+
+				Expect(fakeFilter.NetInCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the range goes past the last port", func() {
+			It("returns a PortRangeOverflowError", func() {
+				mapping := garden.PortMapping{
+					HostPort:      123,
+					ContainerPort: 65530,
+					Protocol:      garden.ProtocolTCP,
+					PortCount:     10,
+				}
+
+				_, err := container.NetInMapping(mapping)
+				Expect(err).To(Equal(linux_container.PortRangeOverflowError{Mapping: mapping}))
+
+				Expect(fakeFilter.NetInCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Net in remove", func() {
+		It("removes the forwarding of each mapping of the host port", func() {
+			_, _, err := container.NetIn(123, 456)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = container.NetIn(123, 789)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = container.NetInRemove(garden.PortMapping{HostPort: 123})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFilter.NetInRemoveCallCount()).To(Equal(2))
+
+			forward, _ := fakeFilter.NetInRemoveArgsForCall(0)
+			added, _ := fakeFilter.NetInArgsForCall(0)
+			Expect(forward).To(Equal(added))
+			Expect(forward.ContainerPort).To(Equal(uint32(456)))
+
+			forward, _ = fakeFilter.NetInRemoveArgsForCall(1)
+			Expect(forward.ContainerPort).To(Equal(uint32(789)))
+		})
+
+		It("removes ranges of ports with their protocol", func() {
+			_, err := container.NetInMapping(garden.PortMapping{
+				HostPort:      123,
+				ContainerPort: 456,
+				Protocol:      garden.ProtocolUDP,
+				PortCount:     3,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = container.NetInRemove(garden.PortMapping{
+				HostPort: 123,
+				Protocol: garden.ProtocolUDP,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			forward, _ := fakeFilter.NetInRemoveArgsForCall(0)
+			Expect(forward).To(Equal(iptables.PortForward{
+				Protocol:      garden.ProtocolUDP,
+				HostPort:      123,
+				ContainerIP:   net.ParseIP("1.2.3.4"),
+				ContainerPort: 456,
+				PortCount:     3,
+			}))
+		})
+
+		It("stops reporting the mappings of the host port", func() {
+			_, _, err := container.NetIn(123, 456)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = container.NetIn(321, 654)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = container.NetInRemove(garden.PortMapping{HostPort: 123})
+			Expect(err).ToNot(HaveOccurred())
+
+			info, err := container.Info()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(info.MappedPorts).To(Equal([]garden.PortMapping{
+				{HostPort: 321, ContainerPort: 654, Protocol: garden.ProtocolTCP, PortCount: 1},
+			}))
+		})
+
+		Context("when the host port was acquired from the port pool", func() {
+			It("releases it back to the pool", func() {
+				hostPort, _, err := container.NetIn(0, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.NetInRemove(garden.PortMapping{HostPort: hostPort})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakePortPool.Released).To(ContainElement(hostPort))
+				Expect(container.Resources().Ports).ToNot(ContainElement(hostPort))
+			})
+
+			It("keeps it while it is mapped for the other protocol", func() {
+				hostPort, _, err := container.NetIn(0, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = container.NetInMapping(garden.PortMapping{
+					HostPort:      hostPort,
+					ContainerPort: 456,
+					Protocol:      garden.ProtocolUDP,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.NetInRemove(garden.PortMapping{HostPort: hostPort})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakePortPool.Released).ToNot(ContainElement(hostPort))
+				Expect(container.Resources().Ports).To(ContainElement(hostPort))
+			})
+		})
+
+		Context("when the host port was provided", func() {
+			It("does not release it to the port pool", func() {
+				_, _, err := container.NetIn(123, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.NetInRemove(garden.PortMapping{HostPort: 123})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakePortPool.Released).ToNot(ContainElement(uint32(123)))
+			})
+		})
+
+		Context("when the host port is not mapped", func() {
+			It("returns an UnmappedPortError", func() {
+				err := container.NetInRemove(garden.PortMapping{HostPort: 123})
+				Expect(err).To(Equal(linux_container.UnmappedPortError{
+					HostPort: 123,
+					Protocol: garden.ProtocolTCP,
+				}))
+			})
+		})
+
+		Context("when the host port is mapped for the other protocol", func() {
+			JustBeforeEach(func() {
+				_, err := container.NetInMapping(garden.PortMapping{
+					HostPort:      123,
+					ContainerPort: 456,
+					Protocol:      garden.ProtocolTCP,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = container.NetInMapping(garden.PortMapping{
+					HostPort:      123,
+					ContainerPort: 456,
+					Protocol:      garden.ProtocolUDP,
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("removes only the mapping for the given protocol", func() {
+				err := container.NetInRemove(garden.PortMapping{
+					HostPort: 123,
+					Protocol: garden.ProtocolUDP,
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.MappedPorts).To(Equal([]garden.PortMapping{
+					{HostPort: 123, ContainerPort: 456, Protocol: garden.ProtocolTCP, PortCount: 1},
+				}))
+			})
+		})
+
+		Context("when the protocol is neither TCP nor UDP", func() {
+			It("returns an UnsupportedPortMappingProtocolError", func() {
+				err := container.NetInRemove(garden.PortMapping{
+					HostPort: 123,
+					Protocol: garden.ProtocolICMP,
+				})
+				Expect(err).To(Equal(linux_container.UnsupportedPortMappingProtocolError{
+					Protocol: garden.ProtocolICMP,
+				}))
+			})
+		})
+
+		Context("when removing the forwarding fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeFilter.NetInRemoveReturns(disaster)
+			})
+
+			It("returns the error and keeps the mapping", func() {
+				_, _, err := container.NetIn(123, 456)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.NetInRemove(garden.PortMapping{HostPort: 123})
+				Expect(err).To(Equal(disaster))
+
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.MappedPorts).To(Equal([]garden.PortMapping{
+					{HostPort: 123, ContainerPort: 456, Protocol: garden.ProtocolTCP, PortCount: 1},
+				}))
+			})
 		})
 	})
 
@@ -563,6 +954,132 @@ var _ = Describe("Linux containers", func() {
 		})
 	})
 
+	Describe("Net out remove", func() {
+		var rule garden.NetOutRule
+
+		BeforeEach(func() {
+			rule = garden.NetOutRule{
+				Protocol: garden.ProtocolTCP,
+				Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))},
+			}
+		})
+
+		It("delegates to the filter", func() {
+			Expect(container.NetOut(rule)).To(Succeed())
+
+			err := container.NetOutRemove(rule)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFilter.NetOutRemoveCallCount()).To(Equal(1))
+			Expect(fakeFilter.NetOutRemoveArgsForCall(0)).To(Equal(rule))
+		})
+
+		It("forgets the rule", func() {
+			Expect(container.NetOut(rule)).To(Succeed())
+			Expect(container.NetOutRemove(rule)).To(Succeed())
+
+			err := container.NetOutRemove(rule)
+			Expect(err).To(Equal(linux_container.UnknownNetOutRuleError{Rule: rule}))
+		})
+
+		It("matches rules that describe the same traffic", func() {
+			Expect(container.NetOut(rule)).To(Succeed())
+
+			equivalent := garden.NetOutRule{
+				Protocol: garden.ProtocolTCP,
+				Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("1.2.3.4").To4())},
+			}
+
+			Expect(container.NetOutRemove(equivalent)).To(Succeed())
+		})
+
+		Context("when the rule was never added", func() {
+			It("returns an UnknownNetOutRuleError without touching the filter", func() {
+				err := container.NetOutRemove(rule)
+				Expect(err).To(Equal(linux_container.UnknownNetOutRuleError{Rule: rule}))
+
+				Expect(fakeFilter.NetOutRemoveCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the filter fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeFilter.NetOutRemoveReturns(disaster)
+			})
+
+			It("returns the error and keeps the rule", func() {
+				Expect(container.NetOut(rule)).To(Succeed())
+
+				err := container.NetOutRemove(rule)
+				Expect(err).To(Equal(disaster))
+
+				fakeFilter.NetOutRemoveReturns(nil)
+				Expect(container.NetOutRemove(rule)).To(Succeed())
+			})
+		})
+	})
+
+	Describe("reporting changes", func() {
+		var changes int
+
+		JustBeforeEach(func() {
+			changes = 0
+			container.OnChange(func() {
+				changes++
+			})
+		})
+
+		It("reports each change to the container's state", func() {
+			Expect(container.SetProperty("some-property", "some-value")).To(Succeed())
+			Expect(container.RemoveProperty("some-property")).To(Succeed())
+			Expect(container.NetOut(garden.NetOutRule{})).To(Succeed())
+			Expect(container.LimitCPU(garden.CPULimits{LimitInShares: 1})).To(Succeed())
+
+			_, _, err := container.NetIn(1, 2)
+			Expect(err).ToNot(HaveOccurred())
+
+			container.RegisterEvent("some-event")
+
+			Expect(changes).To(Equal(6))
+
+			Expect(container.NetInRemove(garden.PortMapping{HostPort: 1})).To(Succeed())
+			Expect(container.NetOutRemove(garden.NetOutRule{})).To(Succeed())
+
+			Expect(changes).To(Equal(8))
+		})
+
+		It("can snapshot the container from the callback", func() {
+			container.OnChange(func() {
+				Expect(container.Snapshot(ioutil.Discard)).To(Succeed())
+				changes++
+			})
+
+			Expect(container.SetProperty("some-property", "some-value")).To(Succeed())
+			Expect(changes).To(Equal(1))
+		})
+
+		It("does not report failed changes", func() {
+			fakeFilter.NetOutReturns(errors.New("oh no!"))
+
+			Expect(container.NetOut(garden.NetOutRule{})).ToNot(Succeed())
+			Expect(container.RemoveProperty("some-undefined-property")).ToNot(Succeed())
+
+			Expect(changes).To(Equal(0))
+		})
+
+		It("does not report inspecting the container", func() {
+			_, err := container.Properties()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = container.CurrentBandwidthLimits()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(changes).To(Equal(0))
+		})
+	})
+
 	Describe("Properties", func() {
 		Describe("CRUD", func() {
 			It("can get a property", func() {
@@ -714,6 +1231,34 @@ var _ = Describe("Linux containers", func() {
 			Expect(info.ContainerIP).To(Equal("1.2.3.4"))
 		})
 
+		It("does not return IPv6 network info when the container has none", func() {
+			info, err := container.Info()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(info.HostIPv6).To(BeEmpty())
+			Expect(info.ContainerIPv6).To(BeEmpty())
+		})
+
+		Context("when the container has an IPv6 network", func() {
+			BeforeEach(func() {
+				_, subnet, err := net.ParseCIDR("fd00::/126")
+				Expect(err).ToNot(HaveOccurred())
+
+				containerResources.IPv6Network = &linux_backend.Network{
+					IP:     net.ParseIP("fd00::1"),
+					Subnet: subnet,
+				}
+			})
+
+			It("returns the container's IPv6 network info", func() {
+				info, err := container.Info()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(info.HostIPv6).To(Equal("fd00::2"))
+				Expect(info.ContainerIPv6).To(Equal("fd00::1"))
+			})
+		})
+
 		It("returns the container's path", func() {
 			info, err := container.Info()
 			Expect(err).ToNot(HaveOccurred())
@@ -730,8 +1275,8 @@ var _ = Describe("Linux containers", func() {
 			info, err := container.Info()
 			Expect(err).ToNot(HaveOccurred())
 			Expect(info.MappedPorts).To(Equal([]garden.PortMapping{
-				{HostPort: 1234, ContainerPort: 5678},
-				{HostPort: 1235, ContainerPort: 5679},
+				{HostPort: 1234, ContainerPort: 5678, Protocol: garden.ProtocolTCP, PortCount: 1},
+				{HostPort: 1235, ContainerPort: 5679, Protocol: garden.ProtocolTCP, PortCount: 1},
 			}))
 
 		})
@@ -2,6 +2,8 @@ package linux_container
 
 import (
 	"bufio"
+	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -11,7 +13,7 @@ import (
 func (c *LinuxContainer) Metrics() (garden.Metrics, error) {
 	cLog := c.logger.Session("metrics")
 
-	diskStat, err := c.quotaManager.GetUsage(cLog, c.resources.UserUID)
+	diskStat, err := c.quotaManager.GetUsage(cLog, c.quotaSubject())
 	if err != nil {
 		return garden.Metrics{}, err
 	}
@@ -31,13 +33,41 @@ func (c *LinuxContainer) Metrics() (garden.Metrics, error) {
 		return garden.Metrics{}, err
 	}
 
+	ioServiceBytes, err := c.getOptionalStat("blkio", "blkio.throttle.io_service_bytes")
+	if err != nil {
+		return garden.Metrics{}, err
+	}
+
+	ioServiced, err := c.getOptionalStat("blkio", "blkio.throttle.io_serviced")
+	if err != nil {
+		return garden.Metrics{}, err
+	}
+
+	pidsCurrent, err := c.getOptionalStat("pids", "pids.current")
+	if err != nil {
+		return garden.Metrics{}, err
+	}
+
 	return garden.Metrics{
-		MemoryStat: parseMemoryStat(memoryStat),
-		CPUStat:    parseCPUStat(cpuUsage, cpuStat),
-		DiskStat:   diskStat,
+		MemoryStat:  parseMemoryStat(memoryStat),
+		CPUStat:     parseCPUStat(cpuUsage, cpuStat),
+		DiskStat:    diskStat,
+		BlockIOStat: parseBlockIOStat(ioServiceBytes, ioServiced),
+		PidStat:     parsePidStat(pidsCurrent),
 	}, nil
 }
 
+// getOptionalStat reads a stat file that kernels built without the feature,
+// or too old for the controller, do not provide, treating its absence as zero.
+func (c *LinuxContainer) getOptionalStat(subsystem, name string) (string, error) {
+	contents, err := c.cgroupsManager.Get(subsystem, name)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	return contents, err
+}
+
 func parseMemoryStat(contents string) (stat garden.ContainerMemoryStat) {
 	scanner := bufio.NewScanner(strings.NewReader(contents))
 
@@ -152,3 +182,65 @@ func parseCPUStat(usage, statContents string) (stat garden.ContainerCPUStat) {
 
 	return
 }
+
+func parsePidStat(current string) (stat garden.ContainerPidStat) {
+	pids, err := strconv.ParseUint(strings.TrimSpace(current), 10, 0)
+	if err != nil {
+		return
+	}
+
+	stat.Current = pids
+
+	return
+}
+
+// parseBlockIOStat reads the bytes and operations each device has serviced
+// from lines such as "8:0 Read 4096"; the throttle files count IO whichever
+// scheduler the device uses.
+func parseBlockIOStat(serviceBytes, serviced string) (stat garden.ContainerBlockIOStat) {
+	device := func(major, minor uint64) *garden.ContainerBlockIODeviceStat {
+		for i, d := range stat.Devices {
+			if d.Major == major && d.Minor == minor {
+				return &stat.Devices[i]
+			}
+		}
+
+		stat.Devices = append(stat.Devices, garden.ContainerBlockIODeviceStat{Major: major, Minor: minor})
+
+		return &stat.Devices[len(stat.Devices)-1]
+	}
+
+	parse := func(contents string, read, write func(*garden.ContainerBlockIODeviceStat) *uint64) {
+		scanner := bufio.NewScanner(strings.NewReader(contents))
+
+		for scanner.Scan() {
+			var major, minor, value uint64
+			var op string
+
+			// the final "Total" line names no device, and so does not match
+			_, err := fmt.Sscanf(scanner.Text(), "%d:%d %s %d", &major, &minor, &op, &value)
+			if err != nil {
+				continue
+			}
+
+			switch op {
+			case "Read":
+				*read(device(major, minor)) = value
+			case "Write":
+				*write(device(major, minor)) = value
+			}
+		}
+	}
+
+	parse(serviceBytes,
+		func(d *garden.ContainerBlockIODeviceStat) *uint64 { return &d.ReadBytes },
+		func(d *garden.ContainerBlockIODeviceStat) *uint64 { return &d.WriteBytes },
+	)
+
+	parse(serviced,
+		func(d *garden.ContainerBlockIODeviceStat) *uint64 { return &d.Reads },
+		func(d *garden.ContainerBlockIODeviceStat) *uint64 { return &d.Writes },
+	)
+
+	return
+}
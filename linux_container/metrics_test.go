@@ -3,6 +3,8 @@ package linux_container_test
 import (
 	"errors"
 	"net"
+	"os"
+	"syscall"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -210,6 +212,141 @@ system 2
 			})
 		})
 
+		Describe("block io info", func() {
+			BeforeEach(func() {
+				fakeCgroups.WhenGetting("blkio", "blkio.throttle.io_service_bytes", func() (string, error) {
+					return `8:0 Read 4096
+8:0 Write 8192
+8:0 Sync 8192
+8:0 Async 4096
+8:0 Total 12288
+8:16 Read 1024
+8:16 Write 0
+8:16 Sync 0
+8:16 Async 1024
+8:16 Total 1024
+Total 13312
+`, nil
+				})
+
+				fakeCgroups.WhenGetting("blkio", "blkio.throttle.io_serviced", func() (string, error) {
+					return `8:0 Read 1
+8:0 Write 2
+8:0 Sync 2
+8:0 Async 1
+8:0 Total 3
+8:16 Read 1
+8:16 Write 0
+8:16 Sync 0
+8:16 Async 1
+8:16 Total 1
+Total 4
+`, nil
+				})
+			})
+
+			It("is returned in the response", func() {
+				metrics, err := container.Metrics()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metrics.BlockIOStat).To(Equal(garden.ContainerBlockIOStat{
+					Devices: []garden.ContainerBlockIODeviceStat{
+						{
+							Major:      8,
+							Minor:      0,
+							ReadBytes:  4096,
+							WriteBytes: 8192,
+							Reads:      1,
+							Writes:     2,
+						},
+						{
+							Major:      8,
+							Minor:      16,
+							ReadBytes:  1024,
+							WriteBytes: 0,
+							Reads:      1,
+							Writes:     0,
+						},
+					},
+				}))
+			})
+		})
+
+		Context("when getting blkio/blkio.throttle.io_service_bytes fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("blkio", "blkio.throttle.io_service_bytes", func() (string, error) {
+					return "", disaster
+				})
+			})
+
+			It("returns an error", func() {
+				_, err := container.Metrics()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when the kernel does not provide the blkio throttle files", func() {
+			JustBeforeEach(func() {
+				for _, name := range []string{"blkio.throttle.io_service_bytes", "blkio.throttle.io_serviced"} {
+					missing := &os.PathError{Op: "open", Path: name, Err: syscall.ENOENT}
+
+					fakeCgroups.WhenGetting("blkio", name, func() (string, error) {
+						return "", missing
+					})
+				}
+			})
+
+			It("reports no block io", func() {
+				metrics, err := container.Metrics()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metrics.BlockIOStat).To(Equal(garden.ContainerBlockIOStat{}))
+			})
+		})
+
+		Describe("pid info", func() {
+			It("is returned in the response", func() {
+				fakeCgroups.WhenGetting("pids", "pids.current", func() (string, error) {
+					return "42", nil
+				})
+
+				metrics, err := container.Metrics()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metrics.PidStat).To(Equal(garden.ContainerPidStat{
+					Current: 42,
+				}))
+			})
+		})
+
+		Context("when getting pids/pids.current fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("pids", "pids.current", func() (string, error) {
+					return "", disaster
+				})
+			})
+
+			It("returns an error", func() {
+				_, err := container.Metrics()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when the kernel has no pids cgroup", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("pids", "pids.current", func() (string, error) {
+					return "", &os.PathError{Op: "open", Path: "pids.current", Err: syscall.ENOENT}
+				})
+			})
+
+			It("reports no pids", func() {
+				metrics, err := container.Metrics()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metrics.PidStat).To(Equal(garden.ContainerPidStat{}))
+			})
+		})
+
 		Describe("disk usage info", func() {
 			It("is returned in the response", func() {
 				fakeQuotaManager.GetUsageResult = garden.ContainerDiskStat{
@@ -0,0 +1,303 @@
+package linux_container
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
+)
+
+var netOutProtocols = map[garden.Protocol]string{
+	garden.ProtocolAll:  "", // iptables -S leaves out "-p all"
+	garden.ProtocolTCP:  "tcp",
+	garden.ProtocolUDP:  "udp",
+	garden.ProtocolICMP: "icmp",
+}
+
+// ip6tables -S names ICMPv6 after /etc/protocols, or icmpv6 without it
+var ipv6ICMPProtocols = []string{"ipv6-icmp", "icmpv6"}
+
+func (c *LinuxContainer) NetworkState() (garden.NetworkState, error) {
+	cLog := c.logger.Session("network-state")
+
+	rules, err := c.filter.Rules()
+	if err != nil {
+		cLog.Error("failed-to-list-rules", err)
+		return garden.NetworkState{}, err
+	}
+
+	qdiscs, err := c.bandwidthManager.Qdiscs(cLog)
+	if err != nil {
+		return garden.NetworkState{}, err
+	}
+
+	c.netInsMutex.RLock()
+	netIns := append([]NetInSpec{}, c.netIns...)
+	c.netInsMutex.RUnlock()
+
+	c.netOutsMutex.RLock()
+	netOuts := append([]garden.NetOutRule{}, c.netOuts...)
+	c.netOutsMutex.RUnlock()
+
+	// every log chain rule is in the log chain, which Setup never leaves empty
+	logChain := ""
+	if len(rules.Log) > 0 {
+		logChain = rules.Log[0].Chain
+	}
+
+	drift := natDrift(netIns, c.resources.Network.IP, rules.NAT)
+	drift = append(drift, filterDrift(netOuts, logChain, rules.Instance, false)...)
+
+	if ipv6IP := c.ipv6IP(); ipv6IP != nil {
+		drift = append(drift, natDrift(netIns, ipv6IP, rules.IPv6NAT)...)
+		drift = append(drift, filterDrift(netOuts, logChain, rules.IPv6Instance, true)...)
+	}
+
+	return garden.NetworkState{
+		InstanceChain:     rules.Instance,
+		LogChain:          rules.Log,
+		NATChain:          rules.NAT,
+		Qdiscs:            qdiscs,
+		IPv6InstanceChain: rules.IPv6Instance,
+		IPv6NATChain:      rules.IPv6NAT,
+		Drift:             drift,
+	}, nil
+}
+
+// natDrift compares the DNAT rules the filter adds for each port mapping with
+// the DNAT rules in the container's NAT chain for the address family of
+// containerIP.
+func natDrift(netIns []NetInSpec, containerIP net.IP, rules []garden.IPTablesRule) []garden.NetworkDrift {
+	drift := []garden.NetworkDrift{}
+	matched := make([]bool, len(rules))
+
+	for _, spec := range netIns {
+		mapping := garden.PortMapping{
+			HostPort:      spec.HostPort,
+			ContainerPort: spec.ContainerPort,
+			Protocol:      spec.Protocol,
+			PortCount:     spec.PortCount,
+		}
+
+		forward := iptables.PortForward{
+			Protocol:      spec.Protocol,
+			HostPort:      spec.HostPort,
+			ContainerIP:   containerIP,
+			ContainerPort: spec.ContainerPort,
+			PortCount:     spec.PortCount,
+		}
+
+		for _, dnat := range iptables.DNATTargets(forward) {
+			dnat := dnat
+
+			found := matchRule(rules, matched, func(r garden.IPTablesRule) bool {
+				return r.Target == "DNAT" &&
+					r.Protocol == portMappingProtocols[spec.Protocol] &&
+					r.DestinationPorts == dnat.DestinationPorts &&
+					r.ToDestination == dnat.ToDestination
+			})
+
+			if !found {
+				drift = append(drift, garden.NetworkDrift{
+					NetIn:   &mapping,
+					Message: fmt.Sprintf("no DNAT rule from host port %s to %s", dnat.DestinationPorts, dnat.ToDestination),
+				})
+			}
+		}
+	}
+
+	for i, r := range rules {
+		if r.Target == "DNAT" && !matched[i] {
+			rule := r
+			drift = append(drift, garden.NetworkDrift{
+				Unexpected: &rule,
+				Message:    "DNAT rule for no port mapping",
+			})
+		}
+	}
+
+	return drift
+}
+
+// filterDrift compares the rules the filter inserts for each NetOut rule with
+// the rules in the container's instance chain for one address family which
+// return or go to the log chain. The instance chain's other rules are set up
+// by net.sh. The IPv6 log chain has the same name as the IPv4 one.
+func filterDrift(netOuts []garden.NetOutRule, logChain string, rules []garden.IPTablesRule, ipv6 bool) []garden.NetworkDrift {
+	drift := []garden.NetworkDrift{}
+	matched := make([]bool, len(rules))
+
+	tool := "iptables"
+	if ipv6 {
+		tool = "ip6tables"
+	}
+
+	for _, netOut := range netOuts {
+		netOut := netOut
+
+		networks := ipv4Networks(netOut.Networks)
+		if ipv6 {
+			networks = ipv6Networks(netOut.Networks)
+		}
+
+		for _, network := range networks {
+			for _, ports := range portRanges(netOut.Ports) {
+				destination := iptablesDestination(network, ipv6)
+				destinationPorts := iptablesPorts(ports)
+
+				found := matchRule(rules, matched, func(r garden.IPTablesRule) bool {
+					return isNetOutProtocol(r, netOut.Protocol, ipv6) &&
+						r.Destination == destination &&
+						r.DestinationPorts == destinationPorts &&
+						isNetOutTarget(r, netOut.Log, logChain)
+				})
+
+				if !found {
+					drift = append(drift, garden.NetworkDrift{
+						NetOut:  &netOut,
+						Message: fmt.Sprintf("no %s rule allowing destination %q ports %q", tool, destination, destinationPorts),
+					})
+				}
+			}
+		}
+	}
+
+	for i, r := range rules {
+		if matched[i] {
+			continue
+		}
+
+		if isNetOutTarget(r, false, logChain) || isNetOutTarget(r, true, logChain) {
+			rule := r
+			drift = append(drift, garden.NetworkDrift{
+				Unexpected: &rule,
+				Message:    tool + " rule for no net out rule",
+			})
+		}
+	}
+
+	return drift
+}
+
+func isNetOutProtocol(r garden.IPTablesRule, protocol garden.Protocol, ipv6 bool) bool {
+	if ipv6 && protocol == garden.ProtocolICMP {
+		for _, name := range ipv6ICMPProtocols {
+			if r.Protocol == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return r.Protocol == netOutProtocols[protocol]
+}
+
+func isNetOutTarget(r garden.IPTablesRule, log bool, logChain string) bool {
+	if log {
+		return r.Goto && logChain != "" && r.Target == logChain
+	}
+
+	return !r.Goto && r.Target == "RETURN"
+}
+
+// matchRule marks the first unmatched rule satisfying match as matched,
+// reporting whether there was one.
+func matchRule(rules []garden.IPTablesRule, matched []bool, match func(garden.IPTablesRule) bool) bool {
+	for i, r := range rules {
+		if !matched[i] && match(r) {
+			matched[i] = true
+			return true
+		}
+	}
+
+	return false
+}
+
+// ipv4Networks returns the IPv4 networks of a rule, or a single nil network
+// matching any destination if it names none. The rule's IPv6 networks are in
+// the IPv6 instance chain.
+func ipv4Networks(networks []garden.IPRange) []*garden.IPRange {
+	if len(networks) == 0 {
+		return []*garden.IPRange{nil}
+	}
+
+	var ipv4 []*garden.IPRange
+	for i, network := range networks {
+		if isIPv6(network.Start) || isIPv6(network.End) {
+			continue
+		}
+
+		ipv4 = append(ipv4, &networks[i])
+	}
+
+	return ipv4
+}
+
+// ipv6Networks returns the IPv6 networks of a rule, or a single nil network
+// matching any destination if it names none.
+func ipv6Networks(networks []garden.IPRange) []*garden.IPRange {
+	if len(networks) == 0 {
+		return []*garden.IPRange{nil}
+	}
+
+	var ipv6 []*garden.IPRange
+	for i, network := range networks {
+		if isIPv6(network.Start) || isIPv6(network.End) {
+			ipv6 = append(ipv6, &networks[i])
+		}
+	}
+
+	return ipv6
+}
+
+func portRanges(ports []garden.PortRange) []*garden.PortRange {
+	if len(ports) == 0 {
+		return []*garden.PortRange{nil}
+	}
+
+	ranges := make([]*garden.PortRange, len(ports))
+	for i := range ports {
+		ranges[i] = &ports[i]
+	}
+
+	return ranges
+}
+
+// iptablesDestination prints a network as iptables -S, or ip6tables -S, prints
+// the destination the filter gave for it.
+func iptablesDestination(network *garden.IPRange, ipv6 bool) string {
+	hostMask := "/32"
+	if ipv6 {
+		hostMask = "/128"
+	}
+
+	switch {
+	case network == nil:
+		return ""
+	case network.Start != nil && network.End != nil:
+		return network.Start.String() + "-" + network.End.String()
+	case network.Start != nil:
+		return network.Start.String() + hostMask
+	case network.End != nil:
+		return network.End.String() + hostMask
+	}
+
+	return ""
+}
+
+func iptablesPorts(ports *garden.PortRange) string {
+	switch {
+	case ports == nil:
+		return ""
+	case ports.Start == ports.End:
+		return fmt.Sprintf("%d", ports.Start)
+	}
+
+	return fmt.Sprintf("%d:%d", ports.Start, ports.End)
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil
+}
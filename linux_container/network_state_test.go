@@ -0,0 +1,341 @@
+package linux_container_test
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/linux_backend"
+	"github.com/cloudfoundry-incubator/garden-linux/linux_container"
+	"github.com/cloudfoundry-incubator/garden-linux/network"
+	networkFakes "github.com/cloudfoundry-incubator/garden-linux/network/fakes"
+	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager/fake_cgroups_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool/fake_port_pool"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/process"
+	"github.com/cloudfoundry-incubator/garden-linux/process_tracker/fake_process_tracker"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+)
+
+var _ = Describe("Linux containers", func() {
+	var fakeFilter *networkFakes.FakeFilter
+	var fakeBandwidthManager *fake_bandwidth_manager.FakeBandwidthManager
+	var containerResources *linux_backend.Resources
+	var container *linux_container.LinuxContainer
+
+	BeforeEach(func() {
+		fakeFilter = new(networkFakes.FakeFilter)
+		fakeBandwidthManager = fake_bandwidth_manager.New()
+
+		_, subnet, _ := net.ParseCIDR("1.2.3.0/30")
+		containerResources = linux_backend.NewResources(
+			1234,
+			1235,
+			&linux_backend.Network{
+				IP:     net.ParseIP("1.2.3.4"),
+				Subnet: subnet,
+			},
+			"some-bridge",
+			[]uint32{},
+			nil,
+		)
+
+		container = linux_container.NewLinuxContainer(
+			lagertest.NewTestLogger("test"),
+			"some-id",
+			"some-handle",
+			"/depot/some-id",
+			nil,
+			1*time.Second,
+			containerResources,
+			fake_port_pool.New(1000),
+			fake_command_runner.New(),
+			fake_cgroups_manager.New("/cgroups", "some-id"),
+			fake_quota_manager.New(),
+			fakeBandwidthManager,
+			new(fake_process_tracker.FakeProcessTracker),
+			process.Env{},
+			fakeFilter,
+		)
+	})
+
+	Describe("NetworkState", func() {
+		logRule := garden.IPTablesRule{Table: "filter", Chain: "w--instance-some-id-log", Target: "LOG"}
+
+		dnat := func(hostPort, toDestination string) garden.IPTablesRule {
+			return garden.IPTablesRule{
+				Table:            "nat",
+				Chain:            "w--instance-some-id",
+				Protocol:         "tcp",
+				DestinationPorts: hostPort,
+				Target:           "DNAT",
+				ToDestination:    toDestination,
+			}
+		}
+
+		BeforeEach(func() {
+			fakeBandwidthManager.QdiscsResult = []garden.Qdisc{
+				{Device: "w-some-id-0", Kind: "tbf", Handle: "1:", Parent: "root"},
+			}
+		})
+
+		It("reports the rules and qdiscs in place", func() {
+			rules := network.FilterRules{
+				Instance: []garden.IPTablesRule{{Table: "filter", Chain: "w--instance-some-id", Target: "w--default"}},
+				Log:      []garden.IPTablesRule{logRule},
+				NAT:      []garden.IPTablesRule{},
+			}
+			fakeFilter.RulesReturns(rules, nil)
+
+			state, err := container.NetworkState()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(state.InstanceChain).To(Equal(rules.Instance))
+			Expect(state.LogChain).To(Equal(rules.Log))
+			Expect(state.NATChain).To(BeEmpty())
+			Expect(state.Qdiscs).To(Equal(fakeBandwidthManager.QdiscsResult))
+			Expect(state.Drift).To(BeEmpty())
+		})
+
+		Context("with port mappings", func() {
+			BeforeEach(func() {
+				_, err := container.NetInMapping(garden.PortMapping{HostPort: 1000, ContainerPort: 80, PortCount: 2})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("reports no drift when every port has its DNAT rule", func() {
+				fakeFilter.RulesReturns(network.FilterRules{
+					Log: []garden.IPTablesRule{logRule},
+					NAT: []garden.IPTablesRule{dnat("1001", "1.2.3.4:81"), dnat("1000", "1.2.3.4:80")},
+				}, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(BeEmpty())
+			})
+
+			It("reports ports missing their DNAT rule and DNAT rules for no mapping", func() {
+				stray := dnat("2000", "1.2.3.4:2000")
+				fakeFilter.RulesReturns(network.FilterRules{
+					Log: []garden.IPTablesRule{logRule},
+					NAT: []garden.IPTablesRule{dnat("1000", "1.2.3.4:80"), stray},
+				}, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(HaveLen(2))
+
+				Expect(state.Drift[0].NetIn).To(Equal(&garden.PortMapping{
+					HostPort:      1000,
+					ContainerPort: 80,
+					Protocol:      garden.ProtocolTCP,
+					PortCount:     2,
+				}))
+				Expect(state.Drift[0].Message).To(ContainSubstring("1001"))
+
+				Expect(state.Drift[1].Unexpected).To(Equal(&stray))
+			})
+		})
+
+		Context("with a range of ports mapped to the same ports", func() {
+			BeforeEach(func() {
+				_, err := container.NetInMapping(garden.PortMapping{HostPort: 2000, ContainerPort: 2000, PortCount: 3})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("expects a single DNAT rule for the range", func() {
+				fakeFilter.RulesReturns(network.FilterRules{
+					Log: []garden.IPTablesRule{logRule},
+					NAT: []garden.IPTablesRule{dnat("2000:2002", "1.2.3.4")},
+				}, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(BeEmpty())
+			})
+		})
+
+		Context("with net out rules", func() {
+			BeforeEach(func() {
+				Expect(container.NetOut(garden.NetOutRule{
+					Protocol: garden.ProtocolTCP,
+					Networks: []garden.IPRange{
+						garden.IPRangeFromIP(net.ParseIP("8.8.8.8")),
+						garden.IPRangeFromIP(net.ParseIP("fd00::1")),
+					},
+					Ports: []garden.PortRange{garden.PortRangeFromPort(53)},
+				})).To(Succeed())
+
+				Expect(container.NetOut(garden.NetOutRule{
+					Networks: []garden.IPRange{{Start: net.ParseIP("10.0.0.1"), End: net.ParseIP("10.0.0.9")}},
+					Log:      true,
+				})).To(Succeed())
+			})
+
+			tcpRule := garden.IPTablesRule{
+				Table:            "filter",
+				Chain:            "w--instance-some-id",
+				Protocol:         "tcp",
+				Destination:      "8.8.8.8-8.8.8.8",
+				DestinationPorts: "53",
+				Target:           "RETURN",
+			}
+
+			loggedRule := garden.IPTablesRule{
+				Table:       "filter",
+				Chain:       "w--instance-some-id",
+				Destination: "10.0.0.1-10.0.0.9",
+				Target:      "w--instance-some-id-log",
+				Goto:        true,
+			}
+
+			It("reports no drift when every IPv4 rule is in the instance chain", func() {
+				fakeFilter.RulesReturns(network.FilterRules{
+					Instance: []garden.IPTablesRule{loggedRule, tcpRule},
+					Log:      []garden.IPTablesRule{logRule},
+				}, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(BeEmpty())
+			})
+
+			It("reports net out rules missing from the instance chain and rules for no net out rule", func() {
+				stray := tcpRule
+				stray.DestinationPorts = "54"
+
+				fakeFilter.RulesReturns(network.FilterRules{
+					Instance: []garden.IPTablesRule{tcpRule, stray},
+					Log:      []garden.IPTablesRule{logRule},
+				}, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(HaveLen(2))
+
+				Expect(state.Drift[0].NetOut).ToNot(BeNil())
+				Expect(state.Drift[0].NetOut.Log).To(BeTrue())
+				Expect(state.Drift[0].Message).To(ContainSubstring("10.0.0.1-10.0.0.9"))
+
+				Expect(state.Drift[1].Unexpected).To(Equal(&stray))
+			})
+		})
+
+		Context("with an IPv6 network", func() {
+			tcpRule := garden.IPTablesRule{
+				Table:            "filter",
+				Chain:            "w--instance-some-id",
+				Protocol:         "tcp",
+				Destination:      "8.8.8.8-8.8.8.8",
+				DestinationPorts: "53",
+				Target:           "RETURN",
+			}
+
+			tcp6Rule := tcpRule
+			tcp6Rule.Destination = "fd00::53-fd00::53"
+
+			icmpRule := garden.IPTablesRule{
+				Table:    "filter",
+				Chain:    "w--instance-some-id",
+				Protocol: "icmp",
+				Target:   "RETURN",
+			}
+
+			icmp6Rule := icmpRule
+			icmp6Rule.Protocol = "ipv6-icmp"
+
+			var ipv4Rules network.FilterRules
+
+			BeforeEach(func() {
+				_, subnet, _ := net.ParseCIDR("fd00::/126")
+				containerResources.IPv6Network = &linux_backend.Network{
+					IP:     net.ParseIP("fd00::1"),
+					Subnet: subnet,
+				}
+
+				_, err := container.NetInMapping(garden.PortMapping{HostPort: 1000, ContainerPort: 80})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.NetOut(garden.NetOutRule{
+					Protocol: garden.ProtocolTCP,
+					Networks: []garden.IPRange{
+						garden.IPRangeFromIP(net.ParseIP("8.8.8.8")),
+						garden.IPRangeFromIP(net.ParseIP("fd00::53")),
+					},
+					Ports: []garden.PortRange{garden.PortRangeFromPort(53)},
+				})).To(Succeed())
+
+				Expect(container.NetOut(garden.NetOutRule{Protocol: garden.ProtocolICMP})).To(Succeed())
+
+				ipv4Rules = network.FilterRules{
+					Instance: []garden.IPTablesRule{icmpRule, tcpRule},
+					Log:      []garden.IPTablesRule{logRule},
+					NAT:      []garden.IPTablesRule{dnat("1000", "1.2.3.4:80")},
+				}
+			})
+
+			It("reports the ip6tables chains and no drift when every IPv6 rule is in place", func() {
+				rules := ipv4Rules
+				rules.IPv6Instance = []garden.IPTablesRule{icmp6Rule, tcp6Rule}
+				rules.IPv6NAT = []garden.IPTablesRule{dnat("1000", "[fd00::1]:80")}
+				fakeFilter.RulesReturns(rules, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(state.IPv6InstanceChain).To(Equal(rules.IPv6Instance))
+				Expect(state.IPv6NATChain).To(Equal(rules.IPv6NAT))
+				Expect(state.Drift).To(BeEmpty())
+			})
+
+			It("reports port mappings and net out rules missing from the ip6tables chains", func() {
+				stray := dnat("2000", "[fd00::1]:2000")
+
+				rules := ipv4Rules
+				rules.IPv6NAT = []garden.IPTablesRule{stray}
+				fakeFilter.RulesReturns(rules, nil)
+
+				state, err := container.NetworkState()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(state.Drift).To(HaveLen(4))
+
+				Expect(state.Drift[0].NetIn).ToNot(BeNil())
+				Expect(state.Drift[0].Message).To(ContainSubstring("[fd00::1]:80"))
+
+				Expect(state.Drift[1].Unexpected).To(Equal(&stray))
+
+				Expect(state.Drift[2].NetOut.Protocol).To(Equal(garden.ProtocolTCP))
+				Expect(state.Drift[2].Message).To(ContainSubstring("no ip6tables rule"))
+				Expect(state.Drift[2].Message).To(ContainSubstring("fd00::53-fd00::53"))
+
+				Expect(state.Drift[3].NetOut.Protocol).To(Equal(garden.ProtocolICMP))
+			})
+		})
+
+		Context("when listing the rules fails", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeFilter.RulesReturns(network.FilterRules{}, disaster)
+
+				_, err := container.NetworkState()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when listing the qdiscs fails", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeBandwidthManager.QdiscsError = disaster
+
+				_, err := container.NetworkState()
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+})
@@ -0,0 +1,149 @@
+package linux_container
+
+import (
+	"io"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// how many events a slow subscriber may fall behind before missing some
+const oomEventStreamBuffer = 16
+
+// how many of its latest events a container keeps, as each holds its memory
+// stats and is snapshotted with it
+const oomEventHistory = 10
+
+func (c *LinuxContainer) OomEvents() []garden.OomEvent {
+	c.oomEventsMutex.RLock()
+	defer c.oomEventsMutex.RUnlock()
+
+	events := make([]garden.OomEvent, len(c.oomEvents))
+
+	copy(events, c.oomEvents)
+
+	return events
+}
+
+// StreamOomEvents subscribes to the out of memory events that happen from
+// now on; those that happened before are in the container's info.
+func (c *LinuxContainer) StreamOomEvents() (garden.OomEventStream, error) {
+	c.oomEventsMutex.Lock()
+	defer c.oomEventsMutex.Unlock()
+
+	stream := &oomEventStream{
+		container: c,
+		events:    make(chan garden.OomEvent, oomEventStreamBuffer),
+	}
+
+	if c.oomEventsClosed {
+		close(stream.events)
+		return stream, nil
+	}
+
+	c.oomSubscribers = append(c.oomSubscribers, stream)
+
+	return stream, nil
+}
+
+func (c *LinuxContainer) recordOomEvent(policy garden.OomPolicy) {
+	cLog := c.logger.Session("oom")
+
+	event := garden.OomEvent{
+		Time:   time.Now(),
+		Policy: policy,
+	}
+
+	memoryStat, err := c.cgroupsManager.Get("memory", "memory.stat")
+	if err != nil {
+		cLog.Error("failed-to-get-memory-stat", err)
+	} else {
+		event.MemoryStat = parseMemoryStat(memoryStat)
+	}
+
+	c.oomEventsMutex.Lock()
+
+	c.oomEvents = latestOomEvents(append(c.oomEvents, event))
+
+	for _, subscriber := range c.oomSubscribers {
+		select {
+		case subscriber.events <- event:
+		default:
+			cLog.Info("subscriber-fell-behind", lager.Data{
+				"event": event,
+			})
+		}
+	}
+
+	c.oomEventsMutex.Unlock()
+
+	c.changed()
+}
+
+func (c *LinuxContainer) restoreOomEvents(events []garden.OomEvent) {
+	c.oomEventsMutex.Lock()
+	defer c.oomEventsMutex.Unlock()
+
+	c.oomEvents = latestOomEvents(append(c.oomEvents, events...))
+}
+
+// latestOomEvents drops the oldest events beyond the container's history,
+// reusing the slice so that it never grows past it.
+func latestOomEvents(events []garden.OomEvent) []garden.OomEvent {
+	if len(events) <= oomEventHistory {
+		return events
+	}
+
+	dropped := len(events) - oomEventHistory
+
+	copy(events, events[dropped:])
+
+	return events[:oomEventHistory]
+}
+
+func (c *LinuxContainer) closeOomEventStreams() {
+	c.oomEventsMutex.Lock()
+	defer c.oomEventsMutex.Unlock()
+
+	for _, subscriber := range c.oomSubscribers {
+		close(subscriber.events)
+	}
+
+	c.oomSubscribers = nil
+	c.oomEventsClosed = true
+}
+
+func (c *LinuxContainer) unsubscribeFromOomEvents(stream *oomEventStream) {
+	c.oomEventsMutex.Lock()
+	defer c.oomEventsMutex.Unlock()
+
+	for i, subscriber := range c.oomSubscribers {
+		if subscriber == stream {
+			c.oomSubscribers = append(c.oomSubscribers[:i], c.oomSubscribers[i+1:]...)
+			close(stream.events)
+			return
+		}
+	}
+}
+
+type oomEventStream struct {
+	container *LinuxContainer
+	events    chan garden.OomEvent
+}
+
+func (s *oomEventStream) Next() (garden.OomEvent, error) {
+	event, ok := <-s.events
+	if !ok {
+		return garden.OomEvent{}, io.EOF
+	}
+
+	return event, nil
+}
+
+func (s *oomEventStream) Close() error {
+	s.container.unsubscribeFromOomEvents(s)
+	return nil
+}
+
+var _ garden.OomEventStream = new(oomEventStream)
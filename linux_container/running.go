@@ -12,6 +12,11 @@ import (
 )
 
 func (c *LinuxContainer) Run(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+	// wshd is frozen along with everything else, and would never answer
+	if state := c.State(); state == StatePaused {
+		return nil, InvalidStateError{"run processes in", state}
+	}
+
 	wshPath := path.Join(c.path, "bin", "wsh")
 	sockPath := path.Join(c.path, "run", "wshd.sock")
 
@@ -64,7 +69,14 @@ func (c *LinuxContainer) Run(spec garden.ProcessSpec, processIO garden.ProcessIO
 
 	setRLimitsEnv(wsh, spec.Limits)
 
-	return c.processTracker.Run(processID, wsh, processIO, spec.TTY, signaller)
+	proc, err := c.processTracker.Run(processID, wsh, processIO, spec.TTY, signaller)
+	if err != nil {
+		return nil, err
+	}
+
+	c.changed()
+
+	return proc, nil
 }
 
 func (c *LinuxContainer) Attach(processID uint32, processIO garden.ProcessIO) (garden.Process, error) {
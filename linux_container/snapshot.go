@@ -13,8 +13,9 @@ type ContainerSnapshot struct {
 
 	GraceTime time.Duration
 
-	State  string
-	Events []string
+	State     string
+	Events    []string
+	OomEvents []garden.OomEvent
 
 	Limits LimitsSnapshot
 
@@ -35,14 +36,17 @@ type LimitsSnapshot struct {
 	Disk      *garden.DiskLimits
 	Bandwidth *garden.BandwidthLimits
 	CPU       *garden.CPULimits
+	BlockIO   *garden.BlockIOLimits
+	Pid       *garden.PidLimits
 }
 
 type ResourcesSnapshot struct {
-	UserUID uint32
-	RootUID uint32
-	Network *linux_backend.Network
-	Bridge  string
-	Ports   []uint32
+	UserUID     uint32
+	RootUID     uint32
+	Network     *linux_backend.Network
+	IPv6Network *linux_backend.Network `json:",omitempty"`
+	Bridge      string
+	Ports       []uint32
 }
 
 type ProcessSnapshot struct {
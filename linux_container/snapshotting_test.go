@@ -20,6 +20,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager/fake_cgroups_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool/fake_port_pool"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/cloudfoundry-incubator/garden-linux/process_tracker/fake_process_tracker"
@@ -136,6 +137,17 @@ var _ = Describe("Linux containers", func() {
 			LimitInShares: 1,
 		}
 
+		blockIOLimits := garden.BlockIOLimits{
+			Weight: 500,
+			Devices: []garden.BlockIODeviceLimits{
+				{Major: 8, Minor: 0, WriteBytesPerSecond: 1024},
+			},
+		}
+
+		pidLimits := garden.PidLimits{
+			Max: 1024,
+		}
+
 		JustBeforeEach(func() {
 			var err error
 
@@ -200,10 +212,14 @@ var _ = Describe("Linux containers", func() {
 					{
 						HostPort:      1,
 						ContainerPort: 2,
+						Protocol:      garden.ProtocolTCP,
+						PortCount:     1,
 					},
 					{
 						HostPort:      3,
 						ContainerPort: 4,
+						Protocol:      garden.ProtocolTCP,
+						PortCount:     1,
 					},
 				},
 			))
@@ -242,8 +258,8 @@ var _ = Describe("Linux containers", func() {
 				err := container.LimitMemory(memoryLimits)
 				Expect(err).ToNot(HaveOccurred())
 
-				// oom exits immediately since it's faked out; should see event,
-				// and it should show up in the snapshot
+				// the fake oom notification reports an oom straight away; should
+				// see event, and it should show up in the snapshot
 				Eventually(container.Events).Should(ContainElement("out of memory"))
 				Eventually(container.State).Should(Equal(linux_container.StateStopped))
 
@@ -255,6 +271,12 @@ var _ = Describe("Linux containers", func() {
 
 				err = container.LimitCPU(cpuLimits)
 				Expect(err).ToNot(HaveOccurred())
+
+				err = container.LimitBlockIO(blockIOLimits)
+				Expect(err).ToNot(HaveOccurred())
+
+				err = container.LimitPids(pidLimits)
+				Expect(err).ToNot(HaveOccurred())
 			})
 
 			It("saves them", func() {
@@ -270,6 +292,8 @@ var _ = Describe("Linux containers", func() {
 
 				Expect(snapshot.State).To(Equal("stopped"))
 				Expect(snapshot.Events).To(Equal([]string{"out of memory"}))
+				Expect(snapshot.OomEvents).To(HaveLen(1))
+				Expect(snapshot.OomEvents[0].Policy).To(Equal(garden.OomPolicyStop))
 
 				Expect(snapshot.Limits).To(Equal(
 					linux_container.LimitsSnapshot{
@@ -277,9 +301,42 @@ var _ = Describe("Linux containers", func() {
 						Disk:      &diskLimits,
 						Bandwidth: &bandwidthLimits,
 						CPU:       &cpuLimits,
+						BlockIO:   &blockIOLimits,
+						Pid:       &pidLimits,
 					},
 				))
 			})
+
+			Context("and block io limits set again", func() {
+				JustBeforeEach(func() {
+					err := container.LimitBlockIO(garden.BlockIOLimits{
+						Devices: []garden.BlockIODeviceLimits{
+							{Major: 8, Minor: 0},
+							{Major: 8, Minor: 16, ReadIOPerSecond: 100},
+						},
+					})
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("saves the limits in force", func() {
+					out := new(bytes.Buffer)
+
+					err := container.Snapshot(out)
+					Expect(err).ToNot(HaveOccurred())
+
+					var snapshot linux_container.ContainerSnapshot
+
+					err = json.NewDecoder(out).Decode(&snapshot)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(snapshot.Limits.BlockIO).To(Equal(&garden.BlockIOLimits{
+						Weight: 500,
+						Devices: []garden.BlockIODeviceLimits{
+							{Major: 8, Minor: 16, ReadIOPerSecond: 100},
+						},
+					}))
+				})
+			})
 		})
 
 		Context("with no limits set", func() {
@@ -300,6 +357,8 @@ var _ = Describe("Linux containers", func() {
 						Disk:      nil,
 						Bandwidth: nil,
 						CPU:       nil,
+						BlockIO:   nil,
+						Pid:       nil,
 					},
 				))
 
@@ -323,6 +382,53 @@ var _ = Describe("Linux containers", func() {
 
 		})
 
+		It("restores the container's OOM events", func() {
+			oomEvents := []garden.OomEvent{
+				{
+					Time:   time.Unix(123, 0),
+					Policy: garden.OomPolicyKillProcess,
+					MemoryStat: garden.ContainerMemoryStat{
+						Rss: 1024,
+					},
+				},
+			}
+
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:     "active",
+				Events:    []string{"out of memory"},
+				OomEvents: oomEvents,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(container.OomEvents()).To(Equal(oomEvents))
+		})
+
+		It("keeps only the container's latest 10 OOM events", func() {
+			oomEvents := []garden.OomEvent{}
+			for i := 0; i < 15; i++ {
+				oomEvents = append(oomEvents, garden.OomEvent{
+					Time:   time.Unix(int64(i), 0),
+					Policy: garden.OomPolicyKillProcess,
+				})
+			}
+
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:     "active",
+				Events:    []string{"out of memory"},
+				OomEvents: oomEvents,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(container.OomEvents()).To(Equal(oomEvents[5:]))
+
+			out := new(bytes.Buffer)
+			Expect(container.Snapshot(out)).To(Succeed())
+
+			var snapshot linux_container.ContainerSnapshot
+			Expect(json.NewDecoder(out).Decode(&snapshot)).To(Succeed())
+			Expect(snapshot.OomEvents).To(HaveLen(10))
+		})
+
 		It("restores process state", func() {
 			err := container.Restore(linux_container.ContainerSnapshot{
 				State:  "active",
@@ -450,56 +556,202 @@ var _ = Describe("Linux containers", func() {
 					Path: containerDir + "/net.sh",
 					Args: []string{"setup"},
 				},
-				fake_command_runner.CommandSpec{
-					Path: containerDir + "/net.sh",
-					Args: []string{"in"},
+			))
+
+			Expect(fakeFilter.NetInCallCount()).To(Equal(2))
+			forward, _ := fakeFilter.NetInArgsForCall(0)
+			Expect(forward.HostPort).To(Equal(uint32(1234)))
+			Expect(forward.ContainerPort).To(Equal(uint32(5678)))
+			forward, _ = fakeFilter.NetInArgsForCall(1)
+			Expect(forward.HostPort).To(Equal(uint32(1235)))
+			Expect(forward.ContainerPort).To(Equal(uint32(5679)))
+		})
+
+		It("re-applies the protocol and range of each net-in", func() {
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:  "active",
+				Events: []string{},
+
+				NetIns: []linux_container.NetInSpec{
+					{
+						HostPort:      1234,
+						ContainerPort: 5678,
+						Protocol:      garden.ProtocolUDP,
+						PortCount:     10,
+					},
 				},
-				fake_command_runner.CommandSpec{
-					Path: containerDir + "/net.sh",
-					Args: []string{"in"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			forward, _ := fakeFilter.NetInArgsForCall(0)
+			Expect(forward.Protocol).To(Equal(garden.ProtocolUDP))
+			Expect(forward.PortCount).To(Equal(uint32(10)))
+
+			info, err := container.Info()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(info.MappedPorts).To(Equal([]garden.PortMapping{
+				{HostPort: 1234, ContainerPort: 5678, Protocol: garden.ProtocolUDP, PortCount: 10},
+			}))
+		})
+
+		It("restores net-ins snapshotted before protocols and ranges as single TCP ports", func() {
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:  "active",
+				Events: []string{},
+
+				NetIns: []linux_container.NetInSpec{
+					{
+						HostPort:      1234,
+						ContainerPort: 5678,
+					},
 				},
-			))
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			forward, _ := fakeFilter.NetInArgsForCall(0)
+			Expect(forward.Protocol).To(Equal(garden.ProtocolTCP))
+			Expect(forward.PortCount).To(Equal(uint32(1)))
 		})
 
-		for _, cmd := range []string{"setup", "in"} {
-			command := cmd
+		Context("when the container was checkpointed", func() {
+			It("resumes it from the checkpoint before setting up the network", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "checkpointed",
+					Events: []string{},
+				})
+				Expect(err).ToNot(HaveOccurred())
 
-			Context("when net.sh "+cmd+" fails", func() {
-				disaster := errors.New("oh no!")
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/restore.sh",
+					},
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/net.sh",
+						Args: []string{"setup"},
+					},
+				))
+			})
+
+			It("sets the container's state to active", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "checkpointed",
+					Events: []string{},
+				})
+				Expect(err).ToNot(HaveOccurred())
 
+				Expect(container.State()).To(Equal(linux_container.StateActive))
+			})
+
+			Context("when restore.sh fails", func() {
 				JustBeforeEach(func() {
 					fakeRunner.WhenRunning(
 						fake_command_runner.CommandSpec{
-							Path: containerDir + "/net.sh",
-							Args: []string{command},
+							Path: containerDir + "/restore.sh",
 						}, func(*exec.Cmd) error {
-							return disaster
+							return errors.New("oh no!")
 						},
 					)
 				})
 
-				It("returns the error", func() {
+				It("returns a wrapped error", func() {
 					err := container.Restore(linux_container.ContainerSnapshot{
-						State:  "active",
+						State:  "checkpointed",
 						Events: []string{},
+					})
+					Expect(err).To(MatchError("container: resume checkpoint: oh no!"))
+				})
+			})
+		})
 
-						NetIns: []linux_container.NetInSpec{
-							{
-								HostPort:      1234,
-								ContainerPort: 5678,
-							},
-							{
-								HostPort:      1235,
-								ContainerPort: 5679,
-							},
+		Context("when the container was paused", func() {
+			It("keeps it frozen", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "paused",
+					Events: []string{},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(ContainElement(
+					fake_cgroups_manager.SetValue{
+						Subsystem: "freezer",
+						Name:      "freezer.state",
+						Value:     "FROZEN",
+					},
+				))
+
+				Expect(container.State()).To(Equal(linux_container.StatePaused))
+			})
+		})
+
+		It("does not resume containers that were not checkpointed", func() {
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:  "active",
+				Events: []string{},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).ToNot(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: containerDir + "/restore.sh",
+				},
+			))
+		})
+
+		Context("when net.sh setup fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: containerDir + "/net.sh",
+						Args: []string{"setup"},
+					}, func(*exec.Cmd) error {
+						return disaster
+					},
+				)
+			})
+
+			It("returns the error", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					NetIns: []linux_container.NetInSpec{
+						{
+							HostPort:      1234,
+							ContainerPort: 5678,
 						},
+					},
 
-						NetOuts: []garden.NetOutRule{},
-					})
-					Expect(err).To(Equal(disaster))
+					NetOuts: []garden.NetOutRule{},
 				})
+				Expect(err).To(Equal(disaster))
+				Expect(fakeFilter.NetInCallCount()).To(Equal(0))
 			})
-		}
+		})
+
+		Context("when forwarding a net-in fails", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeFilter.NetInReturns(disaster)
+
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					NetIns: []linux_container.NetInSpec{
+						{
+							HostPort:      1234,
+							ContainerPort: 5678,
+						},
+					},
+
+					NetOuts: []garden.NetOutRule{},
+				})
+				Expect(err).To(Equal(disaster))
+			})
+		})
 
 		It("re-enforces the memory limit", func() {
 			err := container.Restore(linux_container.ContainerSnapshot{
@@ -530,7 +782,7 @@ var _ = Describe("Linux containers", func() {
 				},
 			))
 
-			// oom will exit immediately as the command runner is faked out
+			// the fake oom notification reports an oom as soon as it is waited for
 			Eventually(container.Events).Should(ContainElement("out of memory"))
 		})
 
@@ -546,6 +798,613 @@ var _ = Describe("Linux containers", func() {
 			})
 		})
 
+		It("re-enforces the CPU limits", func() {
+			err := container.Restore(linux_container.ContainerSnapshot{
+				State:  "active",
+				Events: []string{},
+
+				Limits: linux_container.LimitsSnapshot{
+					CPU: &garden.CPULimits{
+						LimitInShares:       512,
+						QuotaInMicroseconds: 50000,
+						Cpus:                "1",
+					},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(
+				fake_cgroups_manager.SetValue{
+					Subsystem: "cpu",
+					Name:      "cpu.shares",
+					Value:     "512",
+				},
+			))
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(
+				fake_cgroups_manager.SetValue{
+					Subsystem: "cpu",
+					Name:      "cpu.cfs_quota_us",
+					Value:     "50000",
+				},
+			))
+
+			Expect(fakeCgroups.SetValues()).To(ContainElement(
+				fake_cgroups_manager.SetValue{
+					Subsystem: "cpuset",
+					Name:      "cpuset.cpus",
+					Value:     "1",
+				},
+			))
+		})
+
+		Context("when re-enforcing the CPU limits fails", func() {
+			disaster := errors.New("oh no!")
+
+			JustBeforeEach(func() {
+				fakeCgroups.WhenSetting("cpu", "cpu.shares", func() error {
+					return disaster
+				})
+			})
+
+			It("returns the error", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						CPU: &garden.CPULimits{
+							LimitInShares: 512,
+						},
+					},
+				})
+				Expect(err).To(Equal(disaster))
+			})
+		})
+
+		Context("when the memory limits in force match", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1024", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.memsw.limit_in_bytes", func() (string, error) {
+					return "1536", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.soft_limit_in_bytes", func() (string, error) {
+					return "9223372036854771712", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.kmem.limit_in_bytes", func() (string, error) {
+					return "9223372036854771712", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.swappiness", func() (string, error) {
+					return "60", nil
+				})
+			})
+
+			It("does not record drift", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Memory: &garden.MemoryLimits{
+							LimitInBytes: 1024,
+							SwapInBytes:  512,
+							OomPolicy:    garden.OomPolicyKillProcess,
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.Events()).ToNot(ContainElement(HavePrefix("memory limits drifted")))
+			})
+		})
+
+		Context("when the kernel has rounded the memory limits to a page", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "1048576", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.memsw.limit_in_bytes", func() (string, error) {
+					return "1048576", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.soft_limit_in_bytes", func() (string, error) {
+					return "1048576", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.kmem.limit_in_bytes", func() (string, error) {
+					return "1048576", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.swappiness", func() (string, error) {
+					return "60", nil
+				})
+			})
+
+			It("does not record drift", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Memory: &garden.MemoryLimits{
+							LimitInBytes:     1048575,
+							SoftLimitInBytes: 1048575,
+							KmemLimitInBytes: 1048575,
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.Events()).ToNot(ContainElement(HavePrefix("memory limits drifted")))
+			})
+		})
+
+		Context("when the memory limit in force has drifted", func() {
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("memory", "memory.limit_in_bytes", func() (string, error) {
+					return "2097152", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.memsw.limit_in_bytes", func() (string, error) {
+					return "2097152", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.soft_limit_in_bytes", func() (string, error) {
+					return "0", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.kmem.limit_in_bytes", func() (string, error) {
+					return "0", nil
+				})
+
+				fakeCgroups.WhenGetting("memory", "memory.swappiness", func() (string, error) {
+					return "0", nil
+				})
+			})
+
+			It("records the drift as an event", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Memory: &garden.MemoryLimits{
+							LimitInBytes: 1048576,
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(container.Events()).To(ContainElement(
+					"memory limits drifted: expected {LimitInBytes:1048576 SoftLimitInBytes:0 SwapInBytes:0 Swappiness:0 KmemLimitInBytes:0 OomPolicy:}, found {LimitInBytes:2097152 SoftLimitInBytes:0 SwapInBytes:0 Swappiness:0 KmemLimitInBytes:0 OomPolicy:}",
+				))
+			})
+		})
+
+		Context("when the CPU limits in force match", func() {
+			var cfsQuota string
+			var cpus string
+
+			BeforeEach(func() {
+				cfsQuota = "50000"
+				cpus = "1"
+			})
+
+			JustBeforeEach(func() {
+				fakeCgroups.WhenGetting("cpu", "cpu.shares", func() (string, error) {
+					return "512", nil
+				})
+
+				fakeCgroups.WhenGetting("cpu", "cpu.cfs_period_us", func() (string, error) {
+					return "100000", nil
+				})
+
+				fakeCgroups.WhenGetting("cpu", "cpu.cfs_quota_us", func() (string, error) {
+					return cfsQuota, nil
+				})
+
+				fakeCgroups.WhenGetting("cpuset", "cpuset.cpus", func() (string, error) {
+					return cpus, nil
+				})
+
+				fakeCgroups.WhenGetting("cpuset", "cpuset.mems", func() (string, error) {
+					return "0", nil
+				})
+			})
+
+			It("neither re-enforces them nor records an event", func() {
+				err := container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						CPU: &garden.CPULimits{
+							LimitInShares:       512,
+							QuotaInMicroseconds: 50000,
+							Cpus:                "1",
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCgroups.SetValues()).To(BeEmpty())
+				Expect(container.Events()).To(BeEmpty())
+			})
+
+			Context("and the kernel reports the cpu list as a range", func() {
+				BeforeEach(func() {
+					cpus = "0-2"
+				})
+
+				It("neither re-enforces them nor records an event", func() {
+					err := container.Restore(linux_container.ContainerSnapshot{
+						State:  "active",
+						Events: []string{},
+
+						Limits: linux_container.LimitsSnapshot{
+							CPU: &garden.CPULimits{
+								LimitInShares:       512,
+								QuotaInMicroseconds: 50000,
+								Cpus:                "0,1,2",
+							},
+						},
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeCgroups.SetValues()).To(BeEmpty())
+					Expect(container.Events()).To(BeEmpty())
+				})
+			})
+
+			Context("but the quota has been lifted", func() {
+				BeforeEach(func() {
+					cfsQuota = "-1"
+				})
+
+				It("re-enforces them and records the drift as an event", func() {
+					err := container.Restore(linux_container.ContainerSnapshot{
+						State:  "active",
+						Events: []string{},
+
+						Limits: linux_container.LimitsSnapshot{
+							CPU: &garden.CPULimits{
+								LimitInShares:       512,
+								QuotaInMicroseconds: 50000,
+							},
+						},
+					})
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fakeCgroups.SetValues()).To(ContainElement(
+						fake_cgroups_manager.SetValue{
+							Subsystem: "cpu",
+							Name:      "cpu.cfs_quota_us",
+							Value:     "50000",
+						},
+					))
+
+					Expect(container.Events()).To(ConsistOf(
+						"cpu limits drifted: " +
+							"expected {LimitInShares:512 QuotaInMicroseconds:50000 PeriodInMicroseconds:0 Cpus: Mems:}, " +
+							"found {LimitInShares:512 QuotaInMicroseconds:0 PeriodInMicroseconds:100000 Cpus:1 Mems:0}",
+					))
+				})
+			})
+		})
+
+		Describe("restoring the block io limits", func() {
+			limits := garden.BlockIOLimits{
+				Weight: 500,
+				Devices: []garden.BlockIODeviceLimits{
+					{Major: 8, Minor: 0, WriteBytesPerSecond: 1024},
+				},
+			}
+
+			var writeBPS string
+
+			restore := func() error {
+				return container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						BlockIO: &limits,
+					},
+				})
+			}
+
+			BeforeEach(func() {
+				writeBPS = "8:0 1024\n"
+
+				fakeCgroups.WhenGetting("blkio", "blkio.weight", func() (string, error) {
+					return "500", nil
+				})
+
+				fakeCgroups.WhenGetting("blkio", "blkio.throttle.write_bps_device", func() (string, error) {
+					return writeBPS, nil
+				})
+			})
+
+			Context("when the limits in force match", func() {
+				It("neither re-enforces them nor records an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeCgroups.SetValues()).To(BeEmpty())
+					Expect(container.Events()).To(BeEmpty())
+				})
+			})
+
+			Context("when a device's throttle has been lifted", func() {
+				BeforeEach(func() {
+					writeBPS = ""
+				})
+
+				It("re-enforces them and records the drift as an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeCgroups.SetValues()).To(ContainElement(
+						fake_cgroups_manager.SetValue{
+							Subsystem: "blkio",
+							Name:      "blkio.throttle.write_bps_device",
+							Value:     "8:0 1024",
+						},
+					))
+
+					Expect(container.Events()).To(HaveLen(1))
+					Expect(container.Events()[0]).To(HavePrefix("block io limits drifted: "))
+				})
+			})
+		})
+
+		Describe("restoring the pid limit", func() {
+			limits := garden.PidLimits{Max: 1024}
+
+			var pidsMax string
+
+			restore := func() error {
+				return container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Pid: &limits,
+					},
+				})
+			}
+
+			BeforeEach(func() {
+				pidsMax = "1024"
+
+				fakeCgroups.WhenGetting("pids", "pids.max", func() (string, error) {
+					return pidsMax, nil
+				})
+			})
+
+			Context("when the limit in force matches", func() {
+				It("neither re-enforces it nor records an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeCgroups.SetValues()).To(BeEmpty())
+					Expect(container.Events()).To(BeEmpty())
+				})
+
+				It("remembers it", func() {
+					Expect(restore()).To(Succeed())
+
+					out := new(bytes.Buffer)
+					Expect(container.Snapshot(out)).To(Succeed())
+
+					var snapshot linux_container.ContainerSnapshot
+					Expect(json.NewDecoder(out).Decode(&snapshot)).To(Succeed())
+
+					Expect(snapshot.Limits.Pid).To(Equal(&limits))
+				})
+			})
+
+			Context("when the limit in force has been lifted", func() {
+				BeforeEach(func() {
+					pidsMax = "max"
+				})
+
+				It("re-enforces it and records the drift as an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeCgroups.SetValues()).To(ConsistOf(
+						fake_cgroups_manager.SetValue{
+							Subsystem: "pids",
+							Name:      "pids.max",
+							Value:     "1024",
+						},
+					))
+
+					Expect(container.Events()).To(ConsistOf(
+						"pid limits drifted: expected {Max:1024}, found {Max:0}",
+					))
+				})
+			})
+		})
+
+		Describe("restoring the bandwidth limits", func() {
+			limits := garden.BandwidthLimits{
+				RateInBytesPerSecond:      128,
+				BurstRateInBytesPerSecond: 256,
+			}
+
+			restore := func() error {
+				return container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Bandwidth: &limits,
+					},
+				})
+			}
+
+			Context("when the limits in force match", func() {
+				BeforeEach(func() {
+					fakeBandwidthManager.GetLimitsResult = garden.ContainerBandwidthStat{
+						InRate:   128,
+						InBurst:  256,
+						OutRate:  128,
+						OutBurst: 256,
+					}
+				})
+
+				It("neither re-enforces them nor records an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeBandwidthManager.EnforcedLimits).To(BeEmpty())
+					Expect(container.Events()).To(BeEmpty())
+				})
+
+				It("remembers them", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(container.CurrentBandwidthLimits()).To(Equal(limits))
+				})
+			})
+
+			Context("when the limits in force have drifted", func() {
+				BeforeEach(func() {
+					fakeBandwidthManager.GetLimitsResult = garden.ContainerBandwidthStat{
+						InRate:   128,
+						InBurst:  256,
+						OutRate:  64,
+						OutBurst: 256,
+					}
+				})
+
+				It("re-enforces them and records the drift as an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeBandwidthManager.EnforcedLimits).To(ConsistOf(limits))
+					Expect(container.Events()).To(ConsistOf(
+						"bandwidth limits drifted: " +
+							"expected {RateInBytesPerSecond:128 BurstRateInBytesPerSecond:256}, " +
+							"found {InRate:128 InBurst:256 OutRate:64 OutBurst:256}",
+					))
+				})
+
+				Context("and re-enforcing them fails", func() {
+					disaster := errors.New("oh no!")
+
+					BeforeEach(func() {
+						fakeBandwidthManager.SetLimitsError = disaster
+					})
+
+					It("returns the error", func() {
+						Expect(restore()).To(Equal(disaster))
+					})
+				})
+			})
+
+			Context("when the limits in force cannot be read", func() {
+				BeforeEach(func() {
+					fakeBandwidthManager.GetLimitsError = errors.New("oh no!")
+				})
+
+				It("re-enforces them without recording an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeBandwidthManager.EnforcedLimits).To(ConsistOf(limits))
+					Expect(container.Events()).To(BeEmpty())
+				})
+			})
+		})
+
+		Describe("restoring the disk limits", func() {
+			limits := garden.DiskLimits{
+				ByteHard:  1024 * 1024,
+				InodeHard: 100,
+			}
+
+			restore := func() error {
+				return container.Restore(linux_container.ContainerSnapshot{
+					State:  "active",
+					Events: []string{},
+
+					Limits: linux_container.LimitsSnapshot{
+						Disk: &limits,
+					},
+				})
+			}
+
+			Context("when the limits in force match", func() {
+				BeforeEach(func() {
+					fakeQuotaManager.GetLimitsResult = garden.DiskLimits{
+						BlockHard: 1024,
+						InodeHard: 100,
+					}
+				})
+
+				It("neither re-enforces them nor records an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeQuotaManager.Limited).To(BeEmpty())
+					Expect(container.Events()).To(BeEmpty())
+				})
+			})
+
+			Context("when the limits in force have drifted", func() {
+				BeforeEach(func() {
+					fakeQuotaManager.GetLimitsResult = garden.DiskLimits{
+						BlockHard: 2048,
+						InodeHard: 100,
+					}
+				})
+
+				It("re-enforces them and records the drift as an event", func() {
+					Expect(restore()).To(Succeed())
+
+					Expect(fakeQuotaManager.Limited).To(HaveKeyWithValue(
+						quota_manager.Subject{ID: "some-id", UID: 1234},
+						limits,
+					))
+
+					Expect(container.Events()).To(HaveLen(1))
+					Expect(container.Events()[0]).To(HavePrefix("disk limits drifted: "))
+				})
+
+				Context("but only in what a loop filesystem does not enforce", func() {
+					BeforeEach(func() {
+						fakeQuotaManager.TypeResult = quota_manager.LoopQuota
+						fakeQuotaManager.GetLimitsResult = garden.DiskLimits{
+							BlockHard: 1024,
+							InodeHard: 65536,
+						}
+					})
+
+					It("neither re-enforces them nor records an event", func() {
+						Expect(restore()).To(Succeed())
+
+						Expect(fakeQuotaManager.Limited).To(BeEmpty())
+						Expect(container.Events()).To(BeEmpty())
+					})
+				})
+
+				Context("and quotas are disabled", func() {
+					BeforeEach(func() {
+						fakeQuotaManager.Disable()
+					})
+
+					It("leaves them be", func() {
+						Expect(restore()).To(Succeed())
+
+						Expect(fakeQuotaManager.Limited).To(BeEmpty())
+						Expect(container.Events()).To(BeEmpty())
+					})
+				})
+			})
+		})
+
 		Context("when re-enforcing the memory limit fails", func() {
 			disaster := errors.New("oh no!")
 
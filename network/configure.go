@@ -50,6 +50,10 @@ type HostConfig struct {
 	ContainerPid  int
 	Subnet        *net.IPNet
 	Mtu           int
+
+	// nil unless the container has an IPv6 network
+	BridgeIPv6 net.IP
+	SubnetIPv6 *net.IPNet
 }
 
 func (c *NetworkConfigurer) ConfigureHost(config *HostConfig) error {
@@ -64,6 +68,8 @@ func (c *NetworkConfigurer) ConfigureHost(config *HostConfig) error {
 		"bridgeName":     config.BridgeName,
 		"bridgeIP":       config.BridgeIP,
 		"subnet":         config.Subnet,
+		"bridgeIPv6":     config.BridgeIPv6,
+		"subnetIPv6":     config.SubnetIPv6,
 		"containerIface": config.ContainerIntf,
 		"hostIface":      config.HostIntf,
 		"mtu":            config.Mtu,
@@ -76,6 +82,12 @@ func (c *NetworkConfigurer) ConfigureHost(config *HostConfig) error {
 		return err
 	}
 
+	if config.BridgeIPv6 != nil {
+		if err = c.configureBridgeIPv6(cLog, bridge, config.BridgeIPv6, config.SubnetIPv6); err != nil {
+			return err
+		}
+	}
+
 	if host, container, err = c.configureVethPair(cLog, config.HostIntf, config.ContainerIntf); err != nil {
 		return err
 	}
@@ -111,6 +123,22 @@ func (c *NetworkConfigurer) configureBridgeIntf(log lager.Logger, name string, i
 	return bridge, nil
 }
 
+// configureBridgeIPv6 gives the bridge the container's IPv6 gateway address.
+// Bridges are created for IPv4 subnets, which containers with different IPv6
+// subnets may share, so the address is added here rather than on creation.
+func (c *NetworkConfigurer) configureBridgeIPv6(log lager.Logger, bridge *net.Interface, ip net.IP, subnet *net.IPNet) error {
+	log = log.Session("bridge-interface-ipv6")
+
+	// creating an existing bridge only adds the address, if it is missing
+	log.Debug("add-ip")
+	if _, err := c.Bridge.Create(bridge.Name, ip, subnet); err != nil {
+		log.Error("add-ip", err)
+		return &ConfigureLinkError{err, "bridge", bridge, ip, subnet}
+	}
+
+	return nil
+}
+
 func (c *NetworkConfigurer) configureVethPair(log lager.Logger, hostName, containerName string) (*net.Interface, *net.Interface, error) {
 	log = log.Session("veth")
 
@@ -157,6 +185,11 @@ type ContainerConfig struct {
 	GatewayIP     net.IP
 	Subnet        *net.IPNet
 	Mtu           int
+
+	// nil unless the container has an IPv6 network
+	ContainerIPv6 net.IP
+	GatewayIPv6   net.IP
+	SubnetIPv6    *net.IPNet
 }
 
 func (c *NetworkConfigurer) ConfigureContainer(config *ContainerConfig) error {
@@ -174,6 +207,17 @@ func (c *NetworkConfigurer) ConfigureContainer(config *ContainerConfig) error {
 		return err
 	}
 
+	if config.ContainerIPv6 != nil {
+		if err := c.configureContainerIPv6(
+			config.ContainerIntf,
+			config.ContainerIPv6,
+			config.GatewayIPv6,
+			config.SubnetIPv6,
+		); err != nil {
+			return err
+		}
+	}
+
 	return c.Hostname.SetHostname(config.Hostname)
 }
 
@@ -203,6 +247,26 @@ func (c *NetworkConfigurer) configureContainerIntf(name string, ip, gatewayIP ne
 	return nil
 }
 
+// configureContainerIPv6 adds an IPv6 address and default route to the
+// container interface, which configureContainerIntf has already brought up.
+func (c *NetworkConfigurer) configureContainerIPv6(name string, ip, gatewayIP net.IP, subnet *net.IPNet) (err error) {
+	var found bool
+	var intf *net.Interface
+	if intf, found, err = c.Link.InterfaceByName(name); !found || err != nil {
+		return &FindLinkError{err, "container", name}
+	}
+
+	if err := c.Link.AddIP(intf, ip, subnet); err != nil {
+		return &ConfigureLinkError{err, "container", intf, ip, subnet}
+	}
+
+	if err := c.Link.AddDefaultGW(intf, gatewayIP); err != nil {
+		return &ConfigureDefaultGWError{err, intf, gatewayIP}
+	}
+
+	return nil
+}
+
 func (c *NetworkConfigurer) configureLoopbackIntf() (err error) {
 	var found bool
 	var lo *net.Interface
@@ -157,6 +157,48 @@ var _ = Describe("Configure", func() {
 				})
 			})
 
+			Describe("giving the bridge an IPv6 address", func() {
+				Context("when the container has an IPv6 network", func() {
+					BeforeEach(func() {
+						config.BridgeName = "bridge"
+						config.BridgeIPv6 = net.ParseIP("fd00::2")
+						_, config.SubnetIPv6, _ = net.ParseCIDR("fd00::/126")
+					})
+
+					It("adds the IPv6 gateway address to the bridge", func() {
+						Expect(configurer.ConfigureHost(config)).To(Succeed())
+
+						Expect(bridger.CreateCalledWith.Name).To(Equal("bridge"))
+						Expect(bridger.CreateCalledWith.IP).To(Equal(net.ParseIP("fd00::2")))
+						Expect(bridger.CreateCalledWith.Subnet).To(Equal(config.SubnetIPv6))
+					})
+
+					Context("when adding the address fails", func() {
+						It("returns a wrapped error", func() {
+							bridger.CreateReturns.Error = errors.New("o no")
+
+							err := configurer.ConfigureHost(config)
+							Expect(err).To(MatchError(&network.ConfigureLinkError{
+								errors.New("o no"),
+								"bridge",
+								existingBridge,
+								config.BridgeIPv6,
+								config.SubnetIPv6,
+							}))
+						})
+					})
+				})
+
+				Context("when the container has no IPv6 network", func() {
+					It("leaves the bridge alone", func() {
+						config.BridgeName = "bridge"
+						Expect(configurer.ConfigureHost(config)).To(Succeed())
+
+						Expect(bridger.CreateCalledWith.Name).To(BeEmpty())
+					})
+				})
+			})
+
 		})
 	})
 
@@ -379,6 +421,44 @@ var _ = Describe("Configure", func() {
 					Expect(err).To(MatchError(&network.ConfigureDefaultGWError{linkConfigurer.AddDefaultGWReturns, &net.Interface{Name: "foo"}, net.ParseIP("2.3.4.5")}))
 				})
 			})
+
+			Context("when the container has an IPv6 network", func() {
+				BeforeEach(func() {
+					config.ContainerIntf = "foo"
+					config.ContainerIPv6, config.SubnetIPv6, _ = net.ParseCIDR("fd00::1/126")
+					config.GatewayIPv6 = net.ParseIP("fd00::2")
+				})
+
+				It("adds the requested IPv6 address as well", func() {
+					config.ContainerIP, config.Subnet, _ = net.ParseCIDR("2.3.4.5/6")
+
+					Expect(configurer.ConfigureContainer(config)).To(Succeed())
+					Expect(linkConfigurer.AddIPCalledWith).To(ContainElement(fakedevices.InterfaceIPAndSubnet{
+						&net.Interface{Name: "foo"},
+						config.ContainerIP,
+						config.Subnet,
+					}))
+					Expect(linkConfigurer.AddIPCalledWith).To(ContainElement(fakedevices.InterfaceIPAndSubnet{
+						&net.Interface{Name: "foo"},
+						config.ContainerIPv6,
+						config.SubnetIPv6,
+					}))
+				})
+
+				It("adds an IPv6 default gateway", func() {
+					Expect(configurer.ConfigureContainer(config)).To(Succeed())
+					Expect(linkConfigurer.AddDefaultGWCalledWith.Interface).To(Equal(&net.Interface{Name: "foo"}))
+					Expect(linkConfigurer.AddDefaultGWCalledWith.IP).To(Equal(net.ParseIP("fd00::2")))
+				})
+			})
+
+			Context("when the container has no IPv6 network", func() {
+				It("only adds the loopback and IPv4 addresses", func() {
+					config.ContainerIntf = "foo"
+					Expect(configurer.ConfigureContainer(config)).To(Succeed())
+					Expect(linkConfigurer.AddIPCalledWith).To(HaveLen(2))
+				})
+			})
 		})
 	})
 })
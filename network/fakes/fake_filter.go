@@ -2,10 +2,12 @@
 package fakes
 
 import (
+	"net"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/network"
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 )
 
 type FakeFilter struct {
@@ -28,6 +30,39 @@ type FakeFilter struct {
 	netOutReturns struct {
 		result1 error
 	}
+	NetOutRemoveStub        func(garden.NetOutRule) error
+	netOutRemoveMutex       sync.RWMutex
+	netOutRemoveArgsForCall []struct {
+		arg1 garden.NetOutRule
+	}
+	netOutRemoveReturns struct {
+		result1 error
+	}
+	NetInStub        func(forward iptables.PortForward, ipv6ContainerIP net.IP) error
+	netInMutex       sync.RWMutex
+	netInArgsForCall []struct {
+		forward         iptables.PortForward
+		ipv6ContainerIP net.IP
+	}
+	netInReturns struct {
+		result1 error
+	}
+	NetInRemoveStub        func(forward iptables.PortForward, ipv6ContainerIP net.IP) error
+	netInRemoveMutex       sync.RWMutex
+	netInRemoveArgsForCall []struct {
+		forward         iptables.PortForward
+		ipv6ContainerIP net.IP
+	}
+	netInRemoveReturns struct {
+		result1 error
+	}
+	RulesStub        func() (network.FilterRules, error)
+	rulesMutex       sync.RWMutex
+	rulesArgsForCall []struct{}
+	rulesReturns     struct {
+		result1 network.FilterRules
+		result2 error
+	}
 }
 
 func (fake *FakeFilter) Setup(logPrefix string) error {
@@ -110,3 +145,128 @@ func (fake *FakeFilter) NetOutReturns(result1 error) {
 }
 
 var _ network.Filter = new(FakeFilter)
+
+func (fake *FakeFilter) NetOutRemove(arg1 garden.NetOutRule) error {
+	fake.netOutRemoveMutex.Lock()
+	fake.netOutRemoveArgsForCall = append(fake.netOutRemoveArgsForCall, struct {
+		arg1 garden.NetOutRule
+	}{arg1})
+	fake.netOutRemoveMutex.Unlock()
+	if fake.NetOutRemoveStub != nil {
+		return fake.NetOutRemoveStub(arg1)
+	} else {
+		return fake.netOutRemoveReturns.result1
+	}
+}
+
+func (fake *FakeFilter) NetOutRemoveCallCount() int {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return len(fake.netOutRemoveArgsForCall)
+}
+
+func (fake *FakeFilter) NetOutRemoveArgsForCall(i int) garden.NetOutRule {
+	fake.netOutRemoveMutex.RLock()
+	defer fake.netOutRemoveMutex.RUnlock()
+	return fake.netOutRemoveArgsForCall[i].arg1
+}
+
+func (fake *FakeFilter) NetOutRemoveReturns(result1 error) {
+	fake.NetOutRemoveStub = nil
+	fake.netOutRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilter) NetIn(forward iptables.PortForward, ipv6ContainerIP net.IP) error {
+	fake.netInMutex.Lock()
+	fake.netInArgsForCall = append(fake.netInArgsForCall, struct {
+		forward         iptables.PortForward
+		ipv6ContainerIP net.IP
+	}{forward, ipv6ContainerIP})
+	fake.netInMutex.Unlock()
+	if fake.NetInStub != nil {
+		return fake.NetInStub(forward, ipv6ContainerIP)
+	} else {
+		return fake.netInReturns.result1
+	}
+}
+
+func (fake *FakeFilter) NetInCallCount() int {
+	fake.netInMutex.RLock()
+	defer fake.netInMutex.RUnlock()
+	return len(fake.netInArgsForCall)
+}
+
+func (fake *FakeFilter) NetInArgsForCall(i int) (iptables.PortForward, net.IP) {
+	fake.netInMutex.RLock()
+	defer fake.netInMutex.RUnlock()
+	return fake.netInArgsForCall[i].forward, fake.netInArgsForCall[i].ipv6ContainerIP
+}
+
+func (fake *FakeFilter) NetInReturns(result1 error) {
+	fake.NetInStub = nil
+	fake.netInReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilter) NetInRemove(forward iptables.PortForward, ipv6ContainerIP net.IP) error {
+	fake.netInRemoveMutex.Lock()
+	fake.netInRemoveArgsForCall = append(fake.netInRemoveArgsForCall, struct {
+		forward         iptables.PortForward
+		ipv6ContainerIP net.IP
+	}{forward, ipv6ContainerIP})
+	fake.netInRemoveMutex.Unlock()
+	if fake.NetInRemoveStub != nil {
+		return fake.NetInRemoveStub(forward, ipv6ContainerIP)
+	} else {
+		return fake.netInRemoveReturns.result1
+	}
+}
+
+func (fake *FakeFilter) NetInRemoveCallCount() int {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return len(fake.netInRemoveArgsForCall)
+}
+
+func (fake *FakeFilter) NetInRemoveArgsForCall(i int) (iptables.PortForward, net.IP) {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return fake.netInRemoveArgsForCall[i].forward, fake.netInRemoveArgsForCall[i].ipv6ContainerIP
+}
+
+func (fake *FakeFilter) NetInRemoveReturns(result1 error) {
+	fake.NetInRemoveStub = nil
+	fake.netInRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFilter) Rules() (network.FilterRules, error) {
+	fake.rulesMutex.Lock()
+	fake.rulesArgsForCall = append(fake.rulesArgsForCall, struct{}{})
+	fake.rulesMutex.Unlock()
+	if fake.RulesStub != nil {
+		return fake.RulesStub()
+	} else {
+		return fake.rulesReturns.result1, fake.rulesReturns.result2
+	}
+}
+
+func (fake *FakeFilter) RulesCallCount() int {
+	fake.rulesMutex.RLock()
+	defer fake.rulesMutex.RUnlock()
+	return len(fake.rulesArgsForCall)
+}
+
+func (fake *FakeFilter) RulesReturns(result1 network.FilterRules, result2 error) {
+	fake.RulesStub = nil
+	fake.rulesReturns = struct {
+		result1 network.FilterRules
+		result2 error
+	}{result1, result2}
+}
+
+var _ network.Filter = new(FakeFilter)
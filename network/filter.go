@@ -1,39 +1,233 @@
 package network
 
 import (
+	"errors"
 	"fmt"
+	"net"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 )
 
+var ErrIPv6NotEnabled = errors.New("network: rule names IPv6 networks but IPv6 is not enabled")
+
 //go:generate counterfeiter . Filter
 
 type Filter interface {
 	Setup(logPrefix string) error
 	TearDown()
 	NetOut(garden.NetOutRule) error
+	NetOutRemove(garden.NetOutRule) error
+
+	// NetIn forwards ports to the container's IPv4 address and, if the filter
+	// has an IPv6 chain and ipv6ContainerIP is not nil, from any of the host's
+	// IPv6 addresses to ipv6ContainerIP.
+	NetIn(forward iptables.PortForward, ipv6ContainerIP net.IP) error
+
+	// NetInRemove removes the forwarding NetIn added.
+	NetInRemove(forward iptables.PortForward, ipv6ContainerIP net.IP) error
+
+	// Rules lists the rules in the container's chains.
+	Rules() (FilterRules, error)
+}
+
+// FilterRules are the rules in a container's chains. The IPv6 chains are
+// only listed if the filter has an IPv6 chain.
+type FilterRules struct {
+	Instance []garden.IPTablesRule
+	Log      []garden.IPTablesRule
+	NAT      []garden.IPTablesRule
+
+	IPv6Instance []garden.IPTablesRule
+	IPv6NAT      []garden.IPTablesRule
+}
+
+type MixedIPRangeError struct {
+	Range garden.IPRange
+}
+
+func (e MixedIPRangeError) Error() string {
+	return fmt.Sprintf("network: range %s-%s mixes IPv4 and IPv6 addresses", e.Range.Start, e.Range.End)
 }
 
 type filter struct {
 	chain iptables.Chain
+
+	// nil unless the container has an IPv6 network
+	ipv6Chain iptables.Chain
 }
 
 func NewFilter(instanceChain iptables.Chain) Filter {
-	return &filter{instanceChain}
+	return &filter{chain: instanceChain}
+}
+
+// NewDualStackFilter creates a filter which sends rules for IPv4 networks to
+// instanceChain and rules for IPv6 networks to ipv6InstanceChain. Rules
+// naming no networks go to both.
+func NewDualStackFilter(instanceChain, ipv6InstanceChain iptables.Chain) Filter {
+	return &filter{chain: instanceChain, ipv6Chain: ipv6InstanceChain}
 }
 
 func (fltr *filter) Setup(logPrefix string) error {
 	if err := fltr.chain.Setup(logPrefix); err != nil {
 		return fmt.Errorf("network: log chain setup: %v", err)
 	}
+
+	if fltr.ipv6Chain != nil {
+		if err := fltr.ipv6Chain.Setup(logPrefix); err != nil {
+			return fmt.Errorf("network: ipv6 log chain setup: %v", err)
+		}
+	}
+
 	return nil
 }
 
 func (fltr *filter) TearDown() {
 	fltr.chain.TearDown()
+
+	if fltr.ipv6Chain != nil {
+		fltr.ipv6Chain.TearDown()
+	}
 }
 
 func (fltr *filter) NetOut(r garden.NetOutRule) error {
-	return fltr.chain.PrependFilterRule(r)
+	return fltr.eachChain(r, iptables.Chain.PrependFilterRule, iptables.Chain.DeleteFilterRule)
+}
+
+func (fltr *filter) NetOutRemove(r garden.NetOutRule) error {
+	return fltr.eachChain(r, iptables.Chain.DeleteFilterRule, iptables.Chain.PrependFilterRule)
+}
+
+func (fltr *filter) NetIn(forward iptables.PortForward, ipv6ContainerIP net.IP) error {
+	return fltr.eachForward(forward, ipv6ContainerIP, iptables.Chain.AppendDNATRules, iptables.Chain.DeleteDNATRules)
+}
+
+func (fltr *filter) NetInRemove(forward iptables.PortForward, ipv6ContainerIP net.IP) error {
+	return fltr.eachForward(forward, ipv6ContainerIP, iptables.Chain.DeleteDNATRules, iptables.Chain.AppendDNATRules)
+}
+
+func (fltr *filter) Rules() (FilterRules, error) {
+	var rules FilterRules
+	var err error
+
+	if rules.Instance, err = fltr.chain.ListRules(iptables.Filter); err != nil {
+		return FilterRules{}, err
+	}
+
+	if rules.Log, err = fltr.chain.ListLogRules(); err != nil {
+		return FilterRules{}, err
+	}
+
+	// net.sh names the container's NAT chain after its filter chain
+	if rules.NAT, err = fltr.chain.ListRules(iptables.Nat); err != nil {
+		return FilterRules{}, err
+	}
+
+	if fltr.ipv6Chain == nil {
+		return rules, nil
+	}
+
+	if rules.IPv6Instance, err = fltr.ipv6Chain.ListRules(iptables.Filter); err != nil {
+		return FilterRules{}, err
+	}
+
+	if rules.IPv6NAT, err = fltr.ipv6Chain.ListRules(iptables.Nat); err != nil {
+		return FilterRules{}, err
+	}
+
+	return rules, nil
+}
+
+// eachChain splits a rule's networks by address family and applies each part
+// to the chain for that family. If the IPv6 part fails, the IPv4 part is
+// undone, so that the rule is never left applied to only one family.
+func (fltr *filter) eachChain(r garden.NetOutRule, apply, undo func(iptables.Chain, garden.NetOutRule) error) error {
+	if fltr.ipv6Chain == nil && len(r.Networks) == 0 {
+		return apply(fltr.chain, r)
+	}
+
+	v4, v6 := r, r
+	v4.Networks, v6.Networks = nil, nil
+
+	for _, network := range r.Networks {
+		isV6, err := isIPv6Range(network)
+		if err != nil {
+			return err
+		}
+
+		if isV6 {
+			v6.Networks = append(v6.Networks, network)
+		} else {
+			v4.Networks = append(v4.Networks, network)
+		}
+	}
+
+	if len(v6.Networks) > 0 && fltr.ipv6Chain == nil {
+		return ErrIPv6NotEnabled
+	}
+
+	appliedV4 := false
+	if len(r.Networks) == 0 || len(v4.Networks) > 0 {
+		if err := apply(fltr.chain, v4); err != nil {
+			return err
+		}
+
+		appliedV4 = true
+	}
+
+	if fltr.ipv6Chain != nil && (len(r.Networks) == 0 || len(v6.Networks) > 0) {
+		if err := apply(fltr.ipv6Chain, v6); err != nil {
+			if appliedV4 {
+				if undoErr := undo(fltr.chain, v4); undoErr != nil {
+					return fmt.Errorf("network: %v (and undoing the ipv4 rule: %v)", err, undoErr)
+				}
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eachForward applies a forward to the IPv4 chain and, if the container has an
+// IPv6 address, to the IPv6 chain, undoing the IPv4 part if the IPv6 part
+// fails.
+func (fltr *filter) eachForward(forward iptables.PortForward, ipv6ContainerIP net.IP, apply, undo func(iptables.Chain, iptables.PortForward) error) error {
+	if err := apply(fltr.chain, forward); err != nil {
+		return err
+	}
+
+	if fltr.ipv6Chain == nil || ipv6ContainerIP == nil {
+		return nil
+	}
+
+	v6 := forward
+	v6.Destination = nil
+	v6.ContainerIP = ipv6ContainerIP
+
+	if err := apply(fltr.ipv6Chain, v6); err != nil {
+		if undoErr := undo(fltr.chain, forward); undoErr != nil {
+			return fmt.Errorf("network: %v (and undoing the ipv4 rules: %v)", err, undoErr)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func isIPv6Range(r garden.IPRange) (bool, error) {
+	startV6 := isIPv6(r.Start)
+	endV6 := isIPv6(r.End)
+
+	if r.Start != nil && r.End != nil && startV6 != endV6 {
+		return false, MixedIPRangeError{r}
+	}
+
+	return startV6 || endV6, nil
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil
 }
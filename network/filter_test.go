@@ -2,9 +2,11 @@ package network_test
 
 import (
 	"errors"
+	"net"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/network"
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 	"github.com/cloudfoundry-incubator/garden-linux/network/iptables/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -61,4 +63,295 @@ var _ = Describe("Filter", func() {
 			Expect(filter.NetOut(garden.NetOutRule{})).To(MatchError("iptables says no"))
 		})
 	})
+
+	Context("NetOutRemove", func() {
+		It("deletes the rule from the chain", func() {
+			rule := garden.NetOutRule{Protocol: garden.ProtocolTCP}
+			Expect(filter.NetOutRemove(rule)).To(Succeed())
+
+			Expect(fakeChain.DeleteFilterRuleCallCount()).To(Equal(1))
+			Expect(fakeChain.DeleteFilterRuleArgsForCall(0)).To(Equal(rule))
+		})
+
+		It("returns an error if one occurs", func() {
+			fakeChain.DeleteFilterRuleReturns(errors.New("iptables says no"))
+			Expect(filter.NetOutRemove(garden.NetOutRule{})).To(MatchError("iptables says no"))
+		})
+	})
+
+	Context("NetIn", func() {
+		forward := iptables.PortForward{
+			Protocol:      garden.ProtocolTCP,
+			Destination:   net.ParseIP("1.2.3.4"),
+			HostPort:      1000,
+			ContainerIP:   net.ParseIP("10.0.0.2"),
+			ContainerPort: 8080,
+			PortCount:     1,
+		}
+
+		It("appends the DNAT rules to the chain", func() {
+			Expect(filter.NetIn(forward, nil)).To(Succeed())
+
+			Expect(fakeChain.AppendDNATRulesCallCount()).To(Equal(1))
+			Expect(fakeChain.AppendDNATRulesArgsForCall(0)).To(Equal(forward))
+		})
+
+		It("ignores the IPv6 address", func() {
+			Expect(filter.NetIn(forward, net.ParseIP("2001:db8::2"))).To(Succeed())
+			Expect(fakeChain.AppendDNATRulesCallCount()).To(Equal(1))
+		})
+
+		It("returns an error if one occurs", func() {
+			fakeChain.AppendDNATRulesReturns(errors.New("iptables says no"))
+			Expect(filter.NetIn(forward, nil)).To(MatchError("iptables says no"))
+		})
+	})
+
+	Context("NetInRemove", func() {
+		It("deletes the DNAT rules from the chain", func() {
+			forward := iptables.PortForward{Protocol: garden.ProtocolUDP, HostPort: 1000, PortCount: 1}
+			Expect(filter.NetInRemove(forward, nil)).To(Succeed())
+
+			Expect(fakeChain.DeleteDNATRulesCallCount()).To(Equal(1))
+			Expect(fakeChain.DeleteDNATRulesArgsForCall(0)).To(Equal(forward))
+		})
+	})
+
+	Context("Rules", func() {
+		It("lists the instance, log and NAT chains", func() {
+			instance := []garden.IPTablesRule{{Table: "filter", Chain: "instance", Target: "RETURN"}}
+			nat := []garden.IPTablesRule{{Table: "nat", Chain: "instance", Target: "DNAT"}}
+			log := []garden.IPTablesRule{{Table: "filter", Chain: "instance-log", Target: "LOG"}}
+
+			fakeChain.ListRulesStub = func(table iptables.Type) ([]garden.IPTablesRule, error) {
+				if table == iptables.Nat {
+					return nat, nil
+				}
+
+				return instance, nil
+			}
+			fakeChain.ListLogRulesReturns(log, nil)
+
+			rules, err := filter.Rules()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules).To(Equal(network.FilterRules{
+				Instance: instance,
+				Log:      log,
+				NAT:      nat,
+			}))
+		})
+
+		It("returns an error if listing a chain fails", func() {
+			fakeChain.ListLogRulesReturns(nil, errors.New("iptables says no"))
+
+			_, err := filter.Rules()
+			Expect(err).To(MatchError("iptables says no"))
+		})
+	})
+
+	Describe("with an IPv6 chain", func() {
+		var fakeIPv6Chain *fakes.FakeChain
+
+		BeforeEach(func() {
+			fakeIPv6Chain = new(fakes.FakeChain)
+			filter = network.NewDualStackFilter(fakeChain, fakeIPv6Chain)
+		})
+
+		It("sets up and tears down both chains", func() {
+			Expect(filter.Setup("logPrefix")).To(Succeed())
+			Expect(fakeIPv6Chain.SetupCallCount()).To(Equal(1))
+			Expect(fakeIPv6Chain.SetupArgsForCall(0)).To(Equal("logPrefix"))
+
+			filter.TearDown()
+			Expect(fakeChain.TearDownCallCount()).To(Equal(1))
+			Expect(fakeIPv6Chain.TearDownCallCount()).To(Equal(1))
+		})
+
+		Context("when the IPv6 chain setup returns an error", func() {
+			It("wraps the error and returns it", func() {
+				fakeIPv6Chain.SetupReturns(errors.New("x"))
+				Expect(filter.Setup("logPrefix")).To(MatchError("network: ipv6 log chain setup: x"))
+			})
+		})
+
+		It("sends a rule without networks to both chains", func() {
+			rule := garden.NetOutRule{Protocol: garden.ProtocolTCP}
+			Expect(filter.NetOut(rule)).To(Succeed())
+
+			Expect(fakeChain.PrependFilterRuleArgsForCall(0)).To(Equal(rule))
+			Expect(fakeIPv6Chain.PrependFilterRuleArgsForCall(0)).To(Equal(rule))
+		})
+
+		It("sends each network to the chain for its address family", func() {
+			v4 := garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))
+			v6 := garden.IPRange{Start: net.ParseIP("2001:db8::1"), End: net.ParseIP("2001:db8::ff")}
+
+			Expect(filter.NetOut(garden.NetOutRule{Networks: []garden.IPRange{v4, v6}})).To(Succeed())
+
+			Expect(fakeChain.PrependFilterRuleCallCount()).To(Equal(1))
+			Expect(fakeChain.PrependFilterRuleArgsForCall(0).Networks).To(Equal([]garden.IPRange{v4}))
+			Expect(fakeIPv6Chain.PrependFilterRuleCallCount()).To(Equal(1))
+			Expect(fakeIPv6Chain.PrependFilterRuleArgsForCall(0).Networks).To(Equal([]garden.IPRange{v6}))
+		})
+
+		It("only removes IPv6 networks from the IPv6 chain", func() {
+			v6 := garden.IPRangeFromIP(net.ParseIP("2001:db8::1"))
+
+			Expect(filter.NetOutRemove(garden.NetOutRule{Networks: []garden.IPRange{v6}})).To(Succeed())
+
+			Expect(fakeChain.DeleteFilterRuleCallCount()).To(Equal(0))
+			Expect(fakeIPv6Chain.DeleteFilterRuleCallCount()).To(Equal(1))
+		})
+
+		Context("when applying the IPv6 part of a rule fails", func() {
+			v4 := garden.IPRangeFromIP(net.ParseIP("1.2.3.4"))
+			v6 := garden.IPRangeFromIP(net.ParseIP("2001:db8::1"))
+			rule := garden.NetOutRule{Networks: []garden.IPRange{v4, v6}}
+
+			BeforeEach(func() {
+				fakeIPv6Chain.PrependFilterRuleReturns(errors.New("ip6tables says no"))
+				fakeIPv6Chain.DeleteFilterRuleReturns(errors.New("ip6tables says no"))
+			})
+
+			It("removes the IPv4 part it added", func() {
+				Expect(filter.NetOut(rule)).To(MatchError("ip6tables says no"))
+
+				Expect(fakeChain.PrependFilterRuleCallCount()).To(Equal(1))
+				Expect(fakeChain.DeleteFilterRuleCallCount()).To(Equal(1))
+				Expect(fakeChain.DeleteFilterRuleArgsForCall(0)).To(Equal(fakeChain.PrependFilterRuleArgsForCall(0)))
+			})
+
+			It("puts back the IPv4 part it removed", func() {
+				Expect(filter.NetOutRemove(rule)).To(MatchError("ip6tables says no"))
+
+				Expect(fakeChain.DeleteFilterRuleCallCount()).To(Equal(1))
+				Expect(fakeChain.PrependFilterRuleCallCount()).To(Equal(1))
+				Expect(fakeChain.PrependFilterRuleArgsForCall(0)).To(Equal(fakeChain.DeleteFilterRuleArgsForCall(0)))
+			})
+
+			Context("and undoing the IPv4 part fails too", func() {
+				BeforeEach(func() {
+					fakeChain.DeleteFilterRuleReturns(errors.New("iptables says no"))
+				})
+
+				It("returns both errors", func() {
+					Expect(filter.NetOut(rule)).To(MatchError("network: ip6tables says no (and undoing the ipv4 rule: iptables says no)"))
+				})
+			})
+		})
+
+		Describe("NetIn", func() {
+			forward := iptables.PortForward{
+				Protocol:      garden.ProtocolTCP,
+				Destination:   net.ParseIP("1.2.3.4"),
+				HostPort:      1000,
+				ContainerIP:   net.ParseIP("10.0.0.2"),
+				ContainerPort: 8080,
+				PortCount:     1,
+			}
+			ipv6ContainerIP := net.ParseIP("2001:db8::2")
+
+			It("forwards from any local IPv6 address to the container's IPv6 address", func() {
+				Expect(filter.NetIn(forward, ipv6ContainerIP)).To(Succeed())
+
+				Expect(fakeChain.AppendDNATRulesArgsForCall(0)).To(Equal(forward))
+
+				v6 := forward
+				v6.Destination = nil
+				v6.ContainerIP = ipv6ContainerIP
+				Expect(fakeIPv6Chain.AppendDNATRulesCallCount()).To(Equal(1))
+				Expect(fakeIPv6Chain.AppendDNATRulesArgsForCall(0)).To(Equal(v6))
+			})
+
+			It("only forwards IPv4 when the container has no IPv6 address", func() {
+				Expect(filter.NetIn(forward, nil)).To(Succeed())
+
+				Expect(fakeChain.AppendDNATRulesCallCount()).To(Equal(1))
+				Expect(fakeIPv6Chain.AppendDNATRulesCallCount()).To(Equal(0))
+			})
+
+			Context("when the IPv6 part fails", func() {
+				BeforeEach(func() {
+					fakeIPv6Chain.AppendDNATRulesReturns(errors.New("ip6tables says no"))
+					fakeIPv6Chain.DeleteDNATRulesReturns(errors.New("ip6tables says no"))
+				})
+
+				It("removes the IPv4 rules it added", func() {
+					Expect(filter.NetIn(forward, ipv6ContainerIP)).To(MatchError("ip6tables says no"))
+
+					Expect(fakeChain.DeleteDNATRulesCallCount()).To(Equal(1))
+					Expect(fakeChain.DeleteDNATRulesArgsForCall(0)).To(Equal(forward))
+				})
+
+				It("puts back the IPv4 rules it removed", func() {
+					Expect(filter.NetInRemove(forward, ipv6ContainerIP)).To(MatchError("ip6tables says no"))
+
+					Expect(fakeChain.AppendDNATRulesCallCount()).To(Equal(1))
+					Expect(fakeChain.AppendDNATRulesArgsForCall(0)).To(Equal(forward))
+				})
+
+				Context("and undoing the IPv4 part fails too", func() {
+					BeforeEach(func() {
+						fakeChain.DeleteDNATRulesReturns(errors.New("iptables says no"))
+					})
+
+					It("returns both errors", func() {
+						Expect(filter.NetIn(forward, ipv6ContainerIP)).To(MatchError("network: ip6tables says no (and undoing the ipv4 rules: iptables says no)"))
+					})
+				})
+			})
+		})
+
+		It("lists the IPv6 instance and NAT chains too", func() {
+			instance := []garden.IPTablesRule{{Table: "filter", Chain: "instance", Target: "RETURN"}}
+			nat := []garden.IPTablesRule{{Table: "nat", Chain: "instance", Target: "DNAT"}}
+			ipv6Instance := []garden.IPTablesRule{{Table: "filter", Chain: "instance", Destination: "2001:db8::1/128", Target: "RETURN"}}
+			ipv6NAT := []garden.IPTablesRule{{Table: "nat", Chain: "instance", Target: "DNAT", ToDestination: "[2001:db8::2]:80"}}
+
+			listRules := func(filterRules, natRules []garden.IPTablesRule) func(iptables.Type) ([]garden.IPTablesRule, error) {
+				return func(table iptables.Type) ([]garden.IPTablesRule, error) {
+					if table == iptables.Nat {
+						return natRules, nil
+					}
+
+					return filterRules, nil
+				}
+			}
+
+			fakeChain.ListRulesStub = listRules(instance, nat)
+			fakeIPv6Chain.ListRulesStub = listRules(ipv6Instance, ipv6NAT)
+
+			rules, err := filter.Rules()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rules.Instance).To(Equal(instance))
+			Expect(rules.NAT).To(Equal(nat))
+			Expect(rules.IPv6Instance).To(Equal(ipv6Instance))
+			Expect(rules.IPv6NAT).To(Equal(ipv6NAT))
+		})
+
+		It("returns an error if listing an IPv6 chain fails", func() {
+			fakeIPv6Chain.ListRulesReturns(nil, errors.New("ip6tables says no"))
+
+			_, err := filter.Rules()
+			Expect(err).To(MatchError("ip6tables says no"))
+		})
+
+		It("rejects a range mixing address families", func() {
+			mixed := garden.IPRange{Start: net.ParseIP("1.2.3.4"), End: net.ParseIP("2001:db8::1")}
+
+			err := filter.NetOut(garden.NetOutRule{Networks: []garden.IPRange{mixed}})
+			Expect(err).To(MatchError(network.MixedIPRangeError{mixed}))
+			Expect(fakeChain.PrependFilterRuleCallCount()).To(Equal(0))
+			Expect(fakeIPv6Chain.PrependFilterRuleCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when there is no IPv6 chain", func() {
+		It("rejects rules naming IPv6 networks", func() {
+			v6 := garden.IPRangeFromIP(net.ParseIP("2001:db8::1"))
+
+			Expect(filter.NetOut(garden.NetOutRule{Networks: []garden.IPRange{v6}})).To(MatchError(network.ErrIPv6NotEnabled))
+			Expect(fakeChain.PrependFilterRuleCallCount()).To(Equal(0))
+		})
+	})
 })
@@ -74,6 +74,46 @@ type FakeChain struct {
 	prependFilterRuleReturns struct {
 		result1 error
 	}
+	DeleteFilterRuleStub        func(rule garden.NetOutRule) error
+	deleteFilterRuleMutex       sync.RWMutex
+	deleteFilterRuleArgsForCall []struct {
+		rule garden.NetOutRule
+	}
+	deleteFilterRuleReturns struct {
+		result1 error
+	}
+	AppendDNATRulesStub        func(forward iptables.PortForward) error
+	appendDNATRulesMutex       sync.RWMutex
+	appendDNATRulesArgsForCall []struct {
+		forward iptables.PortForward
+	}
+	appendDNATRulesReturns struct {
+		result1 error
+	}
+	DeleteDNATRulesStub        func(forward iptables.PortForward) error
+	deleteDNATRulesMutex       sync.RWMutex
+	deleteDNATRulesArgsForCall []struct {
+		forward iptables.PortForward
+	}
+	deleteDNATRulesReturns struct {
+		result1 error
+	}
+	ListRulesStub        func(table iptables.Type) ([]garden.IPTablesRule, error)
+	listRulesMutex       sync.RWMutex
+	listRulesArgsForCall []struct {
+		table iptables.Type
+	}
+	listRulesReturns struct {
+		result1 []garden.IPTablesRule
+		result2 error
+	}
+	ListLogRulesStub        func() ([]garden.IPTablesRule, error)
+	listLogRulesMutex       sync.RWMutex
+	listLogRulesArgsForCall []struct{}
+	listLogRulesReturns     struct {
+		result1 []garden.IPTablesRule
+		result2 error
+	}
 }
 
 func (fake *FakeChain) Setup(logPrefix string) error {
@@ -303,3 +343,159 @@ func (fake *FakeChain) PrependFilterRuleReturns(result1 error) {
 }
 
 var _ iptables.Chain = new(FakeChain)
+
+func (fake *FakeChain) DeleteFilterRule(rule garden.NetOutRule) error {
+	fake.deleteFilterRuleMutex.Lock()
+	fake.deleteFilterRuleArgsForCall = append(fake.deleteFilterRuleArgsForCall, struct {
+		rule garden.NetOutRule
+	}{rule})
+	fake.deleteFilterRuleMutex.Unlock()
+	if fake.DeleteFilterRuleStub != nil {
+		return fake.DeleteFilterRuleStub(rule)
+	} else {
+		return fake.deleteFilterRuleReturns.result1
+	}
+}
+
+func (fake *FakeChain) DeleteFilterRuleCallCount() int {
+	fake.deleteFilterRuleMutex.RLock()
+	defer fake.deleteFilterRuleMutex.RUnlock()
+	return len(fake.deleteFilterRuleArgsForCall)
+}
+
+func (fake *FakeChain) DeleteFilterRuleArgsForCall(i int) garden.NetOutRule {
+	fake.deleteFilterRuleMutex.RLock()
+	defer fake.deleteFilterRuleMutex.RUnlock()
+	return fake.deleteFilterRuleArgsForCall[i].rule
+}
+
+func (fake *FakeChain) DeleteFilterRuleReturns(result1 error) {
+	fake.DeleteFilterRuleStub = nil
+	fake.deleteFilterRuleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChain) AppendDNATRules(forward iptables.PortForward) error {
+	fake.appendDNATRulesMutex.Lock()
+	fake.appendDNATRulesArgsForCall = append(fake.appendDNATRulesArgsForCall, struct {
+		forward iptables.PortForward
+	}{forward})
+	fake.appendDNATRulesMutex.Unlock()
+	if fake.AppendDNATRulesStub != nil {
+		return fake.AppendDNATRulesStub(forward)
+	} else {
+		return fake.appendDNATRulesReturns.result1
+	}
+}
+
+func (fake *FakeChain) AppendDNATRulesCallCount() int {
+	fake.appendDNATRulesMutex.RLock()
+	defer fake.appendDNATRulesMutex.RUnlock()
+	return len(fake.appendDNATRulesArgsForCall)
+}
+
+func (fake *FakeChain) AppendDNATRulesArgsForCall(i int) iptables.PortForward {
+	fake.appendDNATRulesMutex.RLock()
+	defer fake.appendDNATRulesMutex.RUnlock()
+	return fake.appendDNATRulesArgsForCall[i].forward
+}
+
+func (fake *FakeChain) AppendDNATRulesReturns(result1 error) {
+	fake.AppendDNATRulesStub = nil
+	fake.appendDNATRulesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChain) DeleteDNATRules(forward iptables.PortForward) error {
+	fake.deleteDNATRulesMutex.Lock()
+	fake.deleteDNATRulesArgsForCall = append(fake.deleteDNATRulesArgsForCall, struct {
+		forward iptables.PortForward
+	}{forward})
+	fake.deleteDNATRulesMutex.Unlock()
+	if fake.DeleteDNATRulesStub != nil {
+		return fake.DeleteDNATRulesStub(forward)
+	} else {
+		return fake.deleteDNATRulesReturns.result1
+	}
+}
+
+func (fake *FakeChain) DeleteDNATRulesCallCount() int {
+	fake.deleteDNATRulesMutex.RLock()
+	defer fake.deleteDNATRulesMutex.RUnlock()
+	return len(fake.deleteDNATRulesArgsForCall)
+}
+
+func (fake *FakeChain) DeleteDNATRulesArgsForCall(i int) iptables.PortForward {
+	fake.deleteDNATRulesMutex.RLock()
+	defer fake.deleteDNATRulesMutex.RUnlock()
+	return fake.deleteDNATRulesArgsForCall[i].forward
+}
+
+func (fake *FakeChain) DeleteDNATRulesReturns(result1 error) {
+	fake.DeleteDNATRulesStub = nil
+	fake.deleteDNATRulesReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeChain) ListRules(table iptables.Type) ([]garden.IPTablesRule, error) {
+	fake.listRulesMutex.Lock()
+	fake.listRulesArgsForCall = append(fake.listRulesArgsForCall, struct {
+		table iptables.Type
+	}{table})
+	fake.listRulesMutex.Unlock()
+	if fake.ListRulesStub != nil {
+		return fake.ListRulesStub(table)
+	} else {
+		return fake.listRulesReturns.result1, fake.listRulesReturns.result2
+	}
+}
+
+func (fake *FakeChain) ListRulesCallCount() int {
+	fake.listRulesMutex.RLock()
+	defer fake.listRulesMutex.RUnlock()
+	return len(fake.listRulesArgsForCall)
+}
+
+func (fake *FakeChain) ListRulesArgsForCall(i int) iptables.Type {
+	fake.listRulesMutex.RLock()
+	defer fake.listRulesMutex.RUnlock()
+	return fake.listRulesArgsForCall[i].table
+}
+
+func (fake *FakeChain) ListRulesReturns(result1 []garden.IPTablesRule, result2 error) {
+	fake.ListRulesStub = nil
+	fake.listRulesReturns = struct {
+		result1 []garden.IPTablesRule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeChain) ListLogRules() ([]garden.IPTablesRule, error) {
+	fake.listLogRulesMutex.Lock()
+	fake.listLogRulesArgsForCall = append(fake.listLogRulesArgsForCall, struct{}{})
+	fake.listLogRulesMutex.Unlock()
+	if fake.ListLogRulesStub != nil {
+		return fake.ListLogRulesStub()
+	} else {
+		return fake.listLogRulesReturns.result1, fake.listLogRulesReturns.result2
+	}
+}
+
+func (fake *FakeChain) ListLogRulesCallCount() int {
+	fake.listLogRulesMutex.RLock()
+	defer fake.listLogRulesMutex.RUnlock()
+	return len(fake.listLogRulesArgsForCall)
+}
+
+func (fake *FakeChain) ListLogRulesReturns(result1 []garden.IPTablesRule, result2 error) {
+	fake.ListLogRulesStub = nil
+	fake.listLogRulesReturns = struct {
+		result1 []garden.IPTablesRule
+		result2 error
+	}{result1, result2}
+}
+
+var _ iptables.Chain = new(FakeChain)
@@ -0,0 +1,95 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
+)
+
+type FakeRuleManager struct {
+	ApplyStub        func(rules []iptables.Rule) ([]iptables.Rule, error)
+	applyMutex       sync.RWMutex
+	applyArgsForCall []struct {
+		rules []iptables.Rule
+	}
+	applyReturns struct {
+		result1 []iptables.Rule
+		result2 error
+	}
+	ExecStub        func(rules []iptables.Rule) error
+	execMutex       sync.RWMutex
+	execArgsForCall []struct {
+		rules []iptables.Rule
+	}
+	execReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeRuleManager) Apply(rules []iptables.Rule) ([]iptables.Rule, error) {
+	fake.applyMutex.Lock()
+	fake.applyArgsForCall = append(fake.applyArgsForCall, struct {
+		rules []iptables.Rule
+	}{rules})
+	fake.applyMutex.Unlock()
+	if fake.ApplyStub != nil {
+		return fake.ApplyStub(rules)
+	} else {
+		return fake.applyReturns.result1, fake.applyReturns.result2
+	}
+}
+
+func (fake *FakeRuleManager) ApplyCallCount() int {
+	fake.applyMutex.RLock()
+	defer fake.applyMutex.RUnlock()
+	return len(fake.applyArgsForCall)
+}
+
+func (fake *FakeRuleManager) ApplyArgsForCall(i int) []iptables.Rule {
+	fake.applyMutex.RLock()
+	defer fake.applyMutex.RUnlock()
+	return fake.applyArgsForCall[i].rules
+}
+
+func (fake *FakeRuleManager) ApplyReturns(result1 []iptables.Rule, result2 error) {
+	fake.ApplyStub = nil
+	fake.applyReturns = struct {
+		result1 []iptables.Rule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRuleManager) Exec(rules []iptables.Rule) error {
+	fake.execMutex.Lock()
+	fake.execArgsForCall = append(fake.execArgsForCall, struct {
+		rules []iptables.Rule
+	}{rules})
+	fake.execMutex.Unlock()
+	if fake.ExecStub != nil {
+		return fake.ExecStub(rules)
+	} else {
+		return fake.execReturns.result1
+	}
+}
+
+func (fake *FakeRuleManager) ExecCallCount() int {
+	fake.execMutex.RLock()
+	defer fake.execMutex.RUnlock()
+	return len(fake.execArgsForCall)
+}
+
+func (fake *FakeRuleManager) ExecArgsForCall(i int) []iptables.Rule {
+	fake.execMutex.RLock()
+	defer fake.execMutex.RUnlock()
+	return fake.execArgsForCall[i].rules
+}
+
+func (fake *FakeRuleManager) ExecReturns(result1 error) {
+	fake.ExecStub = nil
+	fake.execReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ iptables.RuleManager = new(FakeRuleManager)
@@ -14,6 +14,11 @@ import (
 	"github.com/pivotal-golang/lager"
 )
 
+const (
+	iptablesBin  = "/sbin/iptables"
+	ip6tablesBin = "/sbin/ip6tables"
+)
+
 var protocols = map[garden.Protocol]string{
 	garden.ProtocolAll:  "all",
 	garden.ProtocolTCP:  "tcp",
@@ -25,13 +30,25 @@ var protocols = map[garden.Protocol]string{
 // The chain is not created by this package (currently it is created in net.sh).
 // It is an error to attempt to call Setup on this chain.
 func NewGlobalChain(name string, runner command_runner.CommandRunner, log lager.Logger) Chain {
-	return &chain{name: name, logChainName: "", runner: runner, logger: log}
+	return &chain{name: name, logChainName: "", binary: iptablesBin, rules: NewRuleManager(runner, log), runner: runner, logger: log}
+}
+
+// NewIPv6GlobalChain creates a chain without an associated log chain,
+// managed with ip6tables. Rules appended to it must only name IPv6 networks.
+func NewIPv6GlobalChain(name string, runner command_runner.CommandRunner, log lager.Logger) Chain {
+	return &chain{name: name, logChainName: "", binary: ip6tablesBin, rules: NewIPv6RuleManager(runner, log), runner: runner, logger: log}
 }
 
 // NewLoggingChain creates a chain with an associated log chain.
 // This allows NetOut calls with the 'log' parameter to succesfully log.
 func NewLoggingChain(name string, useKernelLogging bool, runner command_runner.CommandRunner, logger lager.Logger) Chain {
-	return &chain{name: name, logChainName: name + "-log", useKernelLogging: useKernelLogging, runner: runner, logger: logger}
+	return &chain{name: name, logChainName: name + "-log", useKernelLogging: useKernelLogging, binary: iptablesBin, rules: NewRuleManager(runner, logger), runner: runner, logger: logger}
+}
+
+// NewIPv6LoggingChain creates a logging chain managed with ip6tables.
+// Rules prepended to it must only name IPv6 networks.
+func NewIPv6LoggingChain(name string, useKernelLogging bool, runner command_runner.CommandRunner, logger lager.Logger) Chain {
+	return &chain{name: name, logChainName: name + "-log", useKernelLogging: useKernelLogging, binary: ip6tablesBin, rules: NewIPv6RuleManager(runner, logger), runner: runner, logger: logger}
 }
 
 //go:generate counterfeiter . Chain
@@ -50,6 +67,22 @@ type Chain interface {
 	DeleteNatRule(source string, destination string, jump Action, to net.IP) error
 
 	PrependFilterRule(rule garden.NetOutRule) error
+
+	// DeleteFilterRule deletes the rules PrependFilterRule inserted for rule.
+	DeleteFilterRule(rule garden.NetOutRule) error
+
+	// AppendDNATRules appends the NAT table rules forwarding the ports of
+	// forward, all of them or none.
+	AppendDNATRules(forward PortForward) error
+
+	// DeleteDNATRules deletes the rules AppendDNATRules appended for forward.
+	DeleteDNATRules(forward PortForward) error
+
+	// ListRules lists the rules of the chain with this chain's name in table.
+	ListRules(table Type) ([]garden.IPTablesRule, error)
+
+	// ListLogRules lists the rules of the log chain, if there is one.
+	ListLogRules() ([]garden.IPTablesRule, error)
 }
 
 type chain struct {
@@ -57,6 +90,8 @@ type chain struct {
 	name             string
 	logChainName     string
 	useKernelLogging bool
+	binary           string
+	rules            RuleManager
 	runner           command_runner.CommandRunner
 	logger           lager.Logger
 }
@@ -72,19 +107,14 @@ func (ch *chain) Setup(logPrefix string) error {
 
 	ch.TearDown()
 
-	if err := ch.runner.Run(exec.Command("/sbin/iptables", "-w", "-N", ch.logChainName)); err != nil {
-		return fmt.Errorf("iptables: log chain setup: %v", err)
-	}
-	ch.logger.Debug("log-chain-created")
-
 	logParams := ch.buildLogParams(logPrefix)
-	appendFlags := []string{"-w", "-A", ch.logChainName, "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID", "--protocol", "tcp"}
-	if err := ch.runner.Run(exec.Command("/sbin/iptables", append(appendFlags, logParams...)...)); err != nil {
-		return fmt.Errorf("iptables: log chain setup: %v", err)
-	}
-	ch.logger.Debug("log-chain-conntrack-set-up")
+	conntrackFlags := []string{"-A", ch.logChainName, "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID", "--protocol", "tcp"}
 
-	if err := ch.runner.Run(exec.Command("/sbin/iptables", "-w", "-A", ch.logChainName, "--jump", "RETURN")); err != nil {
+	if _, err := ch.rules.Apply([]Rule{
+		{Args: []string{"-N", ch.logChainName}},
+		{Args: append(conntrackFlags, logParams...)},
+		{Args: []string{"-A", ch.logChainName, "--jump", "RETURN"}},
+	}); err != nil {
 		return fmt.Errorf("iptables: log chain setup: %v", err)
 	}
 	ch.logger.Debug("log-chain-setup-finished")
@@ -92,6 +122,31 @@ func (ch *chain) Setup(logPrefix string) error {
 	return nil
 }
 
+func (ch *chain) ListRules(table Type) ([]garden.IPTablesRule, error) {
+	return ch.listRules(table, ch.name)
+}
+
+func (ch *chain) ListLogRules() ([]garden.IPTablesRule, error) {
+	if ch.logChainName == "" {
+		return nil, nil
+	}
+
+	return ch.listRules(Filter, ch.logChainName)
+}
+
+func (ch *chain) listRules(table Type, name string) ([]garden.IPTablesRule, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(ch.binary, "-w", "-t", string(table), "-S", name)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := ch.runner.Run(cmd); err != nil {
+		return nil, fmt.Errorf("iptables: listing %s table: %v, %v", table, err, stderr.String())
+	}
+
+	return parseRules(table, stdout.String()), nil
+}
+
 func (ch *chain) buildLogParams(logPrefix string) []string {
 	if ch.useKernelLogging {
 		return []string{"--jump", "LOG", "--log-prefix", logPrefix}
@@ -106,8 +161,12 @@ func (ch *chain) TearDown() error {
 		panic("cannot tear down chains without associated log chains")
 	}
 
-	ch.runner.Run(exec.Command("/sbin/iptables", "-w", "-F", ch.logChainName))
-	ch.runner.Run(exec.Command("/sbin/iptables", "-w", "-X", ch.logChainName))
+	// the log chain may well not exist, so failures are ignored
+	ch.rules.Exec([]Rule{
+		{Args: []string{"-F", ch.logChainName}},
+		{Args: []string{"-X", ch.logChainName}},
+	})
+
 	return nil
 }
 
@@ -155,8 +214,41 @@ type singleRule struct {
 	Log      bool
 }
 
+// PrependFilterRule inserts the iptables rules for r at the top of the chain,
+// all of them or none.
 func (ch *chain) PrependFilterRule(r garden.NetOutRule) error {
+	return ch.applyFilterRule(r, []string{"-I", ch.name, "1"}, "prepend-filter-rule")
+}
+
+// DeleteFilterRule deletes the iptables rules for r, all of them or none.
+func (ch *chain) DeleteFilterRule(r garden.NetOutRule) error {
+	return ch.applyFilterRule(r, []string{"-D", ch.name}, "delete-filter-rule")
+}
+
+func (ch *chain) applyFilterRule(r garden.NetOutRule, command []string, action string) error {
+	var rules []Rule
+	err := ch.eachSingleRule(r, func(single singleRule) error {
+		match, err := ch.singleRuleSpec(single)
+		if err != nil {
+			return err
+		}
+
+		rules = append(rules, Rule{Args: append(append([]string{}, command...), match...)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	applied, err := ch.rules.Apply(rules)
+	ch.logger.Debug(action, lager.Data{"applied": ruleStrings(applied)})
 
+	return err
+}
+
+// eachSingleRule expands a rule into one iptables rule per network and port
+// range it names.
+func (ch *chain) eachSingleRule(r garden.NetOutRule, apply func(singleRule) error) error {
 	if len(r.Ports) > 0 && !allowsPort(r.Protocol) {
 		return fmt.Errorf("Ports cannot be specified for Protocol %s", strings.ToUpper(protocols[r.Protocol]))
 	}
@@ -181,7 +273,7 @@ func (ch *chain) PrependFilterRule(r garden.NetOutRule) error {
 				single.Networks = &r.Networks[j]
 			}
 
-			if err := ch.prependSingleRule(single); err != nil {
+			if err := apply(single); err != nil {
 				return err
 			}
 		}
@@ -194,16 +286,20 @@ func allowsPort(p garden.Protocol) bool {
 	return p == garden.ProtocolTCP || p == garden.ProtocolUDP
 }
 
-func (ch *chain) prependSingleRule(r singleRule) error {
-	params := []string{"-w", "-I", ch.name, "1"}
-
+// singleRuleSpec builds the match and target of a rule, identical whether it
+// is being inserted or deleted.
+func (ch *chain) singleRuleSpec(r singleRule) ([]string, error) {
 	protocolString, ok := protocols[r.Protocol]
 
 	if !ok {
-		return fmt.Errorf("invalid protocol: %d", r.Protocol)
+		return nil, fmt.Errorf("invalid protocol: %d", r.Protocol)
 	}
 
-	params = append(params, "--protocol", protocolString)
+	if ch.binary == ip6tablesBin && r.Protocol == garden.ProtocolICMP {
+		protocolString = "icmpv6"
+	}
+
+	params := []string{"--protocol", protocolString}
 
 	network := r.Networks
 	if network != nil {
@@ -231,7 +327,11 @@ func (ch *chain) prependSingleRule(r singleRule) error {
 			icmpType = fmt.Sprintf("%d/%d", r.ICMPs.Type, *r.ICMPs.Code)
 		}
 
-		params = append(params, "--icmp-type", icmpType)
+		if ch.binary == ip6tablesBin {
+			params = append(params, "--icmpv6-type", icmpType)
+		} else {
+			params = append(params, "--icmp-type", icmpType)
+		}
 	}
 
 	if r.Log {
@@ -240,17 +340,95 @@ func (ch *chain) prependSingleRule(r singleRule) error {
 		params = append(params, "--jump", "RETURN")
 	}
 
-	ch.logger.Debug("prepend-filter-rule", lager.Data{"parms": params})
+	return params, nil
+}
 
-	var stderr bytes.Buffer
-	cmd := exec.Command("/sbin/iptables", params...)
-	cmd.Stderr = &stderr
-	if err := ch.runner.Run(cmd); err != nil {
-		return fmt.Errorf("iptables: %v, %v", err, stderr.String())
+// PortForward forwards PortCount consecutive ports from HostPort on the host
+// to ContainerPort on ContainerIP.
+type PortForward struct {
+	Protocol garden.Protocol
+
+	// Destination is the host address to forward from, or nil for any of the
+	// host's local addresses.
+	Destination net.IP
+	HostPort    uint32
+
+	ContainerIP   net.IP
+	ContainerPort uint32
+	PortCount     uint32
+}
+
+func (ch *chain) AppendDNATRules(forward PortForward) error {
+	return ch.applyDNATRules(forward, "-A", "append-dnat-rules")
+}
+
+func (ch *chain) DeleteDNATRules(forward PortForward) error {
+	return ch.applyDNATRules(forward, "-D", "delete-dnat-rules")
+}
+
+func (ch *chain) applyDNATRules(forward PortForward, command, action string) error {
+	if !allowsPort(forward.Protocol) {
+		return fmt.Errorf("Ports cannot be forwarded for Protocol %s", strings.ToUpper(protocols[forward.Protocol]))
 	}
-	ch.logger.Debug("prependSingleRule-finished")
 
-	return nil
+	var rules []Rule
+	for _, dnat := range DNATTargets(forward) {
+		args := []string{command, ch.name, "--protocol", protocols[forward.Protocol]}
+
+		if forward.Destination != nil {
+			args = append(args, "--destination", forward.Destination.String())
+		} else {
+			args = append(args, "--match", "addrtype", "--dst-type", "LOCAL")
+		}
+
+		args = append(args,
+			"--destination-port", dnat.DestinationPorts,
+			"--jump", "DNAT",
+			"--to-destination", dnat.ToDestination,
+		)
+
+		rules = append(rules, Rule{Table: Nat, Args: args})
+	}
+
+	applied, err := ch.rules.Apply(rules)
+	ch.logger.Debug(action, lager.Data{"applied": ruleStrings(applied)})
+
+	return err
+}
+
+// A DNATTarget is the destination ports a DNAT rule matches and where it
+// forwards them, as iptables -S prints them: ip6tables -S brackets IPv6
+// addresses followed by a port.
+type DNATTarget struct {
+	DestinationPorts string
+	ToDestination    string
+}
+
+// DNATTargets lists the DNAT rules for a forward: a range forwarded to the
+// same ports keeps them and needs one rule, while shifting a range needs one
+// rule per port.
+func DNATTargets(forward PortForward) []DNATTarget {
+	portCount := forward.PortCount
+	if portCount == 0 {
+		portCount = 1
+	}
+
+	if portCount > 1 && forward.HostPort == forward.ContainerPort {
+		return []DNATTarget{{
+			DestinationPorts: fmt.Sprintf("%d:%d", forward.HostPort, forward.HostPort+portCount-1),
+			ToDestination:    forward.ContainerIP.String(),
+		}}
+	}
+
+	targets := make([]DNATTarget, portCount)
+	for offset := uint32(0); offset < portCount; offset++ {
+		targets[offset] = DNATTarget{
+			DestinationPorts: fmt.Sprintf("%d", forward.HostPort+offset),
+			ToDestination:    net.JoinHostPort(forward.ContainerIP.String(), fmt.Sprintf("%d", forward.ContainerPort+offset)),
+		}
+	}
+
+	return targets
 }
 
 type rule struct {
@@ -261,22 +439,18 @@ type rule struct {
 	jump        Action
 }
 
-func (n *rule) create(chain string, runner command_runner.CommandRunner) error {
-	return runner.Run(exec.Command("/sbin/iptables", flags("-A", chain, n)...))
+func (n *rule) create(chain string, rules RuleManager) error {
+	_, err := rules.Apply([]Rule{{Table: n.typ, Args: flags("-A", chain, n)}})
+	return err
 }
 
-func (n *rule) destroy(chain string, runner command_runner.CommandRunner) error {
-	return runner.Run(exec.Command("/sbin/iptables", flags("-D", chain, n)...))
+func (n *rule) destroy(chain string, rules RuleManager) error {
+	_, err := rules.Apply([]Rule{{Table: n.typ, Args: flags("-D", chain, n)}})
+	return err
 }
 
 func flags(action, chain string, n *rule) []string {
-	rule := []string{"-w"}
-
-	if n.typ != "" {
-		rule = append(rule, "-t", string(n.typ))
-	}
-
-	rule = append(rule, action, chain)
+	rule := []string{action, chain}
 
 	if n.source != "" {
 		rule = append(rule, "--source", n.source)
@@ -300,19 +474,19 @@ type Destroyable interface {
 }
 
 type creater interface {
-	create(chain string, runner command_runner.CommandRunner) error
+	create(chain string, rules RuleManager) error
 }
 
 type destroyer interface {
-	destroy(chain string, runner command_runner.CommandRunner) error
+	destroy(chain string, rules RuleManager) error
 }
 
 func (c *chain) Create(rule creater) error {
-	return rule.create(c.name, c.runner)
+	return rule.create(c.name, c.rules)
 }
 
 func (c *chain) Destroy(rule destroyer) error {
-	return rule.destroy(c.name, c.runner)
+	return rule.destroy(c.name, c.rules)
 }
 
 type Action string
@@ -327,5 +501,6 @@ const (
 type Type string
 
 const (
-	Nat Type = "nat"
+	Filter Type = "filter"
+	Nat    Type = "nat"
 )
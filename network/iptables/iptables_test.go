@@ -2,8 +2,10 @@ package iptables_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"net"
 	"os/exec"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
 	. "github.com/cloudfoundry-incubator/garden-linux/network/iptables"
@@ -15,42 +17,87 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// restored is the iptables-restore input applying lines to the filter table.
+func restored(lines ...string) string {
+	return "*filter\n" + strings.Join(lines, "\n") + "\nCOMMIT\n"
+}
+
+// recordRestores makes runner record the input to each run of restoreBin,
+// failing with *restoreErr and *restoreStderr if they are set. restoreBin
+// claims to support --wait, which costs one extra run of it with --help
+// before the first restore.
+func recordRestores(runner *fake_command_runner.FakeCommandRunner, restoreBin string, inputs *[]string, restoreErr *error, restoreStderr *string) {
+	runner.WhenRunning(
+		fake_command_runner.CommandSpec{
+			Path: restoreBin,
+			Args: []string{"--help"},
+		},
+		func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte("Usage: iptables-restore [-c] [-v] [-t] [-h] [-n] [-w secs] [--wait secs]\n"))
+			return nil
+		},
+	)
+
+	runner.WhenRunning(
+		fake_command_runner.CommandSpec{
+			Path: restoreBin,
+			Args: []string{"--wait", "--noflush"},
+		},
+		func(cmd *exec.Cmd) error {
+			input, err := ioutil.ReadAll(cmd.Stdin)
+			Expect(err).ToNot(HaveOccurred())
+			*inputs = append(*inputs, string(input))
+
+			cmd.Stderr.Write([]byte(*restoreStderr))
+			return *restoreErr
+		},
+	)
+}
+
 var _ = Describe("Iptables", func() {
 	Describe("Chain", func() {
 		var fakeRunner *fake_command_runner.FakeCommandRunner
 		var subject Chain
 		var useKernelLogging bool
 
+		var restoreInputs []string
+		var restoreErr error
+		var restoreStderr string
+
+		BeforeEach(func() {
+			restoreInputs = nil
+			restoreErr = nil
+			restoreStderr = ""
+		})
+
 		JustBeforeEach(func() {
 			fakeRunner = fake_command_runner.New()
+			recordRestores(fakeRunner, "/sbin/iptables-restore", &restoreInputs, &restoreErr, &restoreStderr)
 			subject = NewLoggingChain("foo-bar-baz", useKernelLogging, fakeRunner, lagertest.NewTestLogger("test"))
 		})
 
 		Describe("Setup", func() {
 			Context("when kernel logging is not enabled", func() {
-				It("creates the log chain using iptables", func() {
+				It("tears down, then creates the log chain using iptables-restore", func() {
 					Expect(subject.Setup("logPrefix")).To(Succeed())
 					Expect(fakeRunner).To(HaveExecutedSerially(
 						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-F", "foo-bar-baz-log"},
+							Path: "/sbin/iptables-restore",
+							Args: []string{"--wait", "--noflush"},
 						},
 						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-X", "foo-bar-baz-log"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-N", "foo-bar-baz-log"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-A", "foo-bar-baz-log", "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID", "--protocol", "tcp", "--jump", "NFLOG", "--nflog-prefix", "logPrefix", "--nflog-group", "1"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-A", "foo-bar-baz-log", "--jump", "RETURN"},
+							Path: "/sbin/iptables-restore",
+							Args: []string{"--wait", "--noflush"},
 						}))
+
+					Expect(restoreInputs).To(Equal([]string{restored(
+						"-F foo-bar-baz-log",
+						"-X foo-bar-baz-log",
+					), restored(
+						"-N foo-bar-baz-log",
+						"-A foo-bar-baz-log -m conntrack --ctstate NEW,UNTRACKED,INVALID --protocol tcp --jump NFLOG --nflog-prefix logPrefix --nflog-group 1",
+						"-A foo-bar-baz-log --jump RETURN",
+					)}))
 				})
 			})
 
@@ -61,140 +108,162 @@ var _ = Describe("Iptables", func() {
 
 				It("creates the log chain using iptables", func() {
 					Expect(subject.Setup("logPrefix")).To(Succeed())
-					Expect(fakeRunner).To(HaveExecutedSerially(
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-F", "foo-bar-baz-log"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-X", "foo-bar-baz-log"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-N", "foo-bar-baz-log"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-A", "foo-bar-baz-log", "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID", "--protocol", "tcp",
-								"--jump", "LOG", "--log-prefix", "logPrefix"},
-						},
-						fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-A", "foo-bar-baz-log", "--jump", "RETURN"},
-						}))
+					Expect(restoreInputs).To(HaveLen(2))
+					Expect(restoreInputs[1]).To(Equal(restored(
+						"-N foo-bar-baz-log",
+						"-A foo-bar-baz-log -m conntrack --ctstate NEW,UNTRACKED,INVALID --protocol tcp --jump LOG --log-prefix logPrefix",
+						"-A foo-bar-baz-log --jump RETURN",
+					)))
+				})
+			})
+
+			Context("when the log prefix contains spaces", func() {
+				It("quotes it", func() {
+					Expect(subject.Setup("log prefix")).To(Succeed())
+					Expect(restoreInputs).To(HaveLen(2))
+					Expect(restoreInputs[1]).To(ContainSubstring(`-prefix "log prefix"`))
 				})
 			})
 
-			It("ignores failures to flush", func() {
-				someError := errors.New("y")
+			It("ignores failures to tear down the log chain", func() {
 				fakeRunner.WhenRunning(
 					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-F", "foo-bar-baz-log"},
+						Path: "/sbin/iptables-restore",
+						Args: []string{"--wait", "--noflush"},
 					},
 					func(cmd *exec.Cmd) error {
-						return someError
+						input, err := ioutil.ReadAll(cmd.Stdin)
+						Expect(err).ToNot(HaveOccurred())
+
+						if strings.Contains(string(input), "-X foo-bar-baz-log") {
+							return errors.New("no chain")
+						}
+
+						return nil
 					})
 
 				Expect(subject.Setup("logPrefix")).To(Succeed())
 			})
 
-			It("ignores failures to delete", func() {
-				someError := errors.New("y")
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-X", "foo-bar-baz-log"},
-					},
-					func(cmd *exec.Cmd) error {
-						return someError
-					})
+			It("returns any error returned when the log chain is restored", func() {
+				restoreErr = errors.New("y")
+				restoreStderr = "no chain"
 
-				Expect(subject.Setup("logPrefix")).To(Succeed())
+				Expect(subject.Setup("logPrefix")).To(MatchError("iptables: log chain setup: iptables: restoring filter table: y, no chain"))
 			})
+		})
 
-			It("returns any error returned when the table is created", func() {
-				someError := errors.New("y")
+		Describe("ListRules", func() {
+			var listing string
+
+			JustBeforeEach(func() {
 				fakeRunner.WhenRunning(
 					fake_command_runner.CommandSpec{
 						Path: "/sbin/iptables",
-						Args: []string{"-w", "-N", "foo-bar-baz-log"},
+						Args: []string{"-w", "-t", "nat", "-S", "foo-bar-baz"},
 					},
 					func(cmd *exec.Cmd) error {
-						return someError
+						cmd.Stdout.Write([]byte(listing))
+						return nil
 					})
+			})
 
-				Expect(subject.Setup("logPrefix")).To(MatchError("iptables: log chain setup: y"))
+			BeforeEach(func() {
+				listing = `-N foo-bar-baz
+-A foo-bar-baz -d 10.0.0.1/32 -p tcp -m tcp --dport 8080 -j DNAT --to-destination 10.2.0.2:80
+-A foo-bar-baz -m iprange --dst-range 1.2.3.4-1.2.3.9 -p udp -m udp --dport 12:24 -g foo-bar-baz-log
+-A foo-bar-baz -m comment --comment "some comment" -j RETURN
+`
 			})
 
-			It("returns any error returned when the logging rule is added", func() {
-				someError := errors.New("y")
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-A", "foo-bar-baz-log", "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID", "--protocol", "tcp", "--jump", "LOG", "--log-prefix", "logPrefix"},
+			It("parses each rule the chain lists", func() {
+				rules, err := subject.ListRules(Nat)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(rules).To(Equal([]garden.IPTablesRule{
+					{
+						Table:            "nat",
+						Chain:            "foo-bar-baz",
+						Protocol:         "tcp",
+						Destination:      "10.0.0.1/32",
+						DestinationPorts: "8080",
+						Target:           "DNAT",
+						ToDestination:    "10.2.0.2:80",
+						Spec:             "-A foo-bar-baz -d 10.0.0.1/32 -p tcp -m tcp --dport 8080 -j DNAT --to-destination 10.2.0.2:80",
 					},
-					func(cmd *exec.Cmd) error {
-						return someError
-					})
+					{
+						Table:            "nat",
+						Chain:            "foo-bar-baz",
+						Protocol:         "udp",
+						Destination:      "1.2.3.4-1.2.3.9",
+						DestinationPorts: "12:24",
+						Target:           "foo-bar-baz-log",
+						Goto:             true,
+						Spec:             "-A foo-bar-baz -m iprange --dst-range 1.2.3.4-1.2.3.9 -p udp -m udp --dport 12:24 -g foo-bar-baz-log",
+					},
+					{
+						Table:  "nat",
+						Chain:  "foo-bar-baz",
+						Target: "RETURN",
+						Spec:   `-A foo-bar-baz -m comment --comment "some comment" -j RETURN`,
+					},
+				}))
+			})
+
+			Context("when listing the chain fails", func() {
+				It("returns a wrapped error, including stderr", func() {
+					fakeRunner.WhenRunning(
+						fake_command_runner.CommandSpec{
+							Path: "/sbin/iptables",
+							Args: []string{"-w", "-t", "filter", "-S", "foo-bar-baz"},
+						},
+						func(cmd *exec.Cmd) error {
+							cmd.Stderr.Write([]byte("No chain/target/match by that name."))
+							return errors.New("exit status 1")
+						})
 
-				Expect(subject.Setup("logPrefix")).To(MatchError("iptables: log chain setup: y"))
+					_, err := subject.ListRules(Filter)
+					Expect(err).To(MatchError("iptables: listing filter table: exit status 1, No chain/target/match by that name."))
+				})
 			})
 
-			It("returns any error returned when the RETURN rule is added", func() {
-				someError := errors.New("y")
+			It("lists the log chain's rules in the filter table", func() {
 				fakeRunner.WhenRunning(
 					fake_command_runner.CommandSpec{
 						Path: "/sbin/iptables",
-						Args: []string{"-w", "-A", "foo-bar-baz-log", "--jump", "RETURN"},
+						Args: []string{"-w", "-t", "filter", "-S", "foo-bar-baz-log"},
 					},
 					func(cmd *exec.Cmd) error {
-						return someError
+						cmd.Stdout.Write([]byte("-N foo-bar-baz-log\n-A foo-bar-baz-log -j RETURN\n"))
+						return nil
 					})
 
-				Expect(subject.Setup("logPrefix")).To(MatchError("iptables: log chain setup: y"))
+				rules, err := subject.ListLogRules()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rules).To(Equal([]garden.IPTablesRule{
+					{Table: "filter", Chain: "foo-bar-baz-log", Target: "RETURN", Spec: "-A foo-bar-baz-log -j RETURN"},
+				}))
 			})
-		})
 
-		Describe("TearDown", func() {
-			It("should flush and delete the underlying iptables log chain", func() {
-				Expect(subject.TearDown()).To(Succeed())
-				Expect(fakeRunner).To(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-F", "foo-bar-baz-log"},
-					},
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-X", "foo-bar-baz-log"},
-					}))
+			It("lists no log rules for a chain without a log chain", func() {
+				rules, err := NewGlobalChain("global", fakeRunner, lagertest.NewTestLogger("test")).ListLogRules()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rules).To(BeEmpty())
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
 			})
+		})
 
-			It("ignores failures to flush", func() {
-				someError := errors.New("y")
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-F", "foo-bar-baz-log"},
-					},
-					func(cmd *exec.Cmd) error {
-						return someError
-					})
-
+		Describe("TearDown", func() {
+			It("should flush and delete the underlying iptables log chain in one restore", func() {
 				Expect(subject.TearDown()).To(Succeed())
+				Expect(restoreInputs).To(Equal([]string{restored(
+					"-F foo-bar-baz-log",
+					"-X foo-bar-baz-log",
+				)}))
 			})
 
-			It("ignores failures to delete", func() {
-				someError := errors.New("y")
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-X", "foo-bar-baz-log"},
-					},
-					func(cmd *exec.Cmd) error {
-						return someError
-					})
+			It("ignores failures", func() {
+				restoreErr = errors.New("y")
 
 				Expect(subject.TearDown()).To(Succeed())
 			})
@@ -202,71 +271,60 @@ var _ = Describe("Iptables", func() {
 		})
 
 		Describe("AppendRule", func() {
-			It("runs iptables to create the rule with the correct parameters", func() {
+			It("restores the rule with the correct parameters", func() {
 				subject.AppendRule("", "2.0.0.0/11", Return)
 
-				Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-					Path: "/sbin/iptables",
-					Args: []string{"-w", "-A", "foo-bar-baz", "--destination", "2.0.0.0/11", "--jump", "RETURN"},
-				}))
+				Expect(restoreInputs).To(Equal([]string{restored(
+					"-A foo-bar-baz --destination 2.0.0.0/11 --jump RETURN",
+				)}))
 			})
 		})
 
 		Describe("AppendNatRule", func() {
 			Context("creating a rule", func() {
 				Context("when all parameters are specified", func() {
-					It("runs iptables to create the rule with the correct parameters", func() {
+					It("restores the rule with the correct parameters", func() {
 						subject.AppendNatRule("1.3.5.0/28", "2.0.0.0/11", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-A", "foo-bar-baz", "--source", "1.3.5.0/28", "--destination", "2.0.0.0/11", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --source 1.3.5.0/28 --destination 2.0.0.0/11 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when Source is not specified", func() {
-					It("does not include the --source parameter in the command", func() {
+					It("does not include the --source parameter in the rule", func() {
 						subject.AppendNatRule("", "2.0.0.0/11", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-A", "foo-bar-baz", "--destination", "2.0.0.0/11", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --destination 2.0.0.0/11 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when Destination is not specified", func() {
-					It("does not include the --destination parameter in the command", func() {
+					It("does not include the --destination parameter in the rule", func() {
 						subject.AppendNatRule("1.3.5.0/28", "", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-A", "foo-bar-baz", "--source", "1.3.5.0/28", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --source 1.3.5.0/28 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when To is not specified", func() {
-					It("does not include the --to parameter in the command", func() {
+					It("does not include the --to parameter in the rule", func() {
 						subject.AppendNatRule("1.3.5.0/28", "2.0.0.0/11", Return, nil)
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-A", "foo-bar-baz", "--source", "1.3.5.0/28", "--destination", "2.0.0.0/11", "--jump", "RETURN"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --source 1.3.5.0/28 --destination 2.0.0.0/11 --jump RETURN\nCOMMIT\n",
 						}))
 					})
 				})
 
-				Context("when the command returns an error", func() {
+				Context("when restoring the rule fails", func() {
 					It("returns an error", func() {
-						someError := errors.New("badly laid iptable")
-						fakeRunner.WhenRunning(
-							fake_command_runner.CommandSpec{Path: "/sbin/iptables"},
-							func(cmd *exec.Cmd) error {
-								return someError
-							},
-						)
+						restoreErr = errors.New("badly laid iptable")
 
 						Expect(subject.AppendRule("1.2.3.4/5", "", "")).ToNot(Succeed())
 					})
@@ -274,70 +332,59 @@ var _ = Describe("Iptables", func() {
 			})
 
 			Describe("DeleteRule", func() {
-				It("runs iptables to delete the rule with the correct parameters", func() {
+				It("restores the rule deletion with the correct parameters", func() {
 					subject.DeleteRule("", "2.0.0.0/11", Return)
 
-					Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-						Path: "/sbin/iptables",
-						Args: []string{"-w", "-D", "foo-bar-baz", "--destination", "2.0.0.0/11", "--jump", "RETURN"},
-					}))
+					Expect(restoreInputs).To(Equal([]string{restored(
+						"-D foo-bar-baz --destination 2.0.0.0/11 --jump RETURN",
+					)}))
 				})
 			})
 
 			Context("DeleteNatRule", func() {
 				Context("when all parameters are specified", func() {
-					It("runs iptables to delete the rule with the correct parameters", func() {
+					It("restores the rule deletion with the correct parameters", func() {
 						subject.DeleteNatRule("1.3.5.0/28", "2.0.0.0/11", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-D", "foo-bar-baz", "--source", "1.3.5.0/28", "--destination", "2.0.0.0/11", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-D foo-bar-baz --source 1.3.5.0/28 --destination 2.0.0.0/11 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when Source is not specified", func() {
-					It("does not include the --source parameter in the command", func() {
+					It("does not include the --source parameter in the rule", func() {
 						subject.DeleteNatRule("", "2.0.0.0/11", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-D", "foo-bar-baz", "--destination", "2.0.0.0/11", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-D foo-bar-baz --destination 2.0.0.0/11 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when Destination is not specified", func() {
-					It("does not include the --destination parameter in the command", func() {
+					It("does not include the --destination parameter in the rule", func() {
 						subject.DeleteNatRule("1.3.5.0/28", "", Return, net.ParseIP("1.2.3.4"))
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-D", "foo-bar-baz", "--source", "1.3.5.0/28", "--jump", "RETURN", "--to", "1.2.3.4"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-D foo-bar-baz --source 1.3.5.0/28 --jump RETURN --to 1.2.3.4\nCOMMIT\n",
 						}))
 					})
 				})
 
 				Context("when To is not specified", func() {
-					It("does not include the --to parameter in the command", func() {
+					It("does not include the --to parameter in the rule", func() {
 						subject.DeleteNatRule("1.3.5.0/28", "2.0.0.0/11", Return, nil)
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-t", "nat", "-D", "foo-bar-baz", "--source", "1.3.5.0/28", "--destination", "2.0.0.0/11", "--jump", "RETURN"},
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-D foo-bar-baz --source 1.3.5.0/28 --destination 2.0.0.0/11 --jump RETURN\nCOMMIT\n",
 						}))
 					})
 				})
 
-				Context("when the command returns an error", func() {
+				Context("when restoring the rule fails", func() {
 					It("returns an error", func() {
-						someError := errors.New("badly laid iptable")
-						fakeRunner.WhenRunning(
-							fake_command_runner.CommandSpec{Path: "/sbin/iptables"},
-							func(cmd *exec.Cmd) error {
-								return someError
-							},
-						)
+						restoreErr = errors.New("badly laid iptable")
 
 						Expect(subject.DeleteNatRule("1.3.4.5/6", "", "", nil)).ToNot(Succeed())
 					})
@@ -348,10 +395,9 @@ var _ = Describe("Iptables", func() {
 				Context("when all parameters are defaulted", func() {
 					It("runs iptables with appropriate parameters", func() {
 						Expect(subject.PrependFilterRule(garden.NetOutRule{})).To(Succeed())
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--jump", "RETURN"},
-						}))
+						Expect(restoreInputs).To(Equal([]string{restored(
+							"-I foo-bar-baz 1 --protocol all --jump RETURN",
+						)}))
 					})
 				})
 
@@ -364,10 +410,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol all --jump RETURN",
+							)}))
 						})
 					})
 
@@ -381,10 +426,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--destination", "1.2.3.4", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol all --destination 1.2.3.4 --jump RETURN",
+							)}))
 						})
 					})
 
@@ -403,16 +447,10 @@ var _ = Describe("Iptables", func() {
 							})).To(Succeed())
 
 							Expect(fakeRunner.ExecutedCommands()).To(HaveLen(2))
-							Expect(fakeRunner).To(HaveExecutedSerially(
-								fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--destination", "1.2.3.4", "--jump", "RETURN"},
-								},
-								fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "-m", "iprange", "--dst-range", "2.2.3.4-2.2.3.9", "--jump", "RETURN"},
-								},
-							))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol all --destination 1.2.3.4 --jump RETURN",
+								"-I foo-bar-baz 1 --protocol all -m iprange --dst-range 2.2.3.4-2.2.3.9 --jump RETURN",
+							)}))
 						})
 					})
 
@@ -426,10 +464,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--destination", "1.2.3.4", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol all --destination 1.2.3.4 --jump RETURN",
+							)}))
 						})
 					})
 
@@ -443,10 +480,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "-m", "iprange", "--dst-range", "1.2.3.4-2.3.4.5", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol all -m iprange --dst-range 1.2.3.4-2.3.4.5 --jump RETURN",
+							)}))
 						})
 					})
 				})
@@ -461,10 +497,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination-port", "22", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol tcp --destination-port 22 --jump RETURN",
+							)}))
 						})
 					})
 
@@ -477,10 +512,9 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination-port", "12:24", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol tcp --destination-port 12:24 --jump RETURN",
+							)}))
 						})
 					})
 
@@ -494,16 +528,10 @@ var _ = Describe("Iptables", func() {
 								},
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(
-								fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination-port", "12:24", "--jump", "RETURN"},
-								},
-								fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination-port", "64:942", "--jump", "RETURN"},
-								},
-							))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol tcp --destination-port 12:24 --jump RETURN",
+								"-I foo-bar-baz 1 --protocol tcp --destination-port 64:942 --jump RETURN",
+							)}))
 						})
 					})
 				})
@@ -515,10 +543,9 @@ var _ = Describe("Iptables", func() {
 								Protocol: garden.ProtocolTCP,
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol tcp --jump RETURN",
+							)}))
 						})
 					})
 
@@ -528,10 +555,9 @@ var _ = Describe("Iptables", func() {
 								Protocol: garden.ProtocolUDP,
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "udp", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol udp --jump RETURN",
+							)}))
 						})
 					})
 
@@ -541,10 +567,9 @@ var _ = Describe("Iptables", func() {
 								Protocol: garden.ProtocolICMP,
 							})).To(Succeed())
 
-							Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "icmp", "--jump", "RETURN"},
-							}))
+							Expect(restoreInputs).To(Equal([]string{restored(
+								"-I foo-bar-baz 1 --protocol icmp --jump RETURN",
+							)}))
 						})
 
 						Context("when icmp type is specified", func() {
@@ -556,10 +581,9 @@ var _ = Describe("Iptables", func() {
 									},
 								})).To(Succeed())
 
-								Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "icmp", "--icmp-type", "99", "--jump", "RETURN"},
-								}))
+								Expect(restoreInputs).To(Equal([]string{restored(
+									"-I foo-bar-baz 1 --protocol icmp --icmp-type 99 --jump RETURN",
+								)}))
 							})
 						})
 
@@ -573,10 +597,9 @@ var _ = Describe("Iptables", func() {
 									},
 								})).To(Succeed())
 
-								Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-									Path: "/sbin/iptables",
-									Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "icmp", "--icmp-type", "99/11", "--jump", "RETURN"},
-								}))
+								Expect(restoreInputs).To(Equal([]string{restored(
+									"-I foo-bar-baz 1 --protocol icmp --icmp-type 99/11 --jump RETURN",
+								)}))
 							})
 						})
 					})
@@ -588,10 +611,9 @@ var _ = Describe("Iptables", func() {
 							Log: true,
 						})).To(Succeed())
 
-						Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
-							Path: "/sbin/iptables",
-							Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "all", "--goto", "foo-bar-baz-log"},
-						}))
+						Expect(restoreInputs).To(Equal([]string{restored(
+							"-I foo-bar-baz 1 --protocol all --goto foo-bar-baz-log",
+						)}))
 					})
 				})
 
@@ -614,25 +636,13 @@ var _ = Describe("Iptables", func() {
 							},
 						})).To(Succeed())
 
-						Expect(fakeRunner.ExecutedCommands()).To(HaveLen(4))
-						Expect(fakeRunner).To(HaveExecutedSerially(
-							fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination", "1.2.3.4", "--destination-port", "12:24", "--jump", "RETURN"},
-							},
-							fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "--destination", "1.2.3.4", "--destination-port", "64:942", "--jump", "RETURN"},
-							},
-							fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "-m", "iprange", "--dst-range", "2.2.3.4-2.2.3.9", "--destination-port", "12:24", "--jump", "RETURN"},
-							},
-							fake_command_runner.CommandSpec{
-								Path: "/sbin/iptables",
-								Args: []string{"-w", "-I", "foo-bar-baz", "1", "--protocol", "tcp", "-m", "iprange", "--dst-range", "2.2.3.4-2.2.3.9", "--destination-port", "64:942", "--jump", "RETURN"},
-							},
-						))
+						Expect(fakeRunner.ExecutedCommands()).To(HaveLen(2))
+						Expect(restoreInputs).To(Equal([]string{restored(
+							"-I foo-bar-baz 1 --protocol tcp --destination 1.2.3.4 --destination-port 12:24 --jump RETURN",
+							"-I foo-bar-baz 1 --protocol tcp --destination 1.2.3.4 --destination-port 64:942 --jump RETURN",
+							"-I foo-bar-baz 1 --protocol tcp -m iprange --dst-range 2.2.3.4-2.2.3.9 --destination-port 12:24 --jump RETURN",
+							"-I foo-bar-baz 1 --protocol tcp -m iprange --dst-range 2.2.3.4-2.2.3.9 --destination-port 64:942 --jump RETURN",
+						)}))
 					})
 				})
 
@@ -682,21 +692,244 @@ var _ = Describe("Iptables", func() {
 					})
 				})
 
-				Context("when the command returns an error", func() {
+				Context("when restoring the rule fails", func() {
 					It("returns a wrapped error, including stderr", func() {
-						someError := errors.New("badly laid iptable")
-						fakeRunner.WhenRunning(
-							fake_command_runner.CommandSpec{Path: "/sbin/iptables"},
-							func(cmd *exec.Cmd) error {
-								cmd.Stderr.Write([]byte("stderr contents"))
-								return someError
-							},
-						)
+						restoreErr = errors.New("badly laid iptable")
+						restoreStderr = "stderr contents"
+
+						Expect(subject.PrependFilterRule(garden.NetOutRule{})).To(MatchError("iptables: restoring filter table: badly laid iptable, stderr contents"))
+					})
+				})
+			})
+
+			Describe("DeleteFilterRule", func() {
+				It("deletes each of the rules PrependFilterRule inserted", func() {
+					Expect(subject.DeleteFilterRule(garden.NetOutRule{
+						Protocol: garden.ProtocolTCP,
+						Networks: []garden.IPRange{
+							garden.IPRangeFromIP(net.ParseIP("1.2.3.4")),
+						},
+						Ports: []garden.PortRange{
+							garden.PortRangeFromPort(22),
+							{Start: 1000, End: 2000},
+						},
+						Log: true,
+					})).To(Succeed())
+
+					Expect(restoreInputs).To(Equal([]string{restored(
+						"-D foo-bar-baz --protocol tcp -m iprange --dst-range 1.2.3.4-1.2.3.4 --destination-port 22 --goto foo-bar-baz-log",
+						"-D foo-bar-baz --protocol tcp -m iprange --dst-range 1.2.3.4-1.2.3.4 --destination-port 1000:2000 --goto foo-bar-baz-log",
+					)}))
+				})
+
+				Context("when a portrange is specified for ProtocolAll", func() {
+					It("returns a nice error message without running iptables", func() {
+						Expect(subject.DeleteFilterRule(garden.NetOutRule{
+							Protocol: garden.ProtocolAll,
+							Ports:    []garden.PortRange{{Start: 1, End: 5}},
+						})).To(MatchError("Ports cannot be specified for Protocol ALL"))
 
-						Expect(subject.PrependFilterRule(garden.NetOutRule{})).To(MatchError("iptables: badly laid iptable, stderr contents"))
+						Expect(fakeRunner.ExecutedCommands()).To(HaveLen(0))
+					})
+				})
+
+				Context("when restoring the rule fails", func() {
+					It("returns a wrapped error, including stderr", func() {
+						restoreErr = errors.New("exit status 1")
+						restoreStderr = "no such rule"
+
+						Expect(subject.DeleteFilterRule(garden.NetOutRule{})).To(MatchError("iptables: restoring filter table: exit status 1, no such rule"))
 					})
 				})
 			})
+
+			Describe("AppendDNATRules", func() {
+				var forward PortForward
+
+				BeforeEach(func() {
+					forward = PortForward{
+						Protocol:      garden.ProtocolTCP,
+						Destination:   net.ParseIP("1.2.3.4"),
+						HostPort:      1000,
+						ContainerIP:   net.ParseIP("10.0.0.2"),
+						ContainerPort: 8080,
+						PortCount:     1,
+					}
+				})
+
+				It("appends a DNAT rule to the NAT table", func() {
+					Expect(subject.AppendDNATRules(forward)).To(Succeed())
+
+					Expect(restoreInputs).To(Equal([]string{
+						"*nat\n-A foo-bar-baz --protocol tcp --destination 1.2.3.4 --destination-port 1000 --jump DNAT --to-destination 10.0.0.2:8080\nCOMMIT\n",
+					}))
+				})
+
+				Context("when a range is shifted", func() {
+					It("appends one rule per port with a single iptables-restore", func() {
+						forward.Protocol = garden.ProtocolUDP
+						forward.PortCount = 3
+
+						Expect(subject.AppendDNATRules(forward)).To(Succeed())
+
+						Expect(fakeRunner.ExecutedCommands()).To(HaveLen(2))
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n" +
+								"-A foo-bar-baz --protocol udp --destination 1.2.3.4 --destination-port 1000 --jump DNAT --to-destination 10.0.0.2:8080\n" +
+								"-A foo-bar-baz --protocol udp --destination 1.2.3.4 --destination-port 1001 --jump DNAT --to-destination 10.0.0.2:8081\n" +
+								"-A foo-bar-baz --protocol udp --destination 1.2.3.4 --destination-port 1002 --jump DNAT --to-destination 10.0.0.2:8082\n" +
+								"COMMIT\n",
+						}))
+					})
+				})
+
+				Context("when a range keeps its ports", func() {
+					It("appends a single rule for the range", func() {
+						forward.ContainerPort = 1000
+						forward.PortCount = 3
+
+						Expect(subject.AppendDNATRules(forward)).To(Succeed())
+
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --protocol tcp --destination 1.2.3.4 --destination-port 1000:1002 --jump DNAT --to-destination 10.0.0.2\nCOMMIT\n",
+						}))
+					})
+				})
+
+				Context("when no destination is given", func() {
+					It("forwards from any of the host's local addresses", func() {
+						forward.Destination = nil
+
+						Expect(subject.AppendDNATRules(forward)).To(Succeed())
+
+						Expect(restoreInputs).To(Equal([]string{
+							"*nat\n-A foo-bar-baz --protocol tcp --match addrtype --dst-type LOCAL --destination-port 1000 --jump DNAT --to-destination 10.0.0.2:8080\nCOMMIT\n",
+						}))
+					})
+				})
+
+				Context("when the protocol has no ports", func() {
+					It("returns an error without running iptables", func() {
+						forward.Protocol = garden.ProtocolICMP
+
+						Expect(subject.AppendDNATRules(forward)).To(MatchError("Ports cannot be forwarded for Protocol ICMP"))
+						Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+					})
+				})
+
+				Context("when restoring the rule fails", func() {
+					It("returns a wrapped error, including stderr", func() {
+						restoreErr = errors.New("exit status 1")
+						restoreStderr = "no chain"
+
+						Expect(subject.AppendDNATRules(forward)).To(MatchError("iptables: restoring nat table: exit status 1, no chain"))
+					})
+				})
+			})
+
+			Describe("DeleteDNATRules", func() {
+				It("deletes each of the rules AppendDNATRules appended", func() {
+					Expect(subject.DeleteDNATRules(PortForward{
+						Protocol:      garden.ProtocolTCP,
+						Destination:   net.ParseIP("1.2.3.4"),
+						HostPort:      1000,
+						ContainerIP:   net.ParseIP("10.0.0.2"),
+						ContainerPort: 2000,
+						PortCount:     2,
+					})).To(Succeed())
+
+					Expect(restoreInputs).To(Equal([]string{
+						"*nat\n" +
+							"-D foo-bar-baz --protocol tcp --destination 1.2.3.4 --destination-port 1000 --jump DNAT --to-destination 10.0.0.2:2000\n" +
+							"-D foo-bar-baz --protocol tcp --destination 1.2.3.4 --destination-port 1001 --jump DNAT --to-destination 10.0.0.2:2001\n" +
+							"COMMIT\n",
+					}))
+				})
+			})
+		})
+	})
+
+	Describe("IPv6 Chain", func() {
+		var fakeRunner *fake_command_runner.FakeCommandRunner
+		var subject Chain
+
+		var restoreInputs []string
+		var restoreErr error
+		var restoreStderr string
+
+		BeforeEach(func() {
+			restoreInputs = nil
+			fakeRunner = fake_command_runner.New()
+			recordRestores(fakeRunner, "/sbin/ip6tables-restore", &restoreInputs, &restoreErr, &restoreStderr)
+			subject = NewIPv6LoggingChain("foo-bar-baz", false, fakeRunner, lagertest.NewTestLogger("test"))
+		})
+
+		It("creates the log chain using ip6tables", func() {
+			Expect(subject.Setup("logPrefix")).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/ip6tables-restore",
+					Args: []string{"--wait", "--noflush"},
+				}))
+
+			Expect(restoreInputs).To(HaveLen(2))
+			Expect(restoreInputs[0]).To(ContainSubstring("-F foo-bar-baz-log\n"))
+			Expect(restoreInputs[1]).To(ContainSubstring("-N foo-bar-baz-log\n"))
+		})
+
+		It("prepends filter rules using ip6tables", func() {
+			Expect(subject.PrependFilterRule(garden.NetOutRule{
+				Protocol: garden.ProtocolTCP,
+				Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("2001:db8::1"))},
+			})).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{restored(
+				"-I foo-bar-baz 1 --protocol tcp -m iprange --dst-range 2001:db8::1-2001:db8::1 --jump RETURN",
+			)}))
+		})
+
+		It("deletes filter rules using ip6tables", func() {
+			Expect(subject.DeleteFilterRule(garden.NetOutRule{Protocol: garden.ProtocolUDP})).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{restored(
+				"-D foo-bar-baz --protocol udp --jump RETURN",
+			)}))
+		})
+
+		It("uses the ICMPv6 protocol and types", func() {
+			Expect(subject.PrependFilterRule(garden.NetOutRule{
+				Protocol: garden.ProtocolICMP,
+				ICMPs: &garden.ICMPControl{
+					Type: 128,
+					Code: garden.ICMPControlCode(0),
+				},
+			})).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{restored(
+				"-I foo-bar-baz 1 --protocol icmpv6 --icmpv6-type 128/0 --jump RETURN",
+			)}))
+		})
+
+		It("appends DNAT rules to bracketed addresses using ip6tables", func() {
+			Expect(subject.AppendDNATRules(PortForward{
+				Protocol:      garden.ProtocolTCP,
+				HostPort:      1000,
+				ContainerIP:   net.ParseIP("2001:db8::2"),
+				ContainerPort: 8080,
+				PortCount:     1,
+			})).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{
+				"*nat\n-A foo-bar-baz --protocol tcp --match addrtype --dst-type LOCAL --destination-port 1000 --jump DNAT --to-destination [2001:db8::2]:8080\nCOMMIT\n",
+			}))
+		})
+
+		It("appends rules using ip6tables-restore", func() {
+			Expect(subject.AppendRule("2001:db8::/64", "", Return)).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{restored(
+				"-A foo-bar-baz --source 2001:db8::/64 --jump RETURN",
+			)}))
 		})
 	})
 })
@@ -0,0 +1,93 @@
+package iptables
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// parseRules parses the rules in the output of iptables -S, skipping the
+// chain policies and declarations it also prints.
+func parseRules(table Type, output string) []garden.IPTablesRule {
+	rules := []garden.IPTablesRule{}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		args := splitArgs(line)
+		if len(args) < 2 || args[0] != "-A" {
+			continue
+		}
+
+		rule := garden.IPTablesRule{
+			Table: string(table),
+			Chain: args[1],
+			Spec:  line,
+		}
+
+		for i := 2; i < len(args)-1; i++ {
+			value := args[i+1]
+
+			switch args[i] {
+			case "-p", "--protocol":
+				rule.Protocol = value
+			case "-s", "--source", "--src-range":
+				rule.Source = value
+			case "-d", "--destination", "--dst-range":
+				rule.Destination = value
+			case "--dport", "--dports", "--destination-port":
+				rule.DestinationPorts = value
+			case "-j", "--jump":
+				rule.Target = value
+			case "-g", "--goto":
+				rule.Target = value
+				rule.Goto = true
+			case "--to-destination":
+				rule.ToDestination = value
+			default:
+				continue
+			}
+
+			i++
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// splitArgs splits a line of iptables -S output into arguments, which it
+// double-quotes when they contain spaces.
+func splitArgs(line string) []string {
+	var args []string
+	var arg []rune
+	var inArg, quoted, escaped bool
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg = append(arg, r)
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inArg = true
+		case r == ' ' && !quoted:
+			if inArg {
+				args = append(args, string(arg))
+				arg, inArg = nil, false
+			}
+		default:
+			arg = append(arg, r)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, string(arg))
+	}
+
+	return args
+}
@@ -0,0 +1,251 @@
+package iptables
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	iptablesRestoreBin  = "/sbin/iptables-restore"
+	ip6tablesRestoreBin = "/sbin/ip6tables-restore"
+)
+
+// A Rule is a single change to a table, written as the arguments iptables
+// would take without the table, e.g. {"-I", "some-chain", "1", "--jump", "RETURN"}.
+// An empty Table means the filter table.
+type Rule struct {
+	Table Type
+	Args  []string
+}
+
+func (r Rule) String() string {
+	quoted := make([]string, len(r.Args))
+	for i, arg := range r.Args {
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted[i] = arg
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func (r Rule) table() Type {
+	if r.Table == "" {
+		return Filter
+	}
+
+	return r.Table
+}
+
+// inverse returns the rule undoing r.
+func (r Rule) inverse() (Rule, error) {
+	if len(r.Args) < 2 {
+		return Rule{}, fmt.Errorf("iptables: rule cannot be undone: %s", r)
+	}
+
+	command, chain, spec := r.Args[0], r.Args[1], r.Args[2:]
+
+	switch command {
+	case "-A":
+		return Rule{Table: r.Table, Args: append([]string{"-D", chain}, spec...)}, nil
+	case "-I":
+		if len(spec) > 0 {
+			if _, err := strconv.Atoi(spec[0]); err == nil {
+				spec = spec[1:]
+			}
+		}
+
+		return Rule{Table: r.Table, Args: append([]string{"-D", chain}, spec...)}, nil
+	case "-D":
+		return Rule{Table: r.Table, Args: append([]string{"-A", chain}, spec...)}, nil
+	case "-N":
+		return Rule{Table: r.Table, Args: []string{"-X", chain}}, nil
+	}
+
+	return Rule{}, fmt.Errorf("iptables: rule cannot be undone: %s", r)
+}
+
+//go:generate counterfeiter . RuleManager
+type RuleManager interface {
+	// Apply applies every rule or none of them, one iptables-restore per table.
+	// If a table fails to apply, the tables already applied are rolled back.
+	//
+	// It returns the rules left applied: all of them on success, and any that
+	// could not be rolled back on failure.
+	Apply(rules []Rule) ([]Rule, error)
+
+	// Exec applies rules which cannot be undone, such as flushing or deleting
+	// chains, one iptables-restore per table. Nothing is rolled back: the tables
+	// before the first to fail are left applied.
+	Exec(rules []Rule) error
+}
+
+// iptables-restore only takes --wait from iptables 1.6.2; before then it
+// does not take the xtables lock at all, so restores are serialised here
+// instead, at least against each other.
+var unlockedRestoreMutex sync.Mutex
+
+type ruleManager struct {
+	binary string
+	runner command_runner.CommandRunner
+	logger lager.Logger
+
+	detectWait   sync.Once
+	supportsWait bool
+}
+
+// NewRuleManager creates a rule manager applying rules with iptables-restore.
+func NewRuleManager(runner command_runner.CommandRunner, logger lager.Logger) RuleManager {
+	return &ruleManager{binary: iptablesRestoreBin, runner: runner, logger: logger}
+}
+
+// NewIPv6RuleManager creates a rule manager applying rules with ip6tables-restore.
+func NewIPv6RuleManager(runner command_runner.CommandRunner, logger lager.Logger) RuleManager {
+	return &ruleManager{binary: ip6tablesRestoreBin, runner: runner, logger: logger}
+}
+
+func (m *ruleManager) Apply(rules []Rule) ([]Rule, error) {
+	// refuse up front anything which could not be rolled back
+	for _, r := range rules {
+		if _, err := r.inverse(); err != nil {
+			return nil, err
+		}
+	}
+
+	var applied []Rule
+	for _, tableRules := range byTable(rules) {
+		if err := m.restore(tableRules); err != nil {
+			return m.rollback(applied), err
+		}
+
+		applied = append(applied, tableRules...)
+	}
+
+	m.logger.Debug("applied-rules", lager.Data{"rules": ruleStrings(applied)})
+
+	return applied, nil
+}
+
+func (m *ruleManager) Exec(rules []Rule) error {
+	for _, tableRules := range byTable(rules) {
+		if err := m.restore(tableRules); err != nil {
+			return err
+		}
+	}
+
+	m.logger.Debug("executed-rules", lager.Data{"rules": ruleStrings(rules)})
+
+	return nil
+}
+
+// rollback undoes applied rules in reverse order, returning those it could not.
+func (m *ruleManager) rollback(applied []Rule) []Rule {
+	inverses := make([]Rule, 0, len(applied))
+	for i := len(applied) - 1; i >= 0; i-- {
+		inverse, _ := applied[i].inverse()
+		inverses = append(inverses, inverse)
+	}
+
+	var remaining []Rule
+	for _, tableRules := range byTable(inverses) {
+		if err := m.restore(tableRules); err != nil {
+			m.logger.Error("rollback-failed", err, lager.Data{"table": tableRules[0].table()})
+
+			for _, r := range applied {
+				if r.table() == tableRules[0].table() {
+					remaining = append(remaining, r)
+				}
+			}
+		}
+	}
+
+	return remaining
+}
+
+func (m *ruleManager) restore(rules []Rule) error {
+	table := rules[0].table()
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "*%s\n", table)
+	for _, r := range rules {
+		fmt.Fprintln(&input, r)
+	}
+	fmt.Fprintln(&input, "COMMIT")
+
+	m.detectWait.Do(m.detectWaitSupport)
+
+	args := []string{"--noflush"}
+	if m.supportsWait {
+		args = append([]string{"--wait"}, args...)
+	} else {
+		unlockedRestoreMutex.Lock()
+		defer unlockedRestoreMutex.Unlock()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(m.binary, args...)
+	cmd.Stdin = &input
+	cmd.Stderr = &stderr
+
+	if err := m.runner.Run(cmd); err != nil {
+		return fmt.Errorf("iptables: restoring %s table: %v, %v", table, err, stderr.String())
+	}
+
+	return nil
+}
+
+// detectWaitSupport looks for --wait in the usage, which some versions print
+// to stderr, and some only after exiting non-zero.
+func (m *ruleManager) detectWaitSupport() {
+	var usage bytes.Buffer
+	cmd := exec.Command(m.binary, "--help")
+	cmd.Stdout = &usage
+	cmd.Stderr = &usage
+
+	m.runner.Run(cmd)
+
+	m.supportsWait = strings.Contains(usage.String(), "--wait")
+
+	m.logger.Debug("detected-wait-support", lager.Data{
+		"binary":        m.binary,
+		"supports-wait": m.supportsWait,
+	})
+}
+
+// byTable groups rules by table, keeping their order within each table and
+// ordering tables by their first rule.
+func byTable(rules []Rule) [][]Rule {
+	var tables [][]Rule
+	index := map[Type]int{}
+
+	for _, r := range rules {
+		i, ok := index[r.table()]
+		if !ok {
+			i = len(tables)
+			index[r.table()] = i
+			tables = append(tables, nil)
+		}
+
+		tables[i] = append(tables[i], r)
+	}
+
+	return tables
+}
+
+func ruleStrings(rules []Rule) []string {
+	strs := make([]string, len(rules))
+	for i, r := range rules {
+		strs[i] = r.String()
+	}
+
+	return strs
+}
@@ -0,0 +1,224 @@
+package iptables_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os/exec"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/network/iptables"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RuleManager", func() {
+	var (
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		manager    RuleManager
+
+		restoreInputs []string
+		failingInputs map[string]error
+		usage         string
+	)
+
+	BeforeEach(func() {
+		restoreInputs = nil
+		failingInputs = map[string]error{}
+		usage = "Usage: iptables-restore [-c] [-v] [-t] [-h] [-n] [-w secs] [--wait secs]\n"
+
+		fakeRunner = fake_command_runner.New()
+		fakeRunner.WhenRunning(
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+			},
+			func(cmd *exec.Cmd) error {
+				if cmd.Stdin == nil {
+					cmd.Stderr.Write([]byte(usage))
+					return errors.New("exit status 2")
+				}
+
+				input, err := ioutil.ReadAll(cmd.Stdin)
+				Expect(err).ToNot(HaveOccurred())
+
+				restoreInputs = append(restoreInputs, string(input))
+				return failingInputs[string(input)]
+			},
+		)
+
+		manager = NewRuleManager(fakeRunner, lagertest.NewTestLogger("test"))
+	})
+
+	filterRule := Rule{Args: []string{"-I", "some-chain", "1", "--jump", "RETURN"}}
+	natRule := Rule{Table: Nat, Args: []string{"-A", "some-nat-chain", "--jump", "DNAT", "--to-destination", "1.2.3.4:80"}}
+
+	It("applies each table's rules with a single iptables-restore", func() {
+		applied, err := manager.Apply([]Rule{
+			filterRule,
+			natRule,
+			{Args: []string{"-N", "other-chain"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--wait", "--noflush"},
+			},
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--wait", "--noflush"},
+			},
+		))
+
+		Expect(restoreInputs).To(Equal([]string{
+			"*filter\n-I some-chain 1 --jump RETURN\n-N other-chain\nCOMMIT\n",
+			"*nat\n-A some-nat-chain --jump DNAT --to-destination 1.2.3.4:80\nCOMMIT\n",
+		}))
+
+		Expect(applied).To(Equal([]Rule{filterRule, {Args: []string{"-N", "other-chain"}}, natRule}))
+	})
+
+	It("does not run iptables-restore when there are no rules", func() {
+		applied, err := manager.Apply(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(applied).To(BeEmpty())
+		Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+	})
+
+	It("refuses rules it could not roll back without applying anything", func() {
+		_, err := manager.Apply([]Rule{
+			filterRule,
+			{Args: []string{"-F", "some-chain"}},
+		})
+		Expect(err).To(MatchError("iptables: rule cannot be undone: -F some-chain"))
+		Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+	})
+
+	Context("when a table fails to apply", func() {
+		BeforeEach(func() {
+			failingInputs["*nat\n-A some-nat-chain --jump DNAT --to-destination 1.2.3.4:80\nCOMMIT\n"] = errors.New("oh no")
+		})
+
+		It("rolls back the tables already applied and returns the error", func() {
+			applied, err := manager.Apply([]Rule{
+				filterRule,
+				{Args: []string{"-D", "some-chain", "--jump", "DROP"}},
+				natRule,
+			})
+			Expect(err).To(MatchError("iptables: restoring nat table: oh no, "))
+			Expect(applied).To(BeEmpty())
+
+			Expect(restoreInputs).To(HaveLen(3))
+			Expect(restoreInputs[2]).To(Equal("*filter\n-A some-chain --jump DROP\n-D some-chain --jump RETURN\nCOMMIT\n"))
+		})
+
+		Context("and the rollback fails too", func() {
+			BeforeEach(func() {
+				failingInputs["*filter\n-D some-chain --jump RETURN\nCOMMIT\n"] = errors.New("stuck")
+			})
+
+			It("returns the rules left applied", func() {
+				applied, err := manager.Apply([]Rule{filterRule, natRule})
+				Expect(err).To(MatchError("iptables: restoring nat table: oh no, "))
+				Expect(applied).To(Equal([]Rule{filterRule}))
+			})
+		})
+	})
+
+	Describe("Exec", func() {
+		It("runs rules which cannot be undone, with one iptables-restore per table", func() {
+			Expect(manager.Exec([]Rule{
+				{Args: []string{"-F", "some-chain"}},
+				{Table: Nat, Args: []string{"-F", "some-nat-chain"}},
+				{Args: []string{"-X", "some-chain"}},
+			})).To(Succeed())
+
+			Expect(restoreInputs).To(Equal([]string{
+				"*filter\n-F some-chain\n-X some-chain\nCOMMIT\n",
+				"*nat\n-F some-nat-chain\nCOMMIT\n",
+			}))
+		})
+
+		It("returns the first error without rolling back or running later tables", func() {
+			failingInputs["*filter\n-X some-chain\nCOMMIT\n"] = errors.New("oh no")
+
+			err := manager.Exec([]Rule{
+				{Args: []string{"-X", "some-chain"}},
+				{Table: Nat, Args: []string{"-X", "some-nat-chain"}},
+			})
+			Expect(err).To(MatchError("iptables: restoring filter table: oh no, "))
+			Expect(restoreInputs).To(HaveLen(1))
+		})
+	})
+
+	It("checks for --wait support only once", func() {
+		_, err := manager.Apply([]Rule{filterRule})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manager.Apply([]Rule{natRule})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeRunner.ExecutedCommands()).To(HaveLen(3))
+		Expect(fakeRunner).To(HaveExecutedSerially(
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--help"},
+			},
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--wait", "--noflush"},
+			},
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--wait", "--noflush"},
+			},
+		))
+	})
+
+	Context("when iptables-restore does not support --wait", func() {
+		BeforeEach(func() {
+			usage = "Usage: iptables-restore [-c] [-v] [-t] [-h] [-n]\n"
+		})
+
+		It("restores without it", func() {
+			_, err := manager.Apply([]Rule{filterRule})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables-restore",
+					Args: []string{"--noflush"},
+				},
+			))
+			Expect(restoreInputs).To(Equal([]string{"*filter\n-I some-chain 1 --jump RETURN\nCOMMIT\n"}))
+		})
+	})
+
+	Describe("IPv6", func() {
+		It("applies rules with ip6tables-restore", func() {
+			fakeRunner.WhenRunning(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/ip6tables-restore",
+					Args: []string{"--help"},
+				},
+				func(cmd *exec.Cmd) error {
+					cmd.Stdout.Write([]byte(usage))
+					return nil
+				},
+			)
+			manager = NewIPv6RuleManager(fakeRunner, lagertest.NewTestLogger("test"))
+
+			_, err := manager.Apply([]Rule{filterRule})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/ip6tables-restore",
+					Args: []string{"--wait", "--noflush"},
+				},
+			))
+		})
+	})
+})
@@ -27,6 +27,7 @@ func (s StaticSubnetSelector) SelectSubnet(dynamic *net.IPNet, existing []*net.I
 type dynamicSubnetSelector int
 
 // DynamicSubnetSelector requests the next unallocated ("dynamic") subnet from the dynamic range.
+// The subnets hold four addresses: /30s in an IPv4 range and /126s in an IPv6 range.
 // Returns an error if there are no remaining subnets in the dynamic range.
 var DynamicSubnetSelector dynamicSubnetSelector = 0
 
@@ -38,6 +39,10 @@ func (dynamicSubnetSelector) SelectSubnet(dynamic *net.IPNet, existing []*net.IP
 
 	min := dynamic.IP
 	mask := net.CIDRMask(30, 32) // /30
+	if min.To4() == nil {
+		mask = net.CIDRMask(126, 128) // /126
+	}
+
 	for ip := min; dynamic.Contains(ip); ip = next(ip) {
 		subnet := &net.IPNet{ip, mask}
 		ip = next(next(next(ip)))
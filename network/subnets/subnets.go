@@ -29,7 +29,8 @@ type Subnets interface {
 	// Remove an IP address so it appears to be associated with the given subnet.
 	Remove(*linux_backend.Network) error
 
-	// Returns the number of /30 subnets which can be Acquired by a DynamicSubnetSelector.
+	// Returns the number of /30 (or, for IPv6, /126) subnets which can be Acquired by a
+	// DynamicSubnetSelector.
 	Capacity() int
 }
 
@@ -125,11 +126,12 @@ func (p *pool) Release(network *linux_backend.Network) error {
 	return ErrReleasedUnallocatedSubnet
 }
 
-// Capacity returns the number of /30 subnets that can be allocated
-// from the pool's dynamic allocation range.
+// Capacity returns the number of /30 (or /126) subnets that can be allocated
+// from the pool's dynamic allocation range. IPv6 ranges can hold more than
+// fit in an int, so the capacity is capped at math.MaxInt32.
 func (m *pool) Capacity() int {
 	masked, total := m.dynamicRange.Mask.Size()
-	return int(math.Pow(2, float64(total-masked)) / 4)
+	return int(math.Min(math.Pow(2, float64(total-masked))/4, math.MaxInt32))
 }
 
 // Returns the gateway IP of a given subnet, which is always the maximum valid IP
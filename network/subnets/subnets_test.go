@@ -1,6 +1,7 @@
 package subnets_test
 
 import (
+	"math"
 	"net"
 	"runtime"
 
@@ -22,6 +23,26 @@ var _ = Describe("Subnet Pool", func() {
 	})
 
 	Describe("Capacity", func() {
+		Context("when the dynamic allocation net is an IPv6 range", func() {
+			BeforeEach(func() {
+				defaultSubnetPool = subnetPool("fd00::/120")
+			})
+
+			It("returns the number of /126 subnets", func() {
+				Expect(subnetpool.Capacity()).To(Equal(64))
+			})
+
+			Context("and holds more subnets than fit in an int32", func() {
+				BeforeEach(func() {
+					defaultSubnetPool = subnetPool("fd00::/64")
+				})
+
+				It("caps the capacity", func() {
+					Expect(subnetpool.Capacity()).To(Equal(math.MaxInt32))
+				})
+			})
+		})
+
 		Context("when the dynamic allocation net is empty", func() {
 			BeforeEach(func() {
 				defaultSubnetPool = subnetPool("10.2.3.0/32")
@@ -375,6 +396,43 @@ var _ = Describe("Subnet Pool", func() {
 			})
 		})
 
+		Describe("Dynamic /126 Subnet Allocation", func() {
+			BeforeEach(func() {
+				defaultSubnetPool = subnetPool("fd00::/124")
+			})
+
+			It("returns /126 networks within the IPv6 range", func() {
+				network, err := subnetpool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(network.Subnet.String()).To(Equal("fd00::/126"))
+				Expect(network.IP.String()).To(Equal("fd00::1"))
+
+				network, err = subnetpool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(network.Subnet.String()).To(Equal("fd00::4/126"))
+				Expect(network.IP.String()).To(Equal("fd00::5"))
+			})
+
+			It("uses the last address but one as the gateway", func() {
+				network, err := subnetpool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(subnets.GatewayIP(network.Subnet).String()).To(Equal("fd00::2"))
+			})
+
+			It("runs out once every /126 is allocated", func() {
+				for i := 0; i < 4; i++ {
+					_, err := subnetpool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				_, err := subnetpool.Acquire(subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+				Expect(err).To(Equal(subnets.ErrInsufficientSubnets))
+			})
+		})
+
 		Describe("Dynamic /30 Subnet Allocation", func() {
 			Context("when the pool does not have sufficient IPs to allocate a subnet", func() {
 				BeforeEach(func() {
@@ -1,40 +1,59 @@
 package bandwidth_manager
 
 import (
-	"bytes"
 	"fmt"
 	"os/exec"
 	"path"
-	"regexp"
-	"strconv"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
+	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/pivotal-golang/lager"
 )
 
-var IN_RATE_PATTERN = regexp.MustCompile(`qdisc tbf [0-9a-f]+: root refcnt \d+ rate (\d+)([KMG]?)bit burst (\d+)([KMG]?)b`)
-var OUT_RATE_PATTERN = regexp.MustCompile(`police 0x[0-9a-f]+ rate (\d+)([KMG]?)bit burst (\d+)([KMG]?)b`)
-
 type BandwidthManager interface {
 	SetLimits(lager.Logger, garden.BandwidthLimits) error
 	GetLimits(lager.Logger) (garden.ContainerBandwidthStat, error)
+
+	// Qdiscs lists the queueing disciplines on the container's host interface.
+	Qdiscs(lager.Logger) ([]garden.Qdisc, error)
+}
+
+// RateLimit is a token bucket limiting the traffic through an interface.
+type RateLimit struct {
+	RateInBytesPerSecond uint64
+	BurstInBytes         uint64
+}
+
+type TrafficControl interface {
+	// TokenBucketLimit returns the limit of the tbf qdisc at the root of the
+	// interface, or nil if there is none.
+	TokenBucketLimit(iface string) (*RateLimit, error)
+
+	// PoliceLimit returns the limit policed by a filter on the interface's
+	// ingress qdisc, or nil if there is none.
+	PoliceLimit(iface string) (*RateLimit, error)
+
+	// Qdiscs lists the queueing disciplines on the interface.
+	Qdiscs(iface string) ([]garden.Qdisc, error)
 }
 
 type ContainerBandwidthManager struct {
 	containerPath string
 	containerID   string
 
-	runner command_runner.CommandRunner
+	runner         command_runner.CommandRunner
+	trafficControl TrafficControl
 }
 
-func New(containerPath, containerID string, runner command_runner.CommandRunner) *ContainerBandwidthManager {
+func New(containerPath, containerID string, runner command_runner.CommandRunner, trafficControl TrafficControl) *ContainerBandwidthManager {
 	return &ContainerBandwidthManager{
 		containerPath: containerPath,
 		containerID:   containerID,
 
-		runner: runner,
+		runner:         runner,
+		trafficControl: trafficControl,
 	}
 }
 
@@ -56,86 +75,53 @@ func (m *ContainerBandwidthManager) SetLimits(
 	return runner.Run(setRate)
 }
 
-func (m *ContainerBandwidthManager) GetLimits(logger lager.Logger) (garden.ContainerBandwidthStat, error) {
-	limits := garden.ContainerBandwidthStat{}
+func (m *ContainerBandwidthManager) Qdiscs(logger lager.Logger) ([]garden.Qdisc, error) {
+	config, err := process.EnvFromFile(path.Join(m.containerPath, "etc", "config"))
+	if err != nil {
+		return nil, err
+	}
 
-	runner := logging.Runner{
-		CommandRunner: m.runner,
-		Logger:        logger,
+	qdiscs, err := m.trafficControl.Qdiscs(config["network_host_iface"])
+	if err != nil {
+		logger.Error("failed-to-list-qdiscs", err)
+		return nil, err
 	}
 
-	egressOut := new(bytes.Buffer)
+	return qdiscs, nil
+}
 
-	egress := exec.Command(path.Join(m.containerPath, "net.sh"), "get_egress_info")
-	egress.Env = []string{"ID=" + m.containerID}
-	egress.Stdout = egressOut
+func (m *ContainerBandwidthManager) GetLimits(logger lager.Logger) (garden.ContainerBandwidthStat, error) {
+	limits := garden.ContainerBandwidthStat{}
 
-	err := runner.Run(egress)
+	config, err := process.EnvFromFile(path.Join(m.containerPath, "etc", "config"))
 	if err != nil {
 		return limits, err
 	}
 
-	matches := IN_RATE_PATTERN.FindStringSubmatch(string(egressOut.Bytes()))
-	if matches != nil {
-		inRate, err := strconv.ParseUint(matches[1], 10, 0)
-		if err != nil {
-			return limits, err
-		}
-
-		inBurst, err := strconv.ParseUint(matches[3], 10, 0)
-		if err != nil {
-			return limits, err
-		}
+	iface := config["network_host_iface"]
 
-		inRateUnit := matches[2]
-		inBurstUnit := matches[4]
-
-		limits.InRate = convertUnits(inRate, inRateUnit) / 8
-		limits.InBurst = convertUnits(inBurst, inBurstUnit)
+	// traffic into the container leaves the host's end of the veth pair
+	in, err := m.trafficControl.TokenBucketLimit(iface)
+	if err != nil {
+		logger.Error("failed-to-get-ingress-limit", err)
+		return limits, err
 	}
 
-	ingressOut := new(bytes.Buffer)
-
-	ingress := exec.Command(path.Join(m.containerPath, "net.sh"), "get_ingress_info")
-	ingress.Env = []string{"ID=" + m.containerID}
-	ingress.Stdout = ingressOut
+	if in != nil {
+		limits.InRate = in.RateInBytesPerSecond
+		limits.InBurst = in.BurstInBytes
+	}
 
-	err = runner.Run(ingress)
+	out, err := m.trafficControl.PoliceLimit(iface)
 	if err != nil {
+		logger.Error("failed-to-get-egress-limit", err)
 		return limits, err
 	}
 
-	matches = OUT_RATE_PATTERN.FindStringSubmatch(string(ingressOut.Bytes()))
-	if matches != nil {
-		outRate, err := strconv.ParseUint(matches[1], 10, 0)
-		if err != nil {
-			return limits, err
-		}
-
-		outBurst, err := strconv.ParseUint(matches[3], 10, 0)
-		if err != nil {
-			return limits, err
-		}
-
-		outRateUnit := matches[2]
-		outBurstUnit := matches[4]
-
-		limits.OutRate = convertUnits(outRate, outRateUnit) / 8
-		limits.OutBurst = convertUnits(outBurst, outBurstUnit)
+	if out != nil {
+		limits.OutRate = out.RateInBytesPerSecond
+		limits.OutBurst = out.BurstInBytes
 	}
 
-	return limits, err
-}
-
-func convertUnits(num uint64, unit string) uint64 {
-	switch unit {
-	case "K":
-		return num * 1024
-	case "M":
-		return num * (1024 ^ 2)
-	case "G":
-		return num * (1024 ^ 3)
-	default:
-		return num
-	}
+	return limits, nil
 }
@@ -3,7 +3,10 @@ package bandwidth_manager_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager/fake_bandwidth_manager"
 	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
 	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
 )
@@ -23,7 +27,7 @@ var _ = Describe("setting rate limits", func() {
 	BeforeEach(func() {
 		fakeRunner = fake_command_runner.New()
 		logger = lagertest.NewTestLogger("test")
-		bandwidthManager = bandwidth_manager.New("/depot/some-id", "some-id", fakeRunner)
+		bandwidthManager = bandwidth_manager.New("/depot/some-id", "some-id", fakeRunner, fake_bandwidth_manager.NewTrafficControl())
 	})
 
 	It("executes net_rate.sh with the appropriate environment", func() {
@@ -70,38 +74,45 @@ var _ = Describe("setting rate limits", func() {
 })
 
 var _ = Describe("getting bandwidth limits", func() {
+	var fakeTrafficControl *fake_bandwidth_manager.FakeTrafficControl
+	var containerPath string
+
 	BeforeEach(func() {
+		var err error
+
+		containerPath, err = ioutil.TempDir("", "some-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = os.MkdirAll(path.Join(containerPath, "etc"), 0755)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = ioutil.WriteFile(
+			path.Join(containerPath, "etc", "config"),
+			[]byte("id=some-id\nnetwork_host_iface=w-some-id-0\nnetwork_container_iface=w-some-id-1\n"),
+			0644,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
 		fakeRunner = fake_command_runner.New()
+		fakeTrafficControl = fake_bandwidth_manager.NewTrafficControl()
 		logger = lagertest.NewTestLogger("test")
-		bandwidthManager = bandwidth_manager.New("/depot/some-id", "some-id", fakeRunner)
-	})
-
-	It("executes net.sh get_egress_info and get_ingress_info", func() {
-		fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-			Path: "/depot/some-id/net.sh",
-			Args: []string{"get_egress_info"},
-			Env:  []string{"ID=some-id"},
-		}, func(cmd *exec.Cmd) error {
-			cmd.Stdout.Write([]byte(`qdisc tbf 8010: root refcnt 2 rate 8192bit burst 64Kb lat 24.4ms
-qdisc ingress ffff: parent ffff:fff1 ----------------
-`))
-			return nil
-		})
+		bandwidthManager = bandwidth_manager.New(containerPath, "some-id", fakeRunner, fakeTrafficControl)
+	})
 
-		fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-			Path: "/depot/some-id/net.sh",
-			Args: []string{"get_ingress_info"},
-			Env:  []string{"ID=some-id"},
-		}, func(cmd *exec.Cmd) error {
-			cmd.Stdout.Write([]byte(`filter protocol ip pref 1 u32
-filter protocol ip pref 1 u32 fh 800: ht divisor 1
-filter protocol ip pref 1 u32 fh 800::800 order 2048 key ht 800 bkt 0 flowid :1
-  match 00000000/00000000 at 12
- police 0x10 rate 8192bit burst 64Kb mtu 2Kb action drop overhead 0b
-ref 1 bind 1
-`))
-			return nil
-		})
+	AfterEach(func() {
+		os.RemoveAll(containerPath)
+	})
+
+	It("reports the limits on the container's host interface", func() {
+		fakeTrafficControl.TokenBucketLimits["w-some-id-0"] = &bandwidth_manager.RateLimit{
+			RateInBytesPerSecond: 1024,
+			BurstInBytes:         65536,
+		}
+
+		fakeTrafficControl.PoliceLimits["w-some-id-0"] = &bandwidth_manager.RateLimit{
+			RateInBytesPerSecond: 2048,
+			BurstInBytes:         131072,
+		}
 
 		usage, err := bandwidthManager.GetLimits(logger)
 		Expect(err).ToNot(HaveOccurred())
@@ -109,21 +120,31 @@ ref 1 bind 1
 		Expect(usage.InRate).To(Equal(uint64(1024)))
 		Expect(usage.InBurst).To(Equal(uint64(65536)))
 
-		Expect(usage.OutRate).To(Equal(uint64(1024)))
-		Expect(usage.OutBurst).To(Equal(uint64(65536)))
+		Expect(usage.OutRate).To(Equal(uint64(2048)))
+		Expect(usage.OutBurst).To(Equal(uint64(131072)))
+	})
+
+	It("runs nothing", func() {
+		_, err := bandwidthManager.GetLimits(logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
 	})
 
-	Context("when net.sh get_egress_info fails", func() {
+	Context("when there are no limits", func() {
+		It("returns 0 limits and does not error", func() {
+			usage, err := bandwidthManager.GetLimits(logger)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(usage).To(BeZero())
+		})
+	})
+
+	Context("when getting the token bucket limit fails", func() {
 		disaster := errors.New("oh no!")
 
 		BeforeEach(func() {
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_egress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(*exec.Cmd) error {
-				return disaster
-			})
+			fakeTrafficControl.TokenBucketLimitError = disaster
 		})
 
 		It("returns the error", func() {
@@ -132,93 +153,93 @@ ref 1 bind 1
 		})
 	})
 
-	Context("when net.sh get_egress_info output doesn't match", func() {
-		It("returns 0 limits and does not error", func() {
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_egress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(cmd *exec.Cmd) error {
-				cmd.Stdout.Write([]byte(`qdisc pfifo_fast 0: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1
-`))
-				return nil
-			})
+	Context("when getting the police limit fails", func() {
+		disaster := errors.New("oh no!")
 
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_ingress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(cmd *exec.Cmd) error {
-				cmd.Stdout.Write([]byte(`filter protocol ip pref 1 u32
-filter protocol ip pref 1 u32 fh 800: ht divisor 1
-filter protocol ip pref 1 u32 fh 800::800 order 2048 key ht 800 bkt 0 flowid :1
-  match 00000000/00000000 at 12
- police 0x10 rate 8192bit burst 64Kb mtu 2Kb action drop overhead 0b
-ref 1 bind 1
-`))
-				return nil
-			})
+		BeforeEach(func() {
+			fakeTrafficControl.PoliceLimitError = disaster
+		})
 
-			usage, err := bandwidthManager.GetLimits(logger)
-			Expect(err).ToNot(HaveOccurred())
+		It("returns the error", func() {
+			_, err := bandwidthManager.GetLimits(logger)
+			Expect(err).To(Equal(disaster))
+		})
+	})
 
-			Expect(usage.InRate).To(Equal(uint64(0)))
-			Expect(usage.InBurst).To(Equal(uint64(0)))
+	Context("when the container's config cannot be read", func() {
+		BeforeEach(func() {
+			err := os.RemoveAll(path.Join(containerPath, "etc"))
+			Expect(err).ToNot(HaveOccurred())
+		})
 
-			Expect(usage.OutRate).To(Equal(uint64(1024)))
-			Expect(usage.OutBurst).To(Equal(uint64(65536)))
+		It("returns an error", func() {
+			_, err := bandwidthManager.GetLimits(logger)
+			Expect(err).To(HaveOccurred())
 		})
 	})
+})
+
+var _ = Describe("listing queueing disciplines", func() {
+	var fakeTrafficControl *fake_bandwidth_manager.FakeTrafficControl
+	var containerPath string
+
+	BeforeEach(func() {
+		var err error
+
+		containerPath, err = ioutil.TempDir("", "some-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = os.MkdirAll(path.Join(containerPath, "etc"), 0755)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = ioutil.WriteFile(
+			path.Join(containerPath, "etc", "config"),
+			[]byte("id=some-id\nnetwork_host_iface=w-some-id-0\n"),
+			0644,
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeRunner = fake_command_runner.New()
+		fakeTrafficControl = fake_bandwidth_manager.NewTrafficControl()
+		logger = lagertest.NewTestLogger("test")
+		bandwidthManager = bandwidth_manager.New(containerPath, "some-id", fakeRunner, fakeTrafficControl)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(containerPath)
+	})
+
+	It("lists the qdiscs on the container's host interface", func() {
+		qdiscs := []garden.Qdisc{
+			{Device: "w-some-id-0", Kind: "tbf", Handle: "8001:", Parent: "root"},
+			{Device: "w-some-id-0", Kind: "ingress", Handle: "ffff:", Parent: "ingress"},
+		}
+		fakeTrafficControl.QdiscLists["w-some-id-0"] = qdiscs
+
+		Expect(bandwidthManager.Qdiscs(logger)).To(Equal(qdiscs))
+	})
 
-	Context("when net.sh get_ingress_info fails", func() {
+	Context("when listing the qdiscs fails", func() {
 		disaster := errors.New("oh no!")
 
 		BeforeEach(func() {
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_ingress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(*exec.Cmd) error {
-				return disaster
-			})
+			fakeTrafficControl.QdiscsError = disaster
 		})
 
 		It("returns the error", func() {
-			_, err := bandwidthManager.GetLimits(logger)
+			_, err := bandwidthManager.Qdiscs(logger)
 			Expect(err).To(Equal(disaster))
 		})
 	})
 
-	Context("when net.sh get_ingress_info output doesn't match", func() {
-		It("returns 0 limits and does not error", func() {
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_egress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(cmd *exec.Cmd) error {
-				cmd.Stdout.Write([]byte(`qdisc tbf 8010: root refcnt 2 rate 8192bit burst 64Kb lat 24.4ms
-qdisc ingress ffff: parent ffff:fff1 ----------------
-`))
-				return nil
-			})
-
-			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
-				Path: "/depot/some-id/net.sh",
-				Args: []string{"get_ingress_info"},
-				Env:  []string{"ID=some-id"},
-			}, func(cmd *exec.Cmd) error {
-				cmd.Stdout.Write([]byte(``))
-				return nil
-			})
-
-			usage, err := bandwidthManager.GetLimits(logger)
-			Expect(err).ToNot(HaveOccurred())
-
-			Expect(usage.InRate).To(Equal(uint64(1024)))
-			Expect(usage.InBurst).To(Equal(uint64(65536)))
+	Context("when the container's config cannot be read", func() {
+		BeforeEach(func() {
+			Expect(os.RemoveAll(path.Join(containerPath, "etc"))).To(Succeed())
+		})
 
-			Expect(usage.OutRate).To(Equal(uint64(0)))
-			Expect(usage.OutBurst).To(Equal(uint64(0)))
+		It("returns an error", func() {
+			_, err := bandwidthManager.Qdiscs(logger)
+			Expect(err).To(HaveOccurred())
 		})
 	})
 })
@@ -11,6 +11,9 @@ type FakeBandwidthManager struct {
 
 	GetLimitsError  error
 	GetLimitsResult garden.ContainerBandwidthStat
+
+	QdiscsError  error
+	QdiscsResult []garden.Qdisc
 }
 
 func New() *FakeBandwidthManager {
@@ -34,3 +37,11 @@ func (m *FakeBandwidthManager) GetLimits(logger lager.Logger) (garden.ContainerB
 
 	return m.GetLimitsResult, nil
 }
+
+func (m *FakeBandwidthManager) Qdiscs(logger lager.Logger) ([]garden.Qdisc, error) {
+	if m.QdiscsError != nil {
+		return nil, m.QdiscsError
+	}
+
+	return m.QdiscsResult, nil
+}
@@ -0,0 +1,49 @@
+package fake_bandwidth_manager
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager"
+)
+
+type FakeTrafficControl struct {
+	TokenBucketLimits     map[string]*bandwidth_manager.RateLimit
+	TokenBucketLimitError error
+
+	PoliceLimits     map[string]*bandwidth_manager.RateLimit
+	PoliceLimitError error
+
+	QdiscLists  map[string][]garden.Qdisc
+	QdiscsError error
+}
+
+func NewTrafficControl() *FakeTrafficControl {
+	return &FakeTrafficControl{
+		TokenBucketLimits: make(map[string]*bandwidth_manager.RateLimit),
+		PoliceLimits:      make(map[string]*bandwidth_manager.RateLimit),
+		QdiscLists:        make(map[string][]garden.Qdisc),
+	}
+}
+
+func (tc *FakeTrafficControl) TokenBucketLimit(iface string) (*bandwidth_manager.RateLimit, error) {
+	if tc.TokenBucketLimitError != nil {
+		return nil, tc.TokenBucketLimitError
+	}
+
+	return tc.TokenBucketLimits[iface], nil
+}
+
+func (tc *FakeTrafficControl) PoliceLimit(iface string) (*bandwidth_manager.RateLimit, error) {
+	if tc.PoliceLimitError != nil {
+		return nil, tc.PoliceLimitError
+	}
+
+	return tc.PoliceLimits[iface], nil
+}
+
+func (tc *FakeTrafficControl) Qdiscs(iface string) ([]garden.Qdisc, error) {
+	if tc.QdiscsError != nil {
+		return nil, tc.QdiscsError
+	}
+
+	return tc.QdiscLists[iface], nil
+}
@@ -0,0 +1,337 @@
+package bandwidth_manager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+const (
+	tcaKind    = 1
+	tcaOptions = 2
+
+	tcaTbfParms  = 1
+	tcaTbfRate64 = 4
+
+	tcaU32Police = 6
+	tcaU32Act    = 7
+
+	tcaActKind    = 1
+	tcaActOptions = 2
+
+	tcaPoliceTbf    = 1
+	tcaPoliceRate64 = 8
+
+	tcHRoot          = 0xffffffff
+	tcHIngress       = 0xffff0000
+	tcHIngressParent = 0xfffffff1
+
+	tcmsgLen = 20
+
+	timeUnitsPerSec = 1000000
+)
+
+// NetlinkTrafficControl reads an interface's rate limits from the kernel over
+// rtnetlink, as tc(8) would.
+type NetlinkTrafficControl struct{}
+
+type NetlinkError struct {
+	Op    string
+	Iface string
+	Err   error
+}
+
+func (e *NetlinkError) Error() string {
+	return fmt.Sprintf("bandwidth_manager: %s on %s: %s", e.Op, e.Iface, e.Err)
+}
+
+func (NetlinkTrafficControl) TokenBucketLimit(iface string) (*RateLimit, error) {
+	msgs, err := dumpTC(iface, syscall.RTM_GETQDISC, 0)
+	if err != nil {
+		return nil, &NetlinkError{"list qdiscs", iface, err}
+	}
+
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWQDISC || len(msg.Data) < tcmsgLen {
+			continue
+		}
+
+		parent := binary.LittleEndian.Uint32(msg.Data[12:16])
+		if parent != tcHRoot {
+			continue
+		}
+
+		attrs := parseAttrs(msg.Data[tcmsgLen:])
+		if kind(attrs) != "tbf" {
+			continue
+		}
+
+		options := parseAttrs(attrs[tcaOptions])
+
+		// struct tc_tbf_qopt: rate, peakrate, limit, buffer, mtu
+		parms := options[tcaTbfParms]
+		if len(parms) < 36 {
+			return nil, &NetlinkError{"parse tbf qdisc", iface, fmt.Errorf("short parameters (%d bytes)", len(parms))}
+		}
+
+		rate := uint64(binary.LittleEndian.Uint32(parms[8:12]))
+		if rate64, found := options[tcaTbfRate64]; found && len(rate64) >= 8 {
+			rate = binary.LittleEndian.Uint64(rate64)
+		}
+
+		return rateLimit(rate, binary.LittleEndian.Uint32(parms[28:32]))
+	}
+
+	return nil, nil
+}
+
+func (NetlinkTrafficControl) PoliceLimit(iface string) (*RateLimit, error) {
+	msgs, err := dumpTC(iface, syscall.RTM_GETTFILTER, tcHIngress)
+	if err != nil {
+		return nil, &NetlinkError{"list ingress filters", iface, err}
+	}
+
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWTFILTER || len(msg.Data) < tcmsgLen {
+			continue
+		}
+
+		attrs := parseAttrs(msg.Data[tcmsgLen:])
+		if kind(attrs) != "u32" {
+			continue
+		}
+
+		police := policeAttrs(parseAttrs(attrs[tcaOptions]))
+		if police == nil {
+			continue
+		}
+
+		// struct tc_police: index, action, limit, burst, mtu, rate, ...
+		parms := police[tcaPoliceTbf]
+		if len(parms) < 32 {
+			return nil, &NetlinkError{"parse police action", iface, fmt.Errorf("short parameters (%d bytes)", len(parms))}
+		}
+
+		rate := uint64(binary.LittleEndian.Uint32(parms[28:32]))
+		if rate64, found := police[tcaPoliceRate64]; found && len(rate64) >= 8 {
+			rate = binary.LittleEndian.Uint64(rate64)
+		}
+
+		return rateLimit(rate, binary.LittleEndian.Uint32(parms[12:16]))
+	}
+
+	return nil, nil
+}
+
+func (NetlinkTrafficControl) Qdiscs(iface string) ([]garden.Qdisc, error) {
+	msgs, err := dumpTC(iface, syscall.RTM_GETQDISC, 0)
+	if err != nil {
+		return nil, &NetlinkError{"list qdiscs", iface, err}
+	}
+
+	qdiscs := []garden.Qdisc{}
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWQDISC || len(msg.Data) < tcmsgLen {
+			continue
+		}
+
+		qdiscs = append(qdiscs, garden.Qdisc{
+			Device: iface,
+			Kind:   kind(parseAttrs(msg.Data[tcmsgLen:])),
+			Handle: formatHandle(binary.LittleEndian.Uint32(msg.Data[8:12])),
+			Parent: formatParent(binary.LittleEndian.Uint32(msg.Data[12:16])),
+		})
+	}
+
+	return qdiscs, nil
+}
+
+// formatHandle prints a handle as tc(8) does, e.g. "8001:" or "1:10".
+func formatHandle(handle uint32) string {
+	major, minor := handle>>16, handle&0xffff
+	if minor == 0 {
+		return fmt.Sprintf("%x:", major)
+	}
+
+	return fmt.Sprintf("%x:%x", major, minor)
+}
+
+func formatParent(parent uint32) string {
+	switch parent {
+	case tcHRoot:
+		return "root"
+	case tcHIngressParent:
+		return "ingress"
+	}
+
+	return formatHandle(parent)
+}
+
+// policeAttrs finds the police action of a u32 filter, which the kernel
+// reports either in the old style or as the filter's first action.
+func policeAttrs(options map[uint16][]byte) map[uint16][]byte {
+	if police, found := options[tcaU32Police]; found {
+		return parseAttrs(police)
+	}
+
+	for _, action := range parseAttrs(options[tcaU32Act]) {
+		attrs := parseAttrs(action)
+		if kind(attrs) == "police" {
+			return parseAttrs(attrs[tcaActOptions])
+		}
+	}
+
+	return nil
+}
+
+// rateLimit converts a bucket size in scheduler ticks back into bytes, the
+// way tc(8) prints it.
+func rateLimit(rate uint64, bufferTicks uint32) (*RateLimit, error) {
+	tickInUsec, err := tickInUsec()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimit{
+		RateInBytesPerSecond: rate,
+		BurstInBytes:         uint64(float64(rate) * float64(bufferTicks) / tickInUsec / timeUnitsPerSec),
+	}, nil
+}
+
+func tickInUsec() (float64, error) {
+	psched, err := ioutil.ReadFile("/proc/net/psched")
+	if err != nil {
+		return 0, err
+	}
+
+	var t2us, us2t, clockRes uint32
+
+	_, err = fmt.Sscanf(string(psched), "%08x %08x %08x", &t2us, &us2t, &clockRes)
+	if err != nil {
+		return 0, fmt.Errorf("bandwidth_manager: malformed /proc/net/psched: %s", err)
+	}
+
+	clockFactor := float64(clockRes) / timeUnitsPerSec
+
+	return float64(t2us) / float64(us2t) * clockFactor, nil
+}
+
+func dumpTC(iface string, msgType uint16, parent uint32) ([]syscall.NetlinkMessage, error) {
+	intf, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	defer syscall.Close(fd)
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	if err != nil {
+		return nil, err
+	}
+
+	request := new(bytes.Buffer)
+	binary.Write(request, binary.LittleEndian, syscall.NlMsghdr{
+		Len:   syscall.NLMSG_HDRLEN + tcmsgLen,
+		Type:  msgType,
+		Flags: syscall.NLM_F_REQUEST | syscall.NLM_F_DUMP,
+		Seq:   1,
+	})
+
+	// struct tcmsg: family, padding, ifindex, handle, parent, info
+	binary.Write(request, binary.LittleEndian, struct {
+		Family  uint8
+		Pad1    uint8
+		Pad2    uint16
+		Ifindex int32
+		Handle  uint32
+		Parent  uint32
+		Info    uint32
+	}{Ifindex: int32(intf.Index), Parent: parent})
+
+	err = syscall.Sendto(fd, request.Bytes(), 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK})
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []syscall.NetlinkMessage
+
+	buf := make([]byte, syscall.Getpagesize()*4)
+
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		received, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, msg := range received {
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return msgs, nil
+
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) < 4 {
+					return nil, syscall.EINVAL
+				}
+
+				errno := int32(binary.LittleEndian.Uint32(msg.Data[0:4]))
+				if errno == 0 {
+					return msgs, nil
+				}
+
+				return nil, syscall.Errno(-errno)
+
+			default:
+				// only messages for the interface are wanted; older kernels
+				// dump every interface's
+				if len(msg.Data) >= 8 && int32(binary.LittleEndian.Uint32(msg.Data[4:8])) == int32(intf.Index) {
+					msgs = append(msgs, msg)
+				}
+			}
+		}
+	}
+}
+
+func parseAttrs(data []byte) map[uint16][]byte {
+	attrs := make(map[uint16][]byte)
+
+	for len(data) >= syscall.SizeofRtAttr {
+		length := int(binary.LittleEndian.Uint16(data[0:2]))
+		attrType := binary.LittleEndian.Uint16(data[2:4]) &^ syscall.NLA_F_NESTED
+
+		if length < syscall.SizeofRtAttr || length > len(data) {
+			break
+		}
+
+		if _, found := attrs[attrType]; !found {
+			attrs[attrType] = data[syscall.SizeofRtAttr:length]
+		}
+
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(data) {
+			break
+		}
+
+		data = data[aligned:]
+	}
+
+	return attrs
+}
+
+func kind(attrs map[uint16][]byte) string {
+	return string(bytes.TrimRight(attrs[tcaKind], "\x00"))
+}
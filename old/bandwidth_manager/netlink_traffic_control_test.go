@@ -0,0 +1,48 @@
+package bandwidth_manager_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden-linux/old/bandwidth_manager"
+)
+
+var _ = Describe("Netlink traffic control", func() {
+	var trafficControl bandwidth_manager.NetlinkTrafficControl
+
+	Context("when the interface does not exist", func() {
+		It("returns a NetlinkError", func() {
+			_, err := trafficControl.TokenBucketLimit("some-missing-iface")
+			Expect(err).To(BeAssignableToTypeOf(&bandwidth_manager.NetlinkError{}))
+			Expect(err.(*bandwidth_manager.NetlinkError).Iface).To(Equal("some-missing-iface"))
+
+			_, err = trafficControl.PoliceLimit("some-missing-iface")
+			Expect(err).To(BeAssignableToTypeOf(&bandwidth_manager.NetlinkError{}))
+
+			_, err = trafficControl.Qdiscs("some-missing-iface")
+			Expect(err).To(BeAssignableToTypeOf(&bandwidth_manager.NetlinkError{}))
+		})
+	})
+
+	Context("when the interface has no limits", func() {
+		It("returns no limits", func() {
+			limit, err := trafficControl.TokenBucketLimit("lo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(BeNil())
+
+			limit, err = trafficControl.PoliceLimit("lo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(BeNil())
+		})
+
+		It("lists only qdiscs which limit nothing", func() {
+			qdiscs, err := trafficControl.Qdiscs("lo")
+			Expect(err).ToNot(HaveOccurred())
+
+			for _, qdisc := range qdiscs {
+				Expect(qdisc.Device).To(Equal("lo"))
+				Expect(qdisc.Kind).ToNot(Equal("tbf"))
+			}
+		})
+	})
+})
@@ -4,4 +4,5 @@ type CgroupsManager interface {
 	Set(subsystem, name, value string) error
 	Get(subsystem, name string) (string, error)
 	SubsystemPath(subsystem string) string
+	NotifyOom() (OomNotification, error)
 }
@@ -75,4 +75,63 @@ var _ = Describe("Container cgroups", func() {
 
 		})
 	})
+
+	Describe("notifying of oom", func() {
+		var containerMemoryCgroupsPath string
+
+		BeforeEach(func() {
+			containerMemoryCgroupsPath = path.Join(cgroupsPath, "memory", "instance-some-container-id")
+
+			err := os.MkdirAll(containerMemoryCgroupsPath, 0755)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(path.Join(containerMemoryCgroupsPath, "memory.oom_control"), []byte("oom_kill_disable 0\n"), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(path.Join(containerMemoryCgroupsPath, "cgroup.event_control"), []byte{}, 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("registers an eventfd for memory.oom_control", func() {
+			notification, err := cgroupsManager.NotifyOom()
+			Expect(err).ToNot(HaveOccurred())
+
+			defer notification.Stop()
+
+			eventControl, err := ioutil.ReadFile(path.Join(containerMemoryCgroupsPath, "cgroup.event_control"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(eventControl)).To(MatchRegexp(`^\d+ \d+$`))
+		})
+
+		Context("when the notification is stopped", func() {
+			It("stops waiting", func() {
+				notification, err := cgroupsManager.NotifyOom()
+				Expect(err).ToNot(HaveOccurred())
+
+				waited := make(chan error)
+				go func() {
+					waited <- notification.Wait()
+				}()
+
+				Consistently(waited).ShouldNot(Receive())
+
+				err = notification.Stop()
+				Expect(err).ToNot(HaveOccurred())
+
+				Eventually(waited).Should(Receive(Equal(cgroups_manager.ErrOomNotificationStopped)))
+			})
+		})
+
+		Context("when the cgroup does not exist", func() {
+			BeforeEach(func() {
+				err := os.RemoveAll(containerMemoryCgroupsPath)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns an error", func() {
+				_, err := cgroupsManager.NotifyOom()
+				Expect(err).To(BeAssignableToTypeOf(&os.PathError{}))
+			})
+		})
+	})
 })
@@ -2,17 +2,26 @@ package fake_cgroups_manager
 
 import (
 	"path"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-linux/old/cgroups_manager"
 )
 
 type FakeCgroupsManager struct {
 	cgroupsPath string
 	id          string
 
-	SetError error
+	SetError       error
+	NotifyOomError error
 
 	setValues    []SetValue
 	getCallbacks []GetCallback
 	setCallbacks []SetCallback
+	mutex        sync.RWMutex
+
+	oomWaitCallback  func() error
+	oomNotifications []*FakeOomNotification
+	oomMutex         sync.Mutex
 }
 
 type SetValue struct {
@@ -45,24 +54,39 @@ func (m *FakeCgroupsManager) Set(subsystem, name, value string) error {
 		return m.SetError
 	}
 
+	if callback := m.setCallback(subsystem, name); callback != nil {
+		return callback()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.setValues = append(m.setValues, SetValue{subsystem, name, value})
+
+	return nil
+}
+
+func (m *FakeCgroupsManager) setCallback(subsystem, name string) func() error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	for _, cb := range m.setCallbacks {
 		if cb.Subsystem == subsystem && cb.Name == name {
-			return cb.Callback()
+			return cb.Callback
 		}
 	}
 
-	m.setValues = append(m.setValues, SetValue{subsystem, name, value})
-
 	return nil
 }
 
 func (m *FakeCgroupsManager) Get(subsytem, name string) (string, error) {
-	for _, cb := range m.getCallbacks {
-		if cb.Subsystem == subsytem && cb.Name == name {
-			return cb.Callback()
-		}
+	if callback := m.getCallback(subsytem, name); callback != nil {
+		return callback()
 	}
 
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	for _, val := range m.setValues {
 		if val.Subsystem == subsytem && val.Name == name {
 			return val.Value, nil
@@ -72,18 +96,122 @@ func (m *FakeCgroupsManager) Get(subsytem, name string) (string, error) {
 	return "", nil
 }
 
+func (m *FakeCgroupsManager) getCallback(subsystem, name string) func() (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for _, cb := range m.getCallbacks {
+		if cb.Subsystem == subsystem && cb.Name == name {
+			return cb.Callback
+		}
+	}
+
+	return nil
+}
+
 func (m *FakeCgroupsManager) SubsystemPath(subsystem string) string {
 	return path.Join(m.cgroupsPath, subsystem, "instance-"+m.id)
 }
 
 func (m *FakeCgroupsManager) SetValues() []SetValue {
-	return m.setValues
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	setValues := make([]SetValue, len(m.setValues))
+	copy(setValues, m.setValues)
+
+	return setValues
 }
 
 func (m *FakeCgroupsManager) WhenGetting(subsystem, name string, callback func() (string, error)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	m.getCallbacks = append(m.getCallbacks, GetCallback{subsystem, name, callback})
 }
 
 func (m *FakeCgroupsManager) WhenSetting(subsystem, name string, callback func() error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	m.setCallbacks = append(m.setCallbacks, SetCallback{subsystem, name, callback})
 }
+
+func (m *FakeCgroupsManager) NotifyOom() (cgroups_manager.OomNotification, error) {
+	if m.NotifyOomError != nil {
+		return nil, m.NotifyOomError
+	}
+
+	m.oomMutex.Lock()
+	defer m.oomMutex.Unlock()
+
+	notification := &FakeOomNotification{
+		waitCallback: m.oomWaitCallback,
+		stop:         make(chan struct{}),
+	}
+	m.oomNotifications = append(m.oomNotifications, notification)
+
+	return notification, nil
+}
+
+func (m *FakeCgroupsManager) OomNotifications() []*FakeOomNotification {
+	m.oomMutex.Lock()
+	defer m.oomMutex.Unlock()
+
+	return m.oomNotifications
+}
+
+// WhenWaitingForOom replaces the default behaviour of notifications, which
+// report an OOM as soon as they are first waited for and then wait until
+// they are stopped.
+func (m *FakeCgroupsManager) WhenWaitingForOom(callback func() error) {
+	m.oomWaitCallback = callback
+}
+
+type FakeOomNotification struct {
+	waitCallback func() error
+
+	waited  bool
+	stopped bool
+	stop    chan struct{}
+	mutex   sync.Mutex
+}
+
+func (n *FakeOomNotification) Wait() error {
+	if n.waitCallback != nil {
+		return n.waitCallback()
+	}
+
+	n.mutex.Lock()
+	waited := n.waited
+	n.waited = true
+	n.mutex.Unlock()
+
+	if !waited {
+		return nil
+	}
+
+	<-n.stop
+
+	return cgroups_manager.ErrOomNotificationStopped
+}
+
+func (n *FakeOomNotification) Stop() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if !n.stopped {
+		close(n.stop)
+	}
+
+	n.stopped = true
+
+	return nil
+}
+
+func (n *FakeOomNotification) IsStopped() bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return n.stopped
+}
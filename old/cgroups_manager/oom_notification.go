@@ -0,0 +1,134 @@
+package cgroups_manager
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+)
+
+var ErrOomNotificationStopped = errors.New("cgroups_manager: oom notification stopped")
+var ErrCgroupRemoved = errors.New("cgroups_manager: cgroup removed")
+
+const efdCloexec = 0x80000
+
+// OomNotification reports when a memory cgroup runs out of memory.
+type OomNotification interface {
+	// Wait blocks until the cgroup runs out of memory, returning nil, or until
+	// the notification is stopped or the cgroup is removed. After an out of
+	// memory it may be called again to wait for the next.
+	Wait() error
+
+	Stop() error
+}
+
+type eventfdOomNotification struct {
+	eventControlPath string
+
+	eventFile      *os.File
+	oomControlFile *os.File
+
+	stopped bool
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// NotifyOom registers an eventfd with the memory cgroup, in the way the
+// kernel's memory controller documents, rather than running a helper.
+func (m *ContainerCgroupsManager) NotifyOom() (OomNotification, error) {
+	cgroupPath := m.SubsystemPath("memory")
+
+	oomControlFile, err := os.Open(path.Join(cgroupPath, "memory.oom_control"))
+	if err != nil {
+		return nil, err
+	}
+
+	efd, _, errno := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, efdCloexec, 0)
+	if errno != 0 {
+		oomControlFile.Close()
+		return nil, os.NewSyscallError("eventfd2", errno)
+	}
+
+	eventFile := os.NewFile(efd, "eventfd")
+
+	notification := &eventfdOomNotification{
+		eventControlPath: path.Join(cgroupPath, "cgroup.event_control"),
+
+		eventFile:      eventFile,
+		oomControlFile: oomControlFile,
+	}
+
+	eventControl, err := os.OpenFile(notification.eventControlPath, os.O_WRONLY, 0)
+	if err != nil {
+		notification.close()
+		return nil, err
+	}
+
+	defer eventControl.Close()
+
+	_, err = fmt.Fprintf(eventControl, "%d %d", eventFile.Fd(), oomControlFile.Fd())
+	if err != nil {
+		notification.close()
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+func (n *eventfdOomNotification) Wait() error {
+	buf := make([]byte, 8)
+
+	_, err := n.eventFile.Read(buf)
+	if err != nil {
+		n.close()
+		return err
+	}
+
+	n.mutex.Lock()
+	stopped := n.stopped
+	n.mutex.Unlock()
+
+	if stopped {
+		n.close()
+		return ErrOomNotificationStopped
+	}
+
+	// the eventfd is also signalled when the cgroup goes away
+	if _, err := os.Stat(n.eventControlPath); os.IsNotExist(err) {
+		n.close()
+		return ErrCgroupRemoved
+	}
+
+	return nil
+}
+
+// Stop wakes up Wait by signalling the eventfd itself.
+func (n *eventfdOomNotification) Stop() error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.stopped || n.closed {
+		return nil
+	}
+
+	n.stopped = true
+
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, 1)
+
+	_, err := n.eventFile.Write(buf)
+	return err
+}
+
+func (n *eventfdOomNotification) close() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.closed = true
+
+	n.oomControlFile.Close()
+	n.eventFile.Close()
+}
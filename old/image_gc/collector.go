@@ -0,0 +1,261 @@
+package image_gc
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/image"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// GracePeriod is how long an image is left alone after it was last used or,
+// if it has never been used, after the collector first saw it. This covers
+// images that are still being fetched and have not yet been acquired.
+var GracePeriod = 10 * time.Minute
+
+// apes docker's *graph.Graph
+type Graph interface {
+	Map() (map[string]*image.Image, error)
+	Delete(name string) error
+}
+
+// apes repository_fetcher.TagStore
+type Tags interface {
+	ImageIDs() []string
+}
+
+// Policy bounds the images kept in the graph that no container uses. A zero
+// value for any limit disables it. Images are removed least recently used
+// first until every limit is met.
+type Policy struct {
+	MaxBytes   int64
+	MaxAge     time.Duration
+	KeepUnused int
+}
+
+func (policy Policy) Enabled() bool {
+	return policy.MaxBytes > 0 || policy.MaxAge > 0 || policy.KeepUnused > 0
+}
+
+type Collector struct {
+	graph   Graph
+	tracker Tracker
+	tags    Tags
+	policy  Policy
+	clock   clock.Clock
+	logger  lager.Logger
+
+	firstSeen map[string]time.Time
+	mutex     *sync.Mutex
+}
+
+type candidate struct {
+	id       string
+	lastUsed time.Time
+}
+
+type byLastUsed []candidate
+
+func (c byLastUsed) Len() int      { return len(c) }
+func (c byLastUsed) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c byLastUsed) Less(i, j int) bool {
+	if c[i].lastUsed.Equal(c[j].lastUsed) {
+		return c[i].id < c[j].id
+	}
+
+	return c[i].lastUsed.Before(c[j].lastUsed)
+}
+
+func New(graph Graph, tracker Tracker, tags Tags, policy Policy, clock clock.Clock, logger lager.Logger) *Collector {
+	return &Collector{
+		graph:   graph,
+		tracker: tracker,
+		tags:    tags,
+		policy:  policy,
+		clock:   clock,
+		logger:  logger.Session("image-gc"),
+
+		firstSeen: map[string]time.Time{},
+		mutex:     new(sync.Mutex),
+	}
+}
+
+// Run collects every interval until stop is closed.
+func (collector *Collector) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := collector.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			if err := collector.Collect(); err != nil {
+				collector.logger.Error("collect-failed", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Collect deletes the image chains no container uses that fall outside the
+// policy. Tagged images are kept, as they can only be fetched by their tag
+// from the local graph. Only images without children are candidates; deleting one makes
+// its parent a candidate in turn, unless the parent is shared.
+func (collector *Collector) Collect() error {
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	cLog := collector.logger.Session("collect")
+
+	images, err := collector.graph.Map()
+	if err != nil {
+		return err
+	}
+
+	now := collector.clock.Now()
+
+	referenced := collector.tracker.Referenced()
+	for _, id := range collector.tags.ImageIDs() {
+		referenced[id] = true
+	}
+
+	inUse := inUse(images, referenced)
+
+	children := map[string]int{}
+	var totalBytes int64
+	for id, img := range images {
+		if img.Parent != "" {
+			children[img.Parent]++
+		}
+
+		totalBytes += layerSize(img)
+
+		if _, seen := collector.firstSeen[id]; !seen {
+			collector.firstSeen[id] = now
+		}
+	}
+
+	for id := range collector.firstSeen {
+		if _, found := images[id]; !found {
+			delete(collector.firstSeen, id)
+		}
+	}
+
+	var candidates []candidate
+	for id := range images {
+		if inUse[id] || children[id] > 0 {
+			continue
+		}
+
+		candidates = append(candidates, candidate{id, collector.lastUsed(id)})
+	}
+
+	sort.Sort(byLastUsed(candidates))
+
+	unused := len(candidates)
+	for _, c := range candidates {
+		idle := now.Sub(c.lastUsed)
+		if idle < GracePeriod {
+			continue
+		}
+
+		expired := collector.policy.MaxAge > 0 && idle > collector.policy.MaxAge
+		tooMany := collector.policy.KeepUnused > 0 && unused > collector.policy.KeepUnused
+		tooBig := collector.policy.MaxBytes > 0 && totalBytes > collector.policy.MaxBytes
+
+		if !expired && !tooMany && !tooBig {
+			continue
+		}
+
+		freed, err := collector.deleteChain(cLog, c.id, images, children, inUse)
+		totalBytes -= freed
+		if err != nil {
+			cLog.Error("delete-failed", err, lager.Data{
+				"image": c.id,
+			})
+
+			continue
+		}
+
+		unused--
+	}
+
+	return nil
+}
+
+// deleteChain deletes the image and then each ancestor left without
+// children, returning the number of bytes freed.
+func (collector *Collector) deleteChain(logger lager.Logger, id string, images map[string]*image.Image, children map[string]int, inUse map[string]bool) (int64, error) {
+	var freed int64
+
+	for id != "" && !inUse[id] && children[id] == 0 {
+		img, found := images[id]
+		if !found {
+			break
+		}
+
+		if err := collector.graph.Delete(id); err != nil {
+			return freed, err
+		}
+
+		logger.Info("deleted", lager.Data{
+			"image": id,
+			"bytes": layerSize(img),
+		})
+
+		if err := collector.tracker.Forget(id); err != nil {
+			logger.Error("forget-failed", err, lager.Data{
+				"image": id,
+			})
+		}
+
+		delete(images, id)
+		delete(collector.firstSeen, id)
+		freed += layerSize(img)
+
+		id = img.Parent
+		if id != "" {
+			children[id]--
+		}
+	}
+
+	return freed, nil
+}
+
+// inUse returns the referenced images along with all of their ancestors.
+func inUse(images map[string]*image.Image, referenced map[string]bool) map[string]bool {
+	inUse := map[string]bool{}
+	for id := range referenced {
+		for id != "" && !inUse[id] {
+			inUse[id] = true
+
+			img, found := images[id]
+			if !found {
+				break
+			}
+
+			id = img.Parent
+		}
+	}
+
+	return inUse
+}
+
+func (collector *Collector) lastUsed(id string) time.Time {
+	if lastUsed, found := collector.tracker.LastUsed(id); found {
+		return lastUsed
+	}
+
+	return collector.firstSeen[id]
+}
+
+// layerSize treats layers whose size docker never recorded as empty.
+func layerSize(img *image.Image) int64 {
+	if img.Size < 0 {
+		return 0
+	}
+
+	return img.Size
+}
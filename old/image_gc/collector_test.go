@@ -0,0 +1,240 @@
+package image_gc_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/image"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden-linux/container_pool/fake_graph"
+	. "github.com/cloudfoundry-incubator/garden-linux/old/image_gc"
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collector", func() {
+	var (
+		trackerDir string
+		graph      *fake_graph.FakeGraph
+		tracker    Tracker
+		tags       *fakes.FakeTagStore
+		fakeClock  *fakeclock.FakeClock
+		policy     Policy
+		collector  *Collector
+	)
+
+	addImage := func(id, parent string, size int64) {
+		graph.SetExists(id, []byte(fmt.Sprintf(`{"id":%q,"parent":%q,"Size":%d}`, id, parent, size)))
+	}
+
+	BeforeEach(func() {
+		var err error
+		trackerDir, err = ioutil.TempDir("", "image-gc")
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1000, 0))
+
+		tracker, err = NewFileTracker(filepath.Join(trackerDir, "references.json"), fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+
+		graph = fake_graph.New()
+		tags = new(fakes.FakeTagStore)
+
+		// base <- app-1
+		// base <- app-2
+		// other
+		addImage("base", "", 100)
+		addImage("app-1", "base", 10)
+		addImage("app-2", "base", 20)
+		addImage("other", "", 50)
+
+		policy = Policy{}
+	})
+
+	JustBeforeEach(func() {
+		collector = New(graph, tracker, tags, policy, fakeClock, lagertest.NewTestLogger("test"))
+
+		// the first pass only notes when each image was first seen
+		Expect(collector.Collect()).To(Succeed())
+		Expect(graph.Deleted).To(BeEmpty())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(trackerDir)
+	})
+
+	Context("with a maximum age", func() {
+		BeforeEach(func() {
+			policy.MaxAge = time.Hour
+		})
+
+		It("deletes images unused for longer than the maximum age", func() {
+			fakeClock.Increment(2 * time.Hour)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("app-1", "app-2", "base", "other"))
+		})
+
+		It("deletes children before their parents", func() {
+			fakeClock.Increment(2 * time.Hour)
+
+			Expect(collector.Collect()).To(Succeed())
+
+			positions := map[string]int{}
+			for i, id := range graph.Deleted {
+				positions[id] = i
+			}
+
+			Expect(positions["base"]).To(BeNumerically(">", positions["app-1"]))
+			Expect(positions["base"]).To(BeNumerically(">", positions["app-2"]))
+		})
+
+		It("keeps images used more recently than the maximum age", func() {
+			fakeClock.Increment(90 * time.Minute)
+			Expect(tracker.Acquire("container-1", "app-1")).To(Succeed())
+			Expect(tracker.Release("container-1")).To(Succeed())
+
+			fakeClock.Increment(30 * time.Minute)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("app-2", "other"))
+		})
+
+		It("never deletes images referenced by containers, or their parents", func() {
+			Expect(tracker.Acquire("container-1", "app-1")).To(Succeed())
+
+			fakeClock.Increment(2 * time.Hour)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("app-2", "other"))
+			Expect(graph.Exists("base")).To(BeTrue())
+		})
+
+		It("never deletes tagged images, or their parents", func() {
+			tags.ImageIDsReturns([]string{"app-2"})
+
+			fakeClock.Increment(2 * time.Hour)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("app-1", "other"))
+			Expect(graph.Exists("base")).To(BeTrue())
+		})
+
+		It("leaves images alone within the grace period", func() {
+			policy.MaxAge = time.Minute
+			collector = New(graph, tracker, tags, policy, fakeClock, lagertest.NewTestLogger("test"))
+
+			Expect(collector.Collect()).To(Succeed())
+
+			fakeClock.Increment(GracePeriod / 2)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(BeEmpty())
+		})
+
+		Context("when deleting an image fails", func() {
+			BeforeEach(func() {
+				graph.WhenDeleting = func(id string) error {
+					if id == "app-1" {
+						return errors.New("oh no!")
+					}
+
+					return nil
+				}
+			})
+
+			It("carries on with the other images", func() {
+				fakeClock.Increment(2 * time.Hour)
+
+				Expect(collector.Collect()).To(Succeed())
+				Expect(graph.Deleted).To(ConsistOf("app-2", "other"))
+				Expect(graph.Exists("base")).To(BeTrue())
+			})
+		})
+	})
+
+	Context("with a maximum number of unused images", func() {
+		BeforeEach(func() {
+			policy.KeepUnused = 1
+		})
+
+		It("deletes the least recently used images beyond the limit", func() {
+			fakeClock.Increment(GracePeriod)
+			Expect(tracker.Acquire("container-1", "app-2")).To(Succeed())
+			fakeClock.Increment(GracePeriod)
+			Expect(tracker.Release("container-1")).To(Succeed())
+
+			fakeClock.Increment(GracePeriod)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("app-1", "other"))
+			Expect(graph.Exists("app-2")).To(BeTrue())
+			Expect(graph.Exists("base")).To(BeTrue())
+		})
+	})
+
+	Context("with a maximum size", func() {
+		BeforeEach(func() {
+			policy.MaxBytes = 125
+		})
+
+		It("deletes the least recently used images until the graph fits", func() {
+			Expect(tracker.Acquire("container-1", "other")).To(Succeed())
+			Expect(tracker.Release("container-1")).To(Succeed())
+
+			fakeClock.Increment(GracePeriod)
+			Expect(tracker.Acquire("container-2", "app-1")).To(Succeed())
+			Expect(tracker.Release("container-2")).To(Succeed())
+			Expect(tracker.Acquire("container-3", "app-2")).To(Succeed())
+			Expect(tracker.Release("container-3")).To(Succeed())
+
+			fakeClock.Increment(GracePeriod)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(ConsistOf("other", "app-1"))
+		})
+	})
+
+	Context("with no limits", func() {
+		It("deletes nothing", func() {
+			fakeClock.Increment(24 * time.Hour)
+
+			Expect(collector.Collect()).To(Succeed())
+			Expect(graph.Deleted).To(BeEmpty())
+		})
+	})
+
+	Describe("Run", func() {
+		BeforeEach(func() {
+			policy.MaxAge = time.Hour
+		})
+
+		It("collects on every interval until stopped", func() {
+			stop := make(chan struct{})
+			done := make(chan struct{})
+
+			go func() {
+				collector.Run(3*time.Hour, stop)
+				close(done)
+			}()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(3 * time.Hour)
+
+			Eventually(func() map[string]*image.Image {
+				remaining, _ := graph.Map()
+				return remaining
+			}).Should(BeEmpty())
+
+			close(stop)
+			Eventually(done).Should(BeClosed())
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package image_gc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestImageGC(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ImageGC Suite")
+}
@@ -0,0 +1,56 @@
+package image_gc
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// Lister describes the images in the graph, along with how the tracker has
+// seen them used.
+type Lister struct {
+	graph   Graph
+	tracker Tracker
+}
+
+func NewLister(graph Graph, tracker Tracker) *Lister {
+	return &Lister{
+		graph:   graph,
+		tracker: tracker,
+	}
+}
+
+// List returns every image in the graph, ordered by ID. An image is in use
+// if a container's rootfs is layered on it or on one of its children.
+func (lister *Lister) List() ([]garden.ImageInfo, error) {
+	images, err := lister.graph.Map()
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := inUse(images, lister.tracker.Referenced())
+
+	infos := []garden.ImageInfo{}
+	for id, img := range images {
+		lastUsed, _ := lister.tracker.LastUsed(id)
+
+		infos = append(infos, garden.ImageInfo{
+			ID:       id,
+			Parent:   img.Parent,
+			Size:     layerSize(img),
+			Created:  img.Created,
+			LastUsed: lastUsed,
+			InUse:    inUse[id],
+		})
+	}
+
+	sort.Sort(byID(infos))
+
+	return infos, nil
+}
+
+type byID []garden.ImageInfo
+
+func (infos byID) Len() int           { return len(infos) }
+func (infos byID) Swap(i, j int)      { infos[i], infos[j] = infos[j], infos[i] }
+func (infos byID) Less(i, j int) bool { return infos[i].ID < infos[j].ID }
@@ -0,0 +1,79 @@
+package image_gc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/container_pool/fake_graph"
+	. "github.com/cloudfoundry-incubator/garden-linux/old/image_gc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lister", func() {
+	var (
+		trackerDir string
+		graph      *fake_graph.FakeGraph
+		tracker    Tracker
+		fakeClock  *fakeclock.FakeClock
+		lister     *Lister
+	)
+
+	BeforeEach(func() {
+		var err error
+		trackerDir, err = ioutil.TempDir("", "image-gc")
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1000, 0))
+
+		tracker, err = NewFileTracker(filepath.Join(trackerDir, "references.json"), fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+
+		graph = fake_graph.New()
+		graph.SetExists("base", []byte(`{"id":"base","created":"2015-01-01T00:00:00Z","Size":100}`))
+		graph.SetExists("app", []byte(`{"id":"app","parent":"base","Size":10}`))
+		graph.SetExists("pulled", []byte(`{"id":"pulled","Size":-1}`))
+
+		lister = NewLister(graph, tracker)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(trackerDir)
+	})
+
+	It("lists the images in the graph with their sizes and when they were last used", func() {
+		Expect(tracker.Acquire("container-1", "app")).To(Succeed())
+
+		fakeClock.Increment(time.Minute)
+		Expect(tracker.Touch("pulled")).To(Succeed())
+
+		images, err := lister.List()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(images).To(Equal([]garden.ImageInfo{
+			{
+				ID:       "app",
+				Parent:   "base",
+				Size:     10,
+				LastUsed: time.Unix(1000, 0),
+				InUse:    true,
+			},
+			{
+				ID:      "base",
+				Size:    100,
+				Created: time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
+				InUse:   true,
+			},
+			{
+				ID:       "pulled",
+				Size:     0,
+				LastUsed: time.Unix(1000, 0).Add(time.Minute),
+			},
+		}))
+	})
+})
@@ -0,0 +1,67 @@
+package image_gc
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+)
+
+// LayerParents tells which image a container's rootfs layer was created on.
+type LayerParents interface {
+	// Parent returns the image the layer is on, or os.ErrNotExist if the
+	// graph driver has no such layer.
+	Parent(layerID string) (string, error)
+}
+
+// AUFSLayers reads the parents of layers from the aufs graph driver's root,
+// where the file layers/<id> lists a layer's parents, nearest first.
+type AUFSLayers struct {
+	Root string
+}
+
+func (layers AUFSLayers) Parent(layerID string) (string, error) {
+	file, err := os.Open(filepath.Join(layers.Root, "layers", layerID))
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if parent := scanner.Text(); parent != "" {
+			return parent, nil
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// Seed references the image under each container's rootfs layer on behalf of
+// the container, unless the tracker already holds a reference for it.
+// Containers created before images were tracked are otherwise unknown to the
+// tracker, so it must be seeded before the first collection. Containers
+// without a layer in the graph driver, such as those on an overlay rootfs,
+// are skipped.
+func Seed(tracker Tracker, layers LayerParents, containerIDs []string) error {
+	for _, containerID := range containerIDs {
+		imageID, err := layers.Parent(containerID)
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if imageID == "" {
+			continue
+		}
+
+		if err := tracker.Adopt(containerID, imageID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package image_gc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/image_gc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Seed", func() {
+	var (
+		tmpDir  string
+		layers  AUFSLayers
+		tracker Tracker
+	)
+
+	writeLayer := func(id, parents string) {
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "aufs", "layers", id), []byte(parents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "image-seed")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(tmpDir, "aufs", "layers"), 0755)).To(Succeed())
+		layers = AUFSLayers{Root: filepath.Join(tmpDir, "aufs")}
+
+		tracker, err = NewFileTracker(filepath.Join(tmpDir, "references.json"), fakeclock.NewFakeClock(time.Unix(1000, 0)))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	It("references the image under each container's layer", func() {
+		writeLayer("container-1", "image-1\nbase-image\n")
+		writeLayer("container-2", "image-2\n")
+
+		Expect(Seed(tracker, layers, []string{"container-1", "container-2"})).To(Succeed())
+
+		Expect(tracker.Referenced()).To(Equal(map[string]bool{
+			"image-1": true,
+			"image-2": true,
+		}))
+	})
+
+	It("keeps references the tracker already holds", func() {
+		writeLayer("container-1", "image-1\n")
+		Expect(tracker.Acquire("container-1", "image-2")).To(Succeed())
+
+		Expect(Seed(tracker, layers, []string{"container-1"})).To(Succeed())
+
+		Expect(tracker.Referenced()).To(Equal(map[string]bool{"image-2": true}))
+	})
+
+	It("skips containers without a layer, or whose layer has no parent", func() {
+		writeLayer("container-1", "")
+
+		Expect(Seed(tracker, layers, []string{"container-1", "overlay-container"})).To(Succeed())
+
+		Expect(tracker.Referenced()).To(BeEmpty())
+	})
+})
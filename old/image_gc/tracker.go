@@ -0,0 +1,193 @@
+package image_gc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// Tracker records which image each container's rootfs is layered on, and
+// when each image was last used, so that the collector can tell which
+// images are safe to delete. It is persisted so that references held by
+// containers survive restarts.
+type Tracker interface {
+	Acquire(containerID, imageID string) error
+	Adopt(containerID, imageID string) error
+	Release(containerID string) error
+	Touch(imageID string) error
+	Forget(imageID string) error
+
+	Referenced() map[string]bool
+	LastUsed(imageID string) (time.Time, bool)
+}
+
+type fileTracker struct {
+	path  string
+	clock clock.Clock
+
+	state trackerState
+	mutex *sync.RWMutex
+}
+
+type trackerState struct {
+	Containers map[string]string    `json:"containers"`
+	LastUsed   map[string]time.Time `json:"last_used"`
+}
+
+// NewFileTracker returns a Tracker persisted as JSON at path, loading any
+// references previously written there.
+func NewFileTracker(path string, clock clock.Clock) (Tracker, error) {
+	tracker := &fileTracker{
+		path:  path,
+		clock: clock,
+		state: trackerState{
+			Containers: map[string]string{},
+			LastUsed:   map[string]time.Time{},
+		},
+		mutex: new(sync.RWMutex),
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return tracker, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("image tracker: read %s: %s", path, err)
+	}
+
+	if err := json.Unmarshal(contents, &tracker.state); err != nil {
+		return nil, fmt.Errorf("image tracker: parse %s: %s", path, err)
+	}
+
+	if tracker.state.Containers == nil {
+		tracker.state.Containers = map[string]string{}
+	}
+
+	if tracker.state.LastUsed == nil {
+		tracker.state.LastUsed = map[string]time.Time{}
+	}
+
+	return tracker, nil
+}
+
+func (tracker *fileTracker) Acquire(containerID, imageID string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracker.state.Containers[containerID] = imageID
+	tracker.state.LastUsed[imageID] = tracker.clock.Now()
+
+	return tracker.save()
+}
+
+// Adopt references the image on behalf of a container only if the container
+// holds no reference yet, leaving the image's last use as it was.
+func (tracker *fileTracker) Adopt(containerID, imageID string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if _, found := tracker.state.Containers[containerID]; found {
+		return nil
+	}
+
+	tracker.state.Containers[containerID] = imageID
+
+	return tracker.save()
+}
+
+func (tracker *fileTracker) Release(containerID string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	imageID, found := tracker.state.Containers[containerID]
+	if !found {
+		return nil
+	}
+
+	delete(tracker.state.Containers, containerID)
+	tracker.state.LastUsed[imageID] = tracker.clock.Now()
+
+	return tracker.save()
+}
+
+// Touch records that the image was used other than by a container, such as
+// by being pulled ahead of time.
+func (tracker *fileTracker) Touch(imageID string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	tracker.state.LastUsed[imageID] = tracker.clock.Now()
+
+	return tracker.save()
+}
+
+func (tracker *fileTracker) Forget(imageID string) error {
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	if _, found := tracker.state.LastUsed[imageID]; !found {
+		return nil
+	}
+
+	delete(tracker.state.LastUsed, imageID)
+
+	return tracker.save()
+}
+
+func (tracker *fileTracker) Referenced() map[string]bool {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	referenced := map[string]bool{}
+	for _, imageID := range tracker.state.Containers {
+		referenced[imageID] = true
+	}
+
+	return referenced
+}
+
+func (tracker *fileTracker) LastUsed(imageID string) (time.Time, bool) {
+	tracker.mutex.RLock()
+	defer tracker.mutex.RUnlock()
+
+	lastUsed, found := tracker.state.LastUsed[imageID]
+	return lastUsed, found
+}
+
+// save writes the state to a temporary file and renames it into place, so
+// that a crash never leaves a truncated file behind.
+func (tracker *fileTracker) save() error {
+	contents, err := json.Marshal(tracker.state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(tracker.path), filepath.Base(tracker.path))
+	if err != nil {
+		return fmt.Errorf("image tracker: save: %s", err)
+	}
+
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("image tracker: save: %s", err)
+	}
+
+	if err := os.Rename(tmp.Name(), tracker.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("image tracker: save: %s", err)
+	}
+
+	return nil
+}
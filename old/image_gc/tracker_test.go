@@ -0,0 +1,143 @@
+package image_gc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/image_gc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileTracker", func() {
+	var (
+		trackerDir  string
+		trackerPath string
+		fakeClock   *fakeclock.FakeClock
+		tracker     Tracker
+	)
+
+	BeforeEach(func() {
+		var err error
+		trackerDir, err = ioutil.TempDir("", "image-tracker")
+		Expect(err).ToNot(HaveOccurred())
+
+		trackerPath = filepath.Join(trackerDir, "references.json")
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1000, 0))
+
+		tracker, err = NewFileTracker(trackerPath, fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(trackerDir)
+	})
+
+	It("references images acquired by containers", func() {
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+		Expect(tracker.Acquire("container-2", "image-1")).To(Succeed())
+		Expect(tracker.Acquire("container-3", "image-2")).To(Succeed())
+
+		Expect(tracker.Referenced()).To(Equal(map[string]bool{
+			"image-1": true,
+			"image-2": true,
+		}))
+	})
+
+	It("stops referencing an image once every container has released it", func() {
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+		Expect(tracker.Acquire("container-2", "image-1")).To(Succeed())
+
+		Expect(tracker.Release("container-1")).To(Succeed())
+		Expect(tracker.Referenced()).To(HaveKey("image-1"))
+
+		Expect(tracker.Release("container-2")).To(Succeed())
+		Expect(tracker.Referenced()).To(BeEmpty())
+	})
+
+	It("ignores releases for unknown containers", func() {
+		Expect(tracker.Release("some-container")).To(Succeed())
+	})
+
+	It("records when an image was last used", func() {
+		_, found := tracker.LastUsed("image-1")
+		Expect(found).To(BeFalse())
+
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+
+		lastUsed, found := tracker.LastUsed("image-1")
+		Expect(found).To(BeTrue())
+		Expect(lastUsed).To(Equal(time.Unix(1000, 0)))
+
+		fakeClock.Increment(time.Hour)
+		Expect(tracker.Release("container-1")).To(Succeed())
+
+		lastUsed, found = tracker.LastUsed("image-1")
+		Expect(found).To(BeTrue())
+		Expect(lastUsed).To(Equal(time.Unix(1000, 0).Add(time.Hour)))
+	})
+
+	It("records when an image was touched", func() {
+		Expect(tracker.Touch("image-1")).To(Succeed())
+
+		lastUsed, found := tracker.LastUsed("image-1")
+		Expect(found).To(BeTrue())
+		Expect(lastUsed).To(Equal(time.Unix(1000, 0)))
+		Expect(tracker.Referenced()).To(BeEmpty())
+	})
+
+	It("forgets deleted images", func() {
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+		Expect(tracker.Release("container-1")).To(Succeed())
+
+		Expect(tracker.Forget("image-1")).To(Succeed())
+
+		_, found := tracker.LastUsed("image-1")
+		Expect(found).To(BeFalse())
+	})
+
+	It("adopts images only for containers that hold no reference", func() {
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+
+		Expect(tracker.Adopt("container-1", "image-2")).To(Succeed())
+		Expect(tracker.Adopt("container-2", "image-3")).To(Succeed())
+
+		Expect(tracker.Referenced()).To(Equal(map[string]bool{
+			"image-1": true,
+			"image-3": true,
+		}))
+
+		_, found := tracker.LastUsed("image-3")
+		Expect(found).To(BeFalse())
+	})
+
+	It("persists references across instances", func() {
+		Expect(tracker.Acquire("container-1", "image-1")).To(Succeed())
+		Expect(tracker.Acquire("container-2", "image-2")).To(Succeed())
+		Expect(tracker.Release("container-2")).To(Succeed())
+
+		reloaded, err := NewFileTracker(trackerPath, fakeClock)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(reloaded.Referenced()).To(Equal(map[string]bool{"image-1": true}))
+
+		lastUsed, found := reloaded.LastUsed("image-2")
+		Expect(found).To(BeTrue())
+		Expect(lastUsed.Equal(time.Unix(1000, 0))).To(BeTrue())
+	})
+
+	Context("when the file is corrupt", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(trackerPath, []byte("{"), 0644)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			_, err := NewFileTracker(trackerPath, fakeClock)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
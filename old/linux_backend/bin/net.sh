@@ -14,65 +14,69 @@ nat_postrouting_chain="${GARDEN_IPTABLES_NAT_POSTROUTING_CHAIN}"
 nat_instance_prefix="${GARDEN_IPTABLES_NAT_INSTANCE_PREFIX}"
 interface_name_prefix="${GARDEN_NETWORK_INTERFACE_PREFIX}"
 
+# The functions below manage whichever of iptables and ip6tables this names
+iptables="iptables"
+reject_with="icmp-host-prohibited"
+
 function teardown_deprecated_rules() {
   # Remove jump to garden-dispatch from INPUT
-  iptables -w -S INPUT 2> /dev/null |
+  ${iptables} -w -S INPUT 2> /dev/null |
     grep " -j garden-dispatch" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Remove jump to garden-dispatch from FORWARD
-  iptables -w -S FORWARD 2> /dev/null |
+  ${iptables} -w -S FORWARD 2> /dev/null |
     grep " -j garden-dispatch" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Prune garden-dispatch
-  iptables -w -F garden-dispatch 2> /dev/null || true
+  ${iptables} -w -F garden-dispatch 2> /dev/null || true
 
   # Delete garden-dispatch
-  iptables -w -X garden-dispatch 2> /dev/null || true
+  ${iptables} -w -X garden-dispatch 2> /dev/null || true
 }
 
 function teardown_filter() {
   teardown_deprecated_rules
 
   # Prune garden-forward chain
-  iptables -w -S ${filter_forward_chain} 2> /dev/null |
+  ${iptables} -w -S ${filter_forward_chain} 2> /dev/null |
     grep "\-g ${filter_instance_prefix}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Prune per-instance chains
-  iptables -w -S 2> /dev/null |
+  ${iptables} -w -S 2> /dev/null |
     grep "^-A ${filter_instance_prefix}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Delete per-instance chains
-  iptables -w -S 2> /dev/null |
+  ${iptables} -w -S 2> /dev/null |
     grep "^-N ${filter_instance_prefix}" |
     sed -e "s/-N/-X/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Remove jump to garden-forward from FORWARD
-  iptables -w -S FORWARD 2> /dev/null |
+  ${iptables} -w -S FORWARD 2> /dev/null |
     grep " -j ${filter_forward_chain}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
-  iptables -w -F ${filter_forward_chain} 2> /dev/null || true
-  iptables -w -F ${filter_default_chain} 2> /dev/null || true
+  ${iptables} -w -F ${filter_forward_chain} 2> /dev/null || true
+  ${iptables} -w -F ${filter_default_chain} 2> /dev/null || true
 
   # Remove jump to filter input chain from INPUT
-  iptables -w -S INPUT 2> /dev/null |
+  ${iptables} -w -S INPUT 2> /dev/null |
     grep " -j ${filter_input_chain}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
   # Empty and delete filter input chain
-  iptables -w -F ${filter_input_chain} 2> /dev/null || true
-  iptables -w -X ${filter_input_chain} 2> /dev/null || true
+  ${iptables} -w -F ${filter_input_chain} 2> /dev/null || true
+  ${iptables} -w -X ${filter_input_chain} 2> /dev/null || true
 }
 
 function setup_filter() {
@@ -82,90 +86,90 @@ function setup_filter() {
   default_interface=$(ip route show | grep default | cut -d' ' -f5 | head -1)
 
   # Create, or empty existing, filter input chain
-  iptables -w -N ${filter_input_chain} 2> /dev/null || iptables -w -F ${filter_input_chain}
+  ${iptables} -w -N ${filter_input_chain} 2> /dev/null || ${iptables} -w -F ${filter_input_chain}
 
   # Accept inbound packets if default interface is matched by filter prefix
-  iptables -w -I ${filter_input_chain} -i $default_interface --jump ACCEPT
+  ${iptables} -w -I ${filter_input_chain} -i $default_interface --jump ACCEPT
 
   # Put connection tracking rule in filter input chain
   # to accept packets related to previously established connections
-  iptables -w -A ${filter_input_chain} -m conntrack --ctstate ESTABLISHED,RELATED --jump ACCEPT
+  ${iptables} -w -A ${filter_input_chain} -m conntrack --ctstate ESTABLISHED,RELATED --jump ACCEPT
 
   if [ "${GARDEN_IPTABLES_ALLOW_HOST_ACCESS}" != "true" ]; then
-    iptables -w -A ${filter_input_chain} --jump REJECT --reject-with icmp-host-prohibited
+    ${iptables} -w -A ${filter_input_chain} --jump REJECT --reject-with ${reject_with}
   else
-    iptables -w -A ${filter_input_chain} --jump ACCEPT
+    ${iptables} -w -A ${filter_input_chain} --jump ACCEPT
   fi
 
   # Forward input traffic via ${filter_input_chain}
-  iptables -w -A INPUT -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_input_chain}
+  ${iptables} -w -A INPUT -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_input_chain}
 
   # Create or flush forward chain
-  iptables -w -N ${filter_forward_chain} 2> /dev/null || iptables -w -F ${filter_forward_chain}
-  iptables -w -A ${filter_forward_chain} -j DROP
+  ${iptables} -w -N ${filter_forward_chain} 2> /dev/null || ${iptables} -w -F ${filter_forward_chain}
+  ${iptables} -w -A ${filter_forward_chain} -j DROP
 
   # Create or flush default chain
-  iptables -w -N ${filter_default_chain} 2> /dev/null || iptables -w -F ${filter_default_chain}
+  ${iptables} -w -N ${filter_default_chain} 2> /dev/null || ${iptables} -w -F ${filter_default_chain}
 
   # Always allow established connections to containers
-  iptables -w -A ${filter_default_chain} -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
+  ${iptables} -w -A ${filter_default_chain} -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
 
   # Forward outbound traffic via ${filter_forward_chain}
-  iptables -w -A FORWARD -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_forward_chain}
+  ${iptables} -w -A FORWARD -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_forward_chain}
 
   # Forward inbound traffic immediately
-  iptables -w -I ${filter_forward_chain} -i $default_interface --jump ACCEPT
+  ${iptables} -w -I ${filter_forward_chain} -i $default_interface --jump ACCEPT
 }
 
 function teardown_nat() {
   # Prune prerouting chain
-  iptables -w -t nat -S ${nat_prerouting_chain} 2> /dev/null |
+  ${iptables} -w -t nat -S ${nat_prerouting_chain} 2> /dev/null |
     grep "\-j ${nat_instance_prefix}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w -t nat
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat
 
   # Prune per-instance chains
-  iptables -w -t nat -S 2> /dev/null |
+  ${iptables} -w -t nat -S 2> /dev/null |
     grep "^-A ${nat_instance_prefix}" |
     sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w -t nat
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat
 
   # Delete per-instance chains
-  iptables -w -t nat -S 2> /dev/null |
+  ${iptables} -w -t nat -S 2> /dev/null |
     grep "^-N ${nat_instance_prefix}" |
     sed -e "s/-N/-X/" -e "s/\s\+\$//" |
-    xargs --no-run-if-empty --max-lines=1 iptables -w -t nat
+    xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat
 
   # Flush prerouting chain
-  iptables -w -t nat -F ${nat_prerouting_chain} 2> /dev/null || true
+  ${iptables} -w -t nat -F ${nat_prerouting_chain} 2> /dev/null || true
 
   # Flush postrouting chain
-  iptables -w -t nat -F ${nat_postrouting_chain} 2> /dev/null || true
+  ${iptables} -w -t nat -F ${nat_postrouting_chain} 2> /dev/null || true
 }
 
 function setup_nat() {
   teardown_nat
 
   # Create prerouting chain
-  iptables -w -t nat -N ${nat_prerouting_chain} 2> /dev/null || true
+  ${iptables} -w -t nat -N ${nat_prerouting_chain} 2> /dev/null || true
 
   # Bind chain to PREROUTING
-  (iptables -w -t nat -S PREROUTING | grep -q "\-j ${nat_prerouting_chain}\b") ||
-    iptables -w -t nat -A PREROUTING \
+  (${iptables} -w -t nat -S PREROUTING | grep -q "\-j ${nat_prerouting_chain}\b") ||
+    ${iptables} -w -t nat -A PREROUTING \
       --jump ${nat_prerouting_chain}
 
   # Bind chain to OUTPUT (for traffic originating from same host)
-  (iptables -w -t nat -S OUTPUT | grep -q "\-j ${nat_prerouting_chain}\b") ||
-    iptables -w -t nat -A OUTPUT \
+  (${iptables} -w -t nat -S OUTPUT | grep -q "\-j ${nat_prerouting_chain}\b") ||
+    ${iptables} -w -t nat -A OUTPUT \
       --out-interface "lo" \
       --jump ${nat_prerouting_chain}
 
   # Create postrouting chain
-  iptables -w -t nat -N ${nat_postrouting_chain} 2> /dev/null || true
+  ${iptables} -w -t nat -N ${nat_postrouting_chain} 2> /dev/null || true
 
   # Bind chain to POSTROUTING
-  (iptables -w -t nat -S POSTROUTING | grep -q "\-j ${nat_postrouting_chain}\b") ||
-    iptables -w -t nat -A POSTROUTING \
+  (${iptables} -w -t nat -S POSTROUTING | grep -q "\-j ${nat_postrouting_chain}\b") ||
+    ${iptables} -w -t nat -A POSTROUTING \
       --jump ${nat_postrouting_chain}
 }
 
@@ -176,10 +180,27 @@ case "${1}" in
 
     # Enable forwarding
     echo 1 > /proc/sys/net/ipv4/ip_forward
+
+    if [ "${GARDEN_IPV6_ENABLED:-false}" = "true" ]; then
+      iptables="ip6tables"
+      reject_with="icmp6-adm-prohibited"
+
+      setup_filter
+      setup_nat
+
+      echo 1 > /proc/sys/net/ipv6/conf/all/forwarding
+    fi
     ;;
   teardown)
     teardown_filter
     teardown_nat
+
+    if [ "${GARDEN_IPV6_ENABLED:-false}" = "true" ]; then
+      iptables="ip6tables"
+
+      teardown_filter
+      teardown_nat
+    fi
     ;;
   *)
     echo "Unknown command: ${1}" 1>&2
@@ -103,6 +103,11 @@ function teardown_fs() {
 
 if [ "$action" = "create" ]; then
   setup_fs
+elif [ "$action" = "remount" ]; then
+  # the overlay is not mounted after a reboot, but its directories remain
+  if ! mountpoint -q $rootfs_path; then
+    setup_fs
+  fi
 else
   teardown_fs
 fi
@@ -153,13 +153,30 @@ if [ -x /etc/init.d/apparmor ]; then
   /etc/init.d/apparmor teardown
 fi
 
-# quotaon(8) exits with non-zero status when quotas are ENABLED
-if [ "$DISK_QUOTA_ENABLED" = "true" ] && quotaon -p $CONTAINER_DEPOT_MOUNT_POINT_PATH > /dev/null 2>&1
-then
-  mount -o remount,usrjquota=aquota.user,grpjquota=aquota.group,jqfmt=vfsv0 $CONTAINER_DEPOT_MOUNT_POINT_PATH
-  quotacheck -ugmb -F vfsv0 $CONTAINER_DEPOT_MOUNT_POINT_PATH
-  quotaon $CONTAINER_DEPOT_MOUNT_POINT_PATH
-elif [ "$DISK_QUOTA_ENABLED" = "false" ] && ! quotaon -p $CONTAINER_DEPOT_MOUNT_POINT_PATH > /dev/null 2>&1
-then
-  quotaoff $CONTAINER_DEPOT_MOUNT_POINT_PATH
-fi
+case "${DISK_QUOTA_TYPE:-user}" in
+  user)
+    # quotaon(8) exits with non-zero status when quotas are ENABLED
+    if [ "$DISK_QUOTA_ENABLED" = "true" ] && quotaon -p $CONTAINER_DEPOT_MOUNT_POINT_PATH > /dev/null 2>&1
+    then
+      mount -o remount,usrjquota=aquota.user,grpjquota=aquota.group,jqfmt=vfsv0 $CONTAINER_DEPOT_MOUNT_POINT_PATH
+      quotacheck -ugmb -F vfsv0 $CONTAINER_DEPOT_MOUNT_POINT_PATH
+      quotaon $CONTAINER_DEPOT_MOUNT_POINT_PATH
+    elif [ "$DISK_QUOTA_ENABLED" = "false" ] && ! quotaon -p $CONTAINER_DEPOT_MOUNT_POINT_PATH > /dev/null 2>&1
+    then
+      quotaoff $CONTAINER_DEPOT_MOUNT_POINT_PATH
+    fi
+    ;;
+
+  project)
+    # project quotas must be enabled at mount time (XFS with pquota, or ext4
+    # with the project feature); ext4 only needs them switched on
+    if [ "$DISK_QUOTA_ENABLED" = "true" ] && quotaon -P -p $CONTAINER_DEPOT_MOUNT_POINT_PATH > /dev/null 2>&1
+    then
+      quotaon -P $CONTAINER_DEPOT_MOUNT_POINT_PATH
+    fi
+    ;;
+
+  loop)
+    # every container gets a filesystem of its own; nothing to set up
+    ;;
+esac
@@ -0,0 +1,39 @@
+#!/bin/bash
+
+[ -n "$DEBUG" ] && set -o xtrace
+set -o nounset
+set -o errexit
+shopt -s nullglob
+
+cd $(dirname $0)
+
+if [ ! -f ./run/wshd.pid ]
+then
+  echo "wshd is not running..."
+  exit 1
+fi
+
+source ./etc/config
+
+pid=$(cat ./run/wshd.pid)
+
+rm -rf ./checkpoint.tmp
+mkdir -p ./checkpoint.tmp
+
+# dump the whole process tree, which kills it once the dump is complete
+criu dump \
+  --tree $pid \
+  --freeze-cgroup ${GARDEN_CGROUP_PATH}/freezer/instance-$id \
+  --images-dir ./checkpoint.tmp \
+  --work-dir ./tmp \
+  --log-file dump.log \
+  --manage-cgroups \
+  --ext-unix-sk \
+  --tcp-established \
+  --file-locks
+
+# only replace a previous checkpoint with a complete one
+rm -rf ./checkpoint
+mv ./checkpoint.tmp ./checkpoint
+
+rm -f ./run/wshd.pid
@@ -25,10 +25,21 @@ fi
 
 # Add new group for every subsystem
 
+# Only join the subsystems this kernel has (pids needs 4.3): with a flat
+# hierarchy, the directory of a missing one would be an ordinary cgroup, and
+# joining it would move wshd out of instance-$id and lift its limits
+kernel_subsystems=$(tail -n +2 /proc/cgroups | awk '{print $1}')
+
 # cpuset must be set up first, so that cpuset.cpus and cpuset.mems is assigned
 # otherwise adding the process to the subsystem's tasks will fail with ENOSPC
-for system_path in ${GARDEN_CGROUP_PATH}/{cpuset,cpu,cpuacct,devices,memory}
+for subsystem in cpuset cpu cpuacct blkio devices memory freezer pids
 do
+  if ! echo "$kernel_subsystems" | grep -qx $subsystem
+  then
+    continue
+  fi
+
+  system_path=${GARDEN_CGROUP_PATH}/$subsystem
   instance_path=$system_path/instance-$id
 
   mkdir -p $instance_path
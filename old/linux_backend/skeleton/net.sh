@@ -20,6 +20,9 @@ interface_name_prefix="${GARDEN_NETWORK_INTERFACE_PREFIX}"
 filter_instance_chain="${filter_instance_prefix}${id}"
 nat_instance_chain="${filter_instance_prefix}${id}"
 
+network_container_ipv6="${network_container_ipv6:-}"
+network_cidr_ipv6="${network_cidr_ipv6:-}"
+
 function teardown_filter() {
   # Prune forward chain
   iptables --wait -S ${filter_forward_chain} 2> /dev/null |
@@ -51,6 +54,48 @@ function setup_filter() {
     --goto ${filter_instance_chain}
 }
 
+function teardown_ipv6() {
+  ip6tables --wait -S ${filter_forward_chain} 2> /dev/null |
+    grep "\-g ${filter_instance_chain}\b" |
+    sed -e "s/-A/-D/" |
+    xargs --no-run-if-empty --max-lines=1 ip6tables --wait
+
+  ip6tables --wait -F ${filter_instance_chain} 2> /dev/null || true
+  ip6tables --wait -X ${filter_instance_chain} 2> /dev/null || true
+
+  ip6tables --wait --table nat -S ${nat_prerouting_chain} 2> /dev/null |
+    grep "\-j ${nat_instance_chain}\b" |
+    sed -e "s/-A/-D/" |
+    xargs --no-run-if-empty --max-lines=1 ip6tables --wait --table nat
+
+  ip6tables --wait --table nat -F ${nat_instance_chain} 2> /dev/null || true
+  ip6tables --wait --table nat -X ${nat_instance_chain} 2> /dev/null || true
+}
+
+# Mirrors setup_filter and setup_nat for the container's IPv6 network
+function setup_ipv6() {
+  teardown_ipv6
+
+  ip6tables --wait -N ${filter_instance_chain}
+  ip6tables --wait -A ${filter_instance_chain} -s ${network_cidr_ipv6} -d ${network_cidr_ipv6} -j ACCEPT
+  ip6tables --wait -A ${filter_instance_chain} \
+    --goto ${filter_default_chain}
+
+  ip6tables --wait -I ${filter_forward_chain} 2 \
+    --in-interface ${bridge_iface} \
+    --source ${network_container_ipv6} \
+    --goto ${filter_instance_chain}
+
+  ip6tables --wait --table nat -N ${nat_instance_chain}
+  ip6tables --wait --table nat -A ${nat_prerouting_chain} \
+    --jump ${nat_instance_chain}
+
+  (ip6tables --wait --table nat -S ${nat_postrouting_chain} | grep "\-j MASQUERADE\b" | grep -q -F -- "-s ${network_cidr_ipv6}") ||
+    ip6tables --wait --table nat -A ${nat_postrouting_chain} \
+      --source ${network_cidr_ipv6} \
+      --jump MASQUERADE
+}
+
 function teardown_nat() {
   # Prune prerouting chain
   iptables --wait --table nat -S ${nat_prerouting_chain} 2> /dev/null |
@@ -86,50 +131,22 @@ case "${1}" in
     setup_filter
     setup_nat
 
+    if [ -n "${network_container_ipv6}" ]; then
+      setup_ipv6
+    fi
+
     ;;
 
   "teardown")
     teardown_filter
     teardown_nat
 
-    ;;
-
-  "in")
-    if [ -z "${HOST_PORT:-}" ]; then
-      echo "Please specify HOST_PORT..." 1>&2
-      exit 1
-    fi
-
-    if [ -z "${CONTAINER_PORT:-}" ]; then
-      echo "Please specify CONTAINER_PORT..." 1>&2
-      exit 1
-    fi
-
-    iptables --wait --table nat -A ${nat_instance_chain} \
-      --protocol tcp \
-      --destination "${external_ip}" \
-      --destination-port "${HOST_PORT}" \
-      --jump DNAT \
-      --to-destination "${network_container_ip}:${CONTAINER_PORT}"
-
-    ;;
-
-  "get_ingress_info")
-    if [ -z "${ID:-}" ]; then
-      echo "Please specify container ID..." 1>&2
-      exit 1
+    if [ -n "${network_container_ipv6}" ]; then
+      teardown_ipv6
     fi
-    tc filter show dev ${network_host_iface} parent ffff:
 
     ;;
-  "get_egress_info")
-    if [ -z "${ID:-}" ]; then
-      echo "Please specify container ID..." 1>&2
-      exit 1
-    fi
-    tc qdisc show dev ${network_host_iface}
 
-    ;;
   *)
     echo "Unknown command: ${1}" 1>&2
     exit 1
@@ -0,0 +1,64 @@
+#!/bin/bash
+
+[ -n "$DEBUG" ] && set -o xtrace
+set -o nounset
+set -o errexit
+shopt -s nullglob
+
+cd $(dirname $0)
+
+if [ -f ./run/wshd.pid ]
+then
+  echo "wshd is already running..."
+  exit 1
+fi
+
+if [ ! -d ./checkpoint ]
+then
+  echo "no checkpoint to restore..."
+  exit 1
+fi
+
+source ./etc/config
+
+if [ -z "$(ls -A $rootfs_path 2> /dev/null)" ]
+then
+  echo "rootfs is not mounted at $rootfs_path..."
+  exit 1
+fi
+
+# the bridge and the host side of the veth pair do not survive a reboot
+if ! ip link show ${bridge_iface} > /dev/null 2>&1
+then
+  ip link add name ${bridge_iface} type bridge
+  ip addr add ${network_host_ip}/${network_cidr_suffix} dev ${bridge_iface}
+  ip link set ${bridge_iface} up
+fi
+
+ip link del ${network_host_iface} 2> /dev/null || true
+
+criu restore \
+  --images-dir ./checkpoint \
+  --work-dir ./tmp \
+  --log-file restore.log \
+  --root $rootfs_path \
+  --pidfile $(pwd)/run/wshd.pid \
+  --manage-cgroups \
+  --ext-unix-sk \
+  --tcp-established \
+  --file-locks \
+  --veth-pair ${network_container_iface}=${network_host_iface} \
+  --restore-detached
+
+ip link set ${network_host_iface} mtu ${container_iface_mtu}
+ip link set ${network_host_iface} master ${bridge_iface}
+ip link set ${network_host_iface} up
+
+pid=$(cat ./run/wshd.pid)
+
+#set noclobber off to permit overwrite exist file
+set +o noclobber > /dev/null 2>&1
+
+sed -i -e "s/^container_pid=.*/container_pid=$pid/" etc/config
+
+rm -rf ./checkpoint
@@ -20,6 +20,9 @@ network_container_ip=${network_container_ip:-10.0.0.2}
 network_container_iface="${iface_name_prefix}${iface_name}-1"
 bridge_iface="${bridge_iface}"
 network_cidr_suffix=${network_cidr_suffix:-30}
+network_cidr_ipv6=${network_cidr_ipv6:-}
+network_host_ipv6=${network_host_ipv6:-}
+network_container_ipv6=${network_container_ipv6:-}
 user_uid=${user_uid:-10000}
 root_uid=${root_uid:-10000}
 rootfs_path=$(readlink -f $rootfs_path)
@@ -57,6 +60,9 @@ bridge_iface=$bridge_iface
 network_cidr_suffix=$network_cidr_suffix
 container_iface_mtu=$container_iface_mtu
 network_cidr=$network_cidr
+network_cidr_ipv6=$network_cidr_ipv6
+network_host_ipv6=$network_host_ipv6
+network_container_ipv6=$network_container_ipv6
 root_uid=$root_uid
 user_uid=$user_uid
 rootfs_path=$rootfs_path
@@ -4,13 +4,16 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
 	"runtime"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/cloudfoundry/gunk/localip"
@@ -32,6 +35,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/network/devices"
 	"github.com/cloudfoundry-incubator/garden-linux/network/iptables"
 	"github.com/cloudfoundry-incubator/garden-linux/network/subnets"
+	"github.com/cloudfoundry-incubator/garden-linux/old/image_gc"
 	"github.com/cloudfoundry-incubator/garden-linux/old/port_pool"
 	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
 	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
@@ -97,6 +101,36 @@ var disableQuotas = flag.Bool(
 	"disable disk quotas",
 )
 
+var diskQuotaType = flag.String(
+	"diskQuotaType",
+	quota_manager.UserQuota,
+	"how to account for containers' disk usage (user, or project or loop with the aufs graph driver)",
+)
+
+var projectIDPoolStart = flag.Uint(
+	"projectIDPoolStart",
+	10000,
+	"start of per-container project ids, for project disk quotas",
+)
+
+var projectIDPoolSize = flag.Uint(
+	"projectIDPoolSize",
+	256,
+	"size of the project id pool",
+)
+
+var loopImagesPath = flag.String(
+	"loopImagesPath",
+	"",
+	"directory in which to store containers' filesystem images, for loop disk quotas",
+)
+
+var loopImageSize = flag.Uint64(
+	"loopImageSize",
+	10*1024*1024*1024,
+	"initial size in bytes of containers' filesystems, for loop disk quotas",
+)
+
 var containerGraceTime = flag.Duration(
 	"containerGraceTime",
 	0,
@@ -131,10 +165,16 @@ var networkPool = flag.String("networkPool",
 	DefaultNetworkPool,
 	"Pool of dynamically allocated container subnets")
 
+var ipv6NetworkPool = flag.String(
+	"ipv6NetworkPool",
+	"",
+	"Pool of dynamically allocated container IPv6 subnets; containers only get IPv4 networks if empty",
+)
+
 var denyNetworks = flag.String(
 	"denyNetworks",
 	"",
-	"CIDR blocks representing IPs to blacklist",
+	"CIDR blocks representing IPs to blacklist; with IPv6 enabled, containers' IPv6 traffic is rejected unless whitelisted",
 )
 
 var allowNetworks = flag.String(
@@ -149,6 +189,30 @@ var graphRoot = flag.String(
 	"docker image graph",
 )
 
+var graphGCInterval = flag.Duration(
+	"graphGCInterval",
+	5*time.Minute,
+	"how often to delete unused images from the docker graph",
+)
+
+var graphMaxBytes = flag.Int64(
+	"graphMaxBytes",
+	0,
+	"delete unused images once the docker graph grows beyond this size (0 for no limit)",
+)
+
+var graphMaxAge = flag.Duration(
+	"graphMaxAge",
+	0,
+	"delete images no container has used for this long (0 for no limit)",
+)
+
+var graphKeepUnused = flag.Int(
+	"graphKeepUnused",
+	0,
+	"maximum number of unused images to keep in the docker graph (0 for no limit)",
+)
+
 var dockerRegistry = flag.String(
 	"registry",
 	registry.IndexServerAddress(),
@@ -161,6 +225,18 @@ var insecureRegistries = flag.String(
 	"comma-separated list of docker registries to allow connection to even if they are not secure",
 )
 
+var maxConcurrentDownloads = flag.Int(
+	"maxConcurrentDownloads",
+	4,
+	"maximum number of image layers to download at once",
+)
+
+var dockerRegistryCredentials = flag.String(
+	"dockerRegistryCredentials",
+	"",
+	"file of docker registry credentials keyed by registry hostname, in the format of ~/.dockercfg",
+)
+
 var tag = flag.String(
 	"tag",
 	"",
@@ -253,6 +329,19 @@ func Main() {
 	_, dynamicRange, _ := net.ParseCIDR(*networkPool)
 	subnetPool, _ := subnets.NewSubnets(dynamicRange)
 
+	var ipv6SubnetPool container_pool.SubnetPool
+	if *ipv6NetworkPool != "" {
+		_, ipv6DynamicRange, err := net.ParseCIDR(*ipv6NetworkPool)
+		if err != nil || ipv6DynamicRange.IP.To4() != nil {
+			println("-ipv6NetworkPool must be an IPv6 CIDR block")
+			println()
+			flag.Usage()
+			return
+		}
+
+		ipv6SubnetPool, _ = subnets.NewSubnets(ipv6DynamicRange)
+	}
+
 	// TODO: use /proc/sys/net/ipv4/ip_local_port_range by default (end + 1)
 	portPool := port_pool.New(uint32(*portPoolStart), uint32(*portPoolSize))
 
@@ -273,12 +362,6 @@ func Main() {
 
 	runner := sysconfig.NewRunner(config, linux_command_runner.New())
 
-	quotaManager := quota_manager.New(runner, getMountPoint(logger, *depotPath), *binPath)
-
-	if *disableQuotas {
-		quotaManager.Disable()
-	}
-
 	if err := os.MkdirAll(*graphRoot, 0755); err != nil {
 		logger.Fatal("failed-to-create-graph-directory", err)
 	}
@@ -288,29 +371,155 @@ func Main() {
 		logger.Fatal("failed-to-construct-graph-driver", err)
 	}
 
+	// the directories containers write to, besides their depot directory
+	writableDirs := []string{*overlaysPath}
+	if graphDriver.String() == "aufs" {
+		writableDirs = append(writableDirs, path.Join(*graphRoot, "aufs", "diff"))
+	} else if *diskQuotaType == quota_manager.ProjectQuota || *diskQuotaType == quota_manager.LoopQuota {
+		// the other drivers refuse to create a layer whose directory (or
+		// subvolume, or device) already exists, so its writes cannot be
+		// accounted before the rootfs is provided
+		println("-diskQuotaType=" + *diskQuotaType + " requires the aufs graph driver, not " + graphDriver.String())
+		println()
+		flag.Usage()
+		return
+	}
+
+	var quotaManager quota_manager.QuotaManager
+	switch *diskQuotaType {
+	case quota_manager.UserQuota:
+		quotaManager = quota_manager.New(quota_manager.SyscallQuotactl{}, getMountPoint(logger, *depotPath))
+	case quota_manager.ProjectQuota:
+		projectIDPool := uid_pool.New(uint32(*projectIDPoolStart), uint32(*projectIDPoolSize))
+		quotaManager = quota_manager.NewProject(
+			runner,
+			quota_manager.SyscallQuotactl{},
+			getMountPoint(logger, *depotPath),
+			append([]string{*depotPath}, writableDirs...),
+			projectIDPool,
+		)
+	case quota_manager.LoopQuota:
+		if *loopImagesPath == "" {
+			missing("-loopImagesPath")
+			return
+		}
+
+		quotaManager = quota_manager.NewLoop(runner, *loopImagesPath, writableDirs, *loopImageSize)
+	default:
+		println("-diskQuotaType value not recognized")
+		println()
+		flag.Usage()
+		return
+	}
+
+	if *disableQuotas {
+		quotaManager.Disable()
+	}
+
 	graph, err := graph.NewGraph(*graphRoot, graphDriver)
 	if err != nil {
 		logger.Fatal("failed-to-construct-graph", err)
 	}
 
+	tagStore, err := repository_fetcher.NewFileTagStore(path.Join(*graphRoot, "repositories-garden"))
+	if err != nil {
+		logger.Fatal("failed-to-construct-tag-store", err)
+	}
+
+	var registryAuthConfigs *registry.ConfigFile
+	if *dockerRegistryCredentials != "" {
+		registryAuthConfigs, err = repository_fetcher.LoadAuthConfigs(*dockerRegistryCredentials)
+		if err != nil {
+			logger.Fatal("failed-to-load-registry-credentials", err)
+		}
+	}
+
+	fetchProgress := repository_fetcher.NewProgress()
+
+	downloader, err := repository_fetcher.NewDownloader(path.Join(*graphRoot, "downloads-garden"), *maxConcurrentDownloads, fetchProgress)
+	if err != nil {
+		logger.Fatal("failed-to-construct-downloader", err)
+	}
+
 	repoFetcher := repository_fetcher.Retryable{
 		repository_fetcher.New(
 			repository_fetcher.NewRepositoryProvider(
 				*dockerRegistry,
 				strings.Split(*insecureRegistries, ","),
+				registryAuthConfigs,
 			),
 			graph,
+			tagStore,
+			downloader,
 		),
 	}
 
-	dockerRootFSProvider, err := rootfs_provider.NewDocker(repoFetcher, graphDriver, rootfs_provider.SimpleVolumeCreator{}, clock.NewClock())
+	imageTracker, err := image_gc.NewFileTracker(path.Join(*graphRoot, "references-garden"), clock.NewClock())
+	if err != nil {
+		logger.Fatal("failed-to-construct-image-tracker", err)
+	}
+
+	dockerRootFSProvider, err := rootfs_provider.NewDocker(repoFetcher, graphDriver, rootfs_provider.SimpleVolumeCreator{}, imageTracker, fetchProgress, clock.NewClock())
 	if err != nil {
 		logger.Fatal("failed-to-construct-docker-rootfs-provider", err)
 	}
 
+	archiveRootFSProvider, err := rootfs_provider.NewDocker(
+		repository_fetcher.NewArchive(graph, path.Join(*graphRoot, "archives-garden"), repoFetcher),
+		graphDriver,
+		rootfs_provider.SimpleVolumeCreator{},
+		imageTracker,
+		fetchProgress,
+		clock.NewClock(),
+	)
+	if err != nil {
+		logger.Fatal("failed-to-construct-docker-archive-rootfs-provider", err)
+	}
+
+	gcPolicy := image_gc.Policy{
+		MaxBytes:   *graphMaxBytes,
+		MaxAge:     *graphMaxAge,
+		KeepUnused: *graphKeepUnused,
+	}
+
+	if gcPolicy.Enabled() {
+		if *graphGCInterval <= 0 {
+			println("-graphGCInterval must be positive")
+			println()
+			flag.Usage()
+			return
+		}
+
+		if graphDriver.String() != "aufs" {
+			// only the aufs driver records which image a container's layer
+			// is on, which is needed to seed the references of containers
+			// created before images were tracked
+			println("-graphMaxBytes, -graphMaxAge and -graphKeepUnused require the aufs graph driver, not " + graphDriver.String())
+			println()
+			flag.Usage()
+			return
+		}
+
+		containerIDs, err := depotContainerIDs(*depotPath)
+		if err != nil {
+			logger.Fatal("failed-to-list-depot", err)
+		}
+
+		// the tracker must know every container's image before the first
+		// collection, including containers that predate it
+		err = image_gc.Seed(imageTracker, image_gc.AUFSLayers{Root: path.Join(*graphRoot, "aufs")}, containerIDs)
+		if err != nil {
+			logger.Fatal("failed-to-seed-image-references", err)
+		}
+
+		collector := image_gc.New(graph, imageTracker, tagStore, gcPolicy, clock.NewClock(), logger)
+		go collector.Run(*graphGCInterval, nil)
+	}
+
 	rootFSProviders := map[string]rootfs_provider.RootFSProvider{
-		"":       rootfs_provider.NewOverlay(*binPath, *overlaysPath, *rootFSPath, runner),
-		"docker": dockerRootFSProvider,
+		"":               rootfs_provider.NewOverlay(*binPath, *overlaysPath, *rootFSPath, runner),
+		"docker":         dockerRootFSProvider,
+		"docker-archive": archiveRootFSProvider,
 	}
 
 	filterProvider := &provider{
@@ -318,6 +527,7 @@ func Main() {
 		chainPrefix:      config.IPTables.Filter.InstancePrefix,
 		runner:           runner,
 		log:              logger,
+		ipv6:             ipv6SubnetPool != nil,
 	}
 
 	if *externalIP == "" {
@@ -334,19 +544,28 @@ func Main() {
 		panic(fmt.Sprintf("Value of -externalIP %s could not be converted to an IP", *externalIP))
 	}
 
+	var ipv6DefaultChain iptables.Chain
+	if ipv6SubnetPool != nil {
+		ipv6DefaultChain = iptables.NewIPv6GlobalChain(config.IPTables.Filter.DefaultChain, runner, logger.Session("ipv6-global-chain"))
+	}
+
 	pool := container_pool.New(
 		logger,
 		*binPath,
 		*depotPath,
 		config,
 		rootFSProviders,
+		fetchProgress,
+		image_gc.NewLister(graph, imageTracker),
 		uidPool,
 		parsedExternalIP,
 		*mtu,
 		subnetPool,
+		ipv6SubnetPool,
 		bridgemgr.New("w"+config.Tag+"b-", &devices.Bridge{}, &devices.Link{}),
 		filterProvider,
 		iptables.NewGlobalChain(config.IPTables.Filter.DefaultChain, runner, logger.Session("global-chain")),
+		ipv6DefaultChain,
 		portPool,
 		strings.Split(*denyNetworks, ","),
 		strings.Split(*allowNetworks, ","),
@@ -409,6 +628,22 @@ func getMountPoint(logger lager.Logger, depotPath string) string {
 	return strings.Trim(dfOutputWords[len(dfOutputWords)-1], "\n")
 }
 
+func depotContainerIDs(depotPath string) ([]string, error) {
+	entries, err := ioutil.ReadDir(depotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+
+	return ids, nil
+}
+
 func missing(flagName string) {
 	println("missing " + flagName)
 	println()
@@ -427,8 +662,19 @@ type provider struct {
 	chainPrefix      string
 	runner           command_runner.CommandRunner
 	log              lager.Logger
+	ipv6             bool
 }
 
 func (p *provider) ProvideFilter(containerId string) network.Filter {
-	return network.NewFilter(iptables.NewLoggingChain(p.chainPrefix+containerId, p.useKernelLogging, p.runner, p.log.Session(containerId).Session("filter")))
+	log := p.log.Session(containerId).Session("filter")
+	chain := iptables.NewLoggingChain(p.chainPrefix+containerId, p.useKernelLogging, p.runner, log)
+
+	if !p.ipv6 {
+		return network.NewFilter(chain)
+	}
+
+	return network.NewDualStackFilter(
+		chain,
+		iptables.NewIPv6LoggingChain(p.chainPrefix+containerId, p.useKernelLogging, p.runner, log.Session("ipv6")),
+	)
 }
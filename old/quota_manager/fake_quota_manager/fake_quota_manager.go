@@ -4,10 +4,13 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
 	"github.com/pivotal-golang/lager"
 )
 
 type FakeQuotaManager struct {
+	SetupError     error
+	TearDownError  error
 	SetLimitsError error
 	GetLimitsError error
 	GetUsageError  error
@@ -16,8 +19,12 @@ type FakeQuotaManager struct {
 	GetUsageResult  garden.ContainerDiskStat
 
 	MountPointResult string
+	TypeResult       string
 
-	Limited map[uint32]garden.DiskLimits
+	SetUp    []quota_manager.Subject
+	TornDown []quota_manager.Subject
+
+	Limited map[quota_manager.Subject]garden.DiskLimits
 
 	enabled bool
 
@@ -26,13 +33,41 @@ type FakeQuotaManager struct {
 
 func New() *FakeQuotaManager {
 	return &FakeQuotaManager{
-		Limited: make(map[uint32]garden.DiskLimits),
+		Limited: make(map[quota_manager.Subject]garden.DiskLimits),
+
+		TypeResult: quota_manager.UserQuota,
 
 		enabled: true,
 	}
 }
 
-func (m *FakeQuotaManager) SetLimits(logger lager.Logger, uid uint32, limits garden.DiskLimits) error {
+func (m *FakeQuotaManager) Setup(logger lager.Logger, subject quota_manager.Subject) error {
+	if m.SetupError != nil {
+		return m.SetupError
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.SetUp = append(m.SetUp, subject)
+
+	return nil
+}
+
+func (m *FakeQuotaManager) TearDown(logger lager.Logger, subject quota_manager.Subject) error {
+	if m.TearDownError != nil {
+		return m.TearDownError
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.TornDown = append(m.TornDown, subject)
+
+	return nil
+}
+
+func (m *FakeQuotaManager) SetLimits(logger lager.Logger, subject quota_manager.Subject, limits garden.DiskLimits) error {
 	if m.SetLimitsError != nil {
 		return m.SetLimitsError
 	}
@@ -40,12 +75,12 @@ func (m *FakeQuotaManager) SetLimits(logger lager.Logger, uid uint32, limits gar
 	m.Lock()
 	defer m.Unlock()
 
-	m.Limited[uid] = limits
+	m.Limited[subject] = limits
 
 	return nil
 }
 
-func (m *FakeQuotaManager) GetLimits(logger lager.Logger, uid uint32) (garden.DiskLimits, error) {
+func (m *FakeQuotaManager) GetLimits(logger lager.Logger, subject quota_manager.Subject) (garden.DiskLimits, error) {
 	if m.GetLimitsError != nil {
 		return garden.DiskLimits{}, m.GetLimitsError
 	}
@@ -56,7 +91,7 @@ func (m *FakeQuotaManager) GetLimits(logger lager.Logger, uid uint32) (garden.Di
 	return m.GetLimitsResult, nil
 }
 
-func (m *FakeQuotaManager) GetUsage(logger lager.Logger, uid uint32) (garden.ContainerDiskStat, error) {
+func (m *FakeQuotaManager) GetUsage(logger lager.Logger, subject quota_manager.Subject) (garden.ContainerDiskStat, error) {
 	if m.GetUsageError != nil {
 		return garden.ContainerDiskStat{}, m.GetUsageError
 	}
@@ -78,3 +113,7 @@ func (m *FakeQuotaManager) Disable() {
 func (m *FakeQuotaManager) IsEnabled() bool {
 	return m.enabled
 }
+
+func (m *FakeQuotaManager) Type() string {
+	return m.TypeResult
+}
@@ -0,0 +1,61 @@
+package fake_quota_manager
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+)
+
+type FakeQuotactl struct {
+	SetQuotaError error
+	GetQuotaError error
+
+	Quotas map[QuotaKey]quota_manager.Quota
+
+	mutex sync.Mutex
+}
+
+type QuotaKey struct {
+	MountPoint string
+	Type       quota_manager.QuotaType
+	ID         uint32
+}
+
+func NewQuotactl() *FakeQuotactl {
+	return &FakeQuotactl{
+		Quotas: make(map[QuotaKey]quota_manager.Quota),
+	}
+}
+
+func (q *FakeQuotactl) SetQuota(mountPoint string, quotaType quota_manager.QuotaType, id uint32, limits garden.DiskLimits) error {
+	if q.SetQuotaError != nil {
+		return q.SetQuotaError
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key := QuotaKey{mountPoint, quotaType, id}
+
+	quota := q.Quotas[key]
+	quota.BlockSoft = limits.BlockSoft
+	quota.BlockHard = limits.BlockHard
+	quota.InodeSoft = limits.InodeSoft
+	quota.InodeHard = limits.InodeHard
+
+	q.Quotas[key] = quota
+
+	return nil
+}
+
+func (q *FakeQuotactl) GetQuota(mountPoint string, quotaType quota_manager.QuotaType, id uint32) (quota_manager.Quota, error) {
+	if q.GetQuotaError != nil {
+		return quota_manager.Quota{}, q.GetQuotaError
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.Quotas[QuotaKey{mountPoint, quotaType, id}], nil
+}
@@ -0,0 +1,341 @@
+package quota_manager
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+var ErrCannotShrink = errors.New("quota_manager: a container's filesystem cannot be shrunk while it is mounted")
+
+// LoopQuotaManager gives each container an ext4 filesystem of its own, in a
+// sparse image file that is bind-mounted over its directories. Its disk use
+// is bounded by the size of the filesystem, which the hard limit grows; soft
+// limits are not supported.
+type LoopQuotaManager struct {
+	enabled bool
+
+	runner command_runner.CommandRunner
+
+	imagesPath  string
+	initialSize uint64
+
+	// the container's directory under each of these is mounted from its
+	// filesystem
+	dirs []string
+}
+
+func NewLoop(runner command_runner.CommandRunner, imagesPath string, dirs []string, initialSize uint64) *LoopQuotaManager {
+	return &LoopQuotaManager{
+		enabled: true,
+
+		runner: runner,
+
+		imagesPath:  imagesPath,
+		initialSize: initialSize,
+
+		dirs: dirs,
+	}
+}
+
+func (m *LoopQuotaManager) Setup(logger lager.Logger, subject Subject) error {
+	if !m.enabled {
+		return nil
+	}
+
+	err := m.setup(logger, subject)
+	if err != nil {
+		m.TearDown(logger, subject)
+		return err
+	}
+
+	return nil
+}
+
+func (m *LoopQuotaManager) setup(logger lager.Logger, subject Subject) error {
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	image := m.imagePath(subject)
+	mountPoint := m.mountPath(subject)
+
+	err := os.MkdirAll(mountPoint, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = runner.Run(exec.Command("truncate", "-s", fmt.Sprintf("%d", m.initialSize), image))
+	if err != nil {
+		return err
+	}
+
+	err = runner.Run(exec.Command("mkfs.ext4", "-q", "-F", "-m", "0", image))
+	if err != nil {
+		return err
+	}
+
+	device := new(bytes.Buffer)
+
+	losetup := exec.Command("losetup", "--find", "--show", image)
+	losetup.Stdout = device
+
+	err = runner.Run(losetup)
+	if err != nil {
+		return err
+	}
+
+	err = runner.Run(exec.Command("mount", strings.TrimSpace(device.String()), mountPoint))
+	if err != nil {
+		return err
+	}
+
+	for i, dir := range m.dirs {
+		source := path.Join(mountPoint, strconv.Itoa(i))
+		target := path.Join(dir, subject.ID)
+
+		for _, d := range []string{source, target} {
+			err := os.MkdirAll(d, 0755)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = runner.Run(exec.Command("mount", "--bind", source, target))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TearDown detaches the container's filesystem lazily, so that the mounts
+// still made on top of it (e.g. the rootfs) can be cleaned up afterwards.
+func (m *LoopQuotaManager) TearDown(logger lager.Logger, subject Subject) error {
+	if !m.enabled {
+		return nil
+	}
+
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	image := m.imagePath(subject)
+	mountPoint := m.mountPath(subject)
+
+	if _, err := os.Stat(image); os.IsNotExist(err) {
+		return nil
+	}
+
+	for _, dir := range m.dirs {
+		target := path.Join(dir, subject.ID)
+		if isMountPoint(target) {
+			err := runner.Run(exec.Command("umount", "-l", target))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if isMountPoint(mountPoint) {
+		err := runner.Run(exec.Command("umount", "-l", mountPoint))
+		if err != nil {
+			return err
+		}
+	}
+
+	device, err := m.device(logger, subject)
+	if err != nil {
+		return err
+	}
+
+	if device != "" {
+		// frees the device once the lazily unmounted filesystem is let go
+		err := runner.Run(exec.Command("losetup", "-d", device))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(mountPoint)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(image)
+}
+
+func (m *LoopQuotaManager) SetLimits(logger lager.Logger, subject Subject, limits garden.DiskLimits) error {
+	if !m.enabled {
+		return nil
+	}
+
+	size := limits.ByteHard
+	if size == 0 {
+		size = limits.BlockHard * QUOTA_BLOCK_SIZE
+	}
+
+	if size == 0 {
+		return nil
+	}
+
+	info, err := os.Stat(m.imagePath(subject))
+	if err != nil {
+		return err
+	}
+
+	if size < uint64(info.Size()) {
+		return ErrCannotShrink
+	}
+
+	if size == uint64(info.Size()) {
+		return nil
+	}
+
+	device, err := m.device(logger, subject)
+	if err != nil {
+		return err
+	}
+
+	if device == "" {
+		return fmt.Errorf("quota_manager: no loop device for %s", m.imagePath(subject))
+	}
+
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	err = runner.Run(exec.Command("truncate", "-s", fmt.Sprintf("%d", size), m.imagePath(subject)))
+	if err != nil {
+		return err
+	}
+
+	err = runner.Run(exec.Command("losetup", "-c", device))
+	if err != nil {
+		return err
+	}
+
+	return runner.Run(exec.Command("resize2fs", device))
+}
+
+func (m *LoopQuotaManager) GetLimits(logger lager.Logger, subject Subject) (garden.DiskLimits, error) {
+	if !m.enabled {
+		return garden.DiskLimits{}, nil
+	}
+
+	// the image's size is the limit; the filesystem in it is a little smaller
+	info, err := os.Stat(m.imagePath(subject))
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(m.mountPath(subject), &stat)
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+
+	size := uint64(info.Size())
+
+	return garden.DiskLimits{
+		BlockHard: size / QUOTA_BLOCK_SIZE,
+		ByteHard:  size,
+		InodeHard: stat.Files,
+	}, nil
+}
+
+func (m *LoopQuotaManager) GetUsage(logger lager.Logger, subject Subject) (garden.ContainerDiskStat, error) {
+	if !m.enabled {
+		return garden.ContainerDiskStat{}, nil
+	}
+
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(m.mountPath(subject), &stat)
+	if err != nil {
+		return garden.ContainerDiskStat{}, err
+	}
+
+	return garden.ContainerDiskStat{
+		BytesUsed:  (stat.Blocks - stat.Bfree) * uint64(stat.Bsize),
+		InodesUsed: stat.Files - stat.Ffree,
+	}, nil
+}
+
+func (m *LoopQuotaManager) MountPoint() string {
+	return m.imagesPath
+}
+
+func (m *LoopQuotaManager) Disable() {
+	m.enabled = false
+}
+
+func (m *LoopQuotaManager) IsEnabled() bool {
+	return m.enabled
+}
+
+func (m *LoopQuotaManager) Type() string {
+	return LoopQuota
+}
+
+func (m *LoopQuotaManager) imagePath(subject Subject) string {
+	return path.Join(m.imagesPath, subject.ID+".img")
+}
+
+func (m *LoopQuotaManager) mountPath(subject Subject) string {
+	return path.Join(m.imagesPath, subject.ID)
+}
+
+// device finds the loop device backing a container's image, from losetup
+// output of the form "/dev/loop0: [2049]:1234 (/path/to/image)".
+func (m *LoopQuotaManager) device(logger lager.Logger, subject Subject) (string, error) {
+	out := new(bytes.Buffer)
+
+	losetup := exec.Command("losetup", "-j", m.imagePath(subject))
+	losetup.Stdout = out
+
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	err := runner.Run(losetup)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(out.String())
+	if line == "" {
+		return "", nil
+	}
+
+	return strings.SplitN(line, ":", 2)[0], nil
+}
+
+func isMountPoint(dir string) bool {
+	var stat, parentStat syscall.Stat_t
+
+	if err := syscall.Stat(dir, &stat); err != nil {
+		return false
+	}
+
+	if err := syscall.Stat(path.Dir(dir), &parentStat); err != nil {
+		return false
+	}
+
+	return stat.Dev != parentStat.Dev
+}
@@ -0,0 +1,264 @@
+package quota_manager_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+)
+
+var _ = Describe("Loop quota manager", func() {
+	var fakeRunner *fake_command_runner.FakeCommandRunner
+	var logger *lagertest.TestLogger
+	var imagesPath string
+	var overlaysPath string
+	var quotaManager *quota_manager.LoopQuotaManager
+
+	subject := quota_manager.Subject{ID: "some-id", UID: 1234}
+
+	BeforeEach(func() {
+		var err error
+
+		fakeRunner = fake_command_runner.New()
+		logger = lagertest.NewTestLogger("test")
+
+		imagesPath, err = ioutil.TempDir("", "images")
+		Expect(err).ToNot(HaveOccurred())
+
+		overlaysPath, err = ioutil.TempDir("", "overlays")
+		Expect(err).ToNot(HaveOccurred())
+
+		quotaManager = quota_manager.NewLoop(fakeRunner, imagesPath, []string{overlaysPath}, 1024*1024)
+
+		fakeRunner.WhenRunning(
+			fake_command_runner.CommandSpec{
+				Path: "losetup",
+				Args: []string{"--find", "--show", path.Join(imagesPath, "some-id.img")},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte("/dev/loop3\n"))
+				return nil
+			},
+		)
+
+		fakeRunner.WhenRunning(
+			fake_command_runner.CommandSpec{
+				Path: "losetup",
+				Args: []string{"-j", path.Join(imagesPath, "some-id.img")},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte("/dev/loop3: [2049]:1234 (" + path.Join(imagesPath, "some-id.img") + ")\n"))
+				return nil
+			},
+		)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(imagesPath)
+		os.RemoveAll(overlaysPath)
+	})
+
+	Describe("setting up", func() {
+		It("makes a filesystem for the container and mounts it over its directories", func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			image := path.Join(imagesPath, "some-id.img")
+			mountPoint := path.Join(imagesPath, "some-id")
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "truncate",
+					Args: []string{"-s", "1048576", image},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "mkfs.ext4",
+					Args: []string{"-q", "-F", "-m", "0", image},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "losetup",
+					Args: []string{"--find", "--show", image},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "mount",
+					Args: []string{"/dev/loop3", mountPoint},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "mount",
+					Args: []string{"--bind", path.Join(mountPoint, "0"), path.Join(overlaysPath, "some-id")},
+				},
+			))
+		})
+
+		Context("when making the filesystem fails", func() {
+			nastyError := errors.New("oh no!")
+
+			BeforeEach(func() {
+				err := ioutil.WriteFile(path.Join(imagesPath, "some-id.img"), []byte{}, 0644)
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: "mkfs.ext4",
+					}, func(*exec.Cmd) error {
+						return nastyError
+					},
+				)
+			})
+
+			It("returns the error and cleans up", func() {
+				err := quotaManager.Setup(logger, subject)
+				Expect(err).To(Equal(nastyError))
+
+				Expect(path.Join(imagesPath, "some-id.img")).ToNot(BeAnExistingFile())
+				Expect(path.Join(imagesPath, "some-id")).ToNot(BeAnExistingFile())
+			})
+		})
+
+		Context("when quotas are disabled", func() {
+			BeforeEach(func() {
+				quotaManager.Disable()
+			})
+
+			It("runs nothing", func() {
+				err := quotaManager.Setup(logger, subject)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner).ToNot(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "mkfs.ext4",
+					},
+				))
+			})
+		})
+	})
+
+	Describe("tearing down", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(path.Join(imagesPath, "some-id.img"), []byte{}, 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = os.MkdirAll(path.Join(imagesPath, "some-id"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("detaches the loop device and removes the image", func() {
+			err := quotaManager.TearDown(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "losetup",
+					Args: []string{"-d", "/dev/loop3"},
+				},
+			))
+
+			Expect(path.Join(imagesPath, "some-id.img")).ToNot(BeAnExistingFile())
+			Expect(path.Join(imagesPath, "some-id")).ToNot(BeAnExistingFile())
+		})
+
+		Context("when the container was never set up", func() {
+			It("does nothing", func() {
+				err := quotaManager.TearDown(logger, quota_manager.Subject{ID: "other-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("setting quotas", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(path.Join(imagesPath, "some-id.img"), make([]byte, 1024), 0644)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("grows the container's filesystem to the hard limit", func() {
+			err := quotaManager.SetLimits(logger, subject, garden.DiskLimits{ByteHard: 4096})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "truncate",
+					Args: []string{"-s", "4096", path.Join(imagesPath, "some-id.img")},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "losetup",
+					Args: []string{"-c", "/dev/loop3"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "resize2fs",
+					Args: []string{"/dev/loop3"},
+				},
+			))
+		})
+
+		Context("when the hard limit is smaller than the filesystem", func() {
+			It("returns ErrCannotShrink", func() {
+				err := quotaManager.SetLimits(logger, subject, garden.DiskLimits{ByteHard: 512})
+				Expect(err).To(Equal(quota_manager.ErrCannotShrink))
+			})
+		})
+
+		Context("when no hard limit is given", func() {
+			It("runs nothing", func() {
+				err := quotaManager.SetLimits(logger, subject, garden.DiskLimits{ByteSoft: 4096})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("getting quotas limits", func() {
+		BeforeEach(func() {
+			err := ioutil.WriteFile(path.Join(imagesPath, "some-id.img"), make([]byte, 4096), 0644)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = os.MkdirAll(path.Join(imagesPath, "some-id"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports the size of the container's image as the hard limit", func() {
+			limits, err := quotaManager.GetLimits(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(limits.ByteHard).To(Equal(uint64(4096)))
+			Expect(limits.BlockHard).To(Equal(uint64(4)))
+		})
+
+		Context("when the container's image does not exist", func() {
+			It("returns an error", func() {
+				_, err := quotaManager.GetLimits(logger, quota_manager.Subject{ID: "other-id"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("getting usage", func() {
+		BeforeEach(func() {
+			err := os.MkdirAll(path.Join(imagesPath, "some-id"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("reports the usage of the container's filesystem", func() {
+			_, err := quotaManager.GetUsage(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when the container's filesystem does not exist", func() {
+			It("returns an error", func() {
+				_, err := quotaManager.GetUsage(logger, quota_manager.Subject{ID: "other-id"})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
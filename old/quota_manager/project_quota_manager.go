@@ -0,0 +1,309 @@
+package quota_manager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
+	"github.com/cloudfoundry-incubator/garden-linux/old/uid_pool"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// ProjectQuotaManager accounts for everything written under a container's
+// directories, whichever user writes it, by giving the directories a project
+// ID of their own. The filesystem must have project quotas enabled (XFS with
+// pquota, or ext4 with the project feature).
+type ProjectQuotaManager struct {
+	enabled bool
+
+	runner   command_runner.CommandRunner
+	quotactl Quotactl
+
+	mountPoint string
+
+	// the container's directory under each of these is given its project ID;
+	// the first is expected to always exist, and is used to look up the ID
+	dirs []string
+
+	projectIDs uid_pool.UIDPool
+
+	reserveExisting sync.Once
+
+	assigned      map[string]uint32
+	assignedMutex sync.Mutex
+}
+
+func NewProject(runner command_runner.CommandRunner, quotactl Quotactl, mountPoint string, dirs []string, projectIDs uid_pool.UIDPool) *ProjectQuotaManager {
+	return &ProjectQuotaManager{
+		enabled: true,
+
+		runner:   runner,
+		quotactl: quotactl,
+
+		mountPoint: mountPoint,
+
+		dirs: dirs,
+
+		projectIDs: projectIDs,
+
+		assigned: make(map[string]uint32),
+	}
+}
+
+func (m *ProjectQuotaManager) Setup(logger lager.Logger, subject Subject) error {
+	if !m.enabled {
+		return nil
+	}
+
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	// containers that survived a restart still hold their IDs
+	m.reserveExisting.Do(func() {
+		m.reserveProjectIDs(logger)
+	})
+
+	projectID, err := m.projectIDs.Acquire()
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range m.dirs {
+		containerDir := path.Join(dir, subject.ID)
+
+		err := os.MkdirAll(containerDir, 0755)
+		if err != nil {
+			m.projectIDs.Release(projectID)
+			return err
+		}
+
+		// +P makes everything created in the directory inherit the project
+		err = runner.Run(exec.Command("chattr", "-R", "+P", "-p", fmt.Sprintf("%d", projectID), containerDir))
+		if err != nil {
+			m.projectIDs.Release(projectID)
+			return err
+		}
+	}
+
+	// the ID may have been left with limits by a container that was
+	// destroyed uncleanly
+	err = m.setQuota(logger, projectID, garden.DiskLimits{})
+	if err != nil {
+		m.projectIDs.Release(projectID)
+		return err
+	}
+
+	m.assignedMutex.Lock()
+	m.assigned[subject.ID] = projectID
+	m.assignedMutex.Unlock()
+
+	return nil
+}
+
+func (m *ProjectQuotaManager) TearDown(logger lager.Logger, subject Subject) error {
+	if !m.enabled {
+		return nil
+	}
+
+	projectID, err := m.projectID(logger, subject)
+	if err != nil {
+		// nothing was set up
+		return nil
+	}
+
+	m.assignedMutex.Lock()
+	delete(m.assigned, subject.ID)
+	m.assignedMutex.Unlock()
+
+	m.projectIDs.Release(projectID)
+
+	return nil
+}
+
+func (m *ProjectQuotaManager) SetLimits(logger lager.Logger, subject Subject, limits garden.DiskLimits) error {
+	if !m.enabled {
+		return nil
+	}
+
+	projectID, err := m.projectID(logger, subject)
+	if err != nil {
+		return err
+	}
+
+	return m.setQuota(logger, projectID, InBlocks(limits))
+}
+
+func (m *ProjectQuotaManager) GetLimits(logger lager.Logger, subject Subject) (garden.DiskLimits, error) {
+	if !m.enabled {
+		return garden.DiskLimits{}, nil
+	}
+
+	quota, err := m.getQuota(logger, subject)
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+
+	return limitsOf(quota), nil
+}
+
+func (m *ProjectQuotaManager) GetUsage(logger lager.Logger, subject Subject) (garden.ContainerDiskStat, error) {
+	if !m.enabled {
+		return garden.ContainerDiskStat{}, nil
+	}
+
+	quota, err := m.getQuota(logger, subject)
+	if err != nil {
+		return garden.ContainerDiskStat{}, err
+	}
+
+	return usageOf(quota), nil
+}
+
+func (m *ProjectQuotaManager) MountPoint() string {
+	return m.mountPoint
+}
+
+func (m *ProjectQuotaManager) Disable() {
+	m.enabled = false
+}
+
+func (m *ProjectQuotaManager) IsEnabled() bool {
+	return m.enabled
+}
+
+func (m *ProjectQuotaManager) Type() string {
+	return ProjectQuota
+}
+
+func (m *ProjectQuotaManager) getQuota(logger lager.Logger, subject Subject) (Quota, error) {
+	projectID, err := m.projectID(logger, subject)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	quota, err := m.quotactl.GetQuota(m.mountPoint, ProjectQuotaType, projectID)
+	if err != nil {
+		logger.Error("failed-to-get-quota", err)
+		return Quota{}, err
+	}
+
+	return quota, nil
+}
+
+func (m *ProjectQuotaManager) setQuota(logger lager.Logger, projectID uint32, limits garden.DiskLimits) error {
+	err := m.quotactl.SetQuota(m.mountPoint, ProjectQuotaType, projectID, limits)
+	if err != nil {
+		logger.Error("failed-to-set-quota", err)
+		return err
+	}
+
+	return nil
+}
+
+// projectID returns the project ID of a container, reading it back from its
+// directory if it was set up before a restart.
+func (m *ProjectQuotaManager) projectID(logger lager.Logger, subject Subject) (uint32, error) {
+	m.assignedMutex.Lock()
+	defer m.assignedMutex.Unlock()
+
+	if projectID, found := m.assigned[subject.ID]; found {
+		return projectID, nil
+	}
+
+	projectIDs, err := m.lookUpProjectIDs(logger, path.Join(m.dirs[0], subject.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	projectID := projectIDs[0]
+
+	m.projectIDs.Remove(projectID)
+	m.assigned[subject.ID] = projectID
+
+	return projectID, nil
+}
+
+func (m *ProjectQuotaManager) reserveProjectIDs(logger lager.Logger) {
+	entries, err := readDirNames(m.dirs[0])
+	if err != nil || len(entries) == 0 {
+		return
+	}
+
+	containerDirs := make([]string, len(entries))
+	for i, entry := range entries {
+		containerDirs[i] = path.Join(m.dirs[0], entry)
+	}
+
+	projectIDs, err := m.lookUpProjectIDs(logger, containerDirs...)
+	if err != nil {
+		logger.Error("failed-to-reserve-existing-project-ids", err)
+		return
+	}
+
+	for _, projectID := range projectIDs {
+		if projectID != 0 {
+			m.projectIDs.Remove(projectID)
+		}
+	}
+}
+
+// lookUpProjectIDs reads the project IDs of directories, from lsattr output
+// of the form "<project id> <flags> <path>".
+func (m *ProjectQuotaManager) lookUpProjectIDs(logger lager.Logger, dirs ...string) ([]uint32, error) {
+	lsattr := exec.Command("lsattr", append([]string{"-p", "-d"}, dirs...)...)
+
+	out := new(bytes.Buffer)
+	lsattr.Stdout = out
+
+	runner := logging.Runner{
+		Logger:        logger,
+		CommandRunner: m.runner,
+	}
+
+	err := runner.Run(lsattr)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectIDs []uint32
+	for _, line := range strings.Split(out.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		projectID, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("quota_manager: malformed lsattr output: %q", line)
+		}
+
+		projectIDs = append(projectIDs, uint32(projectID))
+	}
+
+	if len(projectIDs) == 0 {
+		return nil, fmt.Errorf("quota_manager: no project ids found for %v", dirs)
+	}
+
+	return projectIDs, nil
+}
+
+func readDirNames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return f.Readdirnames(-1)
+}
@@ -0,0 +1,326 @@
+package quota_manager_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
+	"github.com/cloudfoundry-incubator/garden-linux/old/uid_pool"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+)
+
+var _ = Describe("Project quota manager", func() {
+	var fakeRunner *fake_command_runner.FakeCommandRunner
+	var fakeQuotactl *fake_quota_manager.FakeQuotactl
+	var logger *lagertest.TestLogger
+	var depotPath string
+	var overlaysPath string
+	var quotaManager *quota_manager.ProjectQuotaManager
+
+	subject := quota_manager.Subject{ID: "some-id", UID: 1234}
+
+	projectQuota := func(id uint32) fake_quota_manager.QuotaKey {
+		return fake_quota_manager.QuotaKey{
+			MountPoint: "/some/mount/point",
+			Type:       quota_manager.ProjectQuotaType,
+			ID:         id,
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+
+		fakeRunner = fake_command_runner.New()
+		fakeQuotactl = fake_quota_manager.NewQuotactl()
+		logger = lagertest.NewTestLogger("test")
+
+		depotPath, err = ioutil.TempDir("", "depot")
+		Expect(err).ToNot(HaveOccurred())
+
+		overlaysPath, err = ioutil.TempDir("", "overlays")
+		Expect(err).ToNot(HaveOccurred())
+
+		quotaManager = quota_manager.NewProject(
+			fakeRunner,
+			fakeQuotactl,
+			"/some/mount/point",
+			[]string{depotPath, overlaysPath},
+			uid_pool.New(100, 2),
+		)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(depotPath)
+		os.RemoveAll(overlaysPath)
+	})
+
+	Describe("setting up", func() {
+		It("gives the container's directories a project id of their own", func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(path.Join(depotPath, "some-id")).To(BeADirectory())
+			Expect(path.Join(overlaysPath, "some-id")).To(BeADirectory())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "chattr",
+					Args: []string{"-R", "+P", "-p", "100", path.Join(depotPath, "some-id")},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "chattr",
+					Args: []string{"-R", "+P", "-p", "100", path.Join(overlaysPath, "some-id")},
+				},
+			))
+		})
+
+		It("clears any limits left on the project id", func() {
+			fakeQuotactl.Quotas[projectQuota(100)] = quota_manager.Quota{BlockHard: 42}
+
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeQuotactl.Quotas).To(HaveKeyWithValue(projectQuota(100), quota_manager.Quota{}))
+		})
+
+		It("gives each container a different project id", func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = quotaManager.Setup(logger, quota_manager.Subject{ID: "other-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "chattr",
+					Args: []string{"-R", "+P", "-p", "101", path.Join(depotPath, "other-id")},
+				},
+			))
+		})
+
+		Context("when containers already exist", func() {
+			BeforeEach(func() {
+				err := os.MkdirAll(path.Join(depotPath, "old-id"), 0755)
+				Expect(err).ToNot(HaveOccurred())
+
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: "lsattr",
+						Args: []string{"-p", "-d", path.Join(depotPath, "old-id")},
+					}, func(cmd *exec.Cmd) error {
+						cmd.Stdout.Write([]byte("   100 ----------------P-- " + path.Join(depotPath, "old-id") + "\n"))
+						return nil
+					},
+				)
+			})
+
+			It("does not give out their project ids", func() {
+				err := quotaManager.Setup(logger, subject)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "chattr",
+						Args: []string{"-R", "+P", "-p", "101", path.Join(depotPath, "some-id")},
+					},
+				))
+			})
+		})
+
+		Context("when chattr fails", func() {
+			nastyError := errors.New("oh no!")
+
+			var chattrErr error
+
+			BeforeEach(func() {
+				chattrErr = nastyError
+
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: "chattr",
+					}, func(*exec.Cmd) error {
+						return chattrErr
+					},
+				)
+			})
+
+			It("returns the error and releases the project id", func() {
+				err := quotaManager.Setup(logger, subject)
+				Expect(err).To(Equal(nastyError))
+
+				chattrErr = nil
+
+				err = quotaManager.Setup(logger, quota_manager.Subject{ID: "other-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = quotaManager.Setup(logger, quota_manager.Subject{ID: "third-id"})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "chattr",
+						Args: []string{"-R", "+P", "-p", "100", path.Join(depotPath, "third-id")},
+					},
+				))
+			})
+		})
+
+		Context("when quotas are disabled", func() {
+			BeforeEach(func() {
+				quotaManager.Disable()
+			})
+
+			It("runs nothing", func() {
+				err := quotaManager.Setup(logger, subject)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeRunner).ToNot(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "chattr",
+					},
+				))
+			})
+		})
+	})
+
+	Describe("tearing down", func() {
+		It("releases the container's project id", func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = quotaManager.TearDown(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = quotaManager.Setup(logger, quota_manager.Subject{ID: "other-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = quotaManager.Setup(logger, quota_manager.Subject{ID: "third-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "chattr",
+					Args: []string{"-R", "+P", "-p", "100", path.Join(depotPath, "third-id")},
+				},
+			))
+		})
+	})
+
+	Describe("setting quotas", func() {
+		BeforeEach(func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("sets the quota of the container's project", func() {
+			err := quotaManager.SetLimits(logger, subject, garden.DiskLimits{
+				InodeSoft: 11,
+				InodeHard: 12,
+
+				ByteSoft: 102401,
+				ByteHard: 204801,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeQuotactl.Quotas).To(HaveKeyWithValue(projectQuota(100), quota_manager.Quota{
+				BlockSoft: 101,
+				BlockHard: 201,
+				InodeSoft: 11,
+				InodeHard: 12,
+			}))
+		})
+
+		Context("when the container was set up before a restart", func() {
+			BeforeEach(func() {
+				quotaManager = quota_manager.NewProject(
+					fakeRunner,
+					fakeQuotactl,
+					"/some/mount/point",
+					[]string{depotPath, overlaysPath},
+					uid_pool.New(100, 2),
+				)
+
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{
+						Path: "lsattr",
+						Args: []string{"-p", "-d", path.Join(depotPath, "some-id")},
+					}, func(cmd *exec.Cmd) error {
+						cmd.Stdout.Write([]byte("   101 ----------------P-- " + path.Join(depotPath, "some-id") + "\n"))
+						return nil
+					},
+				)
+			})
+
+			It("reads the project id back from its directory", func() {
+				err := quotaManager.SetLimits(logger, subject, garden.DiskLimits{BlockHard: 2})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeQuotactl.Quotas).To(HaveKeyWithValue(projectQuota(101), quota_manager.Quota{
+					BlockHard: 2,
+				}))
+			})
+		})
+	})
+
+	Describe("getting usage", func() {
+		BeforeEach(func() {
+			err := quotaManager.Setup(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeQuotactl.Quotas[projectQuota(100)] = quota_manager.Quota{
+				BlockSoft: 1,
+				BlockHard: 2,
+				BytesUsed: 1024,
+
+				InodeSoft:  11,
+				InodeHard:  12,
+				InodesUsed: 10,
+			}
+		})
+
+		It("reports the project's usage", func() {
+			usage, err := quotaManager.GetUsage(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(usage).To(Equal(garden.ContainerDiskStat{
+				BytesUsed:  1024,
+				InodesUsed: 10,
+			}))
+		})
+
+		It("reports the project's limits", func() {
+			limits, err := quotaManager.GetLimits(logger, subject)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(limits).To(Equal(garden.DiskLimits{
+				BlockSoft: 1,
+				BlockHard: 2,
+				InodeSoft: 11,
+				InodeHard: 12,
+			}))
+		})
+
+		Context("when getting the quota fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeQuotactl.GetQuotaError = disaster
+			})
+
+			It("returns the error", func() {
+				_, err := quotaManager.GetUsage(logger, subject)
+				Expect(err).To(Equal(disaster))
+			})
+		})
+	})
+})
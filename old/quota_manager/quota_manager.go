@@ -1,45 +1,58 @@
 package quota_manager
 
 import (
-	"bytes"
-	"fmt"
-	"os"
-	"os/exec"
-	"path"
-
 	"github.com/cloudfoundry-incubator/garden"
-	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
-	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/pivotal-golang/lager"
 )
 
 type QuotaManager interface {
-	SetLimits(logger lager.Logger, uid uint32, limits garden.DiskLimits) error
-	GetLimits(logger lager.Logger, uid uint32) (garden.DiskLimits, error)
-	GetUsage(logger lager.Logger, uid uint32) (garden.ContainerDiskStat, error)
+	// Setup prepares a new container's disk accounting, before its root
+	// filesystem is provided.
+	Setup(logger lager.Logger, subject Subject) error
+
+	// TearDown releases a container's disk accounting, before its root
+	// filesystem is cleaned up.
+	TearDown(logger lager.Logger, subject Subject) error
+
+	SetLimits(logger lager.Logger, subject Subject, limits garden.DiskLimits) error
+	GetLimits(logger lager.Logger, subject Subject) (garden.DiskLimits, error)
+	GetUsage(logger lager.Logger, subject Subject) (garden.ContainerDiskStat, error)
 
 	MountPoint() string
 	Disable()
 	IsEnabled() bool
+
+	// Type names the kind of quota enforced, for the setup script.
+	Type() string
 }
 
+// Subject identifies the container whose disk usage is accounted for.
+type Subject struct {
+	ID  string
+	UID uint32
+}
+
+const (
+	UserQuota    = "user"
+	ProjectQuota = "project"
+	LoopQuota    = "loop"
+)
+
 type LinuxQuotaManager struct {
 	enabled bool
 
-	binPath string
-	runner  command_runner.CommandRunner
+	quotactl Quotactl
 
 	mountPoint string
 }
 
 const QUOTA_BLOCK_SIZE = 1024
 
-func New(runner command_runner.CommandRunner, mountPoint, binPath string) *LinuxQuotaManager {
+func New(quotactl Quotactl, mountPoint string) *LinuxQuotaManager {
 	return &LinuxQuotaManager{
 		enabled: true,
 
-		binPath: binPath,
-		runner:  runner,
+		quotactl: quotactl,
 
 		mountPoint: mountPoint,
 	}
@@ -49,132 +62,95 @@ func (m *LinuxQuotaManager) Disable() {
 	m.enabled = false
 }
 
-func (m *LinuxQuotaManager) SetLimits(logger lager.Logger, uid uint32, limits garden.DiskLimits) error {
+// Setup does nothing, as user quotas follow whatever the container's user
+// writes.
+func (m *LinuxQuotaManager) Setup(logger lager.Logger, subject Subject) error {
+	return nil
+}
+
+func (m *LinuxQuotaManager) TearDown(logger lager.Logger, subject Subject) error {
+	return nil
+}
+
+func (m *LinuxQuotaManager) SetLimits(logger lager.Logger, subject Subject, limits garden.DiskLimits) error {
 	if !m.enabled {
 		return nil
 	}
 
-	if limits.ByteSoft != 0 {
-		limits.BlockSoft = (limits.ByteSoft + QUOTA_BLOCK_SIZE - 1) / QUOTA_BLOCK_SIZE
-	}
-
-	if limits.ByteHard != 0 {
-		limits.BlockHard = (limits.ByteHard + QUOTA_BLOCK_SIZE - 1) / QUOTA_BLOCK_SIZE
+	err := m.quotactl.SetQuota(m.mountPoint, UserQuotaType, subject.UID, InBlocks(limits))
+	if err != nil {
+		logger.Error("failed-to-set-quota", err)
+		return err
 	}
 
-	runner := logging.Runner{
-		Logger:        logger,
-		CommandRunner: m.runner,
-	}
+	return nil
+}
 
-	return runner.Run(
-		exec.Command(
-			"setquota",
-			"-u",
-			fmt.Sprintf("%d", uid),
-			fmt.Sprintf("%d", limits.BlockSoft),
-			fmt.Sprintf("%d", limits.BlockHard),
-			fmt.Sprintf("%d", limits.InodeSoft),
-			fmt.Sprintf("%d", limits.InodeHard),
-			m.mountPoint,
-		),
-	)
-}
-
-func (m *LinuxQuotaManager) GetLimits(logger lager.Logger, uid uint32) (garden.DiskLimits, error) {
+func (m *LinuxQuotaManager) GetLimits(logger lager.Logger, subject Subject) (garden.DiskLimits, error) {
 	if !m.enabled {
 		return garden.DiskLimits{}, nil
 	}
 
-	repquota := exec.Command(path.Join(m.binPath, "repquota"), m.mountPoint, fmt.Sprintf("%d", uid))
-
-	limits := garden.DiskLimits{}
-
-	repR, repW, err := os.Pipe()
+	quota, err := m.quotactl.GetQuota(m.mountPoint, UserQuotaType, subject.UID)
 	if err != nil {
-		return limits, err
+		logger.Error("failed-to-get-quota", err)
+		return garden.DiskLimits{}, err
 	}
 
-	defer repR.Close()
-	defer repW.Close()
-
-	repquota.Stdout = repW
+	return limitsOf(quota), nil
+}
 
-	runner := logging.Runner{
-		Logger:        logger,
-		CommandRunner: m.runner,
+func (m *LinuxQuotaManager) GetUsage(logger lager.Logger, subject Subject) (garden.ContainerDiskStat, error) {
+	if !m.enabled {
+		return garden.ContainerDiskStat{}, nil
 	}
 
-	err = runner.Start(repquota)
+	quota, err := m.quotactl.GetQuota(m.mountPoint, UserQuotaType, subject.UID)
 	if err != nil {
-		return limits, err
+		logger.Error("failed-to-get-quota", err)
+		return garden.ContainerDiskStat{}, err
 	}
 
-	defer runner.Wait(repquota)
+	return usageOf(quota), nil
+}
 
-	var skip uint32
+func (m *LinuxQuotaManager) MountPoint() string {
+	return m.mountPoint
+}
 
-	_, err = fmt.Fscanf(
-		repR,
-		"%d %d %d %d %d %d %d %d",
-		&skip,
-		&skip,
-		&limits.BlockSoft,
-		&limits.BlockHard,
-		&skip,
-		&skip,
-		&limits.InodeSoft,
-		&limits.InodeHard,
-	)
+func (m *LinuxQuotaManager) IsEnabled() bool {
+	return m.enabled
+}
 
-	return limits, err
+func (m *LinuxQuotaManager) Type() string {
+	return UserQuota
 }
 
-func (m *LinuxQuotaManager) GetUsage(logger lager.Logger, uid uint32) (garden.ContainerDiskStat, error) {
-	if !m.enabled {
-		return garden.ContainerDiskStat{}, nil
+func limitsOf(quota Quota) garden.DiskLimits {
+	return garden.DiskLimits{
+		BlockSoft: quota.BlockSoft,
+		BlockHard: quota.BlockHard,
+		InodeSoft: quota.InodeSoft,
+		InodeHard: quota.InodeHard,
 	}
+}
 
-	repquota := exec.Command(path.Join(m.binPath, "repquota"), m.mountPoint, fmt.Sprintf("%d", uid))
-
-	usage := garden.ContainerDiskStat{}
-
-	out := new(bytes.Buffer)
-
-	repquota.Stdout = out
-
-	runner := logging.Runner{
-		Logger:        logger,
-		CommandRunner: m.runner,
+func usageOf(quota Quota) garden.ContainerDiskStat {
+	return garden.ContainerDiskStat{
+		BytesUsed:  quota.BytesUsed,
+		InodesUsed: quota.InodesUsed,
 	}
+}
 
-	err := runner.Run(repquota)
-	if err != nil {
-		return usage, err
+// InBlocks converts any limits given in bytes to quota blocks.
+func InBlocks(limits garden.DiskLimits) garden.DiskLimits {
+	if limits.ByteSoft != 0 {
+		limits.BlockSoft = (limits.ByteSoft + QUOTA_BLOCK_SIZE - 1) / QUOTA_BLOCK_SIZE
 	}
 
-	var skip uint32
-
-	_, err = fmt.Fscanf(
-		out,
-		"%d %d %d %d %d %d %d %d",
-		&skip,
-		&usage.BytesUsed,
-		&skip,
-		&skip,
-		&skip,
-		&usage.InodesUsed,
-		&skip,
-		&skip,
-	)
-
-	return usage, err
-}
-
-func (m *LinuxQuotaManager) MountPoint() string {
-	return m.mountPoint
-}
+	if limits.ByteHard != 0 {
+		limits.BlockHard = (limits.ByteHard + QUOTA_BLOCK_SIZE - 1) / QUOTA_BLOCK_SIZE
+	}
 
-func (m *LinuxQuotaManager) IsEnabled() bool {
-	return m.enabled
+	return limits
 }
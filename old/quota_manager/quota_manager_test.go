@@ -2,7 +2,6 @@ package quota_manager_test
 
 import (
 	"errors"
-	"os/exec"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -10,19 +9,24 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
-	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
-	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager/fake_quota_manager"
 )
 
 var _ = Describe("Linux Quota manager", func() {
-	var fakeRunner *fake_command_runner.FakeCommandRunner
+	var fakeQuotactl *fake_quota_manager.FakeQuotactl
 	var logger *lagertest.TestLogger
 	var quotaManager *quota_manager.LinuxQuotaManager
 
+	userQuota := fake_quota_manager.QuotaKey{
+		MountPoint: "/some/mount/point",
+		Type:       quota_manager.UserQuotaType,
+		ID:         1234,
+	}
+
 	BeforeEach(func() {
-		fakeRunner = fake_command_runner.New()
+		fakeQuotactl = fake_quota_manager.NewQuotactl()
 		logger = lagertest.NewTestLogger("test")
-		quotaManager = quota_manager.New(fakeRunner, "/some/mount/point", "/root/path")
+		quotaManager = quota_manager.New(fakeQuotactl, "/some/mount/point")
 	})
 
 	Describe("setting quotas", func() {
@@ -34,21 +38,17 @@ var _ = Describe("Linux Quota manager", func() {
 			InodeHard: 12,
 		}
 
-		It("executes setquota on the container depo's mount point", func() {
-			err := quotaManager.SetLimits(logger, 1234, limits)
+		It("sets the user's quota on the container depo's mount point", func() {
+			err := quotaManager.SetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234}, limits)
 
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(fakeRunner).To(HaveExecutedSerially(
-				fake_command_runner.CommandSpec{
-					Path: "setquota",
-					Args: []string{
-						"-u", "1234",
-						"1", "2", "11", "12",
-						"/some/mount/point",
-					},
-				},
-			))
+			Expect(fakeQuotactl.Quotas).To(HaveKeyWithValue(userQuota, quota_manager.Quota{
+				BlockSoft: 1,
+				BlockHard: 2,
+				InodeSoft: 11,
+				InodeHard: 12,
+			}))
 		})
 
 		Context("when bytes are given", func() {
@@ -60,39 +60,29 @@ var _ = Describe("Linux Quota manager", func() {
 				ByteHard: 204801,
 			}
 
-			It("executes setquota with them converted to blocks", func() {
-				err := quotaManager.SetLimits(logger, 1234, limits)
+			It("sets the quota with them converted to blocks", func() {
+				err := quotaManager.SetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234}, limits)
 
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeRunner).To(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: "setquota",
-						Args: []string{
-							"-u", "1234",
-							"101", "201", "11", "12",
-							"/some/mount/point",
-						},
-					},
-				))
+				Expect(fakeQuotactl.Quotas).To(HaveKeyWithValue(userQuota, quota_manager.Quota{
+					BlockSoft: 101,
+					BlockHard: 201,
+					InodeSoft: 11,
+					InodeHard: 12,
+				}))
 			})
 		})
 
-		Context("when setquota fails", func() {
+		Context("when setting the quota fails", func() {
 			nastyError := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "setquota",
-					}, func(*exec.Cmd) error {
-						return nastyError
-					},
-				)
+				fakeQuotactl.SetQuotaError = nastyError
 			})
 
 			It("returns the error", func() {
-				err := quotaManager.SetLimits(logger, 1234, limits)
+				err := quotaManager.SetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234}, limits)
 				Expect(err).To(Equal(nastyError))
 			})
 		})
@@ -102,175 +92,115 @@ var _ = Describe("Linux Quota manager", func() {
 				quotaManager.Disable()
 			})
 
-			It("runs nothing", func() {
-				err := quotaManager.SetLimits(logger, 1234, limits)
+			It("sets nothing", func() {
+				err := quotaManager.SetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234}, limits)
 
 				Expect(err).ToNot(HaveOccurred())
 
-				Expect(fakeRunner).ToNot(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: "setquota",
-					},
-				))
+				Expect(fakeQuotactl.Quotas).To(BeEmpty())
 			})
 		})
 	})
 
 	Describe("getting quotas limits", func() {
-		It("executes repquota in the root path", func() {
-			fakeRunner.WhenRunning(
-				fake_command_runner.CommandSpec{
-					Path: "/root/path/repquota",
-					Args: []string{"/some/mount/point", "1234"},
-				}, func(cmd *exec.Cmd) error {
-					cmd.Stdout.Write([]byte("1234 111 222 333 444 555 666 777 888\n"))
-
-					return nil
-				},
-			)
-
-			limits, err := quotaManager.GetLimits(logger, 1234)
+		BeforeEach(func() {
+			fakeQuotactl.Quotas[userQuota] = quota_manager.Quota{
+				BlockSoft: 222,
+				BlockHard: 333,
+				BytesUsed: 111,
+
+				InodeSoft:  666,
+				InodeHard:  777,
+				InodesUsed: 555,
+			}
+		})
+
+		It("gets the user's quota on the container depo's mount point", func() {
+			limits, err := quotaManager.GetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(limits.BlockSoft).To(Equal(uint64(222)))
-			Expect(limits.BlockHard).To(Equal(uint64(333)))
+			Expect(limits).To(Equal(garden.DiskLimits{
+				BlockSoft: 222,
+				BlockHard: 333,
 
-			Expect(limits.InodeSoft).To(Equal(uint64(666)))
-			Expect(limits.InodeHard).To(Equal(uint64(777)))
+				InodeSoft: 666,
+				InodeHard: 777,
+			}))
 		})
 
-		Context("when repquota fails", func() {
+		Context("when getting the quota fails", func() {
 			disaster := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/repquota",
-						Args: []string{"/some/mount/point", "1234"},
-					}, func(cmd *exec.Cmd) error {
-						return disaster
-					},
-				)
+				fakeQuotactl.GetQuotaError = disaster
 			})
 
 			It("returns the error", func() {
-				_, err := quotaManager.GetLimits(logger, 1234)
+				_, err := quotaManager.GetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 				Expect(err).To(Equal(disaster))
 			})
 		})
 
-		Context("when the output of repquota is malformed", func() {
-			It("returns an error", func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/repquota",
-						Args: []string{"/some/mount/point", "1234"},
-					}, func(cmd *exec.Cmd) error {
-						cmd.Stdout.Write([]byte("abc\n"))
-
-						return nil
-					},
-				)
-
-				_, err := quotaManager.GetLimits(logger, 1234)
-				Expect(err).To(HaveOccurred())
-			})
-		})
-
 		Context("when quotas are disabled", func() {
 			BeforeEach(func() {
 				quotaManager.Disable()
+				fakeQuotactl.GetQuotaError = errors.New("should not be called")
 			})
 
-			It("runs nothing", func() {
-				limits, err := quotaManager.GetLimits(logger, 1234)
+			It("returns no limits", func() {
+				limits, err := quotaManager.GetLimits(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(limits).To(BeZero())
-
-				Expect(fakeRunner).ToNot(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/bin/repquota",
-					},
-				))
 			})
 		})
 	})
 
 	Describe("getting usage", func() {
-		It("executes repquota in the root path", func() {
-			fakeRunner.WhenRunning(
-				fake_command_runner.CommandSpec{
-					Path: "/root/path/repquota",
-					Args: []string{"/some/mount/point", "1234"},
-				}, func(cmd *exec.Cmd) error {
-					cmd.Stdout.Write([]byte("1234 111 222 333 444 555 666 777 888\n"))
-
-					return nil
-				},
-			)
-
-			limits, err := quotaManager.GetUsage(logger, 1234)
+		BeforeEach(func() {
+			fakeQuotactl.Quotas[userQuota] = quota_manager.Quota{
+				BlockSoft: 222,
+				BlockHard: 333,
+				BytesUsed: 111,
+
+				InodeSoft:  666,
+				InodeHard:  777,
+				InodesUsed: 555,
+			}
+		})
+
+		It("gets the user's quota on the container depo's mount point", func() {
+			usage, err := quotaManager.GetUsage(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 			Expect(err).ToNot(HaveOccurred())
 
-			Expect(limits.BytesUsed).To(Equal(uint64(111)))
-			Expect(limits.InodesUsed).To(Equal(uint64(555)))
+			Expect(usage.BytesUsed).To(Equal(uint64(111)))
+			Expect(usage.InodesUsed).To(Equal(uint64(555)))
 		})
 
-		Context("when repquota fails", func() {
+		Context("when getting the quota fails", func() {
 			disaster := errors.New("oh no!")
 
 			BeforeEach(func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/repquota",
-						Args: []string{"/some/mount/point", "1234"},
-					}, func(cmd *exec.Cmd) error {
-						return disaster
-					},
-				)
+				fakeQuotactl.GetQuotaError = disaster
 			})
 
 			It("returns the error", func() {
-				_, err := quotaManager.GetUsage(logger, 1234)
+				_, err := quotaManager.GetUsage(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 				Expect(err).To(Equal(disaster))
 			})
 		})
 
-		Context("when the output of repquota is malformed", func() {
-			It("returns an error", func() {
-				fakeRunner.WhenRunning(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/repquota",
-						Args: []string{"/some/mount/point", "1234"},
-					}, func(cmd *exec.Cmd) error {
-						cmd.Stdout.Write([]byte("abc\n"))
-
-						return nil
-					},
-				)
-
-				_, err := quotaManager.GetUsage(logger, 1234)
-				Expect(err).To(HaveOccurred())
-			})
-		})
-
 		Context("when quotas are disabled", func() {
 			BeforeEach(func() {
 				quotaManager.Disable()
+				fakeQuotactl.GetQuotaError = errors.New("should not be called")
 			})
 
-			It("runs nothing", func() {
-				usage, err := quotaManager.GetUsage(logger, 1234)
+			It("returns no usage", func() {
+				usage, err := quotaManager.GetUsage(logger, quota_manager.Subject{ID: "some-id", UID: 1234})
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(usage).To(BeZero())
-
-				Expect(fakeRunner).ToNot(HaveExecutedSerially(
-					fake_command_runner.CommandSpec{
-						Path: "/root/path/repquota",
-					},
-				))
 			})
 		})
 	})
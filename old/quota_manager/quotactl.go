@@ -0,0 +1,188 @@
+package quota_manager
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+type QuotaType int
+
+const (
+	UserQuotaType    QuotaType = 0
+	ProjectQuotaType QuotaType = 2
+)
+
+func (t QuotaType) String() string {
+	switch t {
+	case UserQuotaType:
+		return "user"
+	case ProjectQuotaType:
+		return "project"
+	default:
+		return fmt.Sprintf("type %d", int(t))
+	}
+}
+
+// Quota is a user's or project's limits and usage, in QUOTA_BLOCK_SIZE
+// blocks except for BytesUsed.
+type Quota struct {
+	BlockSoft uint64
+	BlockHard uint64
+	BytesUsed uint64
+
+	InodeSoft  uint64
+	InodeHard  uint64
+	InodesUsed uint64
+}
+
+// Quotactl reads and writes the quotas of the filesystem mounted at a mount
+// point.
+type Quotactl interface {
+	SetQuota(mountPoint string, quotaType QuotaType, id uint32, limits garden.DiskLimits) error
+	GetQuota(mountPoint string, quotaType QuotaType, id uint32) (Quota, error)
+}
+
+type QuotactlError struct {
+	Op     string
+	Device string
+	Type   QuotaType
+	ID     uint32
+	Err    error
+}
+
+func (e *QuotactlError) Error() string {
+	return fmt.Sprintf("quota_manager: %s %s quota of %d on %s: %s", e.Op, e.Type, e.ID, e.Device, e.Err)
+}
+
+const (
+	qGetQuota = 0x800007
+	qSetQuota = 0x800008
+
+	qifLimits = 1 | 4
+)
+
+// if_dqblk from linux/quota.h
+type dqblk struct {
+	bHardLimit uint64
+	bSoftLimit uint64
+	curSpace   uint64
+	iHardLimit uint64
+	iSoftLimit uint64
+	curInodes  uint64
+	bTime      uint64
+	iTime      uint64
+	valid      uint32
+}
+
+// SyscallQuotactl calls quotactl(2) directly.
+type SyscallQuotactl struct{}
+
+func (SyscallQuotactl) SetQuota(mountPoint string, quotaType QuotaType, id uint32, limits garden.DiskLimits) error {
+	device, err := mountedDevice(mountPoint)
+	if err != nil {
+		return err
+	}
+
+	quota := dqblk{
+		bHardLimit: limits.BlockHard,
+		bSoftLimit: limits.BlockSoft,
+		iHardLimit: limits.InodeHard,
+		iSoftLimit: limits.InodeSoft,
+		valid:      qifLimits,
+	}
+
+	err = quotactl(qSetQuota, quotaType, device, id, &quota)
+	if err != nil {
+		return &QuotactlError{"set", device, quotaType, id, err}
+	}
+
+	return nil
+}
+
+func (SyscallQuotactl) GetQuota(mountPoint string, quotaType QuotaType, id uint32) (Quota, error) {
+	device, err := mountedDevice(mountPoint)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	var quota dqblk
+
+	err = quotactl(qGetQuota, quotaType, device, id, &quota)
+	if err != nil {
+		return Quota{}, &QuotactlError{"get", device, quotaType, id, err}
+	}
+
+	return Quota{
+		BlockSoft: quota.bSoftLimit,
+		BlockHard: quota.bHardLimit,
+		BytesUsed: quota.curSpace,
+
+		InodeSoft:  quota.iSoftLimit,
+		InodeHard:  quota.iHardLimit,
+		InodesUsed: quota.curInodes,
+	}, nil
+}
+
+func quotactl(cmd int, quotaType QuotaType, device string, id uint32, quota *dqblk) error {
+	special, err := syscall.BytePtrFromString(device)
+	if err != nil {
+		return err
+	}
+
+	_, _, errno := syscall.Syscall6(
+		syscall.SYS_QUOTACTL,
+		uintptr(cmd<<8|int(quotaType)&0xff),
+		uintptr(unsafe.Pointer(special)),
+		uintptr(id),
+		uintptr(unsafe.Pointer(quota)),
+		0,
+		0,
+	)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// mountedDevice finds the block device mounted at a mount point, which is
+// what quotactl(2) addresses filesystems by.
+func mountedDevice(mountPoint string) (string, error) {
+	mounts, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", err
+	}
+
+	defer mounts.Close()
+
+	device := ""
+
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		// the last mount over a mount point is the one in effect
+		if fields[1] == mountPoint {
+			device = fields[0]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if device == "" {
+		return "", fmt.Errorf("quota_manager: nothing is mounted at %s", mountPoint)
+	}
+
+	return device, nil
+}
@@ -0,0 +1,41 @@
+package quota_manager_test
+
+import (
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-linux/old/quota_manager"
+)
+
+var _ = Describe("Quotactl", func() {
+	var quotactl quota_manager.SyscallQuotactl
+
+	Context("when nothing is mounted at the mount point", func() {
+		It("fails to get quotas", func() {
+			_, err := quotactl.GetQuota("/some/unmounted/path", quota_manager.UserQuotaType, 1234)
+			Expect(err).To(MatchError("quota_manager: nothing is mounted at /some/unmounted/path"))
+		})
+
+		It("fails to set quotas", func() {
+			err := quotactl.SetQuota("/some/unmounted/path", quota_manager.UserQuotaType, 1234, garden.DiskLimits{})
+			Expect(err).To(MatchError("quota_manager: nothing is mounted at /some/unmounted/path"))
+		})
+	})
+
+	Describe("QuotactlError", func() {
+		It("describes the failed call", func() {
+			err := &quota_manager.QuotactlError{
+				Op:     "get",
+				Device: "/dev/sda1",
+				Type:   quota_manager.ProjectQuotaType,
+				ID:     1234,
+				Err:    syscall.ESRCH,
+			}
+
+			Expect(err).To(MatchError("quota_manager: get project quota of 1234 on /dev/sda1: no such process"))
+		})
+	})
+})
@@ -0,0 +1,340 @@
+package repository_fetcher
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden-linux/process"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/pivotal-golang/lager"
+)
+
+// ArchiveFetcher loads images from `docker save` tarballs on the local
+// filesystem, for hosts that cannot reach a registry. The URL path names the
+// tarball; an optional fragment selects a tag from the tarball's
+// repositories file when it holds more than one image.
+//
+// Images committed from containers built this way are handled by the
+// wrapped RepositoryFetcher, which must share the same graph.
+type ArchiveFetcher struct {
+	RepositoryFetcher
+
+	graph Graph
+
+	// tarballs are unpacked here, which should be on the graph's filesystem
+	scratchDir string
+
+	loaded  map[string]loadedArchive
+	loading map[string]*sync.Mutex // held while loading the archive with that key
+	mutex   *sync.Mutex            // guards loaded and loading
+}
+
+type loadedArchive struct {
+	modTime time.Time
+	size    int64
+	imageID string
+}
+
+// savedArchive is the unpacked content of a docker save tarball.
+type savedArchive struct {
+	dir          string
+	images       map[string]*image.Image
+	repositories map[string]map[string]string
+}
+
+func NewArchive(graph Graph, scratchDir string, committer RepositoryFetcher) RepositoryFetcher {
+	return &ArchiveFetcher{
+		RepositoryFetcher: committer,
+		graph:             graph,
+		scratchDir:        scratchDir,
+		loaded:            map[string]loadedArchive{},
+		loading:           map[string]*sync.Mutex{},
+		mutex:             new(sync.Mutex),
+	}
+}
+
+func archiveError(archivePath string, err error) error {
+	return fmt.Errorf("repository_fetcher: could not load image archive %s: %s", archivePath, err)
+}
+
+func (fetcher *ArchiveFetcher) Fetch(
+	logger lager.Logger,
+	archiveURL *url.URL,
+	tag string,
+) (string, process.Env, []string, error) {
+	archivePath := archiveURL.Path
+	selected := archiveURL.Fragment
+
+	fLog := logger.Session("fetch-archive", lager.Data{
+		"archive": archivePath,
+		"tag":     selected,
+	})
+
+	fLog.Debug("fetching")
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return "", nil, nil, archiveError(archivePath, err)
+	}
+
+	cacheKey := archivePath + "#" + selected
+
+	archiveMutex := fetcher.archiveMutex(cacheKey)
+	archiveMutex.Lock()
+	defer archiveMutex.Unlock()
+
+	imgID, found := fetcher.cached(cacheKey, info)
+	if !found {
+		imgID, err = fetcher.load(fLog, archivePath, selected)
+		if err != nil {
+			return "", nil, nil, archiveError(archivePath, err)
+		}
+
+		fetcher.mutex.Lock()
+		fetcher.loaded[cacheKey] = loadedArchive{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			imageID: imgID,
+		}
+		fetcher.mutex.Unlock()
+	}
+
+	img, err := localImage(fLog, fetcher.graph, imgID)
+	if err != nil {
+		return "", nil, nil, archiveError(archivePath, err)
+	}
+
+	fLog.Debug("fetched", lager.Data{
+		"image":   imgID,
+		"env":     img.Env(),
+		"volumes": img.Vols(),
+	})
+
+	return imgID, img.Env(), img.Vols(), nil
+}
+
+// archiveMutex returns the mutex serialising loads of one archive, so that
+// loading an archive does not hold up fetches of any other.
+func (fetcher *ArchiveFetcher) archiveMutex(cacheKey string) *sync.Mutex {
+	fetcher.mutex.Lock()
+	defer fetcher.mutex.Unlock()
+
+	archiveMutex, found := fetcher.loading[cacheKey]
+	if !found {
+		archiveMutex = new(sync.Mutex)
+		fetcher.loading[cacheKey] = archiveMutex
+	}
+
+	return archiveMutex
+}
+
+// cached returns the image loaded for the archive with this key, unless the
+// archive has changed since or the image has left the graph.
+func (fetcher *ArchiveFetcher) cached(cacheKey string, info os.FileInfo) (string, bool) {
+	fetcher.mutex.Lock()
+	loaded, found := fetcher.loaded[cacheKey]
+	fetcher.mutex.Unlock()
+
+	if !found ||
+		!loaded.modTime.Equal(info.ModTime()) ||
+		loaded.size != info.Size() ||
+		!fetcher.graph.Exists(loaded.imageID) {
+		return "", false
+	}
+
+	return loaded.imageID, true
+}
+
+func (fetcher *ArchiveFetcher) load(logger lager.Logger, archivePath, tag string) (string, error) {
+	if err := os.MkdirAll(fetcher.scratchDir, 0755); err != nil {
+		return "", err
+	}
+
+	tmpDir, err := ioutil.TempDir(fetcher.scratchDir, "archive")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	saved, err := unpackArchive(archivePath, tmpDir)
+	if err != nil {
+		return "", err
+	}
+
+	imgID, err := saved.top(tag)
+	if err != nil {
+		return "", err
+	}
+
+	// register from the base layer up, stopping at the first layer the
+	// graph already has
+	var pending []*image.Image
+	for id := imgID; id != "" && !fetcher.graph.Exists(id); {
+		img, found := saved.images[id]
+		if !found {
+			return "", fmt.Errorf("layer %s is neither in the archive nor in the graph", id)
+		}
+
+		pending = append([]*image.Image{img}, pending...)
+		id = img.Parent
+	}
+
+	for _, img := range pending {
+		if err := saved.register(fetcher.graph, img); err != nil {
+			return "", fmt.Errorf("register layer %s: %s", img.ID, err)
+		}
+
+		logger.Debug("registered", lager.Data{
+			"layer": img.ID,
+		})
+	}
+
+	return imgID, nil
+}
+
+// unpackArchive reads the image json and repositories files into memory,
+// and writes each layer out to dir so that layers can be registered in
+// parent order regardless of their order in the tarball.
+func unpackArchive(archivePath, dir string) (*savedArchive, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stream, err := archive.DecompressStream(file)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	saved := &savedArchive{
+		dir:          dir,
+		images:       map[string]*image.Image{},
+		repositories: map[string]map[string]string{},
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(path.Clean(hdr.Name), "./")
+
+		if name == "repositories" {
+			if err := json.NewDecoder(tr).Decode(&saved.repositories); err != nil {
+				return nil, fmt.Errorf("parse repositories: %s", err)
+			}
+
+			continue
+		}
+
+		id, entry := path.Split(name)
+		id = strings.TrimSuffix(id, "/")
+		if id == "" || strings.Contains(id, "/") || id == ".." {
+			continue
+		}
+
+		switch entry {
+		case "json":
+			imgJSON, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+
+			img, err := image.NewImgJSON(imgJSON)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %s", name, err)
+			}
+
+			if img.ID != id {
+				return nil, fmt.Errorf("%s describes image %s", name, img.ID)
+			}
+
+			saved.images[id] = img
+
+		case "layer.tar":
+			layer, err := os.Create(saved.layerPath(id))
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = io.Copy(layer, tr)
+			layer.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return saved, nil
+}
+
+func (saved *savedArchive) layerPath(id string) string {
+	return filepath.Join(saved.dir, id+".tar")
+}
+
+// top returns the image the archive was saved for: the one carrying the
+// given tag if one was requested, or else the only image that no other
+// image in the archive builds on.
+func (saved *savedArchive) top(tag string) (string, error) {
+	if tag != "" {
+		var matches []string
+		for _, tags := range saved.repositories {
+			if id, found := tags[tag]; found {
+				matches = append(matches, id)
+			}
+		}
+
+		if len(matches) != 1 {
+			return "", fmt.Errorf("tag %s matches %d images", tag, len(matches))
+		}
+
+		return matches[0], nil
+	}
+
+	parents := map[string]bool{}
+	for _, img := range saved.images {
+		parents[img.Parent] = true
+	}
+
+	var leaves []string
+	for id := range saved.images {
+		if !parents[id] {
+			leaves = append(leaves, id)
+		}
+	}
+
+	if len(leaves) != 1 {
+		return "", fmt.Errorf("archive holds %d images; select one with a tag fragment", len(leaves))
+	}
+
+	return leaves[0], nil
+}
+
+func (saved *savedArchive) register(graph Graph, img *image.Image) error {
+	layer, err := os.Open(saved.layerPath(img.ID))
+	if err != nil {
+		return err
+	}
+	defer layer.Close()
+
+	return graph.Register(img, layer)
+}
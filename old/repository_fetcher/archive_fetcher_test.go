@@ -0,0 +1,300 @@
+package repository_fetcher_test
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden-linux/container_pool/fake_graph"
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher/fake_repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden-linux/process"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ArchiveFetcher", func() {
+	var (
+		graph     *fake_graph.FakeGraph
+		committer *fake_repository_fetcher.FakeRepositoryFetcher
+		fetcher   RepositoryFetcher
+		logger    *lagertest.TestLogger
+
+		tmpDir      string
+		scratchDir  string
+		archivePath string
+
+		registered []string
+		layers     map[string]string
+	)
+
+	writeArchiveAt := func(archivePath string, entries map[string]string) {
+		file, err := os.Create(archivePath)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		tw := tar.NewWriter(file)
+		for name, contents := range entries {
+			Expect(tw.WriteHeader(&tar.Header{
+				Name: name,
+				Mode: 0644,
+				Size: int64(len(contents)),
+			})).To(Succeed())
+
+			_, err := tw.Write([]byte(contents))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+	}
+
+	writeArchive := func(entries map[string]string) {
+		writeArchiveAt(archivePath, entries)
+	}
+
+	archiveURL := func(fragment string) *url.URL {
+		return &url.URL{Scheme: "docker-archive", Path: archivePath, Fragment: fragment}
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "archive-fetcher")
+		Expect(err).ToNot(HaveOccurred())
+
+		archivePath = filepath.Join(tmpDir, "image.tar")
+		scratchDir = filepath.Join(tmpDir, "graph", "archives")
+
+		graph = fake_graph.New()
+		committer = fake_repository_fetcher.New()
+		fetcher = NewArchive(graph, scratchDir, committer)
+		logger = lagertest.NewTestLogger("test")
+
+		registered = nil
+		layers = map[string]string{}
+
+		graph.WhenRegistering = func(img *image.Image, layer archive.ArchiveReader) error {
+			data, err := ioutil.ReadAll(layer)
+			Expect(err).ToNot(HaveOccurred())
+
+			imgJSON, err := json.Marshal(img)
+			Expect(err).ToNot(HaveOccurred())
+
+			registered = append(registered, img.ID)
+			layers[img.ID] = string(data)
+			graph.SetExists(img.ID, imgJSON)
+
+			return nil
+		}
+
+		writeArchive(map[string]string{
+			"layer-1/VERSION":   "1.0",
+			"layer-1/json":      `{"id":"layer-1","config":{"env":["env1=env1Value"]}}`,
+			"layer-1/layer.tar": "layer-1-data",
+			"layer-2/VERSION":   "1.0",
+			"layer-2/json":      `{"id":"layer-2","parent":"layer-1","config":{"env":["env2=env2Value"],"volumes":{"/tmp":{}}}}`,
+			"layer-2/layer.tar": "layer-2-data",
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Fetch", func() {
+		It("registers every layer in parent order and returns the top image", func() {
+			imageID, env, volumes, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(imageID).To(Equal("layer-2"))
+			Expect(env).To(Equal(process.Env{"env1": "env1Value", "env2": "env2Value"}))
+			Expect(volumes).To(Equal([]string{"/tmp"}))
+
+			Expect(registered).To(Equal([]string{"layer-1", "layer-2"}))
+			Expect(layers).To(Equal(map[string]string{
+				"layer-1": "layer-1-data",
+				"layer-2": "layer-2-data",
+			}))
+		})
+
+		It("unpacks the archive under the scratch directory, and cleans up after", func() {
+			registerLayer := graph.WhenRegistering
+
+			var unpacked []os.FileInfo
+			graph.WhenRegistering = func(img *image.Image, layer archive.ArchiveReader) error {
+				var err error
+				unpacked, err = ioutil.ReadDir(scratchDir)
+				Expect(err).ToNot(HaveOccurred())
+
+				return registerLayer(img, layer)
+			}
+
+			_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(unpacked).To(HaveLen(1))
+			Expect(ioutil.ReadDir(scratchDir)).To(BeEmpty())
+		})
+
+		Context("while another archive is loading", func() {
+			var otherArchivePath string
+			var otherLoading, releaseOther chan struct{}
+
+			BeforeEach(func() {
+				otherArchivePath = filepath.Join(tmpDir, "other.tar")
+				writeArchiveAt(otherArchivePath, map[string]string{
+					"other-layer/json":      `{"id":"other-layer"}`,
+					"other-layer/layer.tar": "other-layer-data",
+				})
+
+				otherLoading = make(chan struct{})
+				releaseOther = make(chan struct{})
+
+				registerLayer := graph.WhenRegistering
+				graph.WhenRegistering = func(img *image.Image, layer archive.ArchiveReader) error {
+					if img.ID == "other-layer" {
+						close(otherLoading)
+						<-releaseOther
+					}
+
+					return registerLayer(img, layer)
+				}
+			})
+
+			It("does not wait for it", func() {
+				otherFetched := make(chan error)
+				go func() {
+					defer GinkgoRecover()
+
+					_, _, _, err := fetcher.Fetch(logger, &url.URL{Scheme: "docker-archive", Path: otherArchivePath}, "latest")
+					otherFetched <- err
+				}()
+
+				Eventually(otherLoading).Should(BeClosed())
+
+				imageID, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(imageID).To(Equal("layer-2"))
+
+				close(releaseOther)
+				Eventually(otherFetched).Should(Receive(BeNil()))
+			})
+		})
+
+		Context("when some layers are already in the graph", func() {
+			BeforeEach(func() {
+				graph.SetExists("layer-1", []byte(`{"id":"layer-1"}`))
+			})
+
+			It("only registers the missing layers", func() {
+				_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(registered).To(Equal([]string{"layer-2"}))
+			})
+		})
+
+		Context("when the archive has already been loaded", func() {
+			BeforeEach(func() {
+				_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+				Expect(err).ToNot(HaveOccurred())
+
+				registered = nil
+			})
+
+			It("uses the cached image", func() {
+				imageID, env, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(imageID).To(Equal("layer-2"))
+				Expect(env).To(HaveKeyWithValue("env1", "env1Value"))
+				Expect(registered).To(BeEmpty())
+			})
+
+			Context("and the image has since been removed from the graph", func() {
+				BeforeEach(func() {
+					Expect(graph.Delete("layer-2")).To(Succeed())
+				})
+
+				It("loads the archive again", func() {
+					_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(registered).To(Equal([]string{"layer-2"}))
+				})
+			})
+		})
+
+		Context("when the archive holds several images", func() {
+			BeforeEach(func() {
+				writeArchive(map[string]string{
+					"repositories":      `{"some-repo":{"v1":"layer-1","v2":"layer-2"}}`,
+					"layer-1/json":      `{"id":"layer-1"}`,
+					"layer-1/layer.tar": "layer-1-data",
+					"layer-2/json":      `{"id":"layer-2"}`,
+					"layer-2/layer.tar": "layer-2-data",
+				})
+			})
+
+			It("loads the image with the tag given in the fragment", func() {
+				imageID, _, _, err := fetcher.Fetch(logger, archiveURL("v1"), "v1")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(imageID).To(Equal("layer-1"))
+				Expect(registered).To(Equal([]string{"layer-1"}))
+			})
+
+			Context("and no tag is given", func() {
+				It("returns an error", func() {
+					_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("and the tag is unknown", func() {
+				It("returns an error", func() {
+					_, _, _, err := fetcher.Fetch(logger, archiveURL("v3"), "v3")
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when a parent layer is missing from the archive and the graph", func() {
+			BeforeEach(func() {
+				writeArchive(map[string]string{
+					"layer-2/json":      `{"id":"layer-2","parent":"layer-1"}`,
+					"layer-2/layer.tar": "layer-2-data",
+				})
+			})
+
+			It("returns an error without registering anything", func() {
+				_, _, _, err := fetcher.Fetch(logger, archiveURL(""), "latest")
+				Expect(err).To(HaveOccurred())
+
+				Expect(registered).To(BeEmpty())
+			})
+		})
+
+		Context("when the archive does not exist", func() {
+			It("returns an error", func() {
+				_, _, _, err := fetcher.Fetch(logger, &url.URL{Path: "/no/such/image.tar"}, "latest")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("FetcherCommitRootFS", func() {
+		It("delegates to the wrapped fetcher", func() {
+			committer.CommitResultID = "some-committed-image"
+
+			imageID, err := fetcher.FetcherCommitRootFS(logger, "some-id", "layer-2", "some-repo", "some-tag", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageID).To(Equal("some-committed-image"))
+		})
+	})
+})
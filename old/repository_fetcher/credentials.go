@@ -0,0 +1,85 @@
+package repository_fetcher
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/docker/docker/registry"
+)
+
+// LoadAuthConfigs reads registry credentials keyed by registry hostname from
+// a file in the format of docker's ~/.dockercfg. Hostnames are matched as
+// docker matches them, so keys may be bare hostnames or full index URLs.
+func LoadAuthConfigs(path string) (*registry.ConfigFile, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("repository_fetcher: read registry credentials: %s", err)
+	}
+
+	configs := map[string]registry.AuthConfig{}
+	if err := json.Unmarshal(contents, &configs); err != nil {
+		return nil, fmt.Errorf("repository_fetcher: parse registry credentials %s: %s", path, err)
+	}
+
+	for hostname, config := range configs {
+		if config.Auth == "" {
+			continue
+		}
+
+		config.Username, config.Password, err = decodeAuth(config.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("repository_fetcher: parse registry credentials for %s: %s", hostname, err)
+		}
+
+		config.Auth = ""
+		config.ServerAddress = hostname
+		configs[hostname] = config
+	}
+
+	return &registry.ConfigFile{Configs: configs}, nil
+}
+
+func decodeAuth(auth string) (string, string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(auth)
+	if err != nil {
+		return "", "", err
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("auth is not of the form username:password")
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// authFromURL returns the credentials given in the userinfo of an image URL,
+// or nil if there are none.
+func authFromURL(u *url.URL) *registry.AuthConfig {
+	if u.User == nil || u.User.Username() == "" {
+		return nil
+	}
+
+	password, _ := u.User.Password()
+
+	return &registry.AuthConfig{
+		Username: u.User.Username(),
+		Password: password,
+	}
+}
+
+// redactURL renders an image URL for logging, without its password.
+func redactURL(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	redacted := *u
+	redacted.User = url.User(u.User.Username())
+
+	return redacted.String()
+}
@@ -0,0 +1,76 @@
+package repository_fetcher_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoadAuthConfigs", func() {
+	var (
+		configDir  string
+		configPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		configDir, err = ioutil.TempDir("", "registry-credentials")
+		Expect(err).ToNot(HaveOccurred())
+
+		configPath = filepath.Join(configDir, "dockercfg")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(configDir)
+	})
+
+	It("decodes credentials in the dockercfg format", func() {
+		Expect(ioutil.WriteFile(configPath, []byte(`{
+			"https://index.docker.io/v1/": {"auth": "aW5kZXgtdXNlcjppbmRleC1wYXNzd29yZA==", "email": "some@example.com"},
+			"some-registry:5000": {"auth": "c29tZS11c2VyOnNvbWU6cGFzc3dvcmQ="}
+		}`), 0600)).To(Succeed())
+
+		configs, err := LoadAuthConfigs(configPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		index := configs.ResolveAuthConfig("https://index.docker.io/v1/")
+		Expect(index.Username).To(Equal("index-user"))
+		Expect(index.Password).To(Equal("index-password"))
+		Expect(index.Email).To(Equal("some@example.com"))
+
+		private := configs.ResolveAuthConfig("some-registry:5000")
+		Expect(private.Username).To(Equal("some-user"))
+		Expect(private.Password).To(Equal("some:password"))
+	})
+
+	It("matches hostnames against keys given as urls", func() {
+		Expect(ioutil.WriteFile(configPath, []byte(`{
+			"https://some-registry:5000/v1/": {"auth": "c29tZS11c2VyOnNvbWUtcGFzc3dvcmQ="}
+		}`), 0600)).To(Succeed())
+
+		configs, err := LoadAuthConfigs(configPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(configs.ResolveAuthConfig("some-registry:5000").Username).To(Equal("some-user"))
+	})
+
+	Context("when an auth string is malformed", func() {
+		It("returns an error", func() {
+			Expect(ioutil.WriteFile(configPath, []byte(`{"some-registry": {"auth": "bm8tY29sb24="}}`), 0600)).To(Succeed())
+
+			_, err := LoadAuthConfigs(configPath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when the file does not exist", func() {
+		It("returns an error", func() {
+			_, err := LoadAuthConfigs(filepath.Join(configDir, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,279 @@
+package repository_fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// Partial downloads untouched for this long are assumed to be abandoned,
+// and are removed when a Downloader is constructed.
+var PartialDownloadTTL = 24 * time.Hour
+
+const partialSuffix = ".partial"
+
+// BlobSource opens a layer's data from offset onwards, returning the offset
+// it is actually served from, which is 0 if the source cannot resume.
+type BlobSource func(offset int64) (blob io.ReadCloser, servedFrom int64, err error)
+
+// Downloader downloads layers to disk before they are registered in the
+// graph, at most a given number at a time. Downloads which fail part way
+// through are kept, so that retrying the fetch resumes them, unless the
+// source then refuses to resume them.
+type Downloader struct {
+	path     string
+	slots    chan struct{}
+	progress *Progress
+}
+
+func NewDownloader(path string, maxConcurrent int, progress *Progress) (*Downloader, error) {
+	if maxConcurrent < 1 {
+		return nil, fmt.Errorf("repository_fetcher: invalid maximum concurrent downloads: %d", maxConcurrent)
+	}
+
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("repository_fetcher: create downloads directory: %s", err)
+	}
+
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("repository_fetcher: read downloads directory: %s", err)
+	}
+
+	for _, entry := range entries {
+		if time.Since(entry.ModTime()) > PartialDownloadTTL {
+			os.Remove(filepath.Join(path, entry.Name()))
+		}
+	}
+
+	return &Downloader{
+		path:     path,
+		slots:    make(chan struct{}, maxConcurrent),
+		progress: progress,
+	}, nil
+}
+
+// Download fetches the data of layerID from source into a file, which is
+// returned open at the start. The caller removes the file once done with
+// it. When digest is given, the data is verified against it, and a partial
+// download is resumed; otherwise the layer is downloaded afresh.
+func (downloader *Downloader) Download(logger lager.Logger, key, layerID, digest string, size int64, source BlobSource) (*os.File, error) {
+	if digest != "" && !strings.HasPrefix(digest, digestAlgorithm) {
+		return nil, fmt.Errorf("unsupported digest: %s", digest)
+	}
+
+	dLog := logger.Session("download", lager.Data{
+		"layer":  layerID,
+		"digest": digest,
+	})
+
+	file, err := os.OpenFile(filepath.Join(downloader.path, layerID+partialSuffix), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open download: %s", err)
+	}
+
+	file, err = downloader.download(dLog, key, layerID, digest, size, source, file)
+	if err != nil {
+		dLog.Error("failed", err)
+	}
+
+	return file, err
+}
+
+// start runs download in the background once fewer than the maximum number
+// of downloads are running, blocking until then, so that downloads start in
+// the order they are given.
+func (downloader *Downloader) start(download func()) {
+	downloader.slots <- struct{}{}
+
+	go func() {
+		defer func() { <-downloader.slots }()
+		download()
+	}()
+}
+
+func (downloader *Downloader) download(logger lager.Logger, key, layerID, digest string, size int64, source BlobSource, file *os.File) (*os.File, error) {
+	hash := sha256.New()
+
+	var offset int64
+	if digest == "" {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return nil, err
+		}
+	} else {
+		var err error
+		offset, err = io.Copy(hash, file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if offset > 0 && verifyDigest(hash, digest) == nil {
+			logger.Info("already-downloaded")
+			return rewind(file)
+		}
+	}
+
+	blob, servedFrom, err := source(offset)
+	if err != nil && offset > 0 {
+		// the source may refuse the range itself, e.g. if the partial
+		// download is longer than the blob, so the partial is discarded
+		// rather than kept to fail the same way on every retry
+		logger.Info("discarding-partial", lager.Data{
+			"offset": offset,
+			"error":  err.Error(),
+		})
+
+		if err := discard(file, hash); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+
+		offset = 0
+
+		blob, servedFrom, err = source(0)
+		if err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		downloader.closeIncomplete(file, digest)
+		return nil, err
+	}
+	defer blob.Close()
+
+	if servedFrom != offset {
+		offset = 0
+
+		if err := discard(file, hash); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	if offset > 0 {
+		downloader.progress.report(key, "resuming layer %s from %s", shortID(layerID), humanSize(offset))
+	} else if size > 0 {
+		downloader.progress.report(key, "fetching layer %s (%s)", shortID(layerID), humanSize(size))
+	} else {
+		downloader.progress.report(key, "fetching layer %s", shortID(layerID))
+	}
+
+	started := time.Now()
+
+	counter := &progressLogger{
+		logger:     logger,
+		downloaded: offset,
+		size:       size,
+	}
+
+	downloaded, err := io.Copy(io.MultiWriter(file, hash, counter), blob)
+	if err != nil {
+		logger.Info("interrupted", lager.Data{
+			"downloaded": offset + downloaded,
+		})
+
+		downloader.closeIncomplete(file, digest)
+		return nil, err
+	}
+
+	if digest != "" {
+		if err := verifyDigest(hash, digest); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+	}
+
+	took := time.Since(started)
+
+	downloader.progress.report(key, "fetched layer %s (%s) in %s", shortID(layerID), humanSize(offset+downloaded), took)
+
+	logger.Info("downloaded", lager.Data{
+		"size": offset + downloaded,
+		"took": took,
+	})
+
+	return rewind(file)
+}
+
+// closeIncomplete keeps a partial download to be resumed if its data can be
+// verified once complete, and otherwise removes it.
+func (downloader *Downloader) closeIncomplete(file *os.File, digest string) {
+	file.Close()
+
+	if digest == "" {
+		os.Remove(file.Name())
+	}
+}
+
+// discard empties a partial download and the hash of its data.
+func discard(file *os.File, hash hash.Hash) error {
+	hash.Reset()
+
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return file.Truncate(0)
+}
+
+func rewind(file *os.File) (*os.File, error) {
+	if _, err := file.Seek(0, 0); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func verifyDigest(hash hash.Hash, digest string) error {
+	if !strings.HasPrefix(digest, digestAlgorithm) {
+		return fmt.Errorf("unsupported digest: %s", digest)
+	}
+
+	actual := digestAlgorithm + hex.EncodeToString(hash.Sum(nil))
+	if actual != digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", digest, actual)
+	}
+
+	return nil
+}
+
+// progressLogger logs the progress of a download every tenth of the way.
+type progressLogger struct {
+	logger     lager.Logger
+	downloaded int64
+	size       int64
+	logged     int64
+}
+
+func (p *progressLogger) Write(data []byte) (int, error) {
+	p.downloaded += int64(len(data))
+
+	if p.size > 0 {
+		if tenths := p.downloaded * 10 / p.size; tenths > p.logged {
+			p.logged = tenths
+			p.logger.Debug("progress", lager.Data{
+				"downloaded": p.downloaded,
+				"size":       p.size,
+			})
+		}
+	}
+
+	return len(data), nil
+}
@@ -0,0 +1,283 @@
+package repository_fetcher_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Downloader", func() {
+	var (
+		downloadsDir string
+		progress     *Progress
+		downloader   *Downloader
+		logger       *lagertest.TestLogger
+
+		content []byte
+		digest  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		downloadsDir, err = ioutil.TempDir("", "downloads")
+		Expect(err).ToNot(HaveOccurred())
+
+		progress = NewProgress()
+
+		downloader, err = NewDownloader(downloadsDir, 2, progress)
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+
+		content = []byte("some-layer-data")
+		sum := sha256.Sum256(content)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(downloadsDir)
+	})
+
+	serve := func(data []byte, resumable bool) (BlobSource, *[]int64) {
+		offsets := []int64{}
+
+		return func(offset int64) (io.ReadCloser, int64, error) {
+			offsets = append(offsets, offset)
+
+			if !resumable {
+				offset = 0
+			}
+
+			return ioutil.NopCloser(bytes.NewReader(data[offset:])), offset, nil
+		}, &offsets
+	}
+
+	It("downloads the layer into a file", func() {
+		source, _ := serve(content, true)
+
+		file, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		Expect(ioutil.ReadAll(file)).To(Equal(content))
+	})
+
+	Context("when a partial download exists", func() {
+		BeforeEach(func() {
+			partial := filepath.Join(downloadsDir, "some-layer.partial")
+			Expect(ioutil.WriteFile(partial, content[:5], 0644)).To(Succeed())
+		})
+
+		It("resumes it", func() {
+			source, offsets := serve(content, true)
+
+			file, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			Expect(*offsets).To(Equal([]int64{5}))
+			Expect(ioutil.ReadAll(file)).To(Equal(content))
+		})
+
+		Context("and the source cannot resume", func() {
+			It("downloads it afresh", func() {
+				source, offsets := serve(content, false)
+
+				file, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+				Expect(err).ToNot(HaveOccurred())
+				defer file.Close()
+
+				Expect(*offsets).To(Equal([]int64{5}))
+				Expect(ioutil.ReadAll(file)).To(Equal(content))
+			})
+		})
+
+		Context("and the source refuses to serve from its end", func() {
+			It("discards it and downloads the layer afresh", func() {
+				resumable, _ := serve(content, true)
+
+				offsets := []int64{}
+				source := func(offset int64) (io.ReadCloser, int64, error) {
+					offsets = append(offsets, offset)
+
+					if offset > 0 {
+						return nil, 0, errors.New("416 Requested Range Not Satisfiable")
+					}
+
+					return resumable(offset)
+				}
+
+				file, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+				Expect(err).ToNot(HaveOccurred())
+				defer file.Close()
+
+				Expect(offsets).To(Equal([]int64{5, 0}))
+				Expect(ioutil.ReadAll(file)).To(Equal(content))
+			})
+
+			It("does not keep it when downloading afresh fails too", func() {
+				source := func(offset int64) (io.ReadCloser, int64, error) {
+					return nil, 0, errors.New("416 Requested Range Not Satisfiable")
+				}
+
+				_, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+				Expect(err).To(HaveOccurred())
+
+				_, err = os.Stat(filepath.Join(downloadsDir, "some-layer.partial"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+			})
+		})
+
+		Context("and there is no digest to verify it against", func() {
+			It("downloads it afresh", func() {
+				source, offsets := serve(content, true)
+
+				file, err := downloader.Download(logger, "some-key", "some-layer", "", int64(len(content)), source)
+				Expect(err).ToNot(HaveOccurred())
+				defer file.Close()
+
+				Expect(*offsets).To(Equal([]int64{0}))
+				Expect(ioutil.ReadAll(file)).To(Equal(content))
+			})
+		})
+	})
+
+	Context("when the download is interrupted", func() {
+		var source BlobSource
+
+		BeforeEach(func() {
+			source = func(offset int64) (io.ReadCloser, int64, error) {
+				return ioutil.NopCloser(io.MultiReader(
+					bytes.NewReader(content[:5]),
+					errReader{errors.New("connection reset")},
+				)), 0, nil
+			}
+		})
+
+		It("keeps the partial download, to be resumed", func() {
+			_, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+			Expect(err).To(MatchError("connection reset"))
+
+			partial, err := ioutil.ReadFile(filepath.Join(downloadsDir, "some-layer.partial"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(partial).To(Equal(content[:5]))
+
+			resume, offsets := serve(content, true)
+
+			file, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), resume)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			Expect(*offsets).To(Equal([]int64{5}))
+			Expect(ioutil.ReadAll(file)).To(Equal(content))
+		})
+	})
+
+	Context("when the data does not match the digest", func() {
+		It("returns an error and discards the download", func() {
+			source, _ := serve([]byte("tampered"), true)
+
+			_, err := downloader.Download(logger, "some-key", "some-layer", digest, int64(len(content)), source)
+			Expect(err).To(MatchError(ContainSubstring("blob digest mismatch")))
+
+			_, err = os.Stat(filepath.Join(downloadsDir, "some-layer.partial"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	It("reports progress to the containers watching the fetch", func() {
+		progress.Watch("some-container", parseURL("docker://some-registry/some-repo"), "some-tag")
+
+		source, _ := serve(content, true)
+
+		file, err := downloader.Download(logger, "some-registry/some-repo:some-tag", "some-layer-id-which-is-long", digest, int64(len(content)), source)
+		Expect(err).ToNot(HaveOccurred())
+		file.Close()
+
+		events := progress.Events("some-container")
+		Expect(events).To(HaveLen(2))
+		Expect(events[0]).To(Equal("fetching layer some-layer-i (15 B)"))
+		Expect(events[1]).To(HavePrefix("fetched layer some-layer-i (15 B) in "))
+	})
+
+	It("lets the progress be followed while the fetch is in flight", func() {
+		progress.Watch("some-container", parseURL("docker://some-registry/some-repo"), "some-tag")
+
+		var inFlight []string
+		source := func(offset int64) (io.ReadCloser, int64, error) {
+			return ioutil.NopCloser(io.MultiReader(
+				bytes.NewReader(content[:5]),
+				funcReader(func() { inFlight = progress.Pending("some-container") }),
+				bytes.NewReader(content[5:]),
+			)), 0, nil
+		}
+
+		file, err := downloader.Download(logger, "some-registry/some-repo:some-tag", "some-layer-id-which-is-long", digest, int64(len(content)), source)
+		Expect(err).ToNot(HaveOccurred())
+		file.Close()
+
+		Expect(inFlight).To(Equal([]string{"fetching layer some-layer-i (15 B)"}))
+		Expect(progress.Events("some-container")).To(HaveLen(2))
+	})
+
+	Describe("partial downloads left behind", func() {
+		var stale, fresh string
+
+		BeforeEach(func() {
+			stale = filepath.Join(downloadsDir, "stale.partial")
+			fresh = filepath.Join(downloadsDir, "fresh.partial")
+
+			Expect(ioutil.WriteFile(stale, []byte("old"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(fresh, []byte("new"), 0644)).To(Succeed())
+
+			old := time.Now().Add(-PartialDownloadTTL - time.Hour)
+			Expect(os.Chtimes(stale, old, old)).To(Succeed())
+		})
+
+		It("are removed once abandoned", func() {
+			_, err := NewDownloader(downloadsDir, 1, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = os.Stat(stale)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			_, err = os.Stat(fresh)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("with an invalid maximum number of concurrent downloads", func() {
+		It("returns an error", func() {
+			_, err := NewDownloader(downloadsDir, 0, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// funcReader calls a function when it is read, and reads nothing.
+type funcReader func()
+
+func (r funcReader) Read([]byte) (int, error) {
+	r()
+	return 0, io.EOF
+}
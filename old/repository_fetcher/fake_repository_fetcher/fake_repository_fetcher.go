@@ -1,10 +1,12 @@
 package fake_repository_fetcher
 
 import (
+	"io"
 	"net/url"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden-linux/process"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -13,9 +15,22 @@ type FakeRepositoryFetcher struct {
 	FetchResult string
 	FetchError  error
 
+	committed      []CommitSpec
+	CommitResult   io.ReadCloser
+	CommitResultID string
+	CommitError    error
+
 	mutex *sync.RWMutex
 }
 
+type CommitSpec struct {
+	ID      string
+	ImageID string
+	Dest    string
+	Repo    string
+	Tag     string
+}
+
 type FetchSpec struct {
 	Repository string
 	Tag        string
@@ -46,3 +61,46 @@ func (fetcher *FakeRepositoryFetcher) Fetched() []FetchSpec {
 
 	return fetcher.fetched
 }
+
+func (fetcher *FakeRepositoryFetcher) FetcherCommitAndSaveRootFS(logger lager.Logger, id, imageID, dest string, layerData archive.ArchiveReader) error {
+	if fetcher.CommitError != nil {
+		return fetcher.CommitError
+	}
+
+	fetcher.mutex.Lock()
+	fetcher.committed = append(fetcher.committed, CommitSpec{ID: id, ImageID: imageID, Dest: dest})
+	fetcher.mutex.Unlock()
+
+	return nil
+}
+
+func (fetcher *FakeRepositoryFetcher) FetcherCommitAndStreamRootFS(logger lager.Logger, id, imageID string, layerData archive.ArchiveReader) (io.ReadCloser, error) {
+	if fetcher.CommitError != nil {
+		return nil, fetcher.CommitError
+	}
+
+	fetcher.mutex.Lock()
+	fetcher.committed = append(fetcher.committed, CommitSpec{ID: id, ImageID: imageID})
+	fetcher.mutex.Unlock()
+
+	return fetcher.CommitResult, nil
+}
+
+func (fetcher *FakeRepositoryFetcher) FetcherCommitRootFS(logger lager.Logger, id, imageID, repoName, tag string, layerData archive.ArchiveReader) (string, error) {
+	if fetcher.CommitError != nil {
+		return "", fetcher.CommitError
+	}
+
+	fetcher.mutex.Lock()
+	fetcher.committed = append(fetcher.committed, CommitSpec{ID: id, ImageID: imageID, Repo: repoName, Tag: tag})
+	fetcher.mutex.Unlock()
+
+	return fetcher.CommitResultID, nil
+}
+
+func (fetcher *FakeRepositoryFetcher) Committed() []CommitSpec {
+	fetcher.mutex.RLock()
+	defer fetcher.mutex.RUnlock()
+
+	return fetcher.committed
+}
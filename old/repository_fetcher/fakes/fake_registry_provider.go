@@ -5,13 +5,15 @@ import (
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	"github.com/docker/docker/registry"
 )
 
 type FakeRegistryProvider struct {
-	ProvideRegistryStub        func(hostname string) (repository_fetcher.Registry, error)
+	ProvideRegistryStub        func(hostname string, auth *registry.AuthConfig) (repository_fetcher.Registry, error)
 	provideRegistryMutex       sync.RWMutex
 	provideRegistryArgsForCall []struct {
 		hostname string
+		auth     *registry.AuthConfig
 	}
 	provideRegistryReturns struct {
 		result1 repository_fetcher.Registry
@@ -25,16 +27,27 @@ type FakeRegistryProvider struct {
 	applyDefaultHostnameReturns struct {
 		result1 string
 	}
+	ProvideRegistryV2Stub        func(hostname string, auth *registry.AuthConfig) (repository_fetcher.RegistryV2, error)
+	provideRegistryV2Mutex       sync.RWMutex
+	provideRegistryV2ArgsForCall []struct {
+		hostname string
+		auth     *registry.AuthConfig
+	}
+	provideRegistryV2Returns struct {
+		result1 repository_fetcher.RegistryV2
+		result2 error
+	}
 }
 
-func (fake *FakeRegistryProvider) ProvideRegistry(hostname string) (repository_fetcher.Registry, error) {
+func (fake *FakeRegistryProvider) ProvideRegistry(hostname string, auth *registry.AuthConfig) (repository_fetcher.Registry, error) {
 	fake.provideRegistryMutex.Lock()
 	fake.provideRegistryArgsForCall = append(fake.provideRegistryArgsForCall, struct {
 		hostname string
-	}{hostname})
+		auth     *registry.AuthConfig
+	}{hostname, auth})
 	fake.provideRegistryMutex.Unlock()
 	if fake.ProvideRegistryStub != nil {
-		return fake.ProvideRegistryStub(hostname)
+		return fake.ProvideRegistryStub(hostname, auth)
 	} else {
 		return fake.provideRegistryReturns.result1, fake.provideRegistryReturns.result2
 	}
@@ -46,10 +59,10 @@ func (fake *FakeRegistryProvider) ProvideRegistryCallCount() int {
 	return len(fake.provideRegistryArgsForCall)
 }
 
-func (fake *FakeRegistryProvider) ProvideRegistryArgsForCall(i int) string {
+func (fake *FakeRegistryProvider) ProvideRegistryArgsForCall(i int) (string, *registry.AuthConfig) {
 	fake.provideRegistryMutex.RLock()
 	defer fake.provideRegistryMutex.RUnlock()
-	return fake.provideRegistryArgsForCall[i].hostname
+	return fake.provideRegistryArgsForCall[i].hostname, fake.provideRegistryArgsForCall[i].auth
 }
 
 func (fake *FakeRegistryProvider) ProvideRegistryReturns(result1 repository_fetcher.Registry, result2 error) {
@@ -92,4 +105,38 @@ func (fake *FakeRegistryProvider) ApplyDefaultHostnameReturns(result1 string) {
 	}{result1}
 }
 
+func (fake *FakeRegistryProvider) ProvideRegistryV2(hostname string, auth *registry.AuthConfig) (repository_fetcher.RegistryV2, error) {
+	fake.provideRegistryV2Mutex.Lock()
+	fake.provideRegistryV2ArgsForCall = append(fake.provideRegistryV2ArgsForCall, struct {
+		hostname string
+		auth     *registry.AuthConfig
+	}{hostname, auth})
+	fake.provideRegistryV2Mutex.Unlock()
+	if fake.ProvideRegistryV2Stub != nil {
+		return fake.ProvideRegistryV2Stub(hostname, auth)
+	} else {
+		return fake.provideRegistryV2Returns.result1, fake.provideRegistryV2Returns.result2
+	}
+}
+
+func (fake *FakeRegistryProvider) ProvideRegistryV2CallCount() int {
+	fake.provideRegistryV2Mutex.RLock()
+	defer fake.provideRegistryV2Mutex.RUnlock()
+	return len(fake.provideRegistryV2ArgsForCall)
+}
+
+func (fake *FakeRegistryProvider) ProvideRegistryV2ArgsForCall(i int) (string, *registry.AuthConfig) {
+	fake.provideRegistryV2Mutex.RLock()
+	defer fake.provideRegistryV2Mutex.RUnlock()
+	return fake.provideRegistryV2ArgsForCall[i].hostname, fake.provideRegistryV2ArgsForCall[i].auth
+}
+
+func (fake *FakeRegistryProvider) ProvideRegistryV2Returns(result1 repository_fetcher.RegistryV2, result2 error) {
+	fake.ProvideRegistryV2Stub = nil
+	fake.provideRegistryV2Returns = struct {
+		result1 repository_fetcher.RegistryV2
+		result2 error
+	}{result1, result2}
+}
+
 var _ repository_fetcher.RegistryProvider = new(FakeRegistryProvider)
@@ -0,0 +1,108 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+)
+
+type FakeRegistryV2 struct {
+	GetManifestStub        func(repoName string, reference string) (manifest []byte, mediaType string, err error)
+	getManifestMutex       sync.RWMutex
+	getManifestArgsForCall []struct {
+		repoName  string
+		reference string
+	}
+	getManifestReturns struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}
+	GetBlobStub        func(repoName string, digest string, offset int64) (blob io.ReadCloser, servedFrom int64, err error)
+	getBlobMutex       sync.RWMutex
+	getBlobArgsForCall []struct {
+		repoName string
+		digest   string
+		offset   int64
+	}
+	getBlobReturns struct {
+		result1 io.ReadCloser
+		result2 int64
+		result3 error
+	}
+}
+
+func (fake *FakeRegistryV2) GetManifest(repoName string, reference string) (manifest []byte, mediaType string, err error) {
+	fake.getManifestMutex.Lock()
+	fake.getManifestArgsForCall = append(fake.getManifestArgsForCall, struct {
+		repoName  string
+		reference string
+	}{repoName, reference})
+	fake.getManifestMutex.Unlock()
+	if fake.GetManifestStub != nil {
+		return fake.GetManifestStub(repoName, reference)
+	} else {
+		return fake.getManifestReturns.result1, fake.getManifestReturns.result2, fake.getManifestReturns.result3
+	}
+}
+
+func (fake *FakeRegistryV2) GetManifestCallCount() int {
+	fake.getManifestMutex.RLock()
+	defer fake.getManifestMutex.RUnlock()
+	return len(fake.getManifestArgsForCall)
+}
+
+func (fake *FakeRegistryV2) GetManifestArgsForCall(i int) (string, string) {
+	fake.getManifestMutex.RLock()
+	defer fake.getManifestMutex.RUnlock()
+	return fake.getManifestArgsForCall[i].repoName, fake.getManifestArgsForCall[i].reference
+}
+
+func (fake *FakeRegistryV2) GetManifestReturns(result1 []byte, result2 string, result3 error) {
+	fake.GetManifestStub = nil
+	fake.getManifestReturns = struct {
+		result1 []byte
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeRegistryV2) GetBlob(repoName string, digest string, offset int64) (blob io.ReadCloser, servedFrom int64, err error) {
+	fake.getBlobMutex.Lock()
+	fake.getBlobArgsForCall = append(fake.getBlobArgsForCall, struct {
+		repoName string
+		digest   string
+		offset   int64
+	}{repoName, digest, offset})
+	fake.getBlobMutex.Unlock()
+	if fake.GetBlobStub != nil {
+		return fake.GetBlobStub(repoName, digest, offset)
+	} else {
+		return fake.getBlobReturns.result1, fake.getBlobReturns.result2, fake.getBlobReturns.result3
+	}
+}
+
+func (fake *FakeRegistryV2) GetBlobCallCount() int {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	return len(fake.getBlobArgsForCall)
+}
+
+func (fake *FakeRegistryV2) GetBlobArgsForCall(i int) (string, string, int64) {
+	fake.getBlobMutex.RLock()
+	defer fake.getBlobMutex.RUnlock()
+	return fake.getBlobArgsForCall[i].repoName, fake.getBlobArgsForCall[i].digest, fake.getBlobArgsForCall[i].offset
+}
+
+func (fake *FakeRegistryV2) GetBlobReturns(result1 io.ReadCloser, result2 int64, result3 error) {
+	fake.GetBlobStub = nil
+	fake.getBlobReturns = struct {
+		result1 io.ReadCloser
+		result2 int64
+		result3 error
+	}{result1, result2, result3}
+}
+
+var _ repository_fetcher.RegistryV2 = new(FakeRegistryV2)
@@ -0,0 +1,131 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+)
+
+type FakeTagStore struct {
+	TagStub        func(repoName string, tag string, imageID string) error
+	tagMutex       sync.RWMutex
+	tagArgsForCall []struct {
+		repoName string
+		tag      string
+		imageID  string
+	}
+	tagReturns struct {
+		result1 error
+	}
+	LookupStub        func(repoName string, tag string) (imageID string, found bool)
+	lookupMutex       sync.RWMutex
+	lookupArgsForCall []struct {
+		repoName string
+		tag      string
+	}
+	lookupReturns struct {
+		result1 string
+		result2 bool
+	}
+	ImageIDsStub        func() []string
+	imageIDsMutex       sync.RWMutex
+	imageIDsArgsForCall []struct{}
+	imageIDsReturns     struct {
+		result1 []string
+	}
+}
+
+func (fake *FakeTagStore) Tag(repoName string, tag string, imageID string) error {
+	fake.tagMutex.Lock()
+	fake.tagArgsForCall = append(fake.tagArgsForCall, struct {
+		repoName string
+		tag      string
+		imageID  string
+	}{repoName, tag, imageID})
+	fake.tagMutex.Unlock()
+	if fake.TagStub != nil {
+		return fake.TagStub(repoName, tag, imageID)
+	} else {
+		return fake.tagReturns.result1
+	}
+}
+
+func (fake *FakeTagStore) TagCallCount() int {
+	fake.tagMutex.RLock()
+	defer fake.tagMutex.RUnlock()
+	return len(fake.tagArgsForCall)
+}
+
+func (fake *FakeTagStore) TagArgsForCall(i int) (string, string, string) {
+	fake.tagMutex.RLock()
+	defer fake.tagMutex.RUnlock()
+	return fake.tagArgsForCall[i].repoName, fake.tagArgsForCall[i].tag, fake.tagArgsForCall[i].imageID
+}
+
+func (fake *FakeTagStore) TagReturns(result1 error) {
+	fake.TagStub = nil
+	fake.tagReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTagStore) Lookup(repoName string, tag string) (imageID string, found bool) {
+	fake.lookupMutex.Lock()
+	fake.lookupArgsForCall = append(fake.lookupArgsForCall, struct {
+		repoName string
+		tag      string
+	}{repoName, tag})
+	fake.lookupMutex.Unlock()
+	if fake.LookupStub != nil {
+		return fake.LookupStub(repoName, tag)
+	} else {
+		return fake.lookupReturns.result1, fake.lookupReturns.result2
+	}
+}
+
+func (fake *FakeTagStore) LookupCallCount() int {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return len(fake.lookupArgsForCall)
+}
+
+func (fake *FakeTagStore) LookupArgsForCall(i int) (string, string) {
+	fake.lookupMutex.RLock()
+	defer fake.lookupMutex.RUnlock()
+	return fake.lookupArgsForCall[i].repoName, fake.lookupArgsForCall[i].tag
+}
+
+func (fake *FakeTagStore) LookupReturns(result1 string, result2 bool) {
+	fake.LookupStub = nil
+	fake.lookupReturns = struct {
+		result1 string
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeTagStore) ImageIDs() []string {
+	fake.imageIDsMutex.Lock()
+	fake.imageIDsArgsForCall = append(fake.imageIDsArgsForCall, struct{}{})
+	fake.imageIDsMutex.Unlock()
+	if fake.ImageIDsStub != nil {
+		return fake.ImageIDsStub()
+	} else {
+		return fake.imageIDsReturns.result1
+	}
+}
+
+func (fake *FakeTagStore) ImageIDsCallCount() int {
+	fake.imageIDsMutex.RLock()
+	defer fake.imageIDsMutex.RUnlock()
+	return len(fake.imageIDsArgsForCall)
+}
+
+func (fake *FakeTagStore) ImageIDsReturns(result1 []string) {
+	fake.ImageIDsStub = nil
+	fake.imageIDsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+var _ repository_fetcher.TagStore = new(FakeTagStore)
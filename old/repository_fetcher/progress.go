@@ -0,0 +1,128 @@
+package repository_fetcher
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// Progress collects the layer download events of fetches for the containers
+// waiting on them, so that a container's events explain why it was slow to
+// create. Several containers may wait on the same fetch.
+type Progress struct {
+	watchers map[string]map[string]bool
+	events   map[string][]string
+	mutex    *sync.Mutex
+}
+
+func NewProgress() *Progress {
+	return &Progress{
+		watchers: map[string]map[string]bool{},
+		events:   map[string][]string{},
+		mutex:    new(sync.Mutex),
+	}
+}
+
+// Watch collects the events of fetches of repoURL at tag for containerID,
+// until it is unwatched.
+func (progress *Progress) Watch(containerID string, repoURL *url.URL, tag string) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	key := progressKey(repoURL, tag)
+	if progress.watchers[key] == nil {
+		progress.watchers[key] = map[string]bool{}
+	}
+
+	progress.watchers[key][containerID] = true
+}
+
+// Unwatch stops collecting events for containerID, keeping those collected
+// so far.
+func (progress *Progress) Unwatch(containerID string) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	for key, watchers := range progress.watchers {
+		delete(watchers, containerID)
+		if len(watchers) == 0 {
+			delete(progress.watchers, key)
+		}
+	}
+}
+
+// Pending returns the events collected for containerID so far, keeping them,
+// so that a fetch can be followed while it is in flight.
+func (progress *Progress) Pending(containerID string) []string {
+	if progress == nil {
+		return nil
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	return append([]string{}, progress.events[containerID]...)
+}
+
+// Events returns the events collected for containerID, and forgets them.
+func (progress *Progress) Events(containerID string) []string {
+	if progress == nil {
+		return nil
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	events := progress.events[containerID]
+	delete(progress.events, containerID)
+
+	return events
+}
+
+func (progress *Progress) report(key, format string, args ...interface{}) {
+	if progress == nil {
+		return
+	}
+
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+
+	event := fmt.Sprintf(format, args...)
+	for containerID := range progress.watchers[key] {
+		progress.events[containerID] = append(progress.events[containerID], event)
+	}
+}
+
+// progressKey identifies a fetch, ignoring any credentials in its URL.
+func progressKey(repoURL *url.URL, tag string) string {
+	return repoURL.Host + repoURL.Path + ":" + tag
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+
+	return id
+}
+
+func humanSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(bytes)/(1<<10))
+	}
+
+	return fmt.Sprintf("%d B", bytes)
+}
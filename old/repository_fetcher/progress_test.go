@@ -0,0 +1,34 @@
+package repository_fetcher_test
+
+import (
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Progress", func() {
+	var progress *Progress
+
+	BeforeEach(func() {
+		progress = NewProgress()
+	})
+
+	It("has no events for containers which watched nothing", func() {
+		Expect(progress.Events("some-container")).To(BeEmpty())
+	})
+
+	It("forgets a container's events once they are taken", func() {
+		progress.Watch("some-container", parseURL("docker:///some-repo"), "latest")
+		progress.Unwatch("some-container")
+
+		Expect(progress.Events("some-container")).To(BeEmpty())
+	})
+
+	It("is a no-op when nil", func() {
+		var progress *Progress
+
+		progress.Watch("some-container", parseURL("docker:///some-repo"), "latest")
+		progress.Unwatch("some-container")
+		Expect(progress.Events("some-container")).To(BeNil())
+	})
+})
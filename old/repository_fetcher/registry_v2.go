@@ -0,0 +1,325 @@
+package repository_fetcher
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/registry"
+)
+
+const (
+	MediaTypeManifestV1       = "application/vnd.docker.distribution.manifest.v1+json"
+	MediaTypeSignedManifestV1 = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	MediaTypeManifestV2       = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var ErrRegistryV2Unsupported = errors.New("registry does not support the v2 API")
+
+// RegistryV2 fetches manifests and content-addressed blobs over the Docker
+// Registry HTTP API V2.
+//go:generate counterfeiter . RegistryV2
+type RegistryV2 interface {
+	// GetManifest returns the manifest for a tag or digest reference, along
+	// with its media type.
+	GetManifest(repoName, reference string) (manifest []byte, mediaType string, err error)
+	// GetBlob returns the blob from offset onwards, if the registry
+	// supports ranged requests, returning the offset it is served from.
+	GetBlob(repoName, digest string, offset int64) (blob io.ReadCloser, servedFrom int64, err error)
+}
+
+type registryV2 struct {
+	baseURL  string
+	official bool
+	auth     *registry.AuthConfig
+	client   *http.Client
+
+	tokens map[string]string
+	mutex  *sync.Mutex
+}
+
+// NewRegistryV2 connects to the registry at hostname, returning
+// ErrRegistryV2Unsupported if it does not serve the v2 API. Insecure
+// registries are tried over https without certificate verification, and
+// then over plain http.
+func NewRegistryV2(hostname string, insecure bool, auth *registry.AuthConfig) (RegistryV2, error) {
+	hostname, official := v2Hostname(hostname)
+
+	schemes := []string{"https"}
+	if insecure {
+		schemes = append(schemes, "http")
+	}
+
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+	}
+
+	var err error
+	for _, scheme := range schemes {
+		reg := &registryV2{
+			baseURL:  scheme + "://" + hostname,
+			official: official,
+			auth:     auth,
+			client: &http.Client{
+				Transport: transport,
+				Timeout:   30 * time.Minute,
+			},
+			tokens: map[string]string{},
+			mutex:  new(sync.Mutex),
+		}
+
+		if err = reg.ping(); err == nil {
+			return reg, nil
+		}
+	}
+
+	return nil, err
+}
+
+// v2Hostname strips any scheme and path from a registry address, pointing
+// the docker hub index at the hub's v2 registry.
+func v2Hostname(hostname string) (string, bool) {
+	hostname = strings.TrimPrefix(hostname, "https://")
+	hostname = strings.TrimPrefix(hostname, "http://")
+	hostname = strings.SplitN(hostname, "/", 2)[0]
+
+	switch hostname {
+	case "index.docker.io", "docker.io", "registry-1.docker.io":
+		return "registry-1.docker.io", true
+	}
+
+	return hostname, false
+}
+
+func (reg *registryV2) ping() error {
+	resp, err := reg.client.Get(reg.baseURL + "/v2/")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return ErrRegistryV2Unsupported
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Docker-Distribution-API-Version"), "registry/2") && resp.StatusCode != http.StatusOK {
+		return ErrRegistryV2Unsupported
+	}
+
+	return nil
+}
+
+func (reg *registryV2) GetManifest(repoName, reference string) ([]byte, string, error) {
+	repoName = reg.repository(repoName)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/manifests/%s", reg.baseURL, repoName, reference), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Add("Accept", MediaTypeManifestV2)
+	req.Header.Add("Accept", MediaTypeSignedManifestV1)
+	req.Header.Add("Accept", MediaTypeManifestV1)
+
+	resp, err := reg.do(req, repoName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	manifest, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	mediaType := strings.TrimSpace(strings.SplitN(resp.Header.Get("Content-Type"), ";", 2)[0])
+
+	return manifest, mediaType, nil
+}
+
+func (reg *registryV2) GetBlob(repoName, digest string, offset int64) (io.ReadCloser, int64, error) {
+	repoName = reg.repository(repoName)
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v2/%s/blobs/%s", reg.baseURL, repoName, digest), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := reg.do(req, repoName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return resp.Body, 0, nil
+	}
+
+	return resp.Body, offset, nil
+}
+
+// repository qualifies official images on the docker hub, as the v1 API
+// does implicitly.
+func (reg *registryV2) repository(repoName string) string {
+	if reg.official && !strings.Contains(repoName, "/") {
+		return "library/" + repoName
+	}
+
+	return repoName
+}
+
+// do sends the request, answering any authentication challenge with the
+// registry's credentials. Bearer tokens are cached per repository.
+func (reg *registryV2) do(req *http.Request, repoName string) (*http.Response, error) {
+	scope := "repository:" + repoName + ":pull"
+
+	reg.mutex.Lock()
+	token, found := reg.tokens[scope]
+	reg.mutex.Unlock()
+
+	if found {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := reg.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := reg.authorize(req, challenge, scope); err != nil {
+			return nil, err
+		}
+
+		resp, err = reg.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return nil, fmt.Errorf("registry v2: %s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (reg *registryV2) authorize(req *http.Request, challenge, scope string) error {
+	authType, params := parseChallenge(challenge)
+
+	switch strings.ToLower(authType) {
+	case "basic":
+		if reg.auth == nil || reg.auth.Username == "" {
+			return fmt.Errorf("registry v2: %s requires credentials", req.URL.Host)
+		}
+
+		req.SetBasicAuth(reg.auth.Username, reg.auth.Password)
+		return nil
+
+	case "bearer":
+		token, err := reg.fetchToken(params["realm"], params["service"], scope)
+		if err != nil {
+			return err
+		}
+
+		reg.mutex.Lock()
+		reg.tokens[scope] = token
+		reg.mutex.Unlock()
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	return fmt.Errorf("registry v2: unsupported authentication challenge %q", challenge)
+}
+
+func (reg *registryV2) fetchToken(realm, service, scope string) (string, error) {
+	if realm == "" {
+		return "", errors.New("registry v2: token challenge has no realm")
+	}
+
+	realmURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("registry v2: token realm: %s", err)
+	}
+
+	query := realmURL.Query()
+	if service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", scope)
+	realmURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", realmURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if reg.auth != nil && reg.auth.Username != "" {
+		req.SetBasicAuth(reg.auth.Username, reg.auth.Password)
+	}
+
+	resp, err := reg.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry v2: fetch token: %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("registry v2: fetch token: %s", err)
+	}
+
+	if body.Token == "" {
+		return body.AccessToken, nil
+	}
+
+	return body.Token, nil
+}
+
+// parseChallenge splits a WWW-Authenticate header such as
+// `Bearer realm="https://auth",service="registry"` into its scheme and
+// parameters.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	for _, param := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+
+	return parts[0], params
+}
@@ -0,0 +1,233 @@
+package repository_fetcher_test
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/docker/docker/registry"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("RegistryV2", func() {
+	var server *ghttp.Server
+	var tokenServer *ghttp.Server
+	var hostname string
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		tokenServer = ghttp.NewServer()
+		hostname = server.HTTPTestServer.Listener.Addr().String()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		tokenServer.Close()
+	})
+
+	v2Ping := func(status int) http.HandlerFunc {
+		return ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/"),
+			ghttp.RespondWith(status, "{}", http.Header{
+				"Docker-Distribution-Api-Version": []string{"registry/2.0"},
+			}),
+		)
+	}
+
+	Context("when the registry does not serve the v2 API", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, "404 page not found"))
+		})
+
+		It("returns ErrRegistryV2Unsupported", func() {
+			_, err := NewRegistryV2(hostname, true, nil)
+			Expect(err).To(Equal(ErrRegistryV2Unsupported))
+		})
+	})
+
+	Context("when the registry is anonymous", func() {
+		var reg RegistryV2
+
+		BeforeEach(func() {
+			server.AppendHandlers(v2Ping(http.StatusOK))
+
+			var err error
+			reg, err = NewRegistryV2(hostname, true, nil)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fetches manifests, accepting both schema versions", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/manifests/some-tag"),
+					func(w http.ResponseWriter, req *http.Request) {
+						Expect(req.Header["Accept"]).To(ContainElement(MediaTypeManifestV2))
+						Expect(req.Header["Accept"]).To(ContainElement(MediaTypeSignedManifestV1))
+					},
+					ghttp.RespondWith(http.StatusOK, `{"schemaVersion":2}`, http.Header{
+						"Content-Type": []string{MediaTypeManifestV2},
+					}),
+				),
+			)
+
+			manifest, mediaType, err := reg.GetManifest("some/repo", "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(manifest)).To(Equal(`{"schemaVersion":2}`))
+			Expect(mediaType).To(Equal(MediaTypeManifestV2))
+		})
+
+		It("fetches blobs by digest", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/blobs/sha256:abc"),
+					ghttp.RespondWith(http.StatusOK, "some-blob"),
+				),
+			)
+
+			blob, _, err := reg.GetBlob("some/repo", "sha256:abc", 0)
+			Expect(err).ToNot(HaveOccurred())
+			defer blob.Close()
+
+			Expect(ioutil.ReadAll(blob)).To(Equal([]byte("some-blob")))
+		})
+
+		It("requests blobs from an offset", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/blobs/sha256:abc"),
+					ghttp.VerifyHeaderKV("Range", "bytes=5-"),
+					ghttp.RespondWith(http.StatusPartialContent, "blob"),
+				),
+			)
+
+			blob, servedFrom, err := reg.GetBlob("some/repo", "sha256:abc", 5)
+			Expect(err).ToNot(HaveOccurred())
+			defer blob.Close()
+
+			Expect(servedFrom).To(Equal(int64(5)))
+			Expect(ioutil.ReadAll(blob)).To(Equal([]byte("blob")))
+		})
+
+		Context("when the registry ignores the range", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.RespondWith(http.StatusOK, "some-blob"))
+			})
+
+			It("serves the blob from the start", func() {
+				blob, servedFrom, err := reg.GetBlob("some/repo", "sha256:abc", 5)
+				Expect(err).ToNot(HaveOccurred())
+				defer blob.Close()
+
+				Expect(servedFrom).To(Equal(int64(0)))
+				Expect(ioutil.ReadAll(blob)).To(Equal([]byte("some-blob")))
+			})
+		})
+
+		Context("when the registry returns an error", func() {
+			BeforeEach(func() {
+				server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, `{"errors":[]}`))
+			})
+
+			It("returns an error", func() {
+				_, _, err := reg.GetManifest("some/repo", "some-tag")
+				Expect(err).To(MatchError(ContainSubstring("404")))
+			})
+		})
+	})
+
+	Context("when the registry requires a bearer token", func() {
+		var reg RegistryV2
+
+		BeforeEach(func() {
+			server.AppendHandlers(v2Ping(http.StatusUnauthorized))
+
+			var err error
+			reg, err = NewRegistryV2(hostname, true, &registry.AuthConfig{
+				Username: "some-user",
+				Password: "some-password",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			challenge := `Bearer realm="` + tokenServer.URL() + `/token",service="some-registry"`
+
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/manifests/some-tag"),
+					ghttp.RespondWith(http.StatusUnauthorized, "", http.Header{
+						"Www-Authenticate": []string{challenge},
+					}),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/manifests/some-tag"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWith(http.StatusOK, `{"schemaVersion":1}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/some/repo/blobs/sha256:abc"),
+					ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+					ghttp.RespondWith(http.StatusOK, "some-blob"),
+				),
+			)
+
+			tokenServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/token", "scope=repository%3Asome%2Frepo%3Apull&service=some-registry"),
+					ghttp.VerifyBasicAuth("some-user", "some-password"),
+					ghttp.RespondWith(http.StatusOK, `{"token":"some-token"}`),
+				),
+			)
+		})
+
+		It("authenticates with a token from the realm, and reuses it", func() {
+			manifest, _, err := reg.GetManifest("some/repo", "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(manifest)).To(Equal(`{"schemaVersion":1}`))
+
+			blob, _, err := reg.GetBlob("some/repo", "sha256:abc", 0)
+			Expect(err).ToNot(HaveOccurred())
+			blob.Close()
+
+			Expect(tokenServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("when the registry requires basic authentication", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(
+				v2Ping(http.StatusUnauthorized),
+				ghttp.RespondWith(http.StatusUnauthorized, "", http.Header{
+					"Www-Authenticate": []string{`Basic realm="some-registry"`},
+				}),
+				ghttp.CombineHandlers(
+					ghttp.VerifyBasicAuth("some-user", "some-password"),
+					ghttp.RespondWith(http.StatusOK, "some-blob"),
+				),
+			)
+		})
+
+		It("retries with the credentials", func() {
+			reg, err := NewRegistryV2(hostname, true, &registry.AuthConfig{
+				Username: "some-user",
+				Password: "some-password",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			blob, _, err := reg.GetBlob("some/repo", "sha256:abc", 0)
+			Expect(err).ToNot(HaveOccurred())
+			blob.Close()
+		})
+
+		Context("and there are no credentials", func() {
+			It("returns an error", func() {
+				reg, err := NewRegistryV2(hostname, true, nil)
+				Expect(err).ToNot(HaveOccurred())
+
+				_, _, err = reg.GetBlob("some/repo", "sha256:abc", 0)
+				Expect(err).To(MatchError(ContainSubstring("requires credentials")))
+			})
+		})
+	})
+})
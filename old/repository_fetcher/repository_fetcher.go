@@ -15,6 +15,7 @@ import (
 	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -25,6 +26,8 @@ import (
 type RepositoryFetcher interface {
 	Fetch(logger lager.Logger, url *url.URL, tag string) (imageID string, envvars process.Env, volumes []string, err error)
 	FetcherCommitAndSaveRootFS(logger lager.Logger, id, imageID, dest string, layerData archive.ArchiveReader) error
+	FetcherCommitAndStreamRootFS(logger lager.Logger, id, imageID string, layerData archive.ArchiveReader) (io.ReadCloser, error)
+	FetcherCommitRootFS(logger lager.Logger, id, imageID, repoName, tag string, layerData archive.ArchiveReader) (string, error)
 }
 
 // apes dockers registry.NewEndpoint
@@ -55,7 +58,9 @@ type Graph interface {
 type DockerRepositoryFetcher struct {
 	registryProvider RegistryProvider
 	graph            Graph
+	tagStore         TagStore
 
+	downloader     *Downloader
 	fetchingLayers map[string]chan struct{}
 	fetchingMutex  *sync.Mutex
 	
@@ -89,10 +94,12 @@ type dockerLayer struct {
 	vols []string
 }
 
-func New(registry RegistryProvider, graph Graph) RepositoryFetcher {
+func New(registry RegistryProvider, graph Graph, tagStore TagStore, downloader *Downloader) RepositoryFetcher {
 	return &DockerRepositoryFetcher{
 		registryProvider: registry,
 		graph:            graph,
+		tagStore:         tagStore,
+		downloader:       downloader,
 		fetchingLayers:   map[string]chan struct{}{},
 		fetchingMutex:    new(sync.Mutex),
 		clock:			  clock.NewClock(),
@@ -109,16 +116,66 @@ func (fetcher *DockerRepositoryFetcher) Fetch(
 	tag string,
 ) (string, process.Env, []string, error) {
 	fLog := logger.Session("fetch", lager.Data{
-		"repo": repoURL,
+		"repo": redactURL(repoURL),
 		"tag":  tag,
 	})
 
 	fLog.Debug("fetching")
 
-	path := repoURL.Path[1:]
+	path, digest, err := parseDigestReference(repoURL.Path[1:])
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("repository_fetcher: %s", err)
+	}
+
+	if repoURL.Host == "" && digest == "" {
+		if image, imgID, found := fetcher.lookupLocal(fLog, path, tag); found {
+			fLog.Debug("using-local-image", lager.Data{
+				"image": imgID,
+			})
+
+			return imgID, image.Env(), image.Vols(), nil
+		}
+	}
+
 	hostname := fetcher.registryProvider.ApplyDefaultHostname(repoURL.Host)
+	key := progressKey(repoURL, tag)
+
+	reference := tag
+	if digest != "" {
+		reference = digest
+	}
+
+	// digests are only meaningful to v2 registries, so there is no falling
+	// back to v1 for them
+	registryV2, err := fetcher.registryProvider.ProvideRegistryV2(hostname, authFromURL(repoURL))
+	if err == nil {
+		var image *dockerImage
+		var imgID string
+		imgID, image, err = fetcher.fetchV2(fLog, key, registryV2, path, reference, digest != "")
+		if err == nil {
+			fLog.Debug("fetched", lager.Data{
+				"image":   imgID,
+				"env":     image.Env(),
+				"volumes": image.Vols(),
+			})
+
+			return imgID, image.Env(), image.Vols(), nil
+		}
 
-	registry, err := fetcher.registryProvider.ProvideRegistry(hostname)
+		if digest != "" {
+			return "", nil, nil, fetchError("fetchV2", hostname, path, err)
+		}
+
+		fLog.Error("v2-fetch-failed-falling-back-to-v1", err)
+	} else if digest != "" {
+		return "", nil, nil, fetchError("ProvideRegistryV2", hostname, path, err)
+	} else {
+		fLog.Debug("v2-unavailable", lager.Data{
+			"error": err.Error(),
+		})
+	}
+
+	registry, err := fetcher.registryProvider.ProvideRegistry(hostname, authFromURL(repoURL))
 	if err != nil {
 		logger.Error("failed-to-construct-registry-endpoint", err)
 		return "", nil, nil, fetchError("ProvideRegistry", hostname, path, err)
@@ -148,7 +205,7 @@ func (fetcher *DockerRepositoryFetcher) Fetch(
 		})
 
 		var image *dockerImage
-		image, err = fetcher.fetchFromEndpoint(fLog, registry, endpoint, imgID, token)
+		image, err = fetcher.fetchFromEndpoint(fLog, key, registry, endpoint, imgID, token)
 		if err == nil {
 			fLog.Debug("fetched", lager.Data{
 				"endpoint": endpoint,
@@ -164,75 +221,219 @@ func (fetcher *DockerRepositoryFetcher) Fetch(
 	return "", nil, nil, fetchError("fetchFromEndPoint", hostname, path, fmt.Errorf("all endpoints failed: %v", err))
 }
 
-func (fetcher *DockerRepositoryFetcher) fetchFromEndpoint(logger lager.Logger, registry Registry, endpoint string, imgID string, token []string) (*dockerImage, error) {
-	history, err := registry.GetRemoteHistory(imgID, endpoint, token)
+// lookupLocal resolves images committed into the local graph by
+// FetcherCommitRootFS.
+func (fetcher *DockerRepositoryFetcher) lookupLocal(logger lager.Logger, repoName, tag string) (*dockerImage, string, bool) {
+	imgID, found := fetcher.tagStore.Lookup(repoName, tag)
+	if !found {
+		return nil, "", false
+	}
+
+	image, err := localImage(logger, fetcher.graph, imgID)
 	if err != nil {
-		return nil, err
+		return nil, "", false
 	}
 
+	return image, imgID, true
+}
+
+// localImage walks the parent chain of an image already in the graph,
+// collecting the env and volumes of each layer.
+func localImage(logger lager.Logger, graph Graph, imgID string) (*dockerImage, error) {
 	var allLayers []*dockerLayer
-	for i := len(history) - 1; i >= 0; i-- {
-		layer, err := fetcher.fetchLayer(logger, registry, endpoint, history[i], token)
+	for id := imgID; id != ""; {
+		img, err := graph.Get(id)
 		if err != nil {
+			logger.Error("local-image-missing-layer", err, lager.Data{
+				"image": imgID,
+				"layer": id,
+			})
+
 			return nil, err
 		}
 
-		allLayers = append(allLayers, layer)
+		allLayers = append([]*dockerLayer{{imgEnv(img, logger), imgVolumes(img)}}, allLayers...)
+		id = img.Parent
 	}
 
 	return &dockerImage{allLayers}, nil
 }
 
-func (fetcher *DockerRepositoryFetcher) fetchLayer(logger lager.Logger, registry Registry, endpoint string, layerID string, token []string) (*dockerLayer, error) {
-	for acquired := false; !acquired; acquired = fetcher.fetching(layerID) {
+func (fetcher *DockerRepositoryFetcher) fetchFromEndpoint(logger lager.Logger, key string, registry Registry, endpoint string, imgID string, token []string) (*dockerImage, error) {
+	history, err := registry.GetRemoteHistory(imgID, endpoint, token)
+	if err != nil {
+		return nil, err
 	}
 
-	defer fetcher.doneFetching(layerID)
-
-	img, err := fetcher.graph.Get(layerID)
-	if err == nil {
-		logger.Info("using-cached", lager.Data{
-			"layer": layerID,
+	var layers []remoteLayer
+	for i := len(history) - 1; i >= 0; i-- {
+		layerID := history[i]
+
+		layers = append(layers, remoteLayer{
+			id: layerID,
+			download: func() (*image.Image, *os.File, error) {
+				imgJSON, imgSize, err := registry.GetRemoteImageJSON(layerID, endpoint, token)
+				if err != nil {
+					return nil, nil, fmt.Errorf("get remote image JSON: %v", err)
+				}
+
+				img, err := image.NewImgJSON(imgJSON)
+				if err != nil {
+					return nil, nil, fmt.Errorf("new image JSON: %v", err)
+				}
+
+				// v1 layers have no digest to verify a resumed download against,
+				// but the registry session resumes interrupted requests itself
+				layer, err := fetcher.downloader.Download(logger, key, img.ID, "", int64(imgSize), func(int64) (io.ReadCloser, int64, error) {
+					layer, err := registry.GetRemoteImageLayer(img.ID, endpoint, token, int64(imgSize))
+					if err != nil {
+						return nil, 0, fmt.Errorf("get remote image layer: %v", err)
+					}
+
+					return layer, 0, nil
+				})
+
+				return img, layer, err
+			},
 		})
-
-		return &dockerLayer{imgEnv(img, logger), imgVolumes(img)}, nil
 	}
 
-	imgJSON, imgSize, err := registry.GetRemoteImageJSON(layerID, endpoint, token)
+	allLayers, err := fetcher.fetchLayers(logger, key, layers)
 	if err != nil {
-		return nil, fmt.Errorf("get remote image JSON: %v", err)
+		return nil, err
 	}
 
-	img, err = image.NewImgJSON(imgJSON)
-	if err != nil {
-		return nil, fmt.Errorf("new image JSON: %v", err)
+	return &dockerImage{allLayers}, nil
+}
+
+// remoteLayer is a layer of an image to be fetched, which download fetches
+// into a file if it is not already in the graph. A verified layer's download
+// is checked against its digest, so a complete download can be kept on disk
+// for a later fetch to reuse.
+type remoteLayer struct {
+	id       string
+	verified bool
+	download func() (*image.Image, *os.File, error)
+}
+
+type downloadedLayer struct {
+	img        *image.Image
+	data       *os.File
+	err        error
+	registered bool
+}
+
+// fetchLayers fetches the layers of an image, base first. Layers missing
+// from the graph are downloaded concurrently, and then registered in order,
+// as a layer's parent must be registered before it. Layers are claimed base
+// first, so that fetches sharing layers never wait on each other in a cycle.
+func (fetcher *DockerRepositoryFetcher) fetchLayers(logger lager.Logger, key string, layers []remoteLayer) ([]*dockerLayer, error) {
+	allLayers := make([]*dockerLayer, len(layers))
+
+	var missing []int
+	defer func() {
+		for _, i := range missing {
+			fetcher.doneFetching(layers[i].id)
+		}
+	}()
+
+	for i, layer := range layers {
+		fetcher.claim(key, layer.id)
+
+		img, err := fetcher.graph.Get(layer.id)
+		if err == nil {
+			fetcher.doneFetching(layer.id)
+
+			logger.Info("using-cached", lager.Data{
+				"layer": layer.id,
+			})
+
+			allLayers[i] = &dockerLayer{imgEnv(img, logger), imgVolumes(img)}
+			continue
+		}
+
+		missing = append(missing, i)
 	}
 
-	layer, err := registry.GetRemoteImageLayer(img.ID, endpoint, token, int64(imgSize))
-	if err != nil {
-		return nil, fmt.Errorf("get remote image layer: %v", err)
+	// verified downloads left unregistered when a sibling layer fails are
+	// kept, so that retrying the fetch does not download them again
+	downloads := make([]downloadedLayer, len(layers))
+	defer func() {
+		for i, download := range downloads {
+			if download.data == nil {
+				continue
+			}
+
+			download.data.Close()
+
+			if download.registered || download.err != nil || !layers[i].verified {
+				os.Remove(download.data.Name())
+			}
+		}
+	}()
+
+	failed := make(chan struct{})
+	failedOnce := new(sync.Once)
+
+	wg := new(sync.WaitGroup)
+	for _, i := range missing {
+		select {
+		case <-failed:
+		default:
+			wg.Add(1)
+			layer := layers[i]
+			download := &downloads[i]
+
+			fetcher.downloader.start(func() {
+				defer wg.Done()
+
+				download.img, download.data, download.err = layer.download()
+				if download.err != nil {
+					failedOnce.Do(func() { close(failed) })
+				}
+			})
+		}
 	}
 
-	defer layer.Close()
+	wg.Wait()
 
-	started := time.Now()
+	for _, i := range missing {
+		if downloads[i].err != nil {
+			return nil, downloads[i].err
+		}
+	}
 
-	logger.Info("downloading", lager.Data{
-		"layer": layerID,
-	})
+	for _, i := range missing {
+		img := downloads[i].img
 
-	err = fetcher.graph.Register(img, layer)
-	if err != nil {
-		return nil, fmt.Errorf("register: %s", err)
+		if img.ID != layers[i].id {
+			downloads[i].err = fmt.Errorf("layer %s has ID %s", layers[i].id, img.ID)
+			return nil, downloads[i].err
+		}
+
+		if err := fetcher.graph.Register(img, downloads[i].data); err != nil {
+			return nil, fmt.Errorf("register: %s", err)
+		}
+
+		downloads[i].registered = true
+
+		allLayers[i] = &dockerLayer{imgEnv(img, logger), imgVolumes(img)}
 	}
 
-	logger.Info("downloaded", lager.Data{
-		"layer": layerID,
-		"took":  time.Since(started),
-		"vols":  imgVolumes(img),
-	})
+	return allLayers, nil
+}
+
+// claim waits until no other fetch is fetching layerID, and then claims it,
+// reporting any wait.
+func (fetcher *DockerRepositoryFetcher) claim(key, layerID string) {
+	if fetcher.fetching(layerID) {
+		return
+	}
+
+	fetcher.downloader.progress.report(key, "waited for layer %s being fetched for another container", shortID(layerID))
 
-	return &dockerLayer{imgEnv(img, logger), imgVolumes(img)}, nil
+	for !fetcher.fetching(layerID) {
+	}
 }
 
 func (fetcher *DockerRepositoryFetcher) fetching(layerID string) bool {
@@ -354,6 +555,7 @@ func (fetcher *DockerRepositoryFetcher) FetcherExportImage(logger lager.Logger,
 		}
 		
 		written, err := json.Write(imageInspectRaw)
+		json.Close()
 		if err != nil {
 			return err
 		}
@@ -371,7 +573,9 @@ func (fetcher *DockerRepositoryFetcher) FetcherExportImage(logger lager.Logger,
 		if err != nil {
 			return err
 		}
-		if err := fetcher.FetcherTarImageLayer(logger, n, fsTar); err != nil {
+		err = fetcher.FetcherTarImageLayer(logger, n, fsTar)
+		fsTar.Close()
+		if err != nil {
 			return err
 		}
 
@@ -479,61 +683,117 @@ func (fetcher *DockerRepositoryFetcher) FetcherCommitAndSaveRootFS(logger lager.
 		}
 	}
 	
-	// commit diff as a new image
-	diff_img, err := fetcher.FetcherCreateImage(layerData,id,imageID)
+	fs, err := fetcher.FetcherCommitAndStreamRootFS(logger, id, imageID, layerData)
 	if err != nil {
 		return err
 	}
-	
+	defer fs.Close()
+
+	destFsTar, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer destFsTar.Close()
+
+	if _, err := io.Copy(destFsTar, fs); err != nil {
+		return err
+	}
+
+	logger.Info("FetcherCommitAndSaveRootFS-END", lager.Data{
+		"container-id": id,
+		"parent-imageID": imageID,
+		"save-dest":	dest,
+	})
+
+	return nil
+}
+
+// FetcherCommitAndStreamRootFS registers layerData as a new image on top of
+// imageID and returns the image, in `docker save` format, as a tar stream.
+// The committed image and its export directory are removed when the stream
+// is closed.
+func (fetcher *DockerRepositoryFetcher) FetcherCommitAndStreamRootFS(logger lager.Logger, id, imageID string, layerData archive.ArchiveReader) (io.ReadCloser, error) {
+	cLog := logger.Session("commit-and-stream", lager.Data{
+		"container-id":   id,
+		"parent-imageID": imageID,
+	})
+
+	// commit diff as a new image
+	diff_img, err := fetcher.FetcherCreateImage(layerData, id, imageID)
+	if err != nil {
+		return nil, err
+	}
+
 	random := utils.GenerateRandomID()
 	shortLen := 12
 	if len(id) < shortLen {
 		shortLen = len(id)
 	}
 	random = random[:shortLen]
-	tempdir := filepath.Join("/var/vcap/data/", id, random, "garden-export-")
-	
-	// get image json
-	//tempdir, err := ioutil.TempDir("", "garden-export-")
+	exportRoot := filepath.Join("/var/vcap/data/", id, random)
+	tempdir := filepath.Join(exportRoot, "garden-export-")
+
+	cleanup := func() {
+		os.RemoveAll(exportRoot)
+		os.Remove(filepath.Dir(exportRoot))
+
+		// the diff commit image is not usefull in garden context any more
+		fetcher.FetcherDeleteImage(cLog, diff_img.ID)
+	}
+
 	if err := os.MkdirAll(tempdir, os.FileMode(0755)); err != nil {
-		fetcher.FetcherDeleteImage(logger, diff_img.ID)
-		return err
+		cleanup()
+		return nil, err
 	}
-	defer os.RemoveAll(filepath.Join("/var/vcap/data/", id))
-	
-	if err := fetcher.FetcherExportImage(logger, diff_img.ID, tempdir); err != nil {
-		logger.Error("export-image-fail", err, lager.Data{
+
+	if err := fetcher.FetcherExportImage(cLog, diff_img.ID, tempdir); err != nil {
+		cLog.Error("export-image-fail", err, lager.Data{
 			"imageID": diff_img.ID,
 		})
-		fetcher.FetcherDeleteImage(logger, diff_img.ID)
-		return err
+		cleanup()
+		return nil, err
 	}
-	
+
 	fs, err := archive.Tar(tempdir, archive.Uncompressed)
 	if err != nil {
-		fetcher.FetcherDeleteImage(logger, diff_img.ID)
-		return err
+		cleanup()
+		return nil, err
 	}
-	defer fs.Close()
-	
-	destFsTar, err := os.Create(dest)
-	if err != nil {
+
+	cLog.Info("exported", lager.Data{
+		"imageID": diff_img.ID,
+	})
+
+	return ioutils.NewReadCloserWrapper(fs, func() error {
+		err := fs.Close()
+		cleanup()
 		return err
+	}), nil
+}
+
+// FetcherCommitRootFS registers layerData as a new image on top of imageID and
+// tags it as repoName:tag in the local tag store, returning the new image ID.
+func (fetcher *DockerRepositoryFetcher) FetcherCommitRootFS(logger lager.Logger, id, imageID, repoName, tag string, layerData archive.ArchiveReader) (string, error) {
+	cLog := logger.Session("commit", lager.Data{
+		"container-id":   id,
+		"parent-imageID": imageID,
+		"repo":           repoName,
+		"tag":            tag,
+	})
+
+	img, err := fetcher.FetcherCreateImage(layerData, id, imageID)
+	if err != nil {
+		return "", err
 	}
-	
-	if _, err := io.Copy(destFsTar, fs); err != nil {
-		fetcher.FetcherDeleteImage(logger, diff_img.ID)
-		return err
+
+	if err := fetcher.tagStore.Tag(repoName, tag, img.ID); err != nil {
+		fetcher.FetcherDeleteImage(cLog, img.ID)
+		return "", err
 	}
-	
-	logger.Info("FetcherCommitAndSaveRootFS-END", lager.Data{
-		"container-id": id,
-		"parent-imageID": imageID,
-		"save-dest":	dest,
+
+	cLog.Info("committed", lager.Data{
+		"imageID": img.ID,
 	})
-	
-	// the diff commit image is not usefull in garden context any more
-	fetcher.FetcherDeleteImage(logger, diff_img.ID)
-	
-	return nil
+
+	return img.ID, nil
 }
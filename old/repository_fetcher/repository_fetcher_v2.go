@@ -0,0 +1,244 @@
+package repository_fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
+	"github.com/docker/libtrust"
+	"github.com/pivotal-golang/lager"
+)
+
+const digestAlgorithm = "sha256:"
+
+type manifestV2 struct {
+	SchemaVersion int            `json:"schemaVersion"`
+	MediaType     string         `json:"mediaType"`
+	Config        descriptorV2   `json:"config"`
+	Layers        []descriptorV2 `json:"layers"`
+}
+
+type descriptorV2 struct {
+	MediaType string `json:"mediaType"`
+	Size      int64  `json:"size"`
+	Digest    string `json:"digest"`
+}
+
+// imageConfigV2 is the subset of a schema 2 image configuration which
+// garden uses.
+type imageConfigV2 struct {
+	Architecture  string            `json:"architecture"`
+	OS            string            `json:"os"`
+	Created       time.Time         `json:"created"`
+	Author        string            `json:"author"`
+	DockerVersion string            `json:"docker_version"`
+	Config        *runconfig.Config `json:"config"`
+}
+
+// v2Layer is an image to be registered in the graph from a blob.
+type v2Layer struct {
+	img    *image.Image
+	digest string
+}
+
+// parseDigestReference splits a repository path of the form
+// `repo@sha256:...` into the repository name and the digest.
+func parseDigestReference(path string) (string, string, error) {
+	parts := strings.SplitN(path, "@", 2)
+	if len(parts) == 1 {
+		return path, "", nil
+	}
+
+	if !strings.HasPrefix(parts[1], digestAlgorithm) || len(parts[1]) != len(digestAlgorithm)+2*sha256.Size {
+		return "", "", fmt.Errorf("invalid digest reference: %s", parts[1])
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return digestAlgorithm + hex.EncodeToString(sum[:])
+}
+
+func (fetcher *DockerRepositoryFetcher) fetchV2(logger lager.Logger, key string, registry RegistryV2, repoName, reference string, byDigest bool) (string, *dockerImage, error) {
+	manifest, mediaType, err := registry.GetManifest(repoName, reference)
+	if err != nil {
+		return "", nil, fmt.Errorf("get manifest: %s", err)
+	}
+
+	var versioned struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+
+	if err := json.Unmarshal(manifest, &versioned); err != nil {
+		return "", nil, fmt.Errorf("parse manifest: %s", err)
+	}
+
+	logger.Debug("got-manifest", lager.Data{
+		"media-type":     mediaType,
+		"schema-version": versioned.SchemaVersion,
+	})
+
+	var layers []v2Layer
+	switch versioned.SchemaVersion {
+	case 1:
+		layers, err = layersFromSchema1(manifest, reference, byDigest)
+	case 2:
+		layers, err = fetcher.layersFromSchema2(registry, repoName, manifest, reference, byDigest)
+	default:
+		err = fmt.Errorf("unsupported manifest schema version %d", versioned.SchemaVersion)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	var remoteLayers []remoteLayer
+	for _, layer := range layers {
+		layer := layer
+
+		remoteLayers = append(remoteLayers, remoteLayer{
+			id:       layer.img.ID,
+			verified: true,
+			download: func() (*image.Image, *os.File, error) {
+				data, err := fetcher.downloader.Download(logger, key, layer.img.ID, layer.digest, layer.img.Size, func(offset int64) (io.ReadCloser, int64, error) {
+					blob, servedFrom, err := registry.GetBlob(repoName, layer.digest, offset)
+					if err != nil {
+						return nil, 0, fmt.Errorf("get blob %s: %s", layer.digest, err)
+					}
+
+					return blob, servedFrom, nil
+				})
+
+				return layer.img, data, err
+			},
+		})
+	}
+
+	allLayers, err := fetcher.fetchLayers(logger, key, remoteLayers)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return layers[len(layers)-1].img.ID, &dockerImage{allLayers}, nil
+}
+
+// layersFromSchema1 returns the layers of a schema 1 manifest, base layer
+// first. Signed manifests are digested over their payload, without the
+// signatures.
+func layersFromSchema1(manifest []byte, reference string, byDigest bool) ([]v2Layer, error) {
+	if byDigest {
+		payload := manifest
+		if signed, err := libtrust.ParsePrettySignature(manifest, "signatures"); err == nil {
+			payload, err = signed.Payload()
+			if err != nil {
+				return nil, fmt.Errorf("manifest payload: %s", err)
+			}
+		}
+
+		if digestOf(payload) != reference {
+			return nil, fmt.Errorf("manifest digest mismatch: expected %s, got %s", reference, digestOf(payload))
+		}
+	}
+
+	var data registry.ManifestData
+	if err := json.Unmarshal(manifest, &data); err != nil {
+		return nil, fmt.Errorf("parse manifest: %s", err)
+	}
+
+	if len(data.FSLayers) == 0 || len(data.FSLayers) != len(data.History) {
+		return nil, errors.New("manifest has mismatched layers and history")
+	}
+
+	var layers []v2Layer
+	for i := len(data.FSLayers) - 1; i >= 0; i-- {
+		img, err := image.NewImgJSON([]byte(data.History[i].V1Compatibility))
+		if err != nil {
+			return nil, fmt.Errorf("parse layer history: %s", err)
+		}
+
+		layers = append(layers, v2Layer{img, data.FSLayers[i].BlobSum})
+	}
+
+	return layers, nil
+}
+
+// layersFromSchema2 returns the layers of a schema 2 manifest, base layer
+// first. Schema 2 images have no layer IDs, so they are derived from the
+// chain of layer digests beneath them, and from the config for the top
+// layer, which carries the image's configuration.
+func (fetcher *DockerRepositoryFetcher) layersFromSchema2(registry RegistryV2, repoName string, manifest []byte, reference string, byDigest bool) ([]v2Layer, error) {
+	if byDigest && digestOf(manifest) != reference {
+		return nil, fmt.Errorf("manifest digest mismatch: expected %s, got %s", reference, digestOf(manifest))
+	}
+
+	var parsed manifestV2
+	if err := json.Unmarshal(manifest, &parsed); err != nil {
+		return nil, fmt.Errorf("parse manifest: %s", err)
+	}
+
+	if len(parsed.Layers) == 0 {
+		return nil, errors.New("manifest has no layers")
+	}
+
+	configBlob, _, err := registry.GetBlob(repoName, parsed.Config.Digest, 0)
+	if err != nil {
+		return nil, fmt.Errorf("get image config: %s", err)
+	}
+	defer configBlob.Close()
+
+	configJSON, err := ioutil.ReadAll(configBlob)
+	if err != nil {
+		return nil, fmt.Errorf("get image config: %s", err)
+	}
+
+	if digestOf(configJSON) != parsed.Config.Digest {
+		return nil, fmt.Errorf("image config digest mismatch: expected %s, got %s", parsed.Config.Digest, digestOf(configJSON))
+	}
+
+	var config imageConfigV2
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return nil, fmt.Errorf("parse image config: %s", err)
+	}
+
+	var layers []v2Layer
+	parent := ""
+	for i, layer := range parsed.Layers {
+		chain := parent + " " + layer.Digest
+
+		img := &image.Image{
+			Parent:       parent,
+			Created:      config.Created,
+			Architecture: config.Architecture,
+			OS:           config.OS,
+			Size:         layer.Size,
+		}
+
+		if i == len(parsed.Layers)-1 {
+			chain += " " + parsed.Config.Digest
+
+			img.Author = config.Author
+			img.DockerVersion = config.DockerVersion
+			img.Config = config.Config
+		}
+
+		sum := sha256.Sum256([]byte(chain))
+		img.ID = hex.EncodeToString(sum[:])
+
+		layers = append(layers, v2Layer{img, layer.Digest})
+		parent = img.ID
+	}
+
+	return layers, nil
+}
@@ -0,0 +1,415 @@
+package repository_fetcher_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/libtrust"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	"github.com/cloudfoundry-incubator/garden-linux/container_pool/fake_graph"
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher/fakes"
+	"github.com/cloudfoundry-incubator/garden-linux/process"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RepositoryFetcher with a v2 registry", func() {
+	var (
+		graph                *fake_graph.FakeGraph
+		fakeRegistryProvider *fakes.FakeRegistryProvider
+		fakeRegistry         *fakes.FakeRegistryV2
+		fetcher              RepositoryFetcher
+		logger               *lagertest.TestLogger
+
+		downloadsDir string
+		progress     *Progress
+
+		blobs      map[string][]byte
+		registered []string
+		layerData  map[string][]byte
+		mutex      *sync.Mutex
+	)
+
+	digest := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	addBlob := func(content string) string {
+		d := digest([]byte(content))
+		blobs[d] = []byte(content)
+		return d
+	}
+
+	BeforeEach(func() {
+		graph = fake_graph.New()
+		blobs = map[string][]byte{}
+		registered = nil
+		layerData = map[string][]byte{}
+		mutex = new(sync.Mutex)
+
+		graph.WhenRegistering = func(img *image.Image, layer archive.ArchiveReader) error {
+			data, err := ioutil.ReadAll(layer)
+			Expect(err).ToNot(HaveOccurred())
+
+			mutex.Lock()
+			registered = append(registered, img.ID)
+			layerData[img.ID] = data
+			mutex.Unlock()
+
+			imgJSON, err := json.Marshal(img)
+			Expect(err).ToNot(HaveOccurred())
+			graph.SetExists(img.ID, imgJSON)
+
+			return nil
+		}
+
+		fakeRegistry = new(fakes.FakeRegistryV2)
+		fakeRegistry.GetBlobStub = func(repoName, digest string, offset int64) (io.ReadCloser, int64, error) {
+			mutex.Lock()
+			blob, found := blobs[digest]
+			mutex.Unlock()
+
+			if !found {
+				return nil, 0, errors.New("blob unknown")
+			}
+
+			return ioutil.NopCloser(bytes.NewReader(blob[offset:])), offset, nil
+		}
+
+		fakeRegistryProvider = new(fakes.FakeRegistryProvider)
+		fakeRegistryProvider.ApplyDefaultHostnameReturns("some-registry:4444")
+		fakeRegistryProvider.ProvideRegistryV2Returns(fakeRegistry, nil)
+		fakeRegistryProvider.ProvideRegistryReturns(nil, errors.New("v1 not available"))
+
+		var err error
+		downloadsDir, err = ioutil.TempDir("", "downloads")
+		Expect(err).ToNot(HaveOccurred())
+
+		progress = NewProgress()
+
+		downloader, err := NewDownloader(downloadsDir, 4, progress)
+		Expect(err).ToNot(HaveOccurred())
+
+		fetcher = New(fakeRegistryProvider, graph, new(fakes.FakeTagStore), downloader)
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(downloadsDir)
+	})
+
+	Context("with a schema 1 manifest", func() {
+		var manifest []byte
+
+		BeforeEach(func() {
+			layer1 := addBlob("layer-1-data")
+			layer2 := addBlob("layer-2-data")
+
+			manifest = []byte(fmt.Sprintf(`{
+				"schemaVersion": 1,
+				"name": "some-repo",
+				"tag": "some-tag",
+				"fsLayers": [{"blobSum": %q}, {"blobSum": %q}],
+				"history": [
+					{"v1Compatibility": "{\"id\":\"layer-2\",\"parent\":\"layer-1\",\"config\":{\"Env\":[\"A=1\"],\"Volumes\":{\"/vol\":{}}}}"},
+					{"v1Compatibility": "{\"id\":\"layer-1\",\"config\":{\"Env\":[\"B=2\"]}}"}
+				]
+			}`, layer2, layer1))
+
+			fakeRegistry.GetManifestReturns(manifest, MediaTypeSignedManifestV1, nil)
+		})
+
+		It("registers each layer, base first, and returns the top image", func() {
+			imageID, env, volumes, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(imageID).To(Equal("layer-2"))
+			Expect(env).To(Equal(process.Env{"A": "1", "B": "2"}))
+			Expect(volumes).To(ConsistOf("/vol"))
+
+			Expect(registered).To(Equal([]string{"layer-1", "layer-2"}))
+			Expect(layerData["layer-1"]).To(Equal([]byte("layer-1-data")))
+			Expect(layerData["layer-2"]).To(Equal([]byte("layer-2-data")))
+
+			repoName, reference := fakeRegistry.GetManifestArgsForCall(0)
+			Expect(repoName).To(Equal("some-repo"))
+			Expect(reference).To(Equal("some-tag"))
+
+			Expect(fakeRegistryProvider.ProvideRegistryCallCount()).To(Equal(0))
+		})
+
+		It("does not download layers which already exist", func() {
+			graph.SetExists("layer-1", []byte(`{"id":"layer-1","config":{"Env":["B=2"]}}`))
+
+			_, env, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(env).To(Equal(process.Env{"A": "1", "B": "2"}))
+			Expect(registered).To(Equal([]string{"layer-2"}))
+		})
+
+		Context("when it is signed and fetched by digest", func() {
+			var payloadDigest string
+
+			BeforeEach(func() {
+				key, err := libtrust.GenerateECP256PrivateKey()
+				Expect(err).ToNot(HaveOccurred())
+
+				signature, err := libtrust.NewJSONSignature(manifest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(signature.Sign(key)).To(Succeed())
+
+				signed, err := signature.PrettySignature("signatures")
+				Expect(err).ToNot(HaveOccurred())
+
+				payload, err := signature.Payload()
+				Expect(err).ToNot(HaveOccurred())
+				payloadDigest = digest(payload)
+
+				fakeRegistry.GetManifestReturns(signed, MediaTypeSignedManifestV1, nil)
+			})
+
+			It("verifies the digest of the unsigned payload", func() {
+				imageID, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo@"+payloadDigest), "latest")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(imageID).To(Equal("layer-2"))
+
+				_, reference := fakeRegistry.GetManifestArgsForCall(0)
+				Expect(reference).To(Equal(payloadDigest))
+			})
+		})
+	})
+
+	Context("with a schema 2 manifest", func() {
+		var manifest []byte
+		var layer1, layer2, config string
+
+		BeforeEach(func() {
+			layer1 = addBlob("layer-1-data")
+			layer2 = addBlob("layer-2-data")
+			config = addBlob(`{
+				"architecture": "amd64",
+				"os": "linux",
+				"config": {"Env": ["PATH=/bin"], "Volumes": {"/data": {}}}
+			}`)
+
+			manifest = []byte(fmt.Sprintf(`{
+				"schemaVersion": 2,
+				"mediaType": %q,
+				"config": {"digest": %q},
+				"layers": [{"digest": %q, "size": 12}, {"digest": %q, "size": 12}]
+			}`, MediaTypeManifestV2, config, layer1, layer2))
+
+			fakeRegistry.GetManifestReturns(manifest, MediaTypeManifestV2, nil)
+		})
+
+		It("registers each layer as an image chained on the layer below", func() {
+			imageID, env, volumes, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(env).To(Equal(process.Env{"PATH": "/bin"}))
+			Expect(volumes).To(ConsistOf("/data"))
+
+			Expect(registered).To(HaveLen(2))
+			Expect(registered[1]).To(Equal(imageID))
+
+			base, err := graph.Get(registered[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(base.Parent).To(BeEmpty())
+			Expect(layerData[base.ID]).To(Equal([]byte("layer-1-data")))
+
+			top, err := graph.Get(imageID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(top.Parent).To(Equal(base.ID))
+			Expect(top.OS).To(Equal("linux"))
+			Expect(layerData[top.ID]).To(Equal([]byte("layer-2-data")))
+		})
+
+		It("downloads the layers concurrently", func() {
+			serve := fakeRegistry.GetBlobStub
+
+			requested := make(chan string, 2)
+			bothRequested := make(chan struct{})
+
+			fakeRegistry.GetBlobStub = func(repoName, digest string, offset int64) (io.ReadCloser, int64, error) {
+				if digest == layer1 || digest == layer2 {
+					requested <- digest
+					if len(requested) == 2 {
+						close(bothRequested)
+					}
+
+					select {
+					case <-bothRequested:
+					case <-time.After(5 * time.Second):
+						return nil, 0, errors.New("layers were downloaded one at a time")
+					}
+				}
+
+				return serve(repoName, digest, offset)
+			}
+
+			_, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(registered).To(HaveLen(2))
+		})
+
+		It("reports the progress of each layer to the containers watching the fetch", func() {
+			repoURL := parseURL("docker://some-registry:4444/some-repo")
+			progress.Watch("some-container", repoURL, "some-tag")
+
+			_, _, _, err := fetcher.Fetch(logger, repoURL, "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			progress.Unwatch("some-container")
+
+			events := progress.Events("some-container")
+			Expect(events).To(HaveLen(4))
+			Expect(events).To(ContainElement(fmt.Sprintf("fetching layer %s (12 B)", registered[0][:12])))
+			Expect(events).To(ContainElement(fmt.Sprintf("fetching layer %s (12 B)", registered[1][:12])))
+		})
+
+		It("derives the same image IDs every time", func() {
+			first, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			second, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(second).To(Equal(first))
+			Expect(registered).To(HaveLen(2))
+		})
+
+		It("accepts a digest reference to the manifest", func() {
+			_, _, _, err := fetcher.Fetch(logger, parseURL("docker:///some-repo@"+digest(manifest)), "latest")
+			Expect(err).ToNot(HaveOccurred())
+
+			repoName, reference := fakeRegistry.GetManifestArgsForCall(0)
+			Expect(repoName).To(Equal("some-repo"))
+			Expect(reference).To(Equal(digest(manifest)))
+		})
+
+		Context("when the manifest does not match the digest reference", func() {
+			It("returns an error without falling back to v1", func() {
+				wrong := digest([]byte("something else"))
+
+				_, _, _, err := fetcher.Fetch(logger, parseURL("docker:///some-repo@"+wrong), "latest")
+				Expect(err).To(MatchError(ContainSubstring("manifest digest mismatch")))
+
+				Expect(fakeRegistryProvider.ProvideRegistryCallCount()).To(Equal(0))
+				Expect(registered).To(BeEmpty())
+			})
+		})
+
+		Context("when a blob does not match its digest", func() {
+			BeforeEach(func() {
+				blobs[layer2] = []byte("tampered")
+			})
+
+			It("registers none of the layers", func() {
+				_, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo@"+digest(manifest)), "latest")
+				Expect(err).To(MatchError(ContainSubstring("blob digest mismatch")))
+
+				Expect(registered).To(BeEmpty())
+			})
+
+			It("discards the download, so that a retry starts afresh", func() {
+				fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+
+				blobs[layer2] = []byte("layer-2-data")
+
+				_, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(layerData[registered[1]]).To(Equal([]byte("layer-2-data")))
+			})
+
+			It("keeps the other layers' verified downloads, so that a retry does not download them again", func() {
+				_, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+				Expect(err).To(HaveOccurred())
+
+				entries, err := ioutil.ReadDir(downloadsDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(HaveLen(1))
+
+				blobs[layer2] = []byte("layer-2-data")
+
+				_, _, _, err = fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+				Expect(err).ToNot(HaveOccurred())
+
+				layer1Fetches := 0
+				for i := 0; i < fakeRegistry.GetBlobCallCount(); i++ {
+					if _, d, _ := fakeRegistry.GetBlobArgsForCall(i); d == layer1 {
+						layer1Fetches++
+					}
+				}
+				Expect(layer1Fetches).To(Equal(1))
+
+				Expect(layerData[registered[0]]).To(Equal([]byte("layer-1-data")))
+
+				entries, err = ioutil.ReadDir(downloadsDir)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the digest reference is malformed", func() {
+		It("returns an error", func() {
+			_, _, _, err := fetcher.Fetch(logger, parseURL("docker:///some-repo@md5:abc"), "latest")
+			Expect(err).To(MatchError(ContainSubstring("invalid digest reference")))
+		})
+	})
+
+	Context("when the v2 fetch fails", func() {
+		BeforeEach(func() {
+			fakeRegistry.GetManifestReturns(nil, "", errors.New("manifest unknown"))
+		})
+
+		It("falls back to v1", func() {
+			_, _, _, err := fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(err).To(MatchError(ContainSubstring("v1 not available")))
+
+			Expect(fakeRegistryProvider.ProvideRegistryCallCount()).To(Equal(1))
+		})
+
+		Context("for a digest reference", func() {
+			It("returns the error without falling back to v1", func() {
+				_, _, _, err := fetcher.Fetch(logger, parseURL("docker:///some-repo@"+digest([]byte("x"))), "latest")
+				Expect(err).To(MatchError(ContainSubstring("manifest unknown")))
+
+				Expect(fakeRegistryProvider.ProvideRegistryCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when the registry does not serve the v2 API", func() {
+		BeforeEach(func() {
+			fakeRegistryProvider.ProvideRegistryV2Returns(nil, ErrRegistryV2Unsupported)
+		})
+
+		It("falls back to v1", func() {
+			fetcher.Fetch(logger, parseURL("docker://some-registry:4444/some-repo"), "some-tag")
+			Expect(fakeRegistryProvider.ProvideRegistryCallCount()).To(Equal(1))
+		})
+
+		It("rejects digest references", func() {
+			_, _, _, err := fetcher.Fetch(logger, parseURL("docker:///some-repo@"+digest([]byte("x"))), "latest")
+			Expect(err).To(MatchError(ContainSubstring("ProvideRegistryV2")))
+		})
+	})
+})
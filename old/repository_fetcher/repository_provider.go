@@ -3,11 +3,20 @@ package repository_fetcher
 import (
 	"fmt"
 	"strings"
+
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/utils"
 )
 
 //go:generate counterfeiter . RegistryProvider
 type RegistryProvider interface {
-	ProvideRegistry(hostname string) (Registry, error)
+	// ProvideRegistry returns a session with the registry at hostname. When
+	// auth is nil, the provider's configured credentials for the hostname are
+	// used, if it has any.
+	ProvideRegistry(hostname string, auth *registry.AuthConfig) (Registry, error)
+	// ProvideRegistryV2 is as ProvideRegistry, for registries serving the v2
+	// API. It returns an error if the registry does not serve it.
+	ProvideRegistryV2(hostname string, auth *registry.AuthConfig) (RegistryV2, error)
 	ApplyDefaultHostname(hostname string) string
 }
 
@@ -28,6 +37,7 @@ func (err InsecureRegistryError) Error() string {
 type registryProvider struct {
 	DefaultHostname    string
 	InsecureRegistries []string
+	AuthConfigs        *registry.ConfigFile
 }
 
 func (rp registryProvider) ApplyDefaultHostname(hostname string) string {
@@ -37,7 +47,7 @@ func (rp registryProvider) ApplyDefaultHostname(hostname string) string {
 	return hostname
 }
 
-func (rp registryProvider) ProvideRegistry(hostname string) (Registry, error) {
+func (rp registryProvider) ProvideRegistry(hostname string, auth *registry.AuthConfig) (Registry, error) {
 	var err error
 
 	hostname = rp.ApplyDefaultHostname(hostname)
@@ -53,9 +63,45 @@ func (rp registryProvider) ProvideRegistry(hostname string) (Registry, error) {
 		return nil, err
 	}
 
-	return RegistryNewSession(nil, nil, endpoint, true)
+	if auth == nil {
+		resolved := rp.AuthConfigs.ResolveAuthConfig(hostname)
+		auth = &resolved
+	}
+
+	return RegistryNewSession(auth, utils.NewHTTPRequestFactory(), endpoint, true)
+}
+
+func (rp registryProvider) ProvideRegistryV2(hostname string, auth *registry.AuthConfig) (RegistryV2, error) {
+	hostname = rp.ApplyDefaultHostname(hostname)
+
+	if auth == nil {
+		resolved := rp.AuthConfigs.ResolveAuthConfig(hostname)
+		auth = &resolved
+	}
+
+	return NewRegistryV2(hostname, rp.isInsecure(hostname), auth)
 }
 
-func NewRepositoryProvider(defaultHostname string, insecureRegistries []string) RegistryProvider {
-	return &registryProvider{DefaultHostname: defaultHostname, InsecureRegistries: insecureRegistries}
+func (rp registryProvider) isInsecure(hostname string) bool {
+	for _, insecure := range rp.InsecureRegistries {
+		if insecure == hostname {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewRepositoryProvider returns a RegistryProvider which authenticates with
+// the credentials in authConfigs, if any are given.
+func NewRepositoryProvider(defaultHostname string, insecureRegistries []string, authConfigs *registry.ConfigFile) RegistryProvider {
+	if authConfigs == nil {
+		authConfigs = &registry.ConfigFile{Configs: map[string]registry.AuthConfig{}}
+	}
+
+	return &registryProvider{
+		DefaultHostname:    defaultHostname,
+		InsecureRegistries: insecureRegistries,
+		AuthConfigs:        authConfigs,
+	}
 }
@@ -15,6 +15,8 @@ var _ = Describe("RepositoryProvider", func() {
 	var receivedHost string
 	var receivedInsecureRegistries []string
 	var receivedEndpoint *registry.Endpoint
+	var receivedAuth *registry.AuthConfig
+	var receivedFactory *utils.HTTPRequestFactory
 	var endpointReturnsError error
 	var sessionReturnsError error
 
@@ -25,6 +27,8 @@ var _ = Describe("RepositoryProvider", func() {
 		receivedHost = ""
 		receivedInsecureRegistries = nil
 		receivedEndpoint = nil
+		receivedAuth = nil
+		receivedFactory = nil
 
 		endpointReturnsError = nil
 		sessionReturnsError = nil
@@ -37,7 +41,9 @@ var _ = Describe("RepositoryProvider", func() {
 		}
 
 		returnedSession = &registry.Session{}
-		RegistryNewSession = func(_ *registry.AuthConfig, _ *utils.HTTPRequestFactory, endpoint *registry.Endpoint, _ bool) (*registry.Session, error) {
+		RegistryNewSession = func(auth *registry.AuthConfig, factory *utils.HTTPRequestFactory, endpoint *registry.Endpoint, _ bool) (*registry.Session, error) {
+			receivedAuth = auth
+			receivedFactory = factory
 			receivedEndpoint = endpoint
 			return returnedSession, sessionReturnsError
 		}
@@ -45,15 +51,15 @@ var _ = Describe("RepositoryProvider", func() {
 
 	Context("when the hostname is empty", func() {
 		It("uses the default host and port", func() {
-			provider := NewRepositoryProvider("the-default-host:11", nil)
+			provider := NewRepositoryProvider("the-default-host:11", nil, nil)
 			hostname := provider.ApplyDefaultHostname("")
 
 			Expect(hostname).To(Equal("the-default-host:11"))
 		})
 
 		It("creates a new endpoint based on the default host and port", func() {
-			provider := NewRepositoryProvider("the-default-host:11", nil)
-			provider.ProvideRegistry("")
+			provider := NewRepositoryProvider("the-default-host:11", nil, nil)
+			provider.ProvideRegistry("", nil)
 
 			Expect(receivedHost).To(Equal("the-default-host:11"))
 		})
@@ -61,15 +67,15 @@ var _ = Describe("RepositoryProvider", func() {
 
 	Context("when the hostname is not empty", func() {
 		It("uses the passed in host and port", func() {
-			provider := NewRepositoryProvider("", nil)
+			provider := NewRepositoryProvider("", nil, nil)
 			hostname := provider.ApplyDefaultHostname("the-registry-host:44")
 
 			Expect(hostname).To(Equal("the-registry-host:44"))
 		})
 
 		It("creates a new endpoint based on the supplied host and port", func() {
-			provider := NewRepositoryProvider("", nil)
-			provider.ProvideRegistry("the-registry-host:44")
+			provider := NewRepositoryProvider("", nil, nil)
+			provider.ProvideRegistry("the-registry-host:44", nil)
 
 			Expect(receivedHost).To(Equal("the-registry-host:44"))
 		})
@@ -77,8 +83,8 @@ var _ = Describe("RepositoryProvider", func() {
 
 	Context("when a list of secure repositories is provided", func() {
 		It("creates a new endpoint passing the list of secure repositories", func() {
-			provider := NewRepositoryProvider("", []string{"insecure1", "insecure2"})
-			provider.ProvideRegistry("the-registry-host:44")
+			provider := NewRepositoryProvider("", []string{"insecure1", "insecure2"}, nil)
+			provider.ProvideRegistry("the-registry-host:44", nil)
 
 			Expect(receivedInsecureRegistries).To(Equal([]string{"insecure1", "insecure2"}))
 		})
@@ -88,9 +94,9 @@ var _ = Describe("RepositoryProvider", func() {
 		Context("and the error message does not contain `--insecure-registry`", func() {
 			It("returns the error", func() {
 				endpointReturnsError = errors.New("an error")
-				provider := NewRepositoryProvider("", nil)
+				provider := NewRepositoryProvider("", nil, nil)
 
-				_, err := provider.ProvideRegistry("the-registry-host:44")
+				_, err := provider.ProvideRegistry("the-registry-host:44", nil)
 				Expect(err).To(MatchError("an error"))
 			})
 		})
@@ -98,9 +104,9 @@ var _ = Describe("RepositoryProvider", func() {
 		Context("and the error message DOES contain `--insecure-registry`", func() {
 			It("returns an InsecureRegistryError", func() {
 				endpointReturnsError = errors.New("some text that has --insecure-registry in it")
-				provider := NewRepositoryProvider("", []string{"foo", "bar"})
+				provider := NewRepositoryProvider("", []string{"foo", "bar"}, nil)
 
-				_, err := provider.ProvideRegistry("the-registry-host:44")
+				_, err := provider.ProvideRegistry("the-registry-host:44", nil)
 				Expect(err).To(MatchError(
 					&InsecureRegistryError{
 						Cause:              endpointReturnsError,
@@ -113,8 +119,8 @@ var _ = Describe("RepositoryProvider", func() {
 	})
 
 	It("creates a new session based on the endpoint", func() {
-		provider := NewRepositoryProvider("", nil)
-		session, err := provider.ProvideRegistry("the-registry-host:44")
+		provider := NewRepositoryProvider("", nil, nil)
+		session, err := provider.ProvideRegistry("the-registry-host:44", nil)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(session).To(Equal(returnedSession))
 
@@ -124,10 +130,66 @@ var _ = Describe("RepositoryProvider", func() {
 	Context("when NewSession returns an error", func() {
 		It("returns the error", func() {
 			sessionReturnsError = errors.New("an error")
-			provider := NewRepositoryProvider("", nil)
+			provider := NewRepositoryProvider("", nil, nil)
 
-			_, err := provider.ProvideRegistry("the-registry-host:44")
+			_, err := provider.ProvideRegistry("the-registry-host:44", nil)
 			Expect(err).To(MatchError("an error"))
 		})
 	})
+	Describe("authentication", func() {
+		var authConfigs *registry.ConfigFile
+
+		BeforeEach(func() {
+			authConfigs = &registry.ConfigFile{Configs: map[string]registry.AuthConfig{
+				"the-registry-host:44": {Username: "some-user", Password: "some-password"},
+			}}
+		})
+
+		It("creates the session with a request factory", func() {
+			provider := NewRepositoryProvider("", nil, nil)
+
+			_, err := provider.ProvideRegistry("the-registry-host:44", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(receivedFactory).ToNot(BeNil())
+		})
+
+		It("uses the configured credentials for the registry", func() {
+			provider := NewRepositoryProvider("", nil, authConfigs)
+
+			_, err := provider.ProvideRegistry("the-registry-host:44", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedAuth.Username).To(Equal("some-user"))
+			Expect(receivedAuth.Password).To(Equal("some-password"))
+		})
+
+		It("uses the configured credentials for the default registry", func() {
+			provider := NewRepositoryProvider("the-registry-host:44", nil, authConfigs)
+
+			_, err := provider.ProvideRegistry("", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedAuth.Username).To(Equal("some-user"))
+		})
+
+		It("is anonymous for registries without configured credentials", func() {
+			provider := NewRepositoryProvider("", nil, authConfigs)
+
+			_, err := provider.ProvideRegistry("some-other-host:44", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedAuth).ToNot(BeNil())
+			Expect(receivedAuth.Username).To(BeEmpty())
+		})
+
+		It("prefers the credentials it is given", func() {
+			provider := NewRepositoryProvider("", nil, authConfigs)
+
+			_, err := provider.ProvideRegistry("the-registry-host:44", &registry.AuthConfig{Username: "other-user", Password: "other-password"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(receivedAuth.Username).To(Equal("other-user"))
+			Expect(receivedAuth.Password).To(Equal("other-password"))
+		})
+	})
 })
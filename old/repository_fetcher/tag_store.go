@@ -0,0 +1,121 @@
+package repository_fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TagStore records the repository names and tags of images committed into
+// the local graph, so that they can be resolved without a registry.
+//go:generate counterfeiter -o fakes/fake_tag_store.go . TagStore
+type TagStore interface {
+	Tag(repoName, tag, imageID string) error
+	Lookup(repoName, tag string) (imageID string, found bool)
+
+	// ImageIDs returns the ID of every tagged image.
+	ImageIDs() []string
+}
+
+type fileTagStore struct {
+	path string
+
+	repositories map[string]map[string]string
+	mutex        *sync.RWMutex
+}
+
+// NewFileTagStore returns a TagStore persisted as JSON at path, loading any
+// tags previously written there.
+func NewFileTagStore(path string) (TagStore, error) {
+	store := &fileTagStore{
+		path:         path,
+		repositories: map[string]map[string]string{},
+		mutex:        new(sync.RWMutex),
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("tag store: read %s: %s", path, err)
+	}
+
+	if err := json.Unmarshal(contents, &store.repositories); err != nil {
+		return nil, fmt.Errorf("tag store: parse %s: %s", path, err)
+	}
+
+	return store, nil
+}
+
+func (store *fileTagStore) Tag(repoName, tag, imageID string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	tags, found := store.repositories[repoName]
+	if !found {
+		tags = map[string]string{}
+		store.repositories[repoName] = tags
+	}
+
+	tags[tag] = imageID
+
+	return store.save()
+}
+
+func (store *fileTagStore) Lookup(repoName, tag string) (string, bool) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	imageID, found := store.repositories[repoName][tag]
+	return imageID, found
+}
+
+func (store *fileTagStore) ImageIDs() []string {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	var ids []string
+	for _, tags := range store.repositories {
+		for _, imageID := range tags {
+			ids = append(ids, imageID)
+		}
+	}
+
+	return ids
+}
+
+// save writes the tags to a temporary file and renames it into place, so
+// that a crash never leaves a truncated store behind.
+func (store *fileTagStore) save() error {
+	contents, err := json.Marshal(store.repositories)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(store.path), filepath.Base(store.path))
+	if err != nil {
+		return fmt.Errorf("tag store: save: %s", err)
+	}
+
+	_, err = tmp.Write(contents)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("tag store: save: %s", err)
+	}
+
+	if err := os.Rename(tmp.Name(), store.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("tag store: save: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository_fetcher_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileTagStore", func() {
+	var (
+		storeDir  string
+		storePath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		storeDir, err = ioutil.TempDir("", "tag-store")
+		Expect(err).ToNot(HaveOccurred())
+
+		storePath = filepath.Join(storeDir, "tags.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(storeDir)
+	})
+
+	It("looks up tagged images", func() {
+		store, err := NewFileTagStore(storePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.Tag("some-repo", "some-tag", "some-image-id")).To(Succeed())
+
+		imageID, found := store.Lookup("some-repo", "some-tag")
+		Expect(found).To(BeTrue())
+		Expect(imageID).To(Equal("some-image-id"))
+
+		_, found = store.Lookup("some-repo", "some-other-tag")
+		Expect(found).To(BeFalse())
+	})
+
+	It("lists every tagged image", func() {
+		store, err := NewFileTagStore(storePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.Tag("some-repo", "some-tag", "some-image-id")).To(Succeed())
+		Expect(store.Tag("some-repo", "some-other-tag", "some-other-image-id")).To(Succeed())
+		Expect(store.Tag("another-repo", "latest", "some-image-id")).To(Succeed())
+
+		Expect(store.ImageIDs()).To(ConsistOf("some-image-id", "some-other-image-id", "some-image-id"))
+	})
+
+	It("persists tags across instances", func() {
+		store, err := NewFileTagStore(storePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(store.Tag("some-repo", "some-tag", "some-image-id")).To(Succeed())
+
+		reloaded, err := NewFileTagStore(storePath)
+		Expect(err).ToNot(HaveOccurred())
+
+		imageID, found := reloaded.Lookup("some-repo", "some-tag")
+		Expect(found).To(BeTrue())
+		Expect(imageID).To(Equal("some-image-id"))
+	})
+
+	Context("when the store file is corrupt", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(storePath, []byte("{not json"), 0644)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			_, err := NewFileTagStore(storePath)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
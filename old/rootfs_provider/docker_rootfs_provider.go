@@ -3,11 +3,13 @@ package rootfs_provider
 import (
 	"fmt"
 	"errors"
+	"io"
 	"net/url"
 	"time"
 	"sync"
 
 	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
@@ -20,6 +22,8 @@ type dockerRootFSProvider struct {
 	graphDriver   graphdriver.Driver
 	volumeCreator VolumeCreator
 	repoFetcher   repository_fetcher.RepositoryFetcher
+	imageTracker  ImageTracker
+	progress      *repository_fetcher.Progress
 	clock         clock.Clock
 
 	fallback RootFSProvider
@@ -34,16 +38,29 @@ type GraphDriver interface {
 	graphdriver.Driver
 }
 
+// ImageTracker is told which image each container's rootfs is layered on,
+// so that images no container uses can be garbage collected.
+//go:generate counterfeiter -o fake_image_tracker/fake_image_tracker.go . ImageTracker
+type ImageTracker interface {
+	Acquire(containerID, imageID string) error
+	Release(containerID string) error
+	Touch(imageID string) error
+}
+
 func NewDocker(
 	repoFetcher repository_fetcher.RepositoryFetcher,
 	graphDriver GraphDriver,
 	volumeCreator VolumeCreator,
+	imageTracker ImageTracker,
+	progress *repository_fetcher.Progress,
 	clock clock.Clock,
 ) (RootFSProvider, error) {
 	return &dockerRootFSProvider{
 		repoFetcher:   repoFetcher,
 		graphDriver:   graphDriver,
 		volumeCreator: volumeCreator,
+		imageTracker:  imageTracker,
+		progress:      progress,
 		clock:         clock,
 		activeMutex:	new(sync.Mutex),
 		active:			make(map[string]string),
@@ -55,28 +72,41 @@ func (provider *dockerRootFSProvider) ProvideRootFS(logger lager.Logger, id stri
 		return "", nil, ErrInvalidDockerURL
 	}
 
-	tag := "latest"
-	if len(url.Fragment) > 0 {
-		tag = url.Fragment
-	}
+	tag := tagOf(url)
 
+	// collect the progress of the fetch for the container's events
+	provider.progress.Watch(id, url, tag)
 	imageID, envvars, volumes, err := provider.repoFetcher.Fetch(logger, url, tag)
+	provider.progress.Unwatch(id)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// hold the image before the container's layer exists, so that it is
+	// never collected out from under it
+	err = provider.imageTracker.Acquire(id, imageID)
 	if err != nil {
 		return "", nil, err
 	}
 
 	err = provider.graphDriver.Create(id, imageID)
 	if err != nil {
+		provider.releaseImage(logger, id)
 		return "", nil, err
 	}
 
 	rootPath, err := provider.graphDriver.Get(id, "")
 	if err != nil {
+		provider.graphDriver.Remove(id)
+		provider.releaseImage(logger, id)
 		return "", nil, err
 	}
 
 	for _, v := range volumes {
 		if err = provider.volumeCreator.Create(rootPath, v); err != nil {
+			provider.graphDriver.Put(id)
+			provider.graphDriver.Remove(id)
+			provider.releaseImage(logger, id)
 			return "", nil, err
 		}
 	}
@@ -94,6 +124,47 @@ func (provider *dockerRootFSProvider) ProvideRootFS(logger lager.Logger, id stri
 	return rootPath, envvars, nil
 }
 
+// releaseImage drops the container's hold on its image when providing its
+// rootfs fails, since the container will never be cleaned up.
+func (provider *dockerRootFSProvider) releaseImage(logger lager.Logger, id string) {
+	if err := provider.imageTracker.Release(id); err != nil {
+		logger.Error("release-image", err, lager.Data{"id": id})
+	}
+}
+
+// RemountRootFS mounts the container's layer again; the graph driver only
+// mounts it if it is not mounted already.
+func (provider *dockerRootFSProvider) RemountRootFS(logger lager.Logger, id string, url *url.URL) (string, error) {
+	return provider.graphDriver.Get(id, "")
+}
+
+// PullRootFS fetches the image ahead of any container using it, and counts
+// the pull as a use of the image so that it is not collected straight away.
+func (provider *dockerRootFSProvider) PullRootFS(logger lager.Logger, url *url.URL) (string, error) {
+	if len(url.Path) == 0 {
+		return "", ErrInvalidDockerURL
+	}
+
+	imageID, _, _, err := provider.repoFetcher.Fetch(logger, url, tagOf(url))
+	if err != nil {
+		return "", err
+	}
+
+	if err := provider.imageTracker.Touch(imageID); err != nil {
+		return "", err
+	}
+
+	return imageID, nil
+}
+
+func tagOf(url *url.URL) string {
+	if len(url.Fragment) > 0 {
+		return url.Fragment
+	}
+
+	return "latest"
+}
+
 func (provider *dockerRootFSProvider) CleanupRootFS(logger lager.Logger, id string) error {
 	provider.graphDriver.Put(id)
 
@@ -119,7 +190,11 @@ func (provider *dockerRootFSProvider) CleanupRootFS(logger lager.Logger, id stri
 	delete(provider.active,id)
 	provider.activeMutex.Unlock()
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	return provider.imageTracker.Release(id)
 }
 
 /*******************************************************************************
@@ -147,29 +222,66 @@ func (provider *dockerRootFSProvider) CommitAndSaveRootFS(logger lager.Logger, i
 		"container-id": id,
 		"save-dest":	dest,
 	})
-	imageID,exist := provider.active[id]; 
-	if !exist {
-		return fmt.Errorf("Can not find ImageId with specified %s", id)
-	}
-	
-	archive, err := provider.graphDriver.Diff(id,imageID)
+
+	imageID, diff_layer_data, err := provider.diffLayer(id)
 	if err != nil {
-		return fmt.Errorf("Fail to get diff in %s base %s,due to %s",id,imageID,err.Error())
+		return err
 	}
-	
-	diff_layer_data := ioutils.NewReadCloserWrapper(archive, func() error {
-			err := archive.Close()
-			return err
-		})
-	
+
 	err = provider.repoFetcher.FetcherCommitAndSaveRootFS(logger, id, imageID, dest, diff_layer_data)
 	if err != nil {
 		return fmt.Errorf("Fail to FetcherCommitAndSaveRootFS in %s base %s,due to %s",id,imageID,err.Error())
 	}
-
 	logger.Info("CommitAndSaveRootFS-END", lager.Data{
 		"container-id": id,
 		"save-dest":	dest,
 	})
 	return nil
 }
+
+func (provider *dockerRootFSProvider) CommitAndStreamRootFS(logger lager.Logger, id string) (io.ReadCloser, error) {
+	imageID, layerData, err := provider.diffLayer(id)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := provider.repoFetcher.FetcherCommitAndStreamRootFS(logger, id, imageID, layerData)
+	if err != nil {
+		return nil, fmt.Errorf("rootfs_provider: commit %s based on %s: %s", id, imageID, err)
+	}
+
+	return stream, nil
+}
+
+func (provider *dockerRootFSProvider) CommitRootFS(logger lager.Logger, id, repoName, tag string) (string, error) {
+	imageID, layerData, err := provider.diffLayer(id)
+	if err != nil {
+		return "", err
+	}
+
+	committedID, err := provider.repoFetcher.FetcherCommitRootFS(logger, id, imageID, repoName, tag, layerData)
+	if err != nil {
+		return "", fmt.Errorf("rootfs_provider: commit %s based on %s as %s:%s: %s", id, imageID, repoName, tag, err)
+	}
+
+	return committedID, nil
+}
+
+// diffLayer returns the parent image of the container along with the
+// changes the container has made on top of it.
+func (provider *dockerRootFSProvider) diffLayer(id string) (string, archive.ArchiveReader, error) {
+	provider.activeMutex.Lock()
+	imageID, exist := provider.active[id]
+	provider.activeMutex.Unlock()
+
+	if !exist {
+		return "", nil, fmt.Errorf("Can not find ImageId with specified %s", id)
+	}
+
+	diff, err := provider.graphDriver.Diff(id, imageID)
+	if err != nil {
+		return "", nil, fmt.Errorf("Fail to get diff in %s base %s,due to %s", id, imageID, err.Error())
+	}
+
+	return imageID, ioutils.NewReadCloserWrapper(diff, diff.Close), nil
+}
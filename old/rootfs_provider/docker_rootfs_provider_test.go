@@ -2,11 +2,14 @@ package rootfs_provider_test
 
 import (
 	"errors"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden-linux/old/repository_fetcher/fake_repository_fetcher"
 	. "github.com/cloudfoundry-incubator/garden-linux/old/rootfs_provider"
 	"github.com/cloudfoundry-incubator/garden-linux/old/rootfs_provider/fake_graph_driver"
+	"github.com/cloudfoundry-incubator/garden-linux/old/rootfs_provider/fake_image_tracker"
 	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -35,6 +38,7 @@ var _ = Describe("DockerRootFSProvider", func() {
 		fakeRepositoryFetcher *fake_repository_fetcher.FakeRepositoryFetcher
 		fakeGraphDriver       *fake_graph_driver.FakeGraphDriver
 		fakeVolumeCreator     *FakeVolumeCreator
+		fakeImageTracker      *fake_image_tracker.FakeImageTracker
 		fakeClock             *fakeclock.FakeClock
 
 		provider RootFSProvider
@@ -46,6 +50,7 @@ var _ = Describe("DockerRootFSProvider", func() {
 		fakeRepositoryFetcher = fake_repository_fetcher.New()
 		fakeGraphDriver = &fake_graph_driver.FakeGraphDriver{}
 		fakeVolumeCreator = &FakeVolumeCreator{}
+		fakeImageTracker = new(fake_image_tracker.FakeImageTracker)
 		fakeClock = fakeclock.NewFakeClock(time.Now())
 
 		var err error
@@ -53,6 +58,8 @@ var _ = Describe("DockerRootFSProvider", func() {
 			fakeRepositoryFetcher,
 			fakeGraphDriver,
 			fakeVolumeCreator,
+			fakeImageTracker,
+			nil,
 			fakeClock,
 		)
 		Expect(err).ToNot(HaveOccurred())
@@ -125,6 +132,21 @@ var _ = Describe("DockerRootFSProvider", func() {
 					)
 					Expect(err).To(HaveOccurred())
 				})
+
+				It("removes the graph entry and releases the image", func() {
+					fakeRepositoryFetcher.FetchResult = "some-image-id"
+					fakeGraphDriver.GetReturns("/some/graph/driver/mount/point", nil)
+					fakeVolumeCreator.CreateError = errors.New("o nooo")
+
+					provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+
+					Expect(fakeGraphDriver.PutCallCount()).To(Equal(1))
+					Expect(fakeGraphDriver.RemoveCallCount()).To(Equal(1))
+					Expect(fakeGraphDriver.RemoveArgsForCall(0)).To(Equal("some-id"))
+
+					Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(1))
+					Expect(fakeImageTracker.ReleaseArgsForCall(0)).To(Equal("some-id"))
+				})
 			})
 		})
 
@@ -165,6 +187,8 @@ var _ = Describe("DockerRootFSProvider", func() {
 					fakeRepositoryFetcher,
 					fakeGraphDriver,
 					fakeVolumeCreator,
+					fakeImageTracker,
+					nil,
 					fakeClock,
 				)
 				Expect(err).ToNot(HaveOccurred())
@@ -202,6 +226,43 @@ var _ = Describe("DockerRootFSProvider", func() {
 				)
 				Expect(err).To(Equal(disaster))
 			})
+
+			It("does not hold any image", func() {
+				provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+				Expect(fakeImageTracker.AcquireCallCount()).To(Equal(0))
+			})
+		})
+
+		It("holds the image for the container before creating its graph entry", func() {
+			fakeRepositoryFetcher.FetchResult = "some-image-id"
+			fakeImageTracker.AcquireStub = func(string, string) error {
+				Expect(fakeGraphDriver.CreateCallCount()).To(Equal(0))
+				return nil
+			}
+
+			_, _, err := provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeImageTracker.AcquireCallCount()).To(Equal(1))
+			id, imageID := fakeImageTracker.AcquireArgsForCall(0)
+			Expect(id).To(Equal("some-id"))
+			Expect(imageID).To(Equal("some-image-id"))
+		})
+
+		Context("but holding the image fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeImageTracker.AcquireReturns(disaster)
+			})
+
+			It("returns the error without creating a graph entry", func() {
+				_, _, err := provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+				Expect(err).To(Equal(disaster))
+
+				Expect(fakeGraphDriver.CreateCallCount()).To(Equal(0))
+				Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("but creating the graph entry fails", func() {
@@ -218,6 +279,13 @@ var _ = Describe("DockerRootFSProvider", func() {
 				)
 				Expect(err).To(Equal(disaster))
 			})
+
+			It("releases the image it held", func() {
+				provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+
+				Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(1))
+				Expect(fakeImageTracker.ReleaseArgsForCall(0)).To(Equal("some-id"))
+			})
 		})
 
 		Context("but getting the graph entry fails", func() {
@@ -235,6 +303,155 @@ var _ = Describe("DockerRootFSProvider", func() {
 				)
 				Expect(err).To(Equal(disaster))
 			})
+
+			It("removes the graph entry and releases the image", func() {
+				provider.ProvideRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+
+				Expect(fakeGraphDriver.RemoveCallCount()).To(Equal(1))
+				Expect(fakeGraphDriver.RemoveArgsForCall(0)).To(Equal("some-id"))
+
+				Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(1))
+				Expect(fakeImageTracker.ReleaseArgsForCall(0)).To(Equal("some-id"))
+			})
+		})
+	})
+
+	Describe("PullRootFS", func() {
+		It("fetches the image and returns its ID without creating a container layer", func() {
+			fakeRepositoryFetcher.FetchResult = "some-image-id"
+
+			imageID, err := provider.PullRootFS(logger, parseURL("docker:///some-repository-name#some-tag"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(imageID).To(Equal("some-image-id"))
+
+			Expect(fakeRepositoryFetcher.Fetched()).To(ContainElement(
+				fake_repository_fetcher.FetchSpec{
+					Repository: "docker:///some-repository-name#some-tag",
+					Tag:        "some-tag",
+				},
+			))
+
+			Expect(fakeGraphDriver.CreateCallCount()).To(Equal(0))
+		})
+
+		It("records the pull as a use of the image", func() {
+			fakeRepositoryFetcher.FetchResult = "some-image-id"
+
+			_, err := provider.PullRootFS(logger, parseURL("docker:///some-repository-name"))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeImageTracker.TouchCallCount()).To(Equal(1))
+			Expect(fakeImageTracker.TouchArgsForCall(0)).To(Equal("some-image-id"))
+			Expect(fakeImageTracker.AcquireCallCount()).To(Equal(0))
+		})
+
+		Context("when the url is missing a path", func() {
+			It("returns an error", func() {
+				_, err := provider.PullRootFS(logger, parseURL("docker://"))
+				Expect(err).To(Equal(ErrInvalidDockerURL))
+			})
+		})
+
+		Context("when fetching fails", func() {
+			disaster := errors.New("oh no!")
+
+			BeforeEach(func() {
+				fakeRepositoryFetcher.FetchError = disaster
+			})
+
+			It("returns the error", func() {
+				_, err := provider.PullRootFS(logger, parseURL("docker:///some-repository-name"))
+				Expect(err).To(Equal(disaster))
+				Expect(fakeImageTracker.TouchCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("CommitAndStreamRootFS", func() {
+		Context("when the container was provided by this provider", func() {
+			BeforeEach(func() {
+				fakeRepositoryFetcher.FetchResult = "some-image-id"
+				fakeRepositoryFetcher.CommitResult = ioutil.NopCloser(strings.NewReader("some-image-tar"))
+				fakeGraphDriver.DiffReturns(ioutil.NopCloser(strings.NewReader("some-diff")), nil)
+
+				_, _, err := provider.ProvideRootFS(
+					logger,
+					"some-id",
+					parseURL("docker:///some-repository-name"),
+				)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("commits the container's diff against its parent image", func() {
+				stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeGraphDriver.DiffCallCount()).To(Equal(1))
+				id, parent := fakeGraphDriver.DiffArgsForCall(0)
+				Expect(id).To(Equal("some-id"))
+				Expect(parent).To(Equal("some-image-id"))
+
+				Expect(fakeRepositoryFetcher.Committed()).To(Equal([]fake_repository_fetcher.CommitSpec{
+					{ID: "some-id", ImageID: "some-image-id"},
+				}))
+
+				contents, err := ioutil.ReadAll(stream)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("some-image-tar"))
+			})
+
+			Context("when getting the diff fails", func() {
+				BeforeEach(func() {
+					fakeGraphDriver.DiffReturns(nil, errors.New("oh no!"))
+				})
+
+				It("returns an error", func() {
+					_, err := provider.CommitAndStreamRootFS(logger, "some-id")
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("when committing fails", func() {
+				BeforeEach(func() {
+					fakeRepositoryFetcher.CommitError = errors.New("oh no!")
+				})
+
+				It("returns an error", func() {
+					_, err := provider.CommitAndStreamRootFS(logger, "some-id")
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		Context("when the container is unknown", func() {
+			It("returns an error", func() {
+				_, err := provider.CommitAndStreamRootFS(logger, "some-other-id")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("RemountRootFS", func() {
+		It("gets the container's layer from the graph driver again", func() {
+			fakeGraphDriver.GetReturns("/some/graph/driver/mount/point", nil)
+
+			mountpoint, err := provider.RemountRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mountpoint).To(Equal("/some/graph/driver/mount/point"))
+
+			id, _ := fakeGraphDriver.GetArgsForCall(0)
+			Expect(id).To(Equal("some-id"))
+			Expect(fakeGraphDriver.CreateCallCount()).To(Equal(0))
+		})
+
+		Context("when the graph driver fails to mount the layer", func() {
+			It("returns the error", func() {
+				disaster := errors.New("oh no!")
+				fakeGraphDriver.GetReturns("", disaster)
+
+				_, err := provider.RemountRootFS(logger, "some-id", parseURL("docker:///some-repository-name"))
+				Expect(err).To(Equal(disaster))
+			})
 		})
 	})
 
@@ -252,6 +469,14 @@ var _ = Describe("DockerRootFSProvider", func() {
 			Expect(removed).To(Equal("some-id"))
 		})
 
+		It("releases the container's image", func() {
+			err := provider.CleanupRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(1))
+			Expect(fakeImageTracker.ReleaseArgsForCall(0)).To(Equal("some-id"))
+		})
+
 		Context("when removing the container from the graph fails", func() {
 			disaster := errors.New("oh no!")
 
@@ -310,6 +535,21 @@ var _ = Describe("DockerRootFSProvider", func() {
 
 					Eventually(errs).Should(Receive())
 				})
+
+				It("keeps holding the container's image", func() {
+					errs := make(chan error)
+					go func(errs chan<- error) {
+						errs <- provider.CleanupRootFS(logger, "some-id")
+					}(errs)
+
+					for i := 0; i < 10; i++ {
+						Eventually(fakeClock.WatcherCount).Should(Equal(1))
+						fakeClock.Increment(300 * time.Millisecond)
+					}
+
+					Eventually(errs).Should(Receive())
+					Expect(fakeImageTracker.ReleaseCallCount()).To(Equal(0))
+				})
 			})
 		})
 	})
@@ -0,0 +1,135 @@
+// This file was generated by counterfeiter
+package fake_image_tracker
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-linux/old/rootfs_provider"
+)
+
+type FakeImageTracker struct {
+	AcquireStub        func(containerID string, imageID string) error
+	acquireMutex       sync.RWMutex
+	acquireArgsForCall []struct {
+		containerID string
+		imageID     string
+	}
+	acquireReturns struct {
+		result1 error
+	}
+	ReleaseStub        func(containerID string) error
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		containerID string
+	}
+	releaseReturns struct {
+		result1 error
+	}
+	TouchStub        func(imageID string) error
+	touchMutex       sync.RWMutex
+	touchArgsForCall []struct {
+		imageID string
+	}
+	touchReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeImageTracker) Acquire(containerID string, imageID string) error {
+	fake.acquireMutex.Lock()
+	fake.acquireArgsForCall = append(fake.acquireArgsForCall, struct {
+		containerID string
+		imageID     string
+	}{containerID, imageID})
+	fake.acquireMutex.Unlock()
+	if fake.AcquireStub != nil {
+		return fake.AcquireStub(containerID, imageID)
+	} else {
+		return fake.acquireReturns.result1
+	}
+}
+
+func (fake *FakeImageTracker) AcquireCallCount() int {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	return len(fake.acquireArgsForCall)
+}
+
+func (fake *FakeImageTracker) AcquireArgsForCall(i int) (string, string) {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	return fake.acquireArgsForCall[i].containerID, fake.acquireArgsForCall[i].imageID
+}
+
+func (fake *FakeImageTracker) AcquireReturns(result1 error) {
+	fake.AcquireStub = nil
+	fake.acquireReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeImageTracker) Release(containerID string) error {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		containerID string
+	}{containerID})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		return fake.ReleaseStub(containerID)
+	} else {
+		return fake.releaseReturns.result1
+	}
+}
+
+func (fake *FakeImageTracker) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeImageTracker) ReleaseArgsForCall(i int) string {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].containerID
+}
+
+func (fake *FakeImageTracker) ReleaseReturns(result1 error) {
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeImageTracker) Touch(imageID string) error {
+	fake.touchMutex.Lock()
+	fake.touchArgsForCall = append(fake.touchArgsForCall, struct {
+		imageID string
+	}{imageID})
+	fake.touchMutex.Unlock()
+	if fake.TouchStub != nil {
+		return fake.TouchStub(imageID)
+	} else {
+		return fake.touchReturns.result1
+	}
+}
+
+func (fake *FakeImageTracker) TouchCallCount() int {
+	fake.touchMutex.RLock()
+	defer fake.touchMutex.RUnlock()
+	return len(fake.touchArgsForCall)
+}
+
+func (fake *FakeImageTracker) TouchArgsForCall(i int) string {
+	fake.touchMutex.RLock()
+	defer fake.touchMutex.RUnlock()
+	return fake.touchArgsForCall[i].imageID
+}
+
+func (fake *FakeImageTracker) TouchReturns(result1 error) {
+	fake.TouchStub = nil
+	fake.touchReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rootfs_provider.ImageTracker = new(FakeImageTracker)
@@ -2,6 +2,7 @@
 package fake_rootfs_provider
 
 import (
+	"io"
 	"net/url"
 	"sync"
 
@@ -32,6 +33,59 @@ type FakeRootFSProvider struct {
 	cleanupRootFSReturns struct {
 		result1 error
 	}
+	RemountRootFSStub        func(logger lager.Logger, id string, rootfs *url.URL) (string, error)
+	remountRootFSMutex       sync.RWMutex
+	remountRootFSArgsForCall []struct {
+		logger lager.Logger
+		id     string
+		rootfs *url.URL
+	}
+	remountRootFSReturns struct {
+		result1 string
+		result2 error
+	}
+	CommitAndSaveRootFSStub        func(logger lager.Logger, id string, dest string) error
+	commitAndSaveRootFSMutex       sync.RWMutex
+	commitAndSaveRootFSArgsForCall []struct {
+		logger lager.Logger
+		id     string
+		dest   string
+	}
+	commitAndSaveRootFSReturns struct {
+		result1 error
+	}
+	CommitAndStreamRootFSStub        func(logger lager.Logger, id string) (io.ReadCloser, error)
+	commitAndStreamRootFSMutex       sync.RWMutex
+	commitAndStreamRootFSArgsForCall []struct {
+		logger lager.Logger
+		id     string
+	}
+	commitAndStreamRootFSReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	CommitRootFSStub        func(logger lager.Logger, id string, repoName string, tag string) (imageID string, err error)
+	commitRootFSMutex       sync.RWMutex
+	commitRootFSArgsForCall []struct {
+		logger   lager.Logger
+		id       string
+		repoName string
+		tag      string
+	}
+	commitRootFSReturns struct {
+		result1 string
+		result2 error
+	}
+	PullRootFSStub        func(logger lager.Logger, rootfs *url.URL) (imageID string, err error)
+	pullRootFSMutex       sync.RWMutex
+	pullRootFSArgsForCall []struct {
+		logger lager.Logger
+		rootfs *url.URL
+	}
+	pullRootFSReturns struct {
+		result1 string
+		result2 error
+	}
 }
 
 func (fake *FakeRootFSProvider) ProvideRootFS(logger lager.Logger, id string, rootfs *url.URL) (mountpoint string, envvar process.Env, err error) {
@@ -103,4 +157,177 @@ func (fake *FakeRootFSProvider) CleanupRootFSReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRootFSProvider) RemountRootFS(logger lager.Logger, id string, rootfs *url.URL) (string, error) {
+	fake.remountRootFSMutex.Lock()
+	fake.remountRootFSArgsForCall = append(fake.remountRootFSArgsForCall, struct {
+		logger lager.Logger
+		id     string
+		rootfs *url.URL
+	}{logger, id, rootfs})
+	fake.remountRootFSMutex.Unlock()
+	if fake.RemountRootFSStub != nil {
+		return fake.RemountRootFSStub(logger, id, rootfs)
+	} else {
+		return fake.remountRootFSReturns.result1, fake.remountRootFSReturns.result2
+	}
+}
+
+func (fake *FakeRootFSProvider) RemountRootFSCallCount() int {
+	fake.remountRootFSMutex.RLock()
+	defer fake.remountRootFSMutex.RUnlock()
+	return len(fake.remountRootFSArgsForCall)
+}
+
+func (fake *FakeRootFSProvider) RemountRootFSArgsForCall(i int) (lager.Logger, string, *url.URL) {
+	fake.remountRootFSMutex.RLock()
+	defer fake.remountRootFSMutex.RUnlock()
+	return fake.remountRootFSArgsForCall[i].logger, fake.remountRootFSArgsForCall[i].id, fake.remountRootFSArgsForCall[i].rootfs
+}
+
+func (fake *FakeRootFSProvider) RemountRootFSReturns(result1 string, result2 error) {
+	fake.RemountRootFSStub = nil
+	fake.remountRootFSReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRootFSProvider) CommitAndSaveRootFS(logger lager.Logger, id string, dest string) error {
+	fake.commitAndSaveRootFSMutex.Lock()
+	fake.commitAndSaveRootFSArgsForCall = append(fake.commitAndSaveRootFSArgsForCall, struct {
+		logger lager.Logger
+		id     string
+		dest   string
+	}{logger, id, dest})
+	fake.commitAndSaveRootFSMutex.Unlock()
+	if fake.CommitAndSaveRootFSStub != nil {
+		return fake.CommitAndSaveRootFSStub(logger, id, dest)
+	} else {
+		return fake.commitAndSaveRootFSReturns.result1
+	}
+}
+
+func (fake *FakeRootFSProvider) CommitAndSaveRootFSCallCount() int {
+	fake.commitAndSaveRootFSMutex.RLock()
+	defer fake.commitAndSaveRootFSMutex.RUnlock()
+	return len(fake.commitAndSaveRootFSArgsForCall)
+}
+
+func (fake *FakeRootFSProvider) CommitAndSaveRootFSArgsForCall(i int) (lager.Logger, string, string) {
+	fake.commitAndSaveRootFSMutex.RLock()
+	defer fake.commitAndSaveRootFSMutex.RUnlock()
+	return fake.commitAndSaveRootFSArgsForCall[i].logger, fake.commitAndSaveRootFSArgsForCall[i].id, fake.commitAndSaveRootFSArgsForCall[i].dest
+}
+
+func (fake *FakeRootFSProvider) CommitAndSaveRootFSReturns(result1 error) {
+	fake.CommitAndSaveRootFSStub = nil
+	fake.commitAndSaveRootFSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRootFSProvider) CommitAndStreamRootFS(logger lager.Logger, id string) (io.ReadCloser, error) {
+	fake.commitAndStreamRootFSMutex.Lock()
+	fake.commitAndStreamRootFSArgsForCall = append(fake.commitAndStreamRootFSArgsForCall, struct {
+		logger lager.Logger
+		id     string
+	}{logger, id})
+	fake.commitAndStreamRootFSMutex.Unlock()
+	if fake.CommitAndStreamRootFSStub != nil {
+		return fake.CommitAndStreamRootFSStub(logger, id)
+	} else {
+		return fake.commitAndStreamRootFSReturns.result1, fake.commitAndStreamRootFSReturns.result2
+	}
+}
+
+func (fake *FakeRootFSProvider) CommitAndStreamRootFSCallCount() int {
+	fake.commitAndStreamRootFSMutex.RLock()
+	defer fake.commitAndStreamRootFSMutex.RUnlock()
+	return len(fake.commitAndStreamRootFSArgsForCall)
+}
+
+func (fake *FakeRootFSProvider) CommitAndStreamRootFSArgsForCall(i int) (lager.Logger, string) {
+	fake.commitAndStreamRootFSMutex.RLock()
+	defer fake.commitAndStreamRootFSMutex.RUnlock()
+	return fake.commitAndStreamRootFSArgsForCall[i].logger, fake.commitAndStreamRootFSArgsForCall[i].id
+}
+
+func (fake *FakeRootFSProvider) CommitAndStreamRootFSReturns(result1 io.ReadCloser, result2 error) {
+	fake.CommitAndStreamRootFSStub = nil
+	fake.commitAndStreamRootFSReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRootFSProvider) CommitRootFS(logger lager.Logger, id string, repoName string, tag string) (imageID string, err error) {
+	fake.commitRootFSMutex.Lock()
+	fake.commitRootFSArgsForCall = append(fake.commitRootFSArgsForCall, struct {
+		logger   lager.Logger
+		id       string
+		repoName string
+		tag      string
+	}{logger, id, repoName, tag})
+	fake.commitRootFSMutex.Unlock()
+	if fake.CommitRootFSStub != nil {
+		return fake.CommitRootFSStub(logger, id, repoName, tag)
+	} else {
+		return fake.commitRootFSReturns.result1, fake.commitRootFSReturns.result2
+	}
+}
+
+func (fake *FakeRootFSProvider) CommitRootFSCallCount() int {
+	fake.commitRootFSMutex.RLock()
+	defer fake.commitRootFSMutex.RUnlock()
+	return len(fake.commitRootFSArgsForCall)
+}
+
+func (fake *FakeRootFSProvider) CommitRootFSArgsForCall(i int) (lager.Logger, string, string, string) {
+	fake.commitRootFSMutex.RLock()
+	defer fake.commitRootFSMutex.RUnlock()
+	return fake.commitRootFSArgsForCall[i].logger, fake.commitRootFSArgsForCall[i].id, fake.commitRootFSArgsForCall[i].repoName, fake.commitRootFSArgsForCall[i].tag
+}
+
+func (fake *FakeRootFSProvider) CommitRootFSReturns(result1 string, result2 error) {
+	fake.CommitRootFSStub = nil
+	fake.commitRootFSReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRootFSProvider) PullRootFS(logger lager.Logger, rootfs *url.URL) (imageID string, err error) {
+	fake.pullRootFSMutex.Lock()
+	fake.pullRootFSArgsForCall = append(fake.pullRootFSArgsForCall, struct {
+		logger lager.Logger
+		rootfs *url.URL
+	}{logger, rootfs})
+	fake.pullRootFSMutex.Unlock()
+	if fake.PullRootFSStub != nil {
+		return fake.PullRootFSStub(logger, rootfs)
+	} else {
+		return fake.pullRootFSReturns.result1, fake.pullRootFSReturns.result2
+	}
+}
+
+func (fake *FakeRootFSProvider) PullRootFSCallCount() int {
+	fake.pullRootFSMutex.RLock()
+	defer fake.pullRootFSMutex.RUnlock()
+	return len(fake.pullRootFSArgsForCall)
+}
+
+func (fake *FakeRootFSProvider) PullRootFSArgsForCall(i int) (lager.Logger, *url.URL) {
+	fake.pullRootFSMutex.RLock()
+	defer fake.pullRootFSMutex.RUnlock()
+	return fake.pullRootFSArgsForCall[i].logger, fake.pullRootFSArgsForCall[i].rootfs
+}
+
+func (fake *FakeRootFSProvider) PullRootFSReturns(result1 string, result2 error) {
+	fake.PullRootFSStub = nil
+	fake.pullRootFSReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 var _ rootfs_provider.RootFSProvider = new(FakeRootFSProvider)
@@ -0,0 +1,147 @@
+package rootfs_provider
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+
+	// overlayfs releases predating the upstream kernel merge record
+	// deletions as symlinks to this target rather than as 0/0 devices.
+	overlayWhiteoutTarget = "(overlay-whiteout)"
+)
+
+// aufs keeps its own bookkeeping at the top of each branch; none of it
+// belongs in a layer.
+var aufsMetadata = map[string]bool{
+	".wh..wh.aufs": true,
+	".wh..wh.plnk": true,
+	".wh..wh.orph": true,
+}
+
+// tarOverlayLayer writes the upper directory of a container's overlay to w
+// as a docker layer, translating overlayfs whiteouts and opaque directories
+// into .wh. entries. aufs branches already use that convention and are
+// passed through unchanged.
+func tarOverlayLayer(upperDir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(upperDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(upperDir, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		if aufsMetadata[rel] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		if isOverlayWhiteout(info, link) {
+			return writeWhiteout(tw, filepath.Join(filepath.Dir(rel), whiteoutPrefix+info.Name()))
+		}
+
+		// sockets cannot be archived, and are useless outside the running
+		// container anyway; docker's archive package skips them too
+		if info.Mode()&os.ModeSocket != 0 {
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		hdr.Name = rel
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			if err := copyFile(tw, path); err != nil {
+				return err
+			}
+		}
+
+		if info.IsDir() && isOpaque(path) {
+			return writeWhiteout(tw, filepath.Join(rel, opaqueWhiteout))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
+
+func isOverlayWhiteout(info os.FileInfo, link string) bool {
+	if link == overlayWhiteoutTarget {
+		return true
+	}
+
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && stat.Rdev == 0
+}
+
+func isOpaque(path string) bool {
+	value := make([]byte, 1)
+
+	n, err := syscall.Getxattr(path, "trusted.overlay.opaque", value)
+	return err == nil && n == 1 && value[0] == 'y'
+}
+
+func writeWhiteout(tw *tar.Writer, name string) error {
+	return tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Typeflag: tar.TypeReg,
+		Mode:     0600,
+		ModTime:  time.Now(),
+	})
+}
+
+func copyFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
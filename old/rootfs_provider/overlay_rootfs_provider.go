@@ -1,16 +1,26 @@
 package rootfs_provider
 
 import (
+	"archive/tar"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"os/exec"
 	"path"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden-linux/old/logging"
 	"github.com/cloudfoundry-incubator/garden-linux/process"
 	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/utils"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -36,6 +46,15 @@ func NewOverlay(
 }
 
 func (provider *overlayRootFSProvider) ProvideRootFS(logger lager.Logger, id string, rootfs *url.URL) (string, process.Env, error) {
+	mountpoint, err := provider.mountOverlay(logger, "create", id, rootfs)
+	return mountpoint, nil, err
+}
+
+func (provider *overlayRootFSProvider) RemountRootFS(logger lager.Logger, id string, rootfs *url.URL) (string, error) {
+	return provider.mountOverlay(logger, "remount", id, rootfs)
+}
+
+func (provider *overlayRootFSProvider) mountOverlay(logger lager.Logger, action, id string, rootfs *url.URL) (string, error) {
 	rootFSPath := provider.defaultRootFS
 	if rootfs.Path != "" {
 		rootFSPath = rootfs.Path
@@ -43,7 +62,7 @@ func (provider *overlayRootFSProvider) ProvideRootFS(logger lager.Logger, id str
 
 	// Rootfs path in container spec is empty
 	if rootFSPath == "" {
-		return "", nil, fmt.Errorf("RootFSPath: is a required parameter, since no default rootfs was provided to the server. To provide a default rootfs, use the --rootfs flag on startup.")
+		return "", fmt.Errorf("RootFSPath: is a required parameter, since no default rootfs was provided to the server. To provide a default rootfs, use the --rootfs flag on startup.")
 	}
 
 	pRunner := logging.Runner{
@@ -51,20 +70,20 @@ func (provider *overlayRootFSProvider) ProvideRootFS(logger lager.Logger, id str
 		Logger:        logger,
 	}
 
-	createOverlay := exec.Command(
+	mountOverlay := exec.Command(
 		path.Join(provider.binPath, "overlay.sh"),
-		"create", path.Join(provider.overlaysPath, id), rootFSPath,
+		action, path.Join(provider.overlaysPath, id), rootFSPath,
 	)
 
 	var stderr bytes.Buffer
-	createOverlay.Stderr = &stderr
+	mountOverlay.Stderr = &stderr
 
-	err := pRunner.Run(createOverlay)
+	err := pRunner.Run(mountOverlay)
 	if err != nil {
-		return "", nil, fmt.Errorf("overlay.sh: %v, %v", err, strings.TrimRight(stderr.String(), "\n"))
+		return "", fmt.Errorf("overlay.sh: %v, %v", err, strings.TrimRight(stderr.String(), "\n"))
 	}
 
-	return path.Join(provider.overlaysPath, id, "rootfs"), nil, nil
+	return path.Join(provider.overlaysPath, id, "rootfs"), nil
 }
 
 func (provider *overlayRootFSProvider) CleanupRootFS(logger lager.Logger, id string) error {
@@ -82,5 +101,150 @@ func (provider *overlayRootFSProvider) CleanupRootFS(logger lager.Logger, id str
 }
 
 func (provider *overlayRootFSProvider) CommitAndSaveRootFS(logger lager.Logger, id, dest string) error {
-	return fmt.Errorf("Overlay rootfs provider does not support commitAndSave,id : %s",id)
+	if !strings.HasSuffix(dest, ".tar") {
+		return fmt.Errorf("specified %s must be has suffix .tar", dest)
+	}
+
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		return fmt.Errorf("specified %s exist and it is directory not file", dest)
+	}
+
+	stream, err := provider.CommitAndStreamRootFS(logger, id)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	destTar, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer destTar.Close()
+
+	_, err = io.Copy(destTar, stream)
+	return err
+}
+
+// CommitAndStreamRootFS packages the container's overlay upper directory as
+// a single-layer image in the same archive format the docker provider
+// exports. The layer has no parent, as the base rootfs is a plain directory
+// rather than an image.
+func (provider *overlayRootFSProvider) CommitAndStreamRootFS(logger lager.Logger, id string) (io.ReadCloser, error) {
+	cLog := logger.Session("commit-and-stream", lager.Data{
+		"container-id": id,
+	})
+
+	upperDir := path.Join(provider.overlaysPath, id, "overlay")
+	if _, err := os.Stat(upperDir); err != nil {
+		return nil, fmt.Errorf("rootfs_provider: commit %s: %s", id, err)
+	}
+
+	layer, err := ioutil.TempFile(provider.overlaysPath, id+"-layer-")
+	if err != nil {
+		return nil, fmt.Errorf("rootfs_provider: commit %s: %s", id, err)
+	}
+
+	cleanup := func() {
+		layer.Close()
+		os.Remove(layer.Name())
+	}
+
+	if err := tarOverlayLayer(upperDir, layer); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("rootfs_provider: commit %s: %s", id, err)
+	}
+
+	size, err := layer.Seek(0, os.SEEK_CUR)
+	if err == nil {
+		_, err = layer.Seek(0, os.SEEK_SET)
+	}
+
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("rootfs_provider: commit %s: %s", id, err)
+	}
+
+	img := &image.Image{
+		ID:           utils.GenerateRandomID(),
+		Created:      time.Now().UTC(),
+		Container:    id,
+		Architecture: runtime.GOARCH,
+		OS:           runtime.GOOS,
+		Size:         size,
+	}
+
+	imgJSON, err := json.Marshal(img)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("rootfs_provider: commit %s: %s", id, err)
+	}
+
+	cLog.Info("committed", lager.Data{
+		"imageID": img.ID,
+		"size":    size,
+	})
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeImageArchive(writer, img.ID, imgJSON, layer, size))
+	}()
+
+	return ioutils.NewReadCloserWrapper(reader, func() error {
+		err := reader.Close()
+		cleanup()
+		return err
+	}), nil
+}
+
+func (provider *overlayRootFSProvider) CommitRootFS(logger lager.Logger, id, repoName, tag string) (string, error) {
+	return "", fmt.Errorf("Overlay rootfs provider does not support commit,id : %s", id)
+}
+
+// writeImageArchive writes a single image in the layout produced by docker
+// save: a directory named after the image holding its VERSION, json and
+// layer.tar.
+func writeImageArchive(w io.Writer, imageID string, imgJSON []byte, layer io.Reader, layerSize int64) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     imageID + "/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+		ModTime:  now,
+	}); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name string
+		size int64
+		data io.Reader
+	}{
+		{"VERSION", 3, strings.NewReader("1.0")},
+		{"json", int64(len(imgJSON)), bytes.NewReader(imgJSON)},
+		{"layer.tar", layerSize, layer},
+	}
+
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     path.Join(imageID, file.name),
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     file.size,
+			ModTime:  now,
+		}); err != nil {
+			return err
+		}
+
+		if _, err := io.CopyN(tw, file.data, file.size); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+func (provider *overlayRootFSProvider) PullRootFS(logger lager.Logger, rootfs *url.URL) (string, error) {
+	return "", fmt.Errorf("Overlay rootfs provider does not support pull,rootfs : %s", rootfs)
 }
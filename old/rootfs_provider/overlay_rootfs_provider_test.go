@@ -1,8 +1,15 @@
 package rootfs_provider_test
 
 import (
+	"archive/tar"
+	"bytes"
 	"errors"
+	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"syscall"
 
 	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
 	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
@@ -87,6 +94,33 @@ var _ = Describe("OverlayRootfsProvider", func() {
 		})
 	})
 
+	Describe("RemountRootFS", func() {
+		It("executes overlay.sh remount with the given rootfs", func() {
+			rootfs, err := provider.RemountRootFS(logger, "some-id", parseURL("/some/given/rootfs"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rootfs).To(Equal("/some/overlays/path/some-id/rootfs"))
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/some/bin/path/overlay.sh",
+					Args: []string{"remount", "/some/overlays/path/some-id", "/some/given/rootfs"},
+				},
+			))
+		})
+
+		It("falls back to the default rootfs", func() {
+			_, err := provider.RemountRootFS(logger, "some-id", parseURL(""))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/some/bin/path/overlay.sh",
+					Args: []string{"remount", "/some/overlays/path/some-id", "/some/default/rootfs"},
+				},
+			))
+		})
+	})
+
 	Describe("CleanupRootFS", func() {
 		It("executes overlay.sh cleanup for the id's path", func() {
 			err := provider.CleanupRootFS(logger, "some-id")
@@ -122,4 +156,177 @@ var _ = Describe("OverlayRootfsProvider", func() {
 			})
 		})
 	})
+
+	Describe("CommitAndStreamRootFS", func() {
+		var overlaysPath string
+
+		BeforeEach(func() {
+			var err error
+			overlaysPath, err = ioutil.TempDir("", "overlays")
+			Expect(err).ToNot(HaveOccurred())
+
+			upperDir := filepath.Join(overlaysPath, "some-id", "overlay")
+			Expect(os.MkdirAll(filepath.Join(upperDir, "etc"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(upperDir, ".wh..wh.plnk"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(upperDir, "etc", "config"), []byte("some-config"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(upperDir, ".wh.aufs-deleted"), []byte{}, 0444)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(upperDir, ".wh..wh.aufs"), []byte{}, 0444)).To(Succeed())
+			Expect(os.Symlink("(overlay-whiteout)", filepath.Join(upperDir, "etc", "overlay-deleted"))).To(Succeed())
+			Expect(os.Symlink("config", filepath.Join(upperDir, "etc", "link"))).To(Succeed())
+			Expect(syscall.Mknod(filepath.Join(upperDir, "etc", "socket"), syscall.S_IFSOCK|0644, 0)).To(Succeed())
+
+			provider = NewOverlay("/some/bin/path", overlaysPath, "/some/default/rootfs", fakeRunner)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(overlaysPath)
+		})
+
+		readImage := func(stream io.Reader) (map[string][]byte, *tar.Reader) {
+			files := map[string][]byte{}
+
+			tr := tar.NewReader(stream)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+
+				contents, err := ioutil.ReadAll(tr)
+				Expect(err).ToNot(HaveOccurred())
+
+				files[hdr.Name] = contents
+			}
+
+			var layer []byte
+			for name, contents := range files {
+				if filepath.Base(name) == "layer.tar" {
+					layer = contents
+				}
+			}
+			Expect(layer).ToNot(BeNil())
+
+			return files, tar.NewReader(bytes.NewReader(layer))
+		}
+
+		layerEntries := func(layer *tar.Reader) map[string]*tar.Header {
+			entries := map[string]*tar.Header{}
+			for {
+				hdr, err := layer.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).ToNot(HaveOccurred())
+
+				entries[hdr.Name] = hdr
+			}
+
+			return entries
+		}
+
+		It("streams the upper directory as a single-layer image", func() {
+			stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Close()
+
+			files, layer := readImage(stream)
+
+			var imageDir string
+			for name := range files {
+				if filepath.Base(name) == "VERSION" {
+					imageDir = filepath.Dir(name)
+				}
+			}
+			Expect(imageDir).ToNot(BeEmpty())
+
+			Expect(string(files[imageDir+"/VERSION"])).To(Equal("1.0"))
+			Expect(string(files[imageDir+"/json"])).To(ContainSubstring(`"id":"` + imageDir + `"`))
+			Expect(string(files[imageDir+"/json"])).To(ContainSubstring(`"container":"some-id"`))
+
+			entries := layerEntries(layer)
+			Expect(entries).To(HaveKey("etc/"))
+			Expect(entries).To(HaveKey("etc/config"))
+			Expect(entries["etc/link"].Linkname).To(Equal("config"))
+		})
+
+		It("translates overlay whiteouts and keeps aufs whiteouts", func() {
+			stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Close()
+
+			_, layer := readImage(stream)
+			entries := layerEntries(layer)
+
+			Expect(entries).To(HaveKey("etc/.wh.overlay-deleted"))
+			Expect(entries).ToNot(HaveKey("etc/overlay-deleted"))
+			Expect(entries).To(HaveKey(".wh.aufs-deleted"))
+		})
+
+		It("omits aufs metadata", func() {
+			stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Close()
+
+			_, layer := readImage(stream)
+			entries := layerEntries(layer)
+
+			Expect(entries).ToNot(HaveKey(".wh..wh.aufs"))
+			Expect(entries).ToNot(HaveKey(".wh..wh.plnk/"))
+		})
+
+		It("skips sockets", func() {
+			stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+			defer stream.Close()
+
+			_, layer := readImage(stream)
+			entries := layerEntries(layer)
+
+			Expect(entries).To(HaveKey("etc/config"))
+			Expect(entries).ToNot(HaveKey("etc/socket"))
+		})
+
+		It("removes its temporary layer once the stream is closed", func() {
+			stream, err := provider.CommitAndStreamRootFS(logger, "some-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = ioutil.ReadAll(stream)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stream.Close()).To(Succeed())
+
+			entries, err := ioutil.ReadDir(overlaysPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		Context("when the container has no overlay", func() {
+			It("returns an error", func() {
+				_, err := provider.CommitAndStreamRootFS(logger, "some-other-id")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("CommitAndSaveRootFS", func() {
+			It("writes the image to the destination", func() {
+				dest := filepath.Join(overlaysPath, "image.tar")
+
+				Expect(provider.CommitAndSaveRootFS(logger, "some-id", dest)).To(Succeed())
+
+				file, err := os.Open(dest)
+				Expect(err).ToNot(HaveOccurred())
+				defer file.Close()
+
+				_, layer := readImage(file)
+				Expect(layerEntries(layer)).To(HaveKey("etc/config"))
+			})
+
+			Context("when the destination is not a tar file", func() {
+				It("returns an error", func() {
+					err := provider.CommitAndSaveRootFS(logger, "some-id", filepath.Join(overlaysPath, "image"))
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+	})
 })
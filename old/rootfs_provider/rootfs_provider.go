@@ -1,6 +1,7 @@
 package rootfs_provider
 
 import (
+	"io"
 	"net/url"
 
 	"github.com/cloudfoundry-incubator/garden-linux/process"
@@ -11,5 +12,12 @@ import (
 type RootFSProvider interface {
 	ProvideRootFS(logger lager.Logger, id string, rootfs *url.URL) (mountpoint string, envvar process.Env, err error)
 	CleanupRootFS(logger lager.Logger, id string) error
+
+	// RemountRootFS mounts the rootfs provided for id again if it is not
+	// mounted, as after a reboot, keeping the changes made to it.
+	RemountRootFS(logger lager.Logger, id string, rootfs *url.URL) (mountpoint string, err error)
 	CommitAndSaveRootFS(logger lager.Logger, id, dest string) error
+	CommitAndStreamRootFS(logger lager.Logger, id string) (io.ReadCloser, error)
+	CommitRootFS(logger lager.Logger, id, repoName, tag string) (imageID string, err error)
+	PullRootFS(logger lager.Logger, rootfs *url.URL) (imageID string, err error)
 }